@@ -0,0 +1,112 @@
+package cmp
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type person struct {
+	Name string
+	Age  int
+}
+
+func TestCompare(t *testing.T) {
+	people := []person{
+		{"carol", 30},
+		{"alice", 30},
+		{"bob", 25},
+	}
+
+	byAge := By(func(p person) int { return p.Age })
+	byName := By(func(p person) string { return p.Name })
+
+	slices.SortFunc(people, byAge.Reverse().Then(byName))
+	got := make([]string, 0, len(people))
+	for _, p := range people {
+		got = append(got, p.Name)
+	}
+
+	expected := []string{"alice", "carol", "bob"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("ERROR: SortFunc → %v (expected %v)", got, expected)
+	}
+
+	if p := byAge.Min(people[0], people[1:]...); p.Name != "bob" {
+		t.Errorf("ERROR: Min → %v (expected %v)", p.Name, "bob")
+	}
+	if p := byAge.Max(people[0], people[1:]...); p.Name != "alice" {
+		t.Errorf("ERROR: Max → %v (expected %v)", p.Name, "alice")
+	}
+
+	less := Ordered[int]().Less()
+	if !less(1, 2) || less(2, 1) || less(1, 1) {
+		t.Error("ERROR: Less() doesn't match Ordered()")
+	}
+
+	fold := ByFunc(strings.ToLower, Ordered[string]())
+	if !fold.Equal("Foo", "fOO") {
+		t.Error("ERROR: ByFunc(ToLower) doesn't ignore case")
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Matcher[int]
+		values   []int
+		expected []int
+	}{
+		{"And", And(AtLeast(2), LessThan(5)), []int{1, 2, 4, 5}, []int{2, 4}},
+		{"Or", Or(Equal(1), GreaterThan(4)), []int{1, 2, 5}, []int{1, 5}},
+		{"Not", Not(In(1, 2)), []int{1, 2, 3}, []int{3}},
+		{"Between", Between(2, 3), []int{1, 2, 3, 4}, []int{2, 3}},
+		{"BetweenFunc", BetweenFunc(3, 2, Reverse(Ordered[int]())), []int{1, 2, 3, 4}, []int{2, 3}},
+		{"AtMost.Or", AtMost(1).Or(Equal(3)), []int{1, 2, 3}, []int{1, 3}},
+		{"empty And", And[int](), []int{1}, []int{1}},
+		{"empty Or", Or[int](), []int{1}, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.m.Filter(tc.values); !slices.Equal(got, tc.expected) {
+				t.Errorf("ERROR: Filter(%v) → %v (expected %v)", tc.values, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestStringMatchers(t *testing.T) {
+	glob, err := Glob[string]("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		m        Matcher[string]
+		value    string
+		expected bool
+	}{
+		{"HasPrefix", HasPrefix("foo"), "foobar", true},
+		{"HasSuffix", HasSuffix("bar"), "foobaz", false},
+		{"Contains", Contains("oba"), "foobar", true},
+		{"EqualFold", EqualFold("FooBar"), "foobar", true},
+		{"Regexp", Regexp[string](regexp.MustCompile(`^\d+$`)), "123", true},
+		{"Glob", glob, "main.go", true},
+		{"Glob.Not", glob.Not(), "main.go", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.m(tc.value); got != tc.expected {
+				t.Errorf("ERROR: %q → %v (expected %v)", tc.value, got, tc.expected)
+			}
+		})
+	}
+
+	if _, err := Glob[string]("[a-"); err == nil {
+		t.Error("ERROR: Glob accepted a malformed pattern")
+	}
+}
@@ -0,0 +1,95 @@
+// Package cmp provides composable comparators and matchers
+// for generic values.
+package cmp
+
+import (
+	stdcmp "cmp"
+)
+
+// Compare returns a negative number when a is less than b, a positive
+// one when greater, and zero when equal, like [stdcmp.Compare] and as
+// expected by [slices.SortFunc].
+type Compare[T any] func(a, b T) int
+
+// Ordered returns a [Compare] for naturally ordered types.
+func Ordered[T stdcmp.Ordered]() Compare[T] {
+	return stdcmp.Compare[T]
+}
+
+// By returns a [Compare] ordering values by the given key.
+func By[T any, K stdcmp.Ordered](key func(T) K) Compare[T] {
+	return func(a, b T) int {
+		return stdcmp.Compare(key(a), key(b))
+	}
+}
+
+// ByFunc returns a [Compare] ordering values by the given key
+// using a custom [Compare] for the keys.
+func ByFunc[T, K any](key func(T) K, cmp Compare[K]) Compare[T] {
+	return func(a, b T) int {
+		return cmp(key(a), key(b))
+	}
+}
+
+// Reverse returns a [Compare] inverting the order of another.
+func Reverse[T any](cmp Compare[T]) Compare[T] {
+	return func(a, b T) int {
+		return cmp(b, a)
+	}
+}
+
+// Chain returns a [Compare] trying each of the given, in order, until
+// one tells the values apart.
+func Chain[T any](cmps ...Compare[T]) Compare[T] {
+	return func(a, b T) int {
+		for _, cmp := range cmps {
+			if c := cmp(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
+
+// Reverse returns a [Compare] inverting the order.
+func (cmp Compare[T]) Reverse() Compare[T] {
+	return Reverse(cmp)
+}
+
+// Then returns a [Compare] using the others to break ties.
+func (cmp Compare[T]) Then(others ...Compare[T]) Compare[T] {
+	return Chain(append([]Compare[T]{cmp}, others...)...)
+}
+
+// Less returns a less function, as used by [sort.Slice]
+// and priority queues.
+func (cmp Compare[T]) Less() func(a, b T) bool {
+	return func(a, b T) bool {
+		return cmp(a, b) < 0
+	}
+}
+
+// Equal tells if the values are considered equal.
+func (cmp Compare[T]) Equal(a, b T) bool {
+	return cmp(a, b) == 0
+}
+
+// Min returns the least of the values, the first of the equal ones.
+func (cmp Compare[T]) Min(v T, more ...T) T {
+	for _, x := range more {
+		if cmp(x, v) < 0 {
+			v = x
+		}
+	}
+	return v
+}
+
+// Max returns the greatest of the values, the first of the equal ones.
+func (cmp Compare[T]) Max(v T, more ...T) T {
+	for _, x := range more {
+		if cmp(x, v) > 0 {
+			v = x
+		}
+	}
+	return v
+}
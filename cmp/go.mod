@@ -0,0 +1,3 @@
+module darvaza.org/x/cmp
+
+go 1.22
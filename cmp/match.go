@@ -0,0 +1,131 @@
+package cmp
+
+import (
+	stdcmp "cmp"
+)
+
+// Matcher tells if a value satisfies a condition.
+type Matcher[T any] func(T) bool
+
+// And returns a [Matcher] satisfied when all the given are.
+// Without matchers it's always satisfied.
+func And[T any](matchers ...Matcher[T]) Matcher[T] {
+	return func(v T) bool {
+		for _, m := range matchers {
+			if !m(v) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a [Matcher] satisfied when any of the given is.
+// Without matchers it's never satisfied.
+func Or[T any](matchers ...Matcher[T]) Matcher[T] {
+	return func(v T) bool {
+		for _, m := range matchers {
+			if m(v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a [Matcher] negating another.
+func Not[T any](m Matcher[T]) Matcher[T] {
+	return func(v T) bool {
+		return !m(v)
+	}
+}
+
+// And returns a [Matcher] also requiring the others.
+func (m Matcher[T]) And(others ...Matcher[T]) Matcher[T] {
+	return And(append([]Matcher[T]{m}, others...)...)
+}
+
+// Or returns a [Matcher] alternatively accepting the others.
+func (m Matcher[T]) Or(others ...Matcher[T]) Matcher[T] {
+	return Or(append([]Matcher[T]{m}, others...)...)
+}
+
+// Not returns the negation of the [Matcher].
+func (m Matcher[T]) Not() Matcher[T] {
+	return Not(m)
+}
+
+// Filter returns the values satisfying the [Matcher].
+func (m Matcher[T]) Filter(values []T) []T {
+	var out []T
+	for _, v := range values {
+		if m(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Equal returns a [Matcher] for the given value.
+func Equal[T comparable](value T) Matcher[T] {
+	return func(v T) bool {
+		return v == value
+	}
+}
+
+// In returns a [Matcher] for any of the given values.
+func In[T comparable](values ...T) Matcher[T] {
+	set := make(map[T]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+
+	return func(v T) bool {
+		_, ok := set[v]
+		return ok
+	}
+}
+
+// LessThan returns a [Matcher] for values lower than the limit.
+func LessThan[T stdcmp.Ordered](limit T) Matcher[T] {
+	return func(v T) bool {
+		return v < limit
+	}
+}
+
+// AtMost returns a [Matcher] for values not greater than the limit.
+func AtMost[T stdcmp.Ordered](limit T) Matcher[T] {
+	return func(v T) bool {
+		return v <= limit
+	}
+}
+
+// GreaterThan returns a [Matcher] for values greater than the limit.
+func GreaterThan[T stdcmp.Ordered](limit T) Matcher[T] {
+	return func(v T) bool {
+		return v > limit
+	}
+}
+
+// AtLeast returns a [Matcher] for values not lower than the limit.
+func AtLeast[T stdcmp.Ordered](limit T) Matcher[T] {
+	return func(v T) bool {
+		return v >= limit
+	}
+}
+
+// Between returns a [Matcher] for values within the closed range
+// [lo, hi].
+func Between[T stdcmp.Ordered](lo, hi T) Matcher[T] {
+	return func(v T) bool {
+		return lo <= v && v <= hi
+	}
+}
+
+// BetweenFunc returns a [Matcher] for values within the closed range
+// [lo, hi] according to a custom [Compare].
+func BetweenFunc[T any](lo, hi T, cmp Compare[T]) Matcher[T] {
+	return func(v T) bool {
+		return cmp(lo, v) <= 0 && cmp(v, hi) <= 0
+	}
+}
@@ -0,0 +1,57 @@
+package cmp
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// HasPrefix returns a [Matcher] for strings starting with the prefix.
+func HasPrefix[S ~string](prefix S) Matcher[S] {
+	return func(s S) bool {
+		return strings.HasPrefix(string(s), string(prefix))
+	}
+}
+
+// HasSuffix returns a [Matcher] for strings ending with the suffix.
+func HasSuffix[S ~string](suffix S) Matcher[S] {
+	return func(s S) bool {
+		return strings.HasSuffix(string(s), string(suffix))
+	}
+}
+
+// Contains returns a [Matcher] for strings containing the substring.
+func Contains[S ~string](substr S) Matcher[S] {
+	return func(s S) bool {
+		return strings.Contains(string(s), string(substr))
+	}
+}
+
+// EqualFold returns a [Matcher] for strings equal to the given under
+// Unicode case-folding.
+func EqualFold[S ~string](value S) Matcher[S] {
+	return func(s S) bool {
+		return strings.EqualFold(string(s), string(value))
+	}
+}
+
+// Regexp returns a [Matcher] for strings matching the expression.
+func Regexp[S ~string](re *regexp.Regexp) Matcher[S] {
+	return func(s S) bool {
+		return re.MatchString(string(s))
+	}
+}
+
+// Glob returns a [Matcher] for strings matching the shell pattern,
+// as described by [path.Match]. It fails if the pattern is malformed.
+func Glob[S ~string](pattern string) (Matcher[S], error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	m := func(s S) bool {
+		ok, _ := path.Match(pattern, string(s))
+		return ok
+	}
+	return m, nil
+}
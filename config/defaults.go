@@ -5,6 +5,12 @@ import "github.com/amery/defaults"
 // SetDefaults applies `default` struct-tags and SetDefaults()
 // recursively. If the given object has a `SetDefaults() error`
 // method, it will be invoked instead.
+//
+// Tags apply only to fields holding their initial value, and are
+// parsed according to the type of the field. [time.Duration] uses
+// [time.ParseDuration], slices, maps and structs are decoded as JSON,
+// and types implementing [encoding.TextUnmarshaler] use it. Nested
+// structs, pointers to them and their slices and maps are visited too.
 func SetDefaults(v any) error {
 	if t, ok := v.(defaults.SetterWithError); ok {
 		// defaults.Set will omit this setter on
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errDefaultsTest = errors.New("defaults failed")
+
+type defaultsTestServer struct {
+	Addr    string        `default:"localhost"`
+	Port    uint16        `default:"8080"`
+	Timeout time.Duration `default:"1m30s"`
+}
+
+type defaultsTestNested struct {
+	Name    string `default:"nested"`
+	Server  defaultsTestServer
+	Backup  *defaultsTestServer `default:"{}"`
+	Missing *defaultsTestServer
+}
+
+type defaultsTestSlices struct {
+	Tags    []string             `default:"[\"a\",\"b\"]"`
+	Ports   []int                `default:"[80,443]"`
+	Empty   []string             `default:"[]"`
+	Servers []defaultsTestServer `default:"[{\"Addr\":\"a\"},{\"Port\":1}]"`
+	Limits  map[string]int       `default:"{\"conns\":10}"`
+	Preset  []string             `default:"[\"x\"]"`
+}
+
+type defaultsTestDurations struct {
+	Timeout time.Duration `default:"5s"`
+	Retry   time.Duration `default:"250ms"`
+	Raw     time.Duration `default:"1000"`
+	Preset  time.Duration `default:"1h"`
+}
+
+type defaultsTestSetter struct {
+	Name  string `default:"setter"`
+	Upper string
+}
+
+// SetDefaults is called after the tags are applied
+func (s *defaultsTestSetter) SetDefaults() {
+	s.Upper = strings.ToUpper(s.Name)
+}
+
+type defaultsTestSetterWithError struct {
+	Level string `default:"ignored"`
+}
+
+// SetDefaults replaces the tags entirely
+func (s *defaultsTestSetterWithError) SetDefaults() error {
+	if s.Level == "" {
+		s.Level = "info"
+	}
+	return nil
+}
+
+type defaultsTestSetters struct {
+	Setter    defaultsTestSetter
+	WithError defaultsTestSetterWithError
+	Ptr       *defaultsTestSetter `default:"{}"`
+}
+
+type defaultsTestText struct {
+	Addr   netip.Addr `default:"127.0.0.1"`
+	Prefix netip.Prefix
+}
+
+type defaultsTestFailing struct{}
+
+func (*defaultsTestFailing) SetDefaults() error {
+	return errDefaultsTest
+}
+
+type defaultsTestNestedFailing struct {
+	Name    string `default:"name"`
+	Failing defaultsTestFailing
+}
+
+type defaultsTestInvalid struct {
+	Tags []string `default:"[a,"`
+}
+
+func TestSetDefaults(t *testing.T) {
+	server := defaultsTestServer{Addr: "localhost", Port: 8080, Timeout: 90 * time.Second}
+
+	tests := []struct {
+		name     string
+		v        any
+		expected any
+	}{
+		{"flat",
+			&defaultsTestServer{},
+			&server},
+		{"preset",
+			&defaultsTestServer{Addr: "example.org", Port: 443},
+			&defaultsTestServer{Addr: "example.org", Port: 443, Timeout: 90 * time.Second}},
+		{"nested structs",
+			&defaultsTestNested{Server: defaultsTestServer{Port: 1}},
+			&defaultsTestNested{
+				Name:   "nested",
+				Server: defaultsTestServer{Addr: "localhost", Port: 1, Timeout: 90 * time.Second},
+				Backup: &server,
+			}},
+		{"slices and maps",
+			&defaultsTestSlices{Preset: []string{"y"}},
+			&defaultsTestSlices{
+				Tags:  []string{"a", "b"},
+				Ports: []int{80, 443},
+				Empty: []string{},
+				Servers: []defaultsTestServer{
+					{Addr: "a", Port: 8080, Timeout: 90 * time.Second},
+					{Addr: "localhost", Port: 1, Timeout: 90 * time.Second},
+				},
+				Limits: map[string]int{"conns": 10},
+				Preset: []string{"y"},
+			}},
+		{"durations",
+			&defaultsTestDurations{Preset: time.Minute},
+			&defaultsTestDurations{
+				Timeout: 5 * time.Second,
+				Retry:   250 * time.Millisecond,
+				Raw:     1000,
+				Preset:  time.Minute,
+			}},
+		{"setters",
+			&defaultsTestSetters{},
+			&defaultsTestSetters{
+				Setter:    defaultsTestSetter{Name: "setter", Upper: "SETTER"},
+				WithError: defaultsTestSetterWithError{Level: "info"},
+				Ptr:       &defaultsTestSetter{Name: "setter", Upper: "SETTER"},
+			}},
+		{"entry setter",
+			&defaultsTestSetterWithError{},
+			&defaultsTestSetterWithError{Level: "info"}},
+		{"text unmarshaler",
+			&defaultsTestText{},
+			&defaultsTestText{Addr: netip.MustParseAddr("127.0.0.1")}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := SetDefaults(tc.v); err != nil {
+				t.Fatalf("ERROR: SetDefaults() → %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.v, tc.expected) {
+				t.Errorf("ERROR: SetDefaults() → %+v (expected %+v)", tc.v, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSetDefaultsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        any
+		expected error
+	}{
+		{"entry setter", &defaultsTestFailing{}, errDefaultsTest},
+		{"nested setter", &defaultsTestNestedFailing{}, errDefaultsTest},
+		{"invalid tag", &defaultsTestInvalid{}, nil},
+		{"not a pointer", defaultsTestServer{}, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := SetDefaults(tc.v)
+			switch {
+			case err == nil:
+				t.Errorf("ERROR: SetDefaults() succeeded")
+			case tc.expected != nil && !errors.Is(err, tc.expected):
+				t.Errorf("ERROR: SetDefaults() → %v (expected %v)", err, tc.expected)
+			}
+		})
+	}
+}
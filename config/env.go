@@ -0,0 +1,294 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"darvaza.org/core"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// NewEnvOption returns an [Option] overlaying environment variables
+// onto the decoded object using [Overlay]. os.LookupEnv will be used
+// unless a custom lookup function is provided.
+func NewEnvOption[T any](prefix string, lookupEnv func(string) (string, bool)) Option[T] {
+	return func(v *T) error {
+		return Overlay(v, prefix, lookupEnv)
+	}
+}
+
+// Overlay sets the exposed fields of a struct from environment
+// variables named `PREFIX_SECTION_KEY`, where sections are the nested
+// structs. Names are the field names in upper snake case, `ListenAddr`
+// becoming `LISTEN_ADDR`, unless an `env:"NAME"` tag says otherwise.
+// `env:"-"` excludes a field, and embedded structs share the prefix
+// of their parent.
+//
+// Values are parsed according to the type of the field. Types
+// implementing [encoding.TextUnmarshaler] use it, [time.Duration]
+// uses [time.ParseDuration], slices take comma separated values
+// unless JSON encoded, and maps and structs are decoded as JSON.
+// os.LookupEnv will be used unless a custom lookup function is
+// provided.
+func Overlay(v any, prefix string, lookupEnv func(string) (string, bool)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return core.Wrap(core.ErrInvalid, "Overlay requires a non-nil pointer to a struct")
+	}
+
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	_, err := overlayStruct(rv.Elem(), strings.ToUpper(prefix), lookupEnv)
+	return err
+}
+
+// overlayStruct applies the environment to the fields of a struct,
+// reporting if any was set.
+func overlayStruct(rv reflect.Value, prefix string, lookupEnv func(string) (string, bool)) (bool, error) {
+	var changed bool
+
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		var set bool
+		var err error
+
+		sf := t.Field(i)
+		name, ok := envFieldName(sf)
+		switch {
+		case !ok:
+			continue
+		case sf.Anonymous && sf.Tag.Get("env") == "":
+			// embedded, same prefix
+			set, err = overlaySection(rv.Field(i), prefix, lookupEnv)
+		default:
+			set, err = overlayField(rv.Field(i), envJoin(prefix, name), lookupEnv)
+		}
+
+		if err != nil {
+			return changed, err
+		}
+		changed = changed || set
+	}
+	return changed, nil
+}
+
+func overlayField(rv reflect.Value, name string, lookupEnv func(string) (string, bool)) (bool, error) {
+	if s, ok := lookupEnv(name); ok {
+		if err := setEnvValue(rv, s); err != nil {
+			return false, core.Wrap(err, name)
+		}
+		return true, nil
+	}
+
+	return overlaySection(rv, name, lookupEnv)
+}
+
+// overlaySection applies the environment to nested structs,
+// or pointers to them.
+func overlaySection(rv reflect.Value, prefix string, lookupEnv func(string) (string, bool)) (bool, error) {
+	switch {
+	case isEnvSection(rv.Type()):
+		return overlayStruct(rv, prefix, lookupEnv)
+	case rv.Kind() == reflect.Ptr && isEnvSection(rv.Type().Elem()):
+		return overlayPointer(rv, prefix, lookupEnv)
+	default:
+		return false, nil
+	}
+}
+
+// overlayPointer applies the environment to a pointer to a struct,
+// only allocating it if needed.
+func overlayPointer(rv reflect.Value, name string, lookupEnv func(string) (string, bool)) (bool, error) {
+	if !rv.IsNil() {
+		return overlayStruct(rv.Elem(), name, lookupEnv)
+	}
+
+	p := reflect.New(rv.Type().Elem())
+	changed, err := overlayStruct(p.Elem(), name, lookupEnv)
+	if changed && err == nil {
+		rv.Set(p)
+	}
+	return changed, err
+}
+
+// isEnvSection tells if a type is a struct visited as a section
+// instead of being parsed as a value.
+func isEnvSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func envFieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("env")
+	switch {
+	case tag == "-":
+		return "", false
+	case tag != "":
+		return tag, sf.IsExported()
+	case sf.IsExported(), sf.Anonymous && sf.Type.Kind() == reflect.Struct:
+		// fields of embedded structs are promoted even if
+		// their type isn't exported
+		return envName(sf.Name), true
+	default:
+		return "", false
+	}
+}
+
+func envJoin(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// envName converts a Go name into upper snake case, `HTTPServer`
+// becoming `HTTP_SERVER`.
+func envName(s string) string {
+	var b strings.Builder
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && isEnvWordStart(runes, i) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func isEnvWordStart(runes []rune, i int) bool {
+	prev := runes[i-1]
+	if !unicode.IsUpper(prev) {
+		return prev != '_'
+	}
+
+	// end of an acronym
+	return i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
+
+// setEnvValue parses a value according to the type of the field.
+func setEnvValue(rv reflect.Value, s string) error {
+	if !rv.CanSet() {
+		return nil
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		p := reflect.New(rv.Type().Elem())
+		if err := setEnvValue(p.Elem(), s); err != nil {
+			return err
+		}
+		rv.Set(p)
+		return nil
+	}
+
+	if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	return setEnvKind(rv, s)
+}
+
+func setEnvKind(rv reflect.Value, s string) error {
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+		return nil
+	case reflect.Bool:
+		return setEnvBool(rv, s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return setEnvInt(rv, s)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return setEnvUint(rv, s)
+	case reflect.Float32, reflect.Float64:
+		return setEnvFloat(rv, s)
+	case reflect.Slice:
+		return setEnvSlice(rv, s)
+	default:
+		return setEnvJSON(rv, s)
+	}
+}
+
+func setEnvBool(rv reflect.Value, s string) error {
+	b, err := strconv.ParseBool(s)
+	if err == nil {
+		rv.SetBool(b)
+	}
+	return err
+}
+
+func setEnvInt(rv reflect.Value, s string) error {
+	if rv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err == nil {
+			rv.SetInt(int64(d))
+		}
+		return err
+	}
+
+	n, err := strconv.ParseInt(s, 0, rv.Type().Bits())
+	if err == nil {
+		rv.SetInt(n)
+	}
+	return err
+}
+
+func setEnvUint(rv reflect.Value, s string) error {
+	n, err := strconv.ParseUint(s, 0, rv.Type().Bits())
+	if err == nil {
+		rv.SetUint(n)
+	}
+	return err
+}
+
+func setEnvFloat(rv reflect.Value, s string) error {
+	n, err := strconv.ParseFloat(s, rv.Type().Bits())
+	if err == nil {
+		rv.SetFloat(n)
+	}
+	return err
+}
+
+// setEnvSlice parses JSON arrays or comma separated values.
+func setEnvSlice(rv reflect.Value, s string) error {
+	switch {
+	case rv.Type().Elem().Kind() == reflect.Uint8:
+		rv.SetBytes([]byte(s))
+		return nil
+	case strings.HasPrefix(strings.TrimSpace(s), "["):
+		return setEnvJSON(rv, s)
+	}
+
+	var parts []string
+	if s != "" {
+		parts = strings.Split(s, ",")
+	}
+
+	out := reflect.MakeSlice(rv.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := setEnvValue(out.Index(i), strings.TrimSpace(part)); err != nil {
+			return err
+		}
+	}
+	rv.Set(out)
+	return nil
+}
+
+func setEnvJSON(rv reflect.Value, s string) error {
+	p := reflect.New(rv.Type())
+	if err := json.Unmarshal([]byte(s), p.Interface()); err != nil {
+		return err
+	}
+	rv.Set(p.Elem())
+	return nil
+}
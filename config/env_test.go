@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+type envTestEmbedded struct {
+	Region string
+}
+
+type envTestConfig struct {
+	envTestEmbedded
+
+	Name    string
+	Debug   bool
+	Timeout time.Duration
+	Tags    []string
+	Ports   []int
+	Addr    netip.Addr
+	Limits  map[string]int
+	HTTP    struct {
+		ListenAddr string
+		Port       uint16
+	}
+	TLS *struct {
+		CertFile string `env:"CERT"`
+	}
+	Unused *struct {
+		X string
+	}
+	Skip string `env:"-"`
+}
+
+func mapLookup(m map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := m[k]
+		return v, ok
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Name", "NAME"},
+		{"ListenAddr", "LISTEN_ADDR"},
+		{"HTTPServer", "HTTP_SERVER"},
+		{"TLS", "TLS"},
+		{"CertFile2", "CERT_FILE2"},
+		{"Snake_Case", "SNAKE_CASE"},
+	}
+
+	for _, tc := range tests {
+		if s := envName(tc.name); s != tc.expected {
+			t.Errorf("ERROR: envName(%q) → %q (expected %q)", tc.name, s, tc.expected)
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	var v envTestConfig
+	v.Skip = "kept"
+
+	env := map[string]string{
+		"APP_REGION":           "eu",
+		"APP_NAME":             "foo",
+		"APP_DEBUG":            "true",
+		"APP_TIMEOUT":          "1m30s",
+		"APP_TAGS":             "a, b",
+		"APP_PORTS":            "[1, 2]",
+		"APP_ADDR":             "127.0.0.1",
+		"APP_LIMITS":           `{"a": 1}`,
+		"APP_HTTP_LISTEN_ADDR": "::",
+		"APP_HTTP_PORT":        "0x1f90",
+		"APP_TLS_CERT":         "cert.pem",
+		"APP_SKIP":             "replaced",
+	}
+
+	if err := Overlay(&v, "app", mapLookup(env)); err != nil {
+		t.Fatalf("ERROR: Overlay → %v", err)
+	}
+
+	switch {
+	case v.Region != "eu", v.Name != "foo", !v.Debug, v.Timeout != 90*time.Second:
+		t.Errorf("ERROR: Overlay → %+v", v)
+	case len(v.Tags) != 2 || v.Tags[1] != "b", len(v.Ports) != 2 || v.Ports[1] != 2:
+		t.Errorf("ERROR: slices → %q, %v", v.Tags, v.Ports)
+	case v.Addr != netip.MustParseAddr("127.0.0.1"), v.Limits["a"] != 1:
+		t.Errorf("ERROR: Addr, Limits → %v, %v", v.Addr, v.Limits)
+	case v.HTTP.ListenAddr != "::", v.HTTP.Port != 8080:
+		t.Errorf("ERROR: HTTP section → %+v", v.HTTP)
+	case v.TLS == nil || v.TLS.CertFile != "cert.pem":
+		t.Errorf("ERROR: TLS section → %+v", v.TLS)
+	case v.Unused != nil:
+		t.Errorf("ERROR: section without variables allocated → %+v", v.Unused)
+	case v.Skip != "kept":
+		t.Errorf("ERROR: excluded field set → %q", v.Skip)
+	}
+}
+
+func TestOverlayErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"APP_DEBUG", "maybe"},
+		{"APP_TIMEOUT", "soon"},
+		{"APP_HTTP_PORT", "70000"},
+		{"APP_PORTS", "1,x"},
+		{"APP_ADDR", "localhost"},
+		{"APP_LIMITS", "a=1"},
+	}
+
+	for _, tc := range tests {
+		var v envTestConfig
+
+		err := Overlay(&v, "APP", mapLookup(map[string]string{tc.name: tc.value}))
+		switch {
+		case err == nil:
+			t.Errorf("ERROR: Overlay(%s=%q) succeeded", tc.name, tc.value)
+		case !strings.Contains(err.Error(), tc.name):
+			t.Errorf("ERROR: Overlay(%s=%q) → %q doesn't name the variable", tc.name, tc.value, err)
+		}
+	}
+
+	var v envTestConfig
+	if err := Overlay(v, "APP", nil); !errors.Is(err, core.ErrInvalid) {
+		t.Errorf("ERROR: Overlay(non-pointer) → %v (expected %v)", err, core.ErrInvalid)
+	}
+}
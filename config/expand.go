@@ -0,0 +1,125 @@
+package config
+
+import (
+	"reflect"
+
+	"darvaza.org/core"
+
+	"darvaza.org/x/config/expand"
+)
+
+// NewExpandOption returns an [Option] expanding shell-style variables,
+// like `${VAR:-default}`, within the string values of the decoded
+// object. os.Getenv will be used unless a custom mapper is provided.
+func NewExpandOption[T any](getEnv func(string) string) Option[T] {
+	return func(v *T) error {
+		return Expand(v, getEnv)
+	}
+}
+
+// Expand expands shell-style variables within the exposed string
+// fields of a struct, recursively, and within the strings of its
+// slices, arrays and maps. os.Getenv will be used unless a custom
+// mapper is provided.
+func Expand(v any, getEnv func(string) string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return core.Wrap(core.ErrInvalid, "Expand requires a non-nil pointer")
+	}
+
+	return expandValue(rv.Elem(), getEnv)
+}
+
+func expandValue(rv reflect.Value, getEnv func(string) string) error {
+	switch rv.Kind() {
+	case reflect.String:
+		return expandString(rv, getEnv)
+	case reflect.Ptr, reflect.Interface:
+		return expandElem(rv, getEnv)
+	case reflect.Struct:
+		return expandStruct(rv, getEnv)
+	case reflect.Slice, reflect.Array:
+		return expandSlice(rv, getEnv)
+	case reflect.Map:
+		return expandMap(rv, getEnv)
+	default:
+		return nil
+	}
+}
+
+func expandString(rv reflect.Value, getEnv func(string) string) error {
+	if !rv.CanSet() {
+		return nil
+	}
+
+	s, err := expand.FromString(rv.String(), getEnv)
+	if err != nil {
+		return err
+	}
+
+	rv.SetString(s)
+	return nil
+}
+
+func expandElem(rv reflect.Value, getEnv func(string) string) error {
+	if rv.IsNil() {
+		return nil
+	}
+
+	elem := rv.Elem()
+	if rv.Kind() == reflect.Interface {
+		// interface values aren't addressable,
+		// expand a copy and store it back.
+		if !rv.CanSet() {
+			return nil
+		}
+
+		cp := reflect.New(elem.Type()).Elem()
+		cp.Set(elem)
+		if err := expandValue(cp, getEnv); err != nil {
+			return err
+		}
+		rv.Set(cp)
+		return nil
+	}
+
+	return expandValue(elem, getEnv)
+}
+
+func expandStruct(rv reflect.Value, getEnv func(string) string) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+
+		if err := expandValue(rv.Field(i), getEnv); err != nil {
+			return core.Wrap(err, t.Field(i).Name)
+		}
+	}
+	return nil
+}
+
+func expandSlice(rv reflect.Value, getEnv func(string) string) error {
+	for i := 0; i < rv.Len(); i++ {
+		if err := expandValue(rv.Index(i), getEnv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func expandMap(rv reflect.Value, getEnv func(string) string) error {
+	iter := rv.MapRange()
+	for iter.Next() {
+		// map values aren't addressable,
+		// expand a copy and store it back.
+		v := reflect.New(iter.Value().Type()).Elem()
+		v.Set(iter.Value())
+		if err := expandValue(v, getEnv); err != nil {
+			return err
+		}
+		rv.SetMapIndex(iter.Key(), v)
+	}
+	return nil
+}
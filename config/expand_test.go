@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+type expandTestConfig struct {
+	Name   string
+	Nested struct {
+		Path string
+	}
+	Ptr    *string
+	Any    any
+	List   []string
+	Map    map[string]string
+	Number int
+	hidden string
+}
+
+func TestExpand(t *testing.T) {
+	env := map[string]string{"HOME": "/home/foo", "USER": "foo"}
+	getEnv := func(k string) string { return env[k] }
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"${USER}", "foo"},
+		{"$USER", "foo"},
+		{"${HOME}/.cache", "/home/foo/.cache"},
+		{"${MISSING}", ""},
+		{"${MISSING:-bar}", "bar"},
+		{"${USER:-bar}", "foo"},
+		{`\${USER}`, "${USER}"},
+		{"plain", "plain"},
+	}
+
+	for _, tc := range tests {
+		ptr := tc.value
+		v := expandTestConfig{
+			Name:   tc.value,
+			Ptr:    &ptr,
+			Any:    tc.value,
+			List:   []string{tc.value},
+			Map:    map[string]string{"k": tc.value},
+			hidden: tc.value,
+		}
+		v.Nested.Path = tc.value
+
+		if err := Expand(&v, getEnv); err != nil {
+			t.Errorf("ERROR: Expand(%q) → %v", tc.value, err)
+			continue
+		}
+
+		anyValue, _ := v.Any.(string)
+		got := []string{v.Name, v.Nested.Path, *v.Ptr, anyValue, v.List[0], v.Map["k"]}
+		for _, s := range got {
+			if s != tc.expected {
+				t.Errorf("ERROR: Expand(%q) → %q (expected %q)", tc.value, got, tc.expected)
+				break
+			}
+		}
+		if v.hidden != tc.value {
+			t.Errorf("ERROR: unexported field expanded → %q", v.hidden)
+		}
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	v := expandTestConfig{Name: "${USER"}
+	err := Expand(&v, nil)
+	switch {
+	case err == nil:
+		t.Error("ERROR: Expand accepted an unterminated reference")
+	case !strings.Contains(err.Error(), "Name"):
+		t.Errorf("ERROR: Expand → %q doesn't name the field", err)
+	}
+
+	if err := Expand(v, nil); !errors.Is(err, core.ErrInvalid) {
+		t.Errorf("ERROR: Expand(non-pointer) → %v (expected %v)", err, core.ErrInvalid)
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"darvaza.org/core"
+)
+
+// Format describes a config file format.
+type Format struct {
+	// Name identifies the format, like `json`.
+	Name string
+
+	// Extensions are the filename extensions of the format,
+	// including the leading `.`.
+	Extensions []string
+
+	// Sniff tells if the content looks like this format.
+	Sniff func([]byte) bool
+
+	// Unmarshal decodes the content. Objects must be decoded
+	// as map[string]any.
+	Unmarshal func([]byte, any) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = []*Format{
+		{
+			Name:       "json",
+			Extensions: []string{".json"},
+			Sniff:      SniffJSON,
+			Unmarshal:  unmarshalJSON,
+		},
+		{
+			Name:       "toml",
+			Extensions: []string{".toml"},
+			Sniff:      SniffTOML,
+			Unmarshal:  toml.Unmarshal,
+		},
+		{
+			Name:       "yaml",
+			Extensions: []string{".yaml", ".yml"},
+			Sniff:      SniffYAML,
+			Unmarshal:  yaml.Unmarshal,
+		},
+	}
+)
+
+// RegisterFormat adds a [Format], or replaces the one with the same
+// name. Formats are sniffed in the order they were registered.
+func RegisterFormat(f Format) error {
+	if f.Name == "" {
+		return core.Wrap(core.ErrInvalid, "format without name")
+	}
+
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	for i, p := range formats {
+		if p.Name == f.Name {
+			formats[i] = &f
+			return nil
+		}
+	}
+
+	formats = append(formats, &f)
+	return nil
+}
+
+// SetUnmarshal replaces the decoder of a registered [Format]. JSON,
+// TOML and YAML are supported out of the box, using the standard
+// library, `github.com/BurntSushi/toml` and `gopkg.in/yaml.v3`.
+func SetUnmarshal(name string, fn func([]byte, any) error) error {
+	f, err := GetFormat(name)
+	if err != nil {
+		return err
+	}
+
+	f.Unmarshal = fn
+	return RegisterFormat(f)
+}
+
+// GetFormat returns a copy of the registered [Format] of the given
+// name.
+func GetFormat(name string) (Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, f := range formats {
+		if f.Name == name {
+			return *f, nil
+		}
+	}
+
+	return Format{}, core.Wrapf(core.ErrNotExists, "format %q", name)
+}
+
+// DetectFormat returns the [Format] of a file, by the extension of its
+// name or, if unknown, by sniffing its content.
+func DetectFormat(name string, data []byte) (Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	if ext := strings.ToLower(path.Ext(name)); ext != "" {
+		for _, f := range formats {
+			if core.SliceContains(f.Extensions, ext) {
+				return *f, nil
+			}
+		}
+	}
+
+	for _, f := range formats {
+		if f.Sniff != nil && f.Sniff(data) {
+			return *f, nil
+		}
+	}
+
+	return Format{}, core.Wrap(core.ErrNotExists, "unknown format")
+}
+
+// Decode decodes the content of a file into a map after
+// detecting its [Format].
+func Decode(name string, data []byte) (map[string]any, error) {
+	f, err := DetectFormat(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Unmarshal == nil {
+		return nil, core.Wrapf(core.ErrNotImplemented, "%s decoder", f.Name)
+	}
+
+	m := make(map[string]any)
+	if len(bytes.TrimSpace(data)) == 0 {
+		// empty file
+		return m, nil
+	}
+
+	if err := f.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DecodeFormat decodes the content of a file of any registered
+// [Format] into a new [T] object, using its `json` field names.
+func DecodeFormat[T any](name string, data []byte) (*T, error) {
+	m, err := Decode(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeMap[T](m)
+}
+
+// NewFormatDecoder returns a [Decoder] for every registered [Format],
+// to be used as [Loader.NewDecoder].
+func NewFormatDecoder[T any](string) (Decoder[T], error) {
+	return DecoderFunc[T](DecodeFormat[T]), nil
+}
+
+func decodeMap[T any](m map[string]any) (*T, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(T)
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func unmarshalJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// preserve large integers
+	dec.UseNumber()
+	return dec.Decode(v)
+}
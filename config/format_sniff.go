@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// SniffJSON tells if the content looks like a JSON object.
+func SniffJSON(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// SniffTOML tells if the first statement of the content looks like
+// a TOML table header or key/value pair.
+func SniffTOML(data []byte) bool {
+	line, ok := firstLine(data)
+	switch {
+	case !ok:
+		return false
+	case strings.HasPrefix(line, "["):
+		return strings.HasSuffix(line, "]")
+	default:
+		key, _, ok := strings.Cut(line, "=")
+		return ok && isBareKey(strings.TrimSpace(key))
+	}
+}
+
+// SniffYAML tells if the content starts like a YAML document or
+// mapping.
+func SniffYAML(data []byte) bool {
+	line, ok := firstLine(data)
+	switch {
+	case !ok:
+		return false
+	case line == "---", strings.HasPrefix(line, "%YAML"):
+		return true
+	default:
+		key, _, ok := strings.Cut(line, ":")
+		return ok && isBareKey(strings.TrimSpace(key))
+	}
+}
+
+// firstLine returns the first line that isn't empty or a comment.
+func firstLine(data []byte) (string, bool) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && line[0] != '#' {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// isBareKey tells if a key is valid unquoted in TOML, and wouldn't
+// need quoting in YAML either.
+func isBareKey(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case r == '_', r == '-', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
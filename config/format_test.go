@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"darvaza.org/core"
+)
+
+type formatTestConfig struct {
+	Name string `json:"name"`
+	Big  int64  `json:"big"`
+	HTTP struct {
+		Addr string   `json:"addr"`
+		Port int      `json:"port"`
+		Tags []string `json:"tags"`
+	} `json:"http"`
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"config.json", "", "json"},
+		{"config.TOML", "", "toml"},
+		{"config.yml", "", "yaml"},
+		{"config.yaml", "{}", "yaml"},
+		{"config", `{"a": 1}`, "json"},
+		{"config", "# comment\n[server]\nport = 1", "toml"},
+		{"config", "key = 1", "toml"},
+		{"config", "---\na: 1", "yaml"},
+		{"config", "a: 1", "yaml"},
+		{"config.conf", "a: 1", "yaml"},
+	}
+
+	for _, tc := range tests {
+		f, err := DetectFormat(tc.name, []byte(tc.data))
+		switch {
+		case err != nil:
+			t.Errorf("ERROR: DetectFormat(%q, %q) → %v", tc.name, tc.data, err)
+		case f.Name != tc.expected:
+			t.Errorf("ERROR: DetectFormat(%q, %q) → %q (expected %q)",
+				tc.name, tc.data, f.Name, tc.expected)
+		}
+	}
+
+	if _, err := DetectFormat("config", []byte("plain text")); !errors.Is(err, core.ErrNotExists) {
+		t.Errorf("ERROR: DetectFormat(plain text) → %v (expected %v)", err, core.ErrNotExists)
+	}
+}
+
+func TestDecodeFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"c.json", `{"name": "x", "big": 9007199254740993, "http": {"port": 80, "tags": ["a", "b"]}}`},
+		{"c.toml", "name = \"x\"\nbig = 9007199254740993\n[http]\nport = 80\ntags = [\"a\", \"b\"]\n"},
+		{"c.yaml", "name: x\nbig: 9007199254740993\nhttp:\n  port: 80\n  tags: [a, b]\n"},
+	}
+
+	for _, tc := range tests {
+		v, err := DecodeFormat[formatTestConfig](tc.name, []byte(tc.data))
+		switch {
+		case err != nil:
+			t.Errorf("ERROR: DecodeFormat(%q) → %v", tc.name, err)
+		case v.Name != "x", v.Big != 9007199254740993, v.HTTP.Port != 80, len(v.HTTP.Tags) != 2:
+			t.Errorf("ERROR: DecodeFormat(%q) → %+v", tc.name, v)
+		}
+	}
+
+	if _, err := DecodeFormat[formatTestConfig]("c.json", []byte("{")); err == nil {
+		t.Error("ERROR: DecodeFormat accepted invalid JSON")
+	}
+}
+
+func TestNewFromFiles(t *testing.T) {
+	fSys := fstest.MapFS{
+		"base.json":     {Data: []byte(`{"name": "base", "http": {"addr": "::", "port": 1, "tags": ["a"]}}`)},
+		"override.yaml": {Data: []byte("http:\n  port: 2\n  tags: [b]\n")},
+		"local.toml":    {Data: []byte("name = \"local\"\n")},
+		"empty.json":    {Data: []byte("\n")},
+		"broken.json":   {Data: []byte("{")},
+	}
+
+	var l Loader[formatTestConfig]
+	v, err := l.NewFromFiles(fSys, "base.json", "missing.json", "override.yaml", "empty.json", "local.toml")
+	if err != nil {
+		t.Fatalf("ERROR: NewFromFiles → %v", err)
+	}
+
+	switch {
+	case v.Name != "local":
+		t.Errorf("ERROR: name %q (expected %q)", v.Name, "local")
+	case v.HTTP.Addr != "::":
+		t.Errorf("ERROR: http.addr %q not kept from base", v.HTTP.Addr)
+	case v.HTTP.Port != 2:
+		t.Errorf("ERROR: http.port %v (expected %v)", v.HTTP.Port, 2)
+	case len(v.HTTP.Tags) != 1 || v.HTTP.Tags[0] != "b":
+		t.Errorf("ERROR: http.tags %q not replaced", v.HTTP.Tags)
+	}
+
+	if _, name := l.Last(); name != "local.toml" {
+		t.Errorf("ERROR: Last() → %q (expected %q)", name, "local.toml")
+	}
+
+	// the order matters
+	v, _ = l.NewFromFiles(fSys, "local.toml", "base.json")
+	if v == nil || v.Name != "base" {
+		t.Errorf("ERROR: NewFromFiles(local, base) → %+v", v)
+	}
+
+	if _, err := l.NewFromFiles(fSys, "base.json", "broken.json"); err == nil {
+		t.Error("ERROR: NewFromFiles accepted a broken file")
+	}
+
+	if _, err := l.NewFromFiles(fSys, "missing.json"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("ERROR: NewFromFiles(missing) → %v (expected %v)", err, fs.ErrInvalid)
+	}
+}
+
+func TestMergeMaps(t *testing.T) {
+	dst := map[string]any{
+		"a": 1,
+		"b": map[string]any{"x": 1, "y": 1},
+		"c": map[string]any{"x": 1},
+	}
+	src := map[string]any{
+		"b": map[string]any{"y": 2, "z": 2},
+		"c": "replaced",
+		"d": 2,
+	}
+
+	out := MergeMaps(dst, src)
+	b, _ := out["b"].(map[string]any)
+	switch {
+	case out["a"] != 1, out["c"] != "replaced", out["d"] != 2:
+		t.Errorf("ERROR: MergeMaps → %v", out)
+	case b["x"] != 1, b["y"] != 2, b["z"] != 2:
+		t.Errorf("ERROR: MergeMaps nested → %v", b)
+	}
+}
@@ -2,11 +2,17 @@ module darvaza.org/x/config
 
 go 1.22
 
-require darvaza.org/core v0.16.0
+require (
+	darvaza.org/core v0.16.0
+	darvaza.org/x/fs v0.5.0
+	darvaza.org/x/sync v0.1.0
+)
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/amery/defaults v0.1.0
 	github.com/go-playground/validator/v10 v10.24.0
+	gopkg.in/yaml.v3 v3.0.1
 	mvdan.cc/sh/v3 v3.10.0
 )
 
@@ -21,3 +27,8 @@ require (
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
+
+replace (
+	darvaza.org/x/fs => ../fs
+	darvaza.org/x/sync => ../sync
+)
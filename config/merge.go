@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+)
+
+// NewFromFiles decodes and merges, in order, all the given files
+// that exist, of any registered [Format], before decoding the result
+// into a new object using its `json` field names. Objects are merged
+// recursively while any other value replaces the previous one.
+func (l *Loader[T]) NewFromFiles(fSys fs.FS, names ...string) (*T, error) {
+	var merged map[string]any
+
+	for _, name := range names {
+		m, err := l.readMap(fSys, name)
+		switch {
+		case err != nil:
+			return nil, NewPathError(name, "decode", err)
+		case m != nil:
+			merged = MergeMaps(merged, m)
+		}
+	}
+
+	if merged == nil {
+		return nil, NewPathError("", "load", fs.ErrInvalid)
+	}
+
+	v, err := decodeMap[T](merged)
+	if err != nil {
+		return nil, NewPathError(l.lastName, "decode", err)
+	}
+
+	return l.applyOptions(v)
+}
+
+// readMap decodes a file, returning nil if it should be skipped.
+func (l *Loader[T]) readMap(fSys fs.FS, name string) (map[string]any, error) {
+	data, err := fs.ReadFile(fSys, name)
+	if err == nil {
+		var m map[string]any
+
+		m, err = Decode(name, data)
+		if err == nil {
+			l.remember(fSys, name)
+			return m, nil
+		}
+	}
+
+	if os.IsNotExist(err) || l.IsSkip != nil && l.IsSkip(err) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// MergeMaps merges src into dst recursively, replacing values unless
+// both are maps. dst is returned, created if nil.
+func MergeMaps(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = make(map[string]any, len(src))
+	}
+
+	for k, v := range src {
+		a, ok1 := dst[k].(map[string]any)
+		b, ok2 := v.(map[string]any)
+		if ok1 && ok2 {
+			dst[k] = MergeMaps(a, b)
+		} else {
+			dst[k] = v
+		}
+	}
+	return dst
+}
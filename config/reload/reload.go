@@ -0,0 +1,186 @@
+// Package reload re-loads configuration when its files change,
+// publishing every valid new version to subscribers.
+package reload
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"darvaza.org/core"
+	"darvaza.org/x/fs/watch"
+	xsync "darvaza.org/x/sync"
+
+	"darvaza.org/x/config"
+)
+
+// DefaultEventBuffer is the number of [Event]s buffered for
+// each subscriber when the [Config] doesn't say.
+const DefaultEventBuffer = 8
+
+// ErrClosed indicates the [Watcher] has been closed.
+var ErrClosed = watch.ErrClosed
+
+// Event describes a change of configuration.
+type Event[T any] struct {
+	Current  *T
+	Previous *T
+}
+
+// Config describes how a [Watcher] obtains the configuration.
+type Config[T any] struct {
+	// Load reads the configuration, for example using
+	// a [config.Loader]. Required.
+	Load func(ctx context.Context) (*T, error)
+
+	// Files are the paths watched for changes, using
+	// [watch.Files]. Required.
+	Files []string
+
+	// Validate checks new configurations before they are
+	// published. Default is [config.Validate].
+	Validate func(*T) error
+
+	// OnError is called, if set, for every failure during
+	// [Watcher.Run], including failed reloads.
+	OnError func(error)
+
+	// Watch configures the file watcher.
+	Watch *watch.Config
+
+	// EventBuffer is the number of [Event]s buffered for each
+	// subscriber. Slow subscribers miss events.
+	EventBuffer int
+}
+
+// SetDefaults fills the gaps in the [Config].
+func (cfg *Config[T]) SetDefaults() {
+	if cfg.Validate == nil {
+		cfg.Validate = func(v *T) error { return config.Validate(v) }
+	}
+	if cfg.EventBuffer <= 0 {
+		cfg.EventBuffer = DefaultEventBuffer
+	}
+}
+
+// Watcher holds the current configuration, replacing it when
+// the watched files change.
+type Watcher[T any] struct {
+	mu      sync.Mutex // serialises reloads
+	current atomic.Pointer[T]
+	events  *xsync.Broadcaster[Event[T]]
+	fw      *watch.Files
+	cfg     Config[T]
+}
+
+// New creates a [Watcher] loading the initial configuration.
+func New[T any](ctx context.Context, cfg *Config[T]) (*Watcher[T], error) {
+	switch {
+	case cfg == nil:
+		return nil, core.Wrap(core.ErrInvalid, "config not provided")
+	case cfg.Load == nil:
+		return nil, core.Wrap(core.ErrInvalid, "loader not provided")
+	case len(cfg.Files) == 0:
+		return nil, core.Wrap(core.ErrInvalid, "files not provided")
+	}
+
+	w := &Watcher[T]{cfg: *cfg}
+	w.cfg.SetDefaults()
+
+	v, err := w.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(v)
+
+	if err := w.init(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Watcher[T]) init() error {
+	fw, err := watch.NewFiles(w.cfg.Watch, w.cfg.Files...)
+	if err != nil {
+		return err
+	}
+
+	w.fw = fw
+	w.events = xsync.NewBroadcaster[Event[T]](w.cfg.EventBuffer, xsync.BroadcastDrop)
+	return nil
+}
+
+// Current returns the configuration in use.
+func (w *Watcher[T]) Current() *T {
+	return w.current.Load()
+}
+
+// Subscribe returns a subscription to the [Event]s of every
+// change of configuration.
+func (w *Watcher[T]) Subscribe() (*xsync.Subscription[Event[T]], error) {
+	return w.events.Subscribe()
+}
+
+// Close stops watching the files and ends all subscriptions.
+func (w *Watcher[T]) Close() error {
+	err := w.fw.Close()
+	_ = w.events.Close()
+	return err
+}
+
+// Reload loads and validates the configuration, replacing the
+// current one and publishing the change. On failure the current
+// configuration remains in use. Unchanged configurations aren't
+// published.
+func (w *Watcher[T]) Reload(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	v, err := w.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	prev := w.current.Load()
+	if reflect.DeepEqual(prev, v) {
+		return nil
+	}
+
+	w.current.Store(v)
+	_ = w.events.Publish(ctx, Event[T]{Current: v, Previous: prev})
+	return nil
+}
+
+func (w *Watcher[T]) load(ctx context.Context) (*T, error) {
+	v, err := w.cfg.Load(ctx)
+	switch {
+	case err != nil:
+		return nil, core.Wrap(err, "failed to load config")
+	case v == nil:
+		return nil, core.Wrap(core.ErrInvalid, "loader returned no config")
+	}
+
+	if err := w.cfg.Validate(v); err != nil {
+		return nil, core.Wrap(err, "invalid config")
+	}
+	return v, nil
+}
+
+// Run reloads the configuration whenever the watched files change,
+// until the context is cancelled or the [Watcher] closed.
+func (w *Watcher[T]) Run(ctx context.Context) error {
+	return w.fw.Run(ctx, w.handle, w.onError)
+}
+
+func (w *Watcher[T]) handle(ctx context.Context, _ []watch.Event) {
+	if err := w.Reload(ctx); err != nil {
+		w.onError(err)
+	}
+}
+
+func (w *Watcher[T]) onError(err error) {
+	if fn := w.cfg.OnError; fn != nil {
+		fn(err)
+	}
+}
@@ -0,0 +1,179 @@
+package reload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"darvaza.org/x/fs/watch"
+)
+
+type testConfig struct {
+	Port int `json:"port"`
+}
+
+func newTestConfig(t *testing.T, data string) (*Config[testConfig], string) {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, name, data)
+
+	cfg := &Config[testConfig]{
+		Files: []string{name},
+		Load: func(context.Context) (*testConfig, error) {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+
+			v := new(testConfig)
+			if err := json.Unmarshal(data, v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		},
+		Validate: func(v *testConfig) error {
+			if v.Port <= 0 {
+				return errors.New("port required")
+			}
+			return nil
+		},
+		Watch: &watch.Config{Debounce: 10 * time.Millisecond},
+	}
+	return cfg, name
+}
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	cfg, _ := newTestConfig(t, `{"port": 0}`)
+	if _, err := New(context.Background(), cfg); err == nil {
+		t.Error("ERROR: New accepted an invalid config")
+	}
+
+	cfg, _ = newTestConfig(t, `{"port": 1}`)
+	w, err := New(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if v := w.Current(); v.Port != 1 {
+		t.Errorf("ERROR: Current() → %+v (expected port %v)", v, 1)
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	cfg, name := newTestConfig(t, `{"port": 1}`)
+
+	w, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	s, err := w.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unchanged, not published
+	if err := w.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-s.C():
+		t.Errorf("ERROR: unchanged config published: %+v", ev.Current)
+	default:
+	}
+
+	// failures keep the current
+	for _, data := range []string{"{", `{"port": 0}`} {
+		writeFile(t, name, data)
+		if err := w.Reload(ctx); err == nil {
+			t.Errorf("ERROR: Reload(%q) succeeded", data)
+		}
+		if v := w.Current(); v.Port != 1 {
+			t.Errorf("ERROR: Current() after Reload(%q) → %+v", data, v)
+		}
+	}
+
+	writeFile(t, name, `{"port": 2}`)
+	if err := w.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := <-s.C()
+	if ev.Current.Port != 2 || ev.Previous.Port != 1 {
+		t.Errorf("ERROR: event %+v → %+v (expected 1 → 2)", ev.Previous, ev.Current)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 8)
+	cfg, name := newTestConfig(t, `{"port": 1}`)
+	cfg.OnError = func(err error) { errs <- err }
+
+	w, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := w.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	// other files in the directory are ignored
+	writeFile(t, filepath.Join(filepath.Dir(name), "other.json"), "{")
+
+	writeFile(t, name, `{"port": 2}`)
+	select {
+	case ev := <-s.C():
+		if ev.Current.Port != 2 || w.Current().Port != 2 {
+			t.Errorf("ERROR: event → %+v (expected port 2)", ev.Current)
+		}
+	case err := <-errs:
+		t.Fatalf("ERROR: reload failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ERROR: change not noticed")
+	}
+
+	writeFile(t, name, `{"port": 0}`)
+	select {
+	case <-errs:
+	case ev := <-s.C():
+		t.Errorf("ERROR: invalid config published: %+v", ev.Current)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ERROR: failed reload not reported")
+	}
+	if v := w.Current(); v.Port != 2 {
+		t.Errorf("ERROR: Current() after failed reload → %+v", v)
+	}
+
+	_ = w.Close()
+	select {
+	case err := <-done:
+		if err != ErrClosed {
+			t.Errorf("ERROR: Run() → %v (expected %v)", err, ErrClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ERROR: Run() didn't return after Close()")
+	}
+}
@@ -0,0 +1,201 @@
+// Package pqueue implements generic priority queues on top of a
+// binary heap, allowing to update or remove queued items via their
+// handles.
+package pqueue
+
+import (
+	"cmp"
+	"container/heap"
+
+	"darvaza.org/core"
+)
+
+// Item is the handle of a value in a [Queue].
+type Item[T, P any] struct {
+	value    T
+	priority P
+	owner    *itemHeap[T, P]
+	index    int
+	seq      uint64
+}
+
+// Value returns the value of the [Item].
+func (it *Item[T, P]) Value() T {
+	return it.value
+}
+
+// Priority returns the priority of the [Item].
+func (it *Item[T, P]) Priority() P {
+	return it.priority
+}
+
+// Queued tells if the [Item] is still in a [Queue].
+func (it *Item[T, P]) Queued() bool {
+	return it != nil && it.owner != nil
+}
+
+// Queue is a priority queue popping first the values with the lowest
+// priority according to its less function, and items of the same
+// priority in the order they were pushed. The zero value isn't
+// usable, and a Queue isn't safe for concurrent use.
+type Queue[T, P any] struct {
+	h itemHeap[T, P]
+}
+
+// New creates a [Queue] ordering priorities with the given
+// function.
+func New[T, P any](less func(a, b P) bool) (*Queue[T, P], error) {
+	if less == nil {
+		return nil, core.Wrap(core.ErrInvalid, "less function not provided")
+	}
+
+	q := &Queue[T, P]{
+		h: itemHeap[T, P]{less: less},
+	}
+	return q, nil
+}
+
+// NewOrdered creates a [Queue] popping the lowest priorities first.
+func NewOrdered[T any, P cmp.Ordered]() *Queue[T, P] {
+	return &Queue[T, P]{
+		h: itemHeap[T, P]{less: cmp.Less[P]},
+	}
+}
+
+// Len returns the number of items in the queue.
+func (q *Queue[T, P]) Len() int {
+	if q == nil {
+		return 0
+	}
+	return len(q.h.items)
+}
+
+// Push adds a value to the queue, returning its handle.
+func (q *Queue[T, P]) Push(v T, priority P) *Item[T, P] {
+	it := &Item[T, P]{
+		value:    v,
+		priority: priority,
+		seq:      q.h.seq,
+	}
+	q.h.seq++
+
+	heap.Push(&q.h, it)
+	return it
+}
+
+// Peek returns the next item without removing it.
+func (q *Queue[T, P]) Peek() (*Item[T, P], bool) {
+	if q.Len() == 0 {
+		return nil, false
+	}
+	return q.h.items[0], true
+}
+
+// Pop removes and returns the next item.
+func (q *Queue[T, P]) Pop() (*Item[T, P], bool) {
+	if q.Len() == 0 {
+		return nil, false
+	}
+	it, _ := heap.Pop(&q.h).(*Item[T, P])
+	return it, true
+}
+
+// Update changes the priority of a queued item, returning false if
+// the item doesn't belong to the queue.
+func (q *Queue[T, P]) Update(it *Item[T, P], priority P) bool {
+	if !q.owns(it) {
+		return false
+	}
+
+	it.priority = priority
+	heap.Fix(&q.h, it.index)
+	return true
+}
+
+// Remove removes a queued item, returning false if the item doesn't
+// belong to the queue.
+func (q *Queue[T, P]) Remove(it *Item[T, P]) bool {
+	if !q.owns(it) {
+		return false
+	}
+
+	heap.Remove(&q.h, it.index)
+	return true
+}
+
+// Clear removes all items from the queue.
+func (q *Queue[T, P]) Clear() {
+	if q == nil {
+		return
+	}
+
+	for _, it := range q.h.items {
+		it.owner = nil
+	}
+
+	clear(q.h.items)
+	q.h.items = q.h.items[:0]
+}
+
+// Values returns the queued values in no particular order.
+func (q *Queue[T, P]) Values() []T {
+	if q == nil {
+		return nil
+	}
+
+	out := make([]T, 0, len(q.h.items))
+	for _, it := range q.h.items {
+		out = append(out, it.value)
+	}
+	return out
+}
+
+func (q *Queue[T, P]) owns(it *Item[T, P]) bool {
+	return q != nil && it != nil && it.owner == &q.h
+}
+
+// itemHeap implements [heap.Interface].
+type itemHeap[T, P any] struct {
+	items []*Item[T, P]
+	less  func(a, b P) bool
+	seq   uint64
+}
+
+func (h *itemHeap[T, P]) Len() int { return len(h.items) }
+
+func (h *itemHeap[T, P]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	switch {
+	case h.less(a.priority, b.priority):
+		return true
+	case h.less(b.priority, a.priority):
+		return false
+	default:
+		// FIFO within the same priority
+		return a.seq < b.seq
+	}
+}
+
+func (h *itemHeap[T, P]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *itemHeap[T, P]) Push(x any) {
+	it, _ := x.(*Item[T, P])
+	it.owner = h
+	it.index = len(h.items)
+	h.items = append(h.items, it)
+}
+
+func (h *itemHeap[T, P]) Pop() any {
+	n := len(h.items) - 1
+	it := h.items[n]
+	h.items[n] = nil
+	h.items = h.items[:n]
+
+	it.owner = nil
+	it.index = -1
+	return it
+}
@@ -0,0 +1,119 @@
+package pqueue
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+func popAll[T, P any](q *Queue[T, P]) []T {
+	var out []T
+	for {
+		it, ok := q.Pop()
+		if !ok {
+			return out
+		}
+		out = append(out, it.Value())
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	q := NewOrdered[int, int]()
+
+	values := rand.Perm(100)
+	for _, v := range values {
+		q.Push(v, v)
+	}
+
+	got := popAll(q)
+	slices.Sort(values)
+	if !slices.Equal(got, values) {
+		t.Errorf("ERROR: popped %v (expected %v)", got, values)
+	}
+
+	// FIFO within the same priority
+	q2 := NewOrdered[string, int]()
+	for _, v := range []string{"x", "a", "b", "c"} {
+		q2.Push(v, core.IIf(v == "x", 1, 0))
+	}
+	if got := popAll(q2); !slices.Equal(got, []string{"a", "b", "c", "x"}) {
+		t.Errorf("ERROR: popped %v (expected [a b c x])", got)
+	}
+
+	// custom order
+	q3, err := New[string](func(a, b int) bool { return a > b })
+	if err != nil {
+		t.Fatal(err)
+	}
+	q3.Push("low", 1)
+	q3.Push("high", 9)
+	if it, _ := q3.Peek(); it.Value() != "high" || q3.Len() != 2 {
+		t.Errorf("ERROR: Peek() → %v (expected high)", it.Value())
+	}
+
+	if _, err := New[string, int](nil); err == nil {
+		t.Error("ERROR: New accepted a nil less function")
+	}
+}
+
+func TestQueueHandles(t *testing.T) {
+	q := NewOrdered[string, int]()
+	a := q.Push("a", 1)
+	b := q.Push("b", 2)
+	c := q.Push("c", 3)
+
+	if !q.Update(c, 0) || c.Priority() != 0 {
+		t.Error("ERROR: Update(c, 0) failed")
+	}
+	if !q.Remove(a) || a.Queued() {
+		t.Error("ERROR: Remove(a) failed")
+	}
+
+	// already removed
+	if q.Remove(a) {
+		t.Error("ERROR: Remove(a) succeeded twice")
+	}
+	if q.Update(a, 5) {
+		t.Error("ERROR: Update() of a removed item succeeded")
+	}
+
+	// foreign
+	other := NewOrdered[string, int]()
+	if other.Remove(b) || other.Update(b, 0) {
+		t.Error("ERROR: foreign queue accepted an item")
+	}
+	if q.Remove(nil) {
+		t.Error("ERROR: Remove(nil) succeeded")
+	}
+
+	if got := popAll(q); !slices.Equal(got, []string{"c", "b"}) {
+		t.Errorf("ERROR: popped %v (expected [c b])", got)
+	}
+	if b.Queued() || q.Update(b, 0) {
+		t.Error("ERROR: popped item still queued")
+	}
+}
+
+func TestQueueEmpty(t *testing.T) {
+	q := NewOrdered[int, int]()
+	if it, ok := q.Pop(); ok || it != nil {
+		t.Errorf("ERROR: Pop() on empty → %v, %v", it, ok)
+	}
+	if it, ok := q.Peek(); ok || it != nil {
+		t.Errorf("ERROR: Peek() on empty → %v, %v", it, ok)
+	}
+
+	it := q.Push(1, 1)
+	q.Push(2, 2)
+	q.Clear()
+	if q.Len() != 0 || it.Queued() || len(q.Values()) != 0 {
+		t.Error("ERROR: Clear() left items queued")
+	}
+
+	var nilQueue *Queue[int, int]
+	if _, ok := nilQueue.Pop(); ok || nilQueue.Len() != 0 {
+		t.Error("ERROR: nil queue not empty")
+	}
+}
@@ -0,0 +1,204 @@
+package pqueue
+
+import (
+	"cmp"
+	"context"
+	"io/fs"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// ErrClosed indicates the queue has been closed.
+var ErrClosed = fs.ErrClosed
+
+// Sync is a [Queue] safe for concurrent use, whose consumers can
+// wait for values to be pushed. The priority of an [Item] shouldn't
+// be read while another goroutine may update it.
+type Sync[T, P any] struct {
+	mu     sync.Mutex
+	q      *Queue[T, P]
+	ready  chan struct{}
+	closed bool
+}
+
+// NewSync creates a [Sync] queue ordering priorities with the
+// given function.
+func NewSync[T, P any](less func(a, b P) bool) (*Sync[T, P], error) {
+	q, err := New[T](less)
+	if err != nil {
+		return nil, err
+	}
+	return &Sync[T, P]{q: q}, nil
+}
+
+// NewSyncOrdered creates a [Sync] queue popping the lowest priorities
+// first.
+func NewSyncOrdered[T any, P cmp.Ordered]() *Sync[T, P] {
+	return &Sync[T, P]{q: NewOrdered[T, P]()}
+}
+
+// Len returns the number of items in the queue.
+func (s *Sync[T, P]) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.q.Len()
+}
+
+// Push adds a value to the queue, returning its handle. Push fails
+// with [ErrClosed] if the queue is closed.
+func (s *Sync[T, P]) Push(v T, priority P) (*Item[T, P], error) {
+	if s == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	it := s.q.Push(v, priority)
+	s.unsafeBroadcast()
+	return it, nil
+}
+
+// Peek returns the next item without removing it.
+func (s *Sync[T, P]) Peek() (*Item[T, P], bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.q.Peek()
+}
+
+// TryPop removes and returns the next item if there is any.
+func (s *Sync[T, P]) TryPop() (*Item[T, P], bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.q.Pop()
+}
+
+// Pop removes and returns the next item, waiting for one to be
+// pushed until the context is cancelled. Once closed, the remaining
+// items can still be popped before Pop fails with [ErrClosed].
+func (s *Sync[T, P]) Pop(ctx context.Context) (*Item[T, P], error) {
+	if s == nil {
+		return nil, core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		s.mu.Lock()
+		if it, ok := s.q.Pop(); ok {
+			s.mu.Unlock()
+			return it, nil
+		} else if s.closed {
+			s.mu.Unlock()
+			return nil, ErrClosed
+		}
+
+		ch := s.unsafeWait()
+		s.mu.Unlock()
+
+		select {
+		case <-ch:
+			// try again
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+}
+
+// Update changes the priority of a queued item, returning false if
+// the item doesn't belong to the queue. Waiters are woken up to
+// reconsider the next item.
+func (s *Sync[T, P]) Update(it *Item[T, P], priority P) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ok := s.q.Update(it, priority)
+	if ok {
+		s.unsafeBroadcast()
+	}
+	return ok
+}
+
+// Remove removes a queued item, returning false if the item doesn't
+// belong to the queue.
+func (s *Sync[T, P]) Remove(it *Item[T, P]) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.q.Remove(it)
+}
+
+// Changed returns a channel closed the next time the queue is
+// pushed to, updated or closed, so schedulers can reconsider
+// the next item while waiting for it to be due.
+func (s *Sync[T, P]) Changed() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.unsafeWait()
+}
+
+// Close prevents further pushes and wakes up all waiters.
+// Items already in the queue can still be popped.
+func (s *Sync[T, P]) Close() error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.closed = true
+	s.unsafeBroadcast()
+	return nil
+}
+
+func (s *Sync[T, P]) unsafeWait() <-chan struct{} {
+	if s.ready == nil {
+		s.ready = make(chan struct{})
+	}
+	return s.ready
+}
+
+func (s *Sync[T, P]) unsafeBroadcast() {
+	if s.ready != nil {
+		close(s.ready)
+		s.ready = nil
+	}
+}
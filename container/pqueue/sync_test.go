@@ -0,0 +1,80 @@
+package pqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSync(t *testing.T) {
+	s := NewSyncOrdered[string, int]()
+
+	result := make(chan string, 1)
+	go func() {
+		it, err := s.Pop(context.Background())
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		result <- it.Value()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if _, err := s.Push("a", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case v := <-result:
+		if v != "a" {
+			t.Errorf("ERROR: Pop() → %q (expected %q)", v, "a")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ERROR: Pop() not woken up by Push()")
+	}
+
+	changed := s.Changed()
+	b, _ := s.Push("b", 2)
+	select {
+	case <-changed:
+	default:
+		t.Error("ERROR: Changed() not closed by Push()")
+	}
+
+	if !s.Remove(b) || s.Remove(b) {
+		t.Error("ERROR: Remove(b) didn't succeed exactly once")
+	}
+	if it, ok := s.TryPop(); ok {
+		t.Errorf("ERROR: TryPop() on empty → %v", it.Value())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Pop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ERROR: Pop() → %v (expected %v)", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSyncClose(t *testing.T) {
+	s := NewSyncOrdered[int, int]()
+	_, _ = s.Push(1, 1)
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != ErrClosed {
+		t.Errorf("ERROR: second Close() → %v (expected %v)", err, ErrClosed)
+	}
+	if _, err := s.Push(2, 2); err != ErrClosed {
+		t.Errorf("ERROR: Push() after Close() → %v (expected %v)", err, ErrClosed)
+	}
+
+	// remaining items can be popped
+	if it, err := s.Pop(context.Background()); err != nil || it.Value() != 1 {
+		t.Errorf("ERROR: Pop() after Close() → %v", err)
+	}
+	if _, err := s.Pop(context.Background()); err != ErrClosed {
+		t.Errorf("ERROR: Pop() on closed empty queue → %v (expected %v)", err, ErrClosed)
+	}
+}
@@ -0,0 +1,226 @@
+package sets
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Ordered is a set of comparable values preserving the order of
+// insertion. The zero value is ready to use, but it isn't safe for
+// concurrent use. Removing values is linear on the size of the set.
+type Ordered[T comparable] struct {
+	index  map[T]int
+	values []T
+}
+
+// NewOrdered creates an [Ordered] set with the given values.
+func NewOrdered[T comparable](values ...T) *Ordered[T] {
+	s := &Ordered[T]{
+		index:  make(map[T]int, len(values)),
+		values: make([]T, 0, len(values)),
+	}
+	s.Add(values...)
+	return s
+}
+
+// Add appends the values not yet in the set, returning how many
+// were new.
+func (s *Ordered[T]) Add(values ...T) int {
+	if s.index == nil {
+		s.index = make(map[T]int, len(values))
+	}
+
+	var n int
+	for _, v := range values {
+		if _, ok := s.index[v]; !ok {
+			s.index[v] = len(s.values)
+			s.values = append(s.values, v)
+			n++
+		}
+	}
+	return n
+}
+
+// Remove removes values from the set, returning how many were
+// present.
+func (s *Ordered[T]) Remove(values ...T) int {
+	var n int
+	for _, v := range values {
+		if _, ok := s.index[v]; ok {
+			delete(s.index, v)
+			n++
+		}
+	}
+
+	if n > 0 {
+		s.compact()
+	}
+	return n
+}
+
+// compact removes the values no longer indexed and reindexes
+// the remaining.
+func (s *Ordered[T]) compact() {
+	out := s.values[:0]
+	for _, v := range s.values {
+		if _, ok := s.index[v]; ok {
+			s.index[v] = len(out)
+			out = append(out, v)
+		}
+	}
+
+	clear(s.values[len(out):])
+	s.values = out
+}
+
+// Clear removes all values from the set.
+func (s *Ordered[T]) Clear() {
+	clear(s.index)
+	clear(s.values)
+	s.values = s.values[:0]
+}
+
+// Contains tells if a value is in the set.
+func (s *Ordered[T]) Contains(v T) bool {
+	if s == nil {
+		return false
+	}
+
+	_, ok := s.index[v]
+	return ok
+}
+
+// Index returns the position of a value in the set, or -1 if
+// not present.
+func (s *Ordered[T]) Index(v T) int {
+	if s != nil {
+		if i, ok := s.index[v]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// Len returns the number of values in the set.
+func (s *Ordered[T]) Len() int {
+	return len(s.items())
+}
+
+// Values returns a copy of the values in order of insertion.
+func (s *Ordered[T]) Values() []T {
+	return slices.Clone(s.items())
+}
+
+// ForEach calls a function for each value, in order of insertion,
+// until it returns false.
+func (s *Ordered[T]) ForEach(fn func(T) bool) {
+	if fn == nil {
+		return
+	}
+
+	for _, v := range s.items() {
+		if !fn(v) {
+			return
+		}
+	}
+}
+
+// Filter returns a new [Ordered] set with the values accepted by
+// the given function.
+func (s *Ordered[T]) Filter(fn func(T) bool) *Ordered[T] {
+	out := NewOrdered[T]()
+	for _, v := range s.items() {
+		if fn(v) {
+			out.Add(v)
+		}
+	}
+	return out
+}
+
+// Clone returns a copy of the set.
+func (s *Ordered[T]) Clone() *Ordered[T] {
+	return NewOrdered(s.items()...)
+}
+
+// Set returns an unordered copy of the set.
+func (s *Ordered[T]) Set() Set[T] {
+	return New(s.items()...)
+}
+
+// Equal tells if both sets have the same values, regardless of
+// their order.
+func (s *Ordered[T]) Equal(other *Ordered[T]) bool {
+	if s.Len() != other.Len() {
+		return false
+	}
+
+	for _, v := range s.items() {
+		if !other.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns a new [Ordered] set with the values of this one
+// followed by the new values of the others, in order.
+func (s *Ordered[T]) Union(others ...*Ordered[T]) *Ordered[T] {
+	out := s.Clone()
+	for _, o := range others {
+		out.Add(o.items()...)
+	}
+	return out
+}
+
+// Intersect returns a new [Ordered] set with the values present in
+// all the sets, in the order of this one.
+func (s *Ordered[T]) Intersect(others ...*Ordered[T]) *Ordered[T] {
+	return s.Filter(func(v T) bool {
+		for _, o := range others {
+			if !o.Contains(v) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Difference returns a new [Ordered] set with the values not present
+// in any of the others, in the order of this one.
+func (s *Ordered[T]) Difference(others ...*Ordered[T]) *Ordered[T] {
+	return s.Filter(func(v T) bool {
+		for _, o := range others {
+			if o.Contains(v) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// MarshalJSON encodes the set as an array, in order of insertion.
+func (s Ordered[T]) MarshalJSON() ([]byte, error) {
+	values := s.values
+	if values == nil {
+		values = []T{}
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON appends the values of an array to the set.
+func (s *Ordered[T]) UnmarshalJSON(data []byte) error {
+	var values []T
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	s.Add(values...)
+	return nil
+}
+
+func (s *Ordered[T]) items() []T {
+	if s == nil {
+		return nil
+	}
+	return s.values
+}
@@ -0,0 +1,81 @@
+package sets
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestOrderedOperations(t *testing.T) {
+	a := NewOrdered(3, 1, 2)
+	b := NewOrdered(4, 2, 3)
+
+	tests := []struct {
+		name     string
+		result   *Ordered[int]
+		expected []int
+	}{
+		{"Union", a.Union(b), []int{3, 1, 2, 4}},
+		{"Intersect", a.Intersect(b), []int{3, 2}},
+		{"Difference", a.Difference(b), []int{1}},
+		{"Difference reverse", b.Difference(a), []int{4}},
+		{"Union nil", (*Ordered[int])(nil).Union(b), []int{4, 2, 3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.result.Values(); !slices.Equal(got, tc.expected) {
+				t.Errorf("ERROR: %v (expected %v)", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestOrderedRemove(t *testing.T) {
+	s := NewOrdered(1, 2, 3, 4)
+	if n := s.Add(2, 5); n != 1 {
+		t.Errorf("ERROR: Add(2, 5) → %v (expected 1)", n)
+	}
+	if n := s.Remove(2, 9, 4); n != 2 {
+		t.Errorf("ERROR: Remove(2, 9, 4) → %v (expected 2)", n)
+	}
+
+	if got := s.Values(); !slices.Equal(got, []int{1, 3, 5}) {
+		t.Errorf("ERROR: Values() → %v (expected [1 3 5])", got)
+	}
+	for i, v := range []int{1, 3, 5} {
+		if idx := s.Index(v); idx != i {
+			t.Errorf("ERROR: Index(%v) → %v (expected %v)", v, idx, i)
+		}
+	}
+	if idx := s.Index(2); idx != -1 {
+		t.Errorf("ERROR: Index(2) → %v (expected -1)", idx)
+	}
+}
+
+func TestOrderedJSON(t *testing.T) {
+	var empty Ordered[string]
+	if data, _ := json.Marshal(empty); string(data) != "[]" {
+		t.Errorf("ERROR: Marshal(empty) → %s (expected [])", data)
+	}
+
+	s := NewOrdered("c", "a", "b")
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["c","a","b"]` {
+		t.Errorf("ERROR: Marshal → %s", data)
+	}
+
+	var out Ordered[string]
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Equal(s) {
+		t.Errorf("ERROR: round-trip → %v (expected %v)", out.Values(), s.Values())
+	}
+	if !out.Set().Equal(New("a", "b", "c")) {
+		t.Errorf("ERROR: Set() → %v", Sorted(out.Set()))
+	}
+}
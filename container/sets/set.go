@@ -0,0 +1,202 @@
+// Package sets implements generic sets of comparable values,
+// unordered and preserving insertion order.
+package sets
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+)
+
+// Set is an unordered set of comparable values. The zero value
+// is ready to use, but a Set isn't safe for concurrent use.
+type Set[T comparable] map[T]struct{}
+
+// New creates a [Set] with the given values.
+func New[T comparable](values ...T) Set[T] {
+	s := make(Set[T], len(values))
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+	return s
+}
+
+// Add adds values to the [Set], returning how many were new.
+func (s *Set[T]) Add(values ...T) int {
+	if *s == nil {
+		*s = make(Set[T], len(values))
+	}
+
+	var n int
+	for _, v := range values {
+		if _, ok := (*s)[v]; !ok {
+			(*s)[v] = struct{}{}
+			n++
+		}
+	}
+	return n
+}
+
+// Remove removes values from the [Set], returning how many
+// were present.
+func (s Set[T]) Remove(values ...T) int {
+	var n int
+	for _, v := range values {
+		if _, ok := s[v]; ok {
+			delete(s, v)
+			n++
+		}
+	}
+	return n
+}
+
+// Clear removes all values from the [Set].
+func (s Set[T]) Clear() {
+	for v := range s {
+		delete(s, v)
+	}
+}
+
+// Contains tells if a value is in the [Set].
+func (s Set[T]) Contains(v T) bool {
+	_, ok := s[v]
+	return ok
+}
+
+// ContainsAll tells if all the values are in the [Set].
+func (s Set[T]) ContainsAll(values ...T) bool {
+	for _, v := range values {
+		if !s.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of values in the [Set].
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+// Values returns the values of the [Set] in no particular order.
+func (s Set[T]) Values() []T {
+	out := make([]T, 0, len(s))
+	for v := range s {
+		out = append(out, v)
+	}
+	return out
+}
+
+// ForEach calls a function for each value in the [Set], in no
+// particular order, until it returns false.
+func (s Set[T]) ForEach(fn func(T) bool) {
+	if fn == nil {
+		return
+	}
+
+	for v := range s {
+		if !fn(v) {
+			return
+		}
+	}
+}
+
+// Filter returns a new [Set] with the values accepted by
+// the given function.
+func (s Set[T]) Filter(fn func(T) bool) Set[T] {
+	out := make(Set[T])
+	for v := range s {
+		if fn(v) {
+			out[v] = struct{}{}
+		}
+	}
+	return out
+}
+
+// Clone returns a copy of the [Set].
+func (s Set[T]) Clone() Set[T] {
+	out := make(Set[T], len(s))
+	for v := range s {
+		out[v] = struct{}{}
+	}
+	return out
+}
+
+// Equal tells if both sets have the same values.
+func (s Set[T]) Equal(other Set[T]) bool {
+	return len(s) == len(other) && s.SubsetOf(other)
+}
+
+// SubsetOf tells if all the values of the [Set] are in the other.
+func (s Set[T]) SubsetOf(other Set[T]) bool {
+	if len(s) > len(other) {
+		return false
+	}
+
+	for v := range s {
+		if !other.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns a new [Set] with the values of all the sets.
+func (s Set[T]) Union(others ...Set[T]) Set[T] {
+	out := s.Clone()
+	for _, o := range others {
+		for v := range o {
+			out[v] = struct{}{}
+		}
+	}
+	return out
+}
+
+// Intersect returns a new [Set] with the values present in all
+// the sets.
+func (s Set[T]) Intersect(others ...Set[T]) Set[T] {
+	return s.Filter(func(v T) bool {
+		for _, o := range others {
+			if !o.Contains(v) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Difference returns a new [Set] with the values not present in
+// any of the others.
+func (s Set[T]) Difference(others ...Set[T]) Set[T] {
+	return s.Filter(func(v T) bool {
+		for _, o := range others {
+			if o.Contains(v) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// MarshalJSON encodes the [Set] as an array, in no particular order.
+func (s Set[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Values())
+}
+
+// UnmarshalJSON adds the values of an array to the [Set].
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var values []T
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	s.Add(values...)
+	return nil
+}
+
+// Sorted returns the values of a [Set] in ascending order.
+func Sorted[T cmp.Ordered](s Set[T]) []T {
+	out := s.Values()
+	slices.Sort(out)
+	return out
+}
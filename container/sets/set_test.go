@@ -0,0 +1,106 @@
+package sets
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSetOperations(t *testing.T) {
+	a := New(1, 2, 3)
+	b := New(2, 3, 4)
+	c := New(3, 5)
+
+	tests := []struct {
+		name     string
+		result   Set[int]
+		expected []int
+	}{
+		{"Union", a.Union(b, c), []int{1, 2, 3, 4, 5}},
+		{"Union none", a.Union(), []int{1, 2, 3}},
+		{"Union nil", Set[int](nil).Union(b), []int{2, 3, 4}},
+		{"Intersect", a.Intersect(b), []int{2, 3}},
+		{"Intersect many", a.Intersect(b, c), []int{3}},
+		{"Intersect none", a.Intersect(), []int{1, 2, 3}},
+		{"Intersect empty", a.Intersect(New[int]()), nil},
+		{"Difference", a.Difference(b), []int{1}},
+		{"Difference many", b.Difference(a, c), []int{4}},
+		{"Difference none", a.Difference(), []int{1, 2, 3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Sorted(tc.result); !slices.Equal(got, tc.expected) {
+				t.Errorf("ERROR: %v (expected %v)", got, tc.expected)
+			}
+		})
+	}
+
+	// operands untouched
+	if got := Sorted(a); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("ERROR: operand modified → %v", got)
+	}
+}
+
+func TestSetSubset(t *testing.T) {
+	tests := []struct {
+		name     string
+		s, other Set[int]
+		subset   bool
+		equal    bool
+	}{
+		{"proper", New(1, 2), New(1, 2, 3), true, false},
+		{"same", New(1, 2), New(2, 1), true, true},
+		{"superset", New(1, 2, 3), New(1, 2), false, false},
+		{"disjoint", New(1), New(2), false, false},
+		{"empty", New[int](), New(1), true, false},
+		{"nil", nil, New[int](), true, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.s.SubsetOf(tc.other); got != tc.subset {
+				t.Errorf("ERROR: SubsetOf → %v (expected %v)", got, tc.subset)
+			}
+			if got := tc.s.Equal(tc.other); got != tc.equal {
+				t.Errorf("ERROR: Equal → %v (expected %v)", got, tc.equal)
+			}
+		})
+	}
+
+	if !New(1, 2, 3).ContainsAll(1, 3) || New(1, 2).ContainsAll(1, 4) {
+		t.Error("ERROR: ContainsAll doesn't match SubsetOf")
+	}
+}
+
+func TestSetJSON(t *testing.T) {
+	s := New("b", "a", "c")
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out Set[string]
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Equal(s) {
+		t.Errorf("ERROR: round-trip %s → %v (expected %v)", data, Sorted(out), Sorted(s))
+	}
+
+	// duplicates collapse, in a struct field
+	var v struct {
+		Tags Set[string] `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(`{"tags": ["x", "y", "x"]}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if got := Sorted(v.Tags); !slices.Equal(got, []string{"x", "y"}) {
+		t.Errorf("ERROR: Unmarshal → %v (expected [x y])", got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"a": 1}`), &out); err == nil {
+		t.Error("ERROR: Unmarshal accepted an object")
+	}
+}
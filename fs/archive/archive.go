@@ -0,0 +1,41 @@
+// Package archive exposes tar and zip archives as [fs.FS], and
+// writes the content of an [fs.FS] into new archives.
+package archive
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// walkFiles calls fn for every directory and regular file of
+// the given [fs.FS], other types of entries are skipped.
+func walkFiles(fSys fs.FS, fn func(name string, fi fs.FileInfo) error) error {
+	return fs.WalkDir(fSys, ".", func(name string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case name == ".":
+			return nil
+		case !d.IsDir() && !d.Type().IsRegular():
+			// skipped
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(name, fi)
+	})
+}
+
+// cleanName converts the name of an archive entry into
+// a valid [fs.FS] path, or "" if it should be ignored.
+func cleanName(name string) string {
+	name = path.Clean("/" + strings.TrimPrefix(name, "./"))[1:]
+	if name == "" || !fs.ValidPath(name) {
+		return ""
+	}
+	return name
+}
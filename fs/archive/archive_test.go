@@ -0,0 +1,65 @@
+package archive
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+var testFS = fstest.MapFS{
+	"a.txt":       {Data: []byte("a"), Mode: 0o644},
+	"d":           {Mode: fs.ModeDir | 0o750},
+	"d/b.txt":     {Data: []byte("bb"), Mode: 0o600},
+	"d/e/c.txt":   {Data: []byte("ccc"), Mode: 0o644},
+	"empty/f.txt": {Mode: 0o644},
+}
+
+func TestTarFS(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := WriteTar(&buf, testFS); err != nil {
+		t.Fatal(err)
+	}
+
+	fSys, err := NewTarFS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fstest.TestFS(fSys, "a.txt", "d/b.txt", "d/e/c.txt", "empty/f.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(fSys, "d/e/c.txt")
+	if err != nil || string(data) != "ccc" {
+		t.Errorf("ERROR: ReadFile → %q, %v", data, err)
+	}
+
+	fi, err := fs.Stat(fSys, "d/b.txt")
+	if err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("ERROR: Stat → %v, %v", fi, err)
+	}
+}
+
+func TestZipFS(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := WriteZip(&buf, testFS); err != nil {
+		t.Fatal(err)
+	}
+
+	fSys, err := NewZipFS(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fstest.TestFS(fSys, "a.txt", "d/b.txt", "d/e/c.txt", "empty/f.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(fSys, "d/b.txt")
+	if err != nil || string(data) != "bb" {
+		t.Errorf("ERROR: ReadFile → %q, %v", data, err)
+	}
+}
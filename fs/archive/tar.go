@@ -0,0 +1,264 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+var (
+	_ fs.ReadDirFS  = (*TarFS)(nil)
+	_ fs.ReadFileFS = (*TarFS)(nil)
+	_ fs.StatFS     = (*TarFS)(nil)
+)
+
+// TarFS is a read-only [fs.FS] holding the content of a tar archive
+// in memory. Only directories, regular files and hard links are
+// included. Missing parent directories are implied.
+type TarFS struct {
+	entries map[string]*tarEntry
+}
+
+type tarEntry struct {
+	fi       fs.FileInfo
+	data     []byte
+	children map[string]*tarEntry
+}
+
+// NewTarFS reads a tar archive into a [TarFS]. Compressed
+// archives need to be decompressed by the given [io.Reader].
+func NewTarFS(r io.Reader) (*TarFS, error) {
+	t := &TarFS{
+		entries: make(map[string]*tarEntry),
+	}
+	t.entries["."] = newTarDir(".", time.Time{})
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return t, nil
+		case err != nil:
+			return nil, err
+		}
+
+		if err := t.add(tr, hdr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *TarFS) add(r io.Reader, hdr *tar.Header) error {
+	name := cleanName(hdr.Name)
+	if name == "" {
+		return nil
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		e := t.mkdirAll(name, hdr.ModTime)
+		e.fi = hdr.FileInfo()
+	case tar.TypeReg:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		t.addFile(name, hdr.FileInfo(), data)
+	case tar.TypeLink:
+		target, ok := t.entries[cleanName(hdr.Linkname)]
+		if !ok || target.fi.IsDir() {
+			return &fs.PathError{Op: "link", Path: hdr.Name, Err: fs.ErrNotExist}
+		}
+		t.addFile(name, renamedInfo{target.fi, path.Base(name)}, target.data)
+	}
+	return nil
+}
+
+func (t *TarFS) addFile(name string, fi fs.FileInfo, data []byte) {
+	e := &tarEntry{fi: fi, data: data}
+	t.entries[name] = e
+	t.mkdirAll(path.Dir(name), time.Time{}).children[path.Base(name)] = e
+}
+
+func (t *TarFS) mkdirAll(name string, modTime time.Time) *tarEntry {
+	if e, ok := t.entries[name]; ok && e.fi.IsDir() {
+		return e
+	}
+
+	e := newTarDir(name, modTime)
+	t.entries[name] = e
+	t.mkdirAll(path.Dir(name), time.Time{}).children[path.Base(name)] = e
+	return e
+}
+
+func newTarDir(name string, modTime time.Time) *tarEntry {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name,
+		Mode:     0o755,
+		ModTime:  modTime,
+	}
+
+	return &tarEntry{
+		fi:       hdr.FileInfo(),
+		children: make(map[string]*tarEntry),
+	}
+}
+
+func (t *TarFS) lookup(op, name string) (*tarEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	e, ok := t.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return e, nil
+}
+
+// Open implements the [fs.FS] interface.
+func (t *TarFS) Open(name string) (fs.File, error) {
+	e, err := t.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.fi.IsDir() {
+		return &tarDir{fi: e.fi, entries: e.dirEntries()}, nil
+	}
+	return &tarFile{Reader: bytes.NewReader(e.data), fi: e.fi}, nil
+}
+
+// ReadFile implements the [fs.ReadFileFS] interface.
+func (t *TarFS) ReadFile(name string) ([]byte, error) {
+	e, err := t.lookup("read", name)
+	switch {
+	case err != nil:
+		return nil, err
+	case e.fi.IsDir():
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	default:
+		return bytes.Clone(e.data), nil
+	}
+}
+
+// ReadDir implements the [fs.ReadDirFS] interface.
+func (t *TarFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := t.lookup("readdir", name)
+	switch {
+	case err != nil:
+		return nil, err
+	case !e.fi.IsDir():
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	default:
+		return e.dirEntries(), nil
+	}
+}
+
+// Stat implements the [fs.StatFS] interface.
+func (t *TarFS) Stat(name string) (fs.FileInfo, error) {
+	e, err := t.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return e.fi, nil
+}
+
+func (e *tarEntry) dirEntries() []fs.DirEntry {
+	out := make([]fs.DirEntry, 0, len(e.children))
+	for _, c := range e.children {
+		out = append(out, fs.FileInfoToDirEntry(c.fi))
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name() < out[j].Name()
+	})
+	return out
+}
+
+// renamedInfo is a [fs.FileInfo] with a different name
+type renamedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (fi renamedInfo) Name() string { return fi.name }
+
+var (
+	_ fs.File        = (*tarFile)(nil)
+	_ io.ReadSeeker  = (*tarFile)(nil)
+	_ fs.ReadDirFile = (*tarDir)(nil)
+)
+
+type tarFile struct {
+	*bytes.Reader
+	fi fs.FileInfo
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) { return f.fi, nil }
+func (*tarFile) Close() error                 { return nil }
+
+type tarDir struct {
+	fi      fs.FileInfo
+	entries []fs.DirEntry
+}
+
+func (d *tarDir) Stat() (fs.FileInfo, error) { return d.fi, nil }
+func (*tarDir) Close() error                 { return nil }
+
+func (d *tarDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.fi.Name(), Err: fs.ErrInvalid}
+}
+
+func (d *tarDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		out := d.entries
+		d.entries = nil
+		return out, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(d.entries))
+	out := d.entries[:n]
+	d.entries = d.entries[n:]
+	return out, nil
+}
+
+// WriteTar writes the directories and regular files of
+// an [fs.FS] into a new tar archive.
+func WriteTar(w io.Writer, fSys fs.FS) error {
+	tw := tar.NewWriter(w)
+
+	err := walkFiles(fSys, func(name string, fi fs.FileInfo) error {
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil || fi.IsDir() {
+			return err
+		}
+		return copyFile(tw, fSys, name)
+	})
+
+	if err != nil {
+		_ = tw.Close()
+		return err
+	}
+	return tw.Close()
+}
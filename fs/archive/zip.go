@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+)
+
+// NewZipFS exposes a zip archive as an [fs.FS].
+func NewZipFS(r io.ReaderAt, size int64) (fs.FS, error) {
+	return zip.NewReader(r, size)
+}
+
+// WriteZip writes the directories and regular files of an [fs.FS]
+// into a new zip archive. Files are compressed using Deflate.
+func WriteZip(w io.Writer, fSys fs.FS) error {
+	zw := zip.NewWriter(w)
+
+	err := walkFiles(fSys, func(name string, fi fs.FileInfo) error {
+		hdr, err := zip.FileInfoHeader(fi)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+			hdr.Method = zip.Store
+		} else {
+			hdr.Method = zip.Deflate
+		}
+
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		return copyFile(fw, fSys, name)
+	})
+
+	if err != nil {
+		_ = zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+func copyFile(w io.Writer, fSys fs.FS, name string) error {
+	f, err := fSys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = io.Copy(w, f)
+	return err
+}
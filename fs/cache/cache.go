@@ -0,0 +1,281 @@
+// Package cache provides a read-through caching [fs.FS] wrapper.
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+const (
+	// DefaultTTL is the default time entries are kept.
+	DefaultTTL = time.Minute
+	// DefaultMaxSize is the default limit of cached content.
+	DefaultMaxSize = 64 << 20
+	// DefaultMaxFileSize is the default size of the largest
+	// file to be cached.
+	DefaultMaxFileSize = 1 << 20
+)
+
+var (
+	_ fs.ReadFileFS = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+)
+
+// Config describes the limits of a caching [FS].
+type Config struct {
+	// TTL is how long entries are kept. Default is [DefaultTTL].
+	TTL time.Duration
+	// MaxSize is the limit of the total size of the cached content,
+	// evicting the least recently used files when exceeded.
+	// Default is [DefaultMaxSize].
+	MaxSize int64
+	// MaxFileSize is the size of the largest file to be cached,
+	// larger files are read from the source directly.
+	// Default is [DefaultMaxFileSize].
+	MaxFileSize int64
+}
+
+// SetDefaults fills any gap in the [Config].
+func (cfg *Config) SetDefaults() {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultMaxSize
+	}
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = DefaultMaxFileSize
+	}
+}
+
+// FS is a read-only [fs.FS] caching the content and [fs.FileInfo]
+// of the files of another.
+type FS struct {
+	src     fs.FS
+	entries map[string]*entry
+	lru     *list.List
+	cfg     Config
+	size    int64
+	mu      sync.Mutex
+}
+
+type entry struct {
+	fi      fs.FileInfo
+	elem    *list.Element
+	expires time.Time
+	name    string
+	data    []byte
+	hasData bool
+}
+
+// New wraps an [fs.FS] using the given [Config], or the defaults
+// when nil.
+func New(src fs.FS, cfg *Config) (*FS, error) {
+	if src == nil {
+		return nil, core.Wrap(fs.ErrInvalid, "source not specified")
+	}
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	cfg.SetDefaults()
+
+	return &FS{
+		src:     src,
+		entries: make(map[string]*entry),
+		lru:     list.New(),
+		cfg:     *cfg,
+	}, nil
+}
+
+// Open implements the [fs.FS] interface. Directories and large
+// files are opened on the source directly.
+func (c *FS) Open(name string) (fs.File, error) {
+	fi, err := c.Stat(name)
+	switch {
+	case err != nil:
+		return nil, rename(err, "open")
+	case !fi.Mode().IsRegular(), fi.Size() > c.cfg.MaxFileSize:
+		return c.src.Open(name)
+	}
+
+	data, fi, err := c.read(name)
+	if err != nil {
+		return nil, rename(err, "open")
+	}
+	return &file{Reader: bytes.NewReader(data), fi: fi}, nil
+}
+
+// ReadFile implements the [fs.ReadFileFS] interface.
+func (c *FS) ReadFile(name string) ([]byte, error) {
+	data, _, err := c.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(data), nil
+}
+
+// Stat implements the [fs.StatFS] interface.
+func (c *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	c.mu.Lock()
+	e, ok := c.unsafeGet(name)
+	c.mu.Unlock()
+	if ok {
+		return e.fi, nil
+	}
+
+	fi, err := fs.Stat(c.src, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.unsafeGet(name); !ok {
+		c.unsafeStore(&entry{name: name, fi: fi})
+	}
+	return fi, nil
+}
+
+// read returns the cached content of a file, reading it
+// from the source when needed.
+func (c *FS) read(name string) ([]byte, fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	c.mu.Lock()
+	e, ok := c.unsafeGet(name)
+	c.mu.Unlock()
+	if ok && e.hasData {
+		return e.data, e.fi, nil
+	}
+
+	fi, err := fs.Stat(c.src, name)
+	if err != nil {
+		return nil, nil, rename(err, "read")
+	}
+
+	data, err := fs.ReadFile(c.src, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if int64(len(data)) <= c.cfg.MaxFileSize {
+		c.mu.Lock()
+		c.unsafeStore(&entry{name: name, fi: fi, data: data, hasData: true})
+		c.mu.Unlock()
+	}
+	return data, fi, nil
+}
+
+// unsafeGet returns a valid entry, forgetting it if expired.
+func (c *FS) unsafeGet(name string) (*entry, bool) {
+	e, ok := c.entries[name]
+	switch {
+	case !ok:
+		return nil, false
+	case time.Now().After(e.expires):
+		c.unsafeRemove(e)
+		return nil, false
+	default:
+		c.lru.MoveToFront(e.elem)
+		return e, true
+	}
+}
+
+func (c *FS) unsafeStore(e *entry) {
+	if old, ok := c.entries[e.name]; ok {
+		c.unsafeRemove(old)
+	}
+
+	e.expires = time.Now().Add(c.cfg.TTL)
+	e.elem = c.lru.PushFront(e)
+	c.entries[e.name] = e
+	c.size += int64(len(e.data))
+
+	for c.size > c.cfg.MaxSize {
+		oldest, _ := c.lru.Back().Value.(*entry)
+		c.unsafeRemove(oldest)
+	}
+}
+
+func (c *FS) unsafeRemove(e *entry) {
+	c.lru.Remove(e.elem)
+	delete(c.entries, e.name)
+	c.size -= int64(len(e.data))
+}
+
+// Invalidate forgets the given entries, and anything within
+// them if they are directories.
+func (c *FS) Invalidate(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, name := range names {
+		if name == "." {
+			c.unsafeReset()
+			return
+		}
+
+		prefix := name + "/"
+		for key, e := range c.entries {
+			if key == name || strings.HasPrefix(key, prefix) {
+				c.unsafeRemove(e)
+			}
+		}
+	}
+}
+
+// Reset forgets all entries.
+func (c *FS) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unsafeReset()
+}
+
+func (c *FS) unsafeReset() {
+	c.entries = make(map[string]*entry)
+	c.lru.Init()
+	c.size = 0
+}
+
+// Size returns the total size of the cached content.
+func (c *FS) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+func rename(err error, op string) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return &fs.PathError{Op: op, Path: pe.Path, Err: pe.Err}
+	}
+	return err
+}
+
+var _ io.ReadSeeker = (*file)(nil)
+
+// file is an open cached file
+type file struct {
+	*bytes.Reader
+	fi fs.FileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.fi, nil }
+func (*file) Close() error                 { return nil }
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"darvaza.org/x/fs/watch"
+)
+
+func readString(t *testing.T, fSys fs.FS, name string) string {
+	t.Helper()
+
+	data, err := fs.ReadFile(fSys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCache(t *testing.T) {
+	src := fstest.MapFS{
+		"a.txt":   {Data: []byte("a1")},
+		"d/b.txt": {Data: []byte("b1")},
+	}
+
+	c, err := New(src, &Config{MaxSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fstest.TestFS(c, "a.txt", "d/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = readString(t, c, "a.txt")
+	_ = readString(t, c, "d/b.txt")
+	src["a.txt"].Data = []byte("a2")
+	src["d/b.txt"].Data = []byte("b2")
+
+	tests := []struct {
+		invalidate []string
+		name       string
+		expected   string
+	}{
+		{nil, "d/b.txt", "b1"},
+		{[]string{"a.txt"}, "d/b.txt", "b1"},
+		{[]string{"a.txt"}, "a.txt", "a2"},
+		{[]string{"d"}, "d/b.txt", "b2"},
+	}
+
+	for i, tc := range tests {
+		c.Invalidate(tc.invalidate...)
+		if s := readString(t, c, tc.name); s != tc.expected {
+			t.Errorf("[%v/%v] ERROR: %q → %q (expected %q)",
+				i, len(tests), tc.name, s, tc.expected)
+		}
+	}
+
+	if n := c.Size(); n > 4 {
+		t.Errorf("ERROR: Size → %v (expected at most 4)", n)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	src := fstest.MapFS{"a.txt": {Data: []byte("a1")}}
+
+	c, err := New(src, &Config{TTL: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = readString(t, c, "a.txt")
+	src["a.txt"].Data = []byte("a2")
+	if s := readString(t, c, "a.txt"); s != "a1" {
+		t.Errorf("ERROR: %q (expected %q)", s, "a1")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if s := readString(t, c, "a.txt"); s != "a2" {
+		t.Errorf("ERROR: %q (expected %q)", s, "a2")
+	}
+}
+
+func TestInvalidateEvents(t *testing.T) {
+	src := fstest.MapFS{"d/a.txt": {Data: []byte("a1")}}
+	root := filepath.Join("srv", "data")
+
+	c, err := New(src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = readString(t, c, "d/a.txt")
+	src["d/a.txt"].Data = []byte("a2")
+
+	c.InvalidateEvents(root, []watch.Event{
+		{Name: filepath.Join("srv", "other"), Op: watch.Write},
+	})
+	if s := readString(t, c, "d/a.txt"); s != "a1" {
+		t.Errorf("ERROR: %q (expected %q)", s, "a1")
+	}
+
+	c.InvalidateEvents(root, []watch.Event{
+		{Name: filepath.Join(root, "d", "a.txt"), Op: watch.Write},
+	})
+	if s := readString(t, c, "d/a.txt"); s != "a2" {
+		t.Errorf("ERROR: %q (expected %q)", s, "a2")
+	}
+}
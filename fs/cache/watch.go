@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"path/filepath"
+
+	"darvaza.org/x/fs/watch"
+)
+
+// Watch invalidates the entries reported by a [watch.Watcher]
+// until its events channel is closed. root is the directory
+// of the operating system the source [fs.FS] represents.
+//
+// Watch consumes the events, so the [watch.Watcher] can't be
+// shared with other consumers.
+func (c *FS) Watch(w *watch.Watcher, root string) {
+	for events := range w.Events() {
+		c.InvalidateEvents(root, events)
+	}
+}
+
+// InvalidateEvents invalidates the entries affected by
+// a batch of [watch.Event]s.
+func (c *FS) InvalidateEvents(root string, events []watch.Event) {
+	names := make([]string, 0, len(events))
+	for _, ev := range events {
+		if name, ok := relName(root, ev.Name); ok {
+			names = append(names, name)
+		}
+	}
+
+	c.Invalidate(names...)
+}
+
+// relName converts a path of the operating system into
+// a name relative to root.
+func relName(root, name string) (string, bool) {
+	rel, err := filepath.Rel(root, name)
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,160 @@
+// Package checksum computes manifests of the digests of the files
+// of an [fs.FS], and compares them to detect drift.
+package checksum
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// ErrInvalidManifest indicates a line of a manifest
+// couldn't be parsed.
+var ErrInvalidManifest = core.Wrap(fs.ErrInvalid, "invalid manifest")
+
+// Manifest maps the names of files to their hex encoded digest.
+type Manifest map[string]string
+
+// Names returns the sorted names of the files in the [Manifest].
+func (m Manifest) Names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteTo writes the [Manifest] in the format used by sha256sum
+// and similar tools, sorted by name.
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+
+	for _, name := range m.Names() {
+		n, err := fmt.Fprintf(w, "%s  %s\n", m[name], name)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Parse reads a [Manifest] in the format used by sha256sum and
+// similar tools. Names must be valid [fs.FS] paths.
+func Parse(r io.Reader) (Manifest, error) {
+	m := make(Manifest)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		s := scanner.Text()
+		if s == "" {
+			continue
+		}
+
+		sum, name, ok := strings.Cut(s, " ")
+		// binary mode marker
+		name = strings.TrimPrefix(strings.TrimPrefix(name, " "), "*")
+
+		if _, err := hex.DecodeString(sum); err != nil || !ok || !fs.ValidPath(name) {
+			return nil, core.Wrapf(ErrInvalidManifest, "line %v", line)
+		}
+		m[name] = strings.ToLower(sum)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Compute walks an [fs.FS] computing the digest of every regular
+// file using the given hash, or SHA-256 if nil.
+func Compute(fSys fs.FS, newHash func() hash.Hash) (Manifest, error) {
+	if newHash == nil {
+		newHash = sha256.New
+	}
+
+	m := make(Manifest)
+	err := fs.WalkDir(fSys, ".", func(name string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case !d.Type().IsRegular():
+			return nil
+		}
+
+		sum, err := Sum(fSys, name, newHash())
+		if err != nil {
+			return err
+		}
+		m[name] = sum
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Sum computes the hex encoded digest of a file
+// using the given hash.
+func Sum(fSys fs.FS, name string, h hash.Hash) (string, error) {
+	f, err := fSys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Diff describes the differences between two manifests.
+type Diff struct {
+	// Added are the files only present on the second
+	Added []string
+	// Removed are the files only present on the first
+	Removed []string
+	// Modified are the files with different digests
+	Modified []string
+}
+
+// Empty tells if there are no differences.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Compare returns the differences between two manifests,
+// sorted by name.
+func Compare(a, b Manifest) Diff {
+	var d Diff
+
+	for _, name := range a.Names() {
+		sum, ok := b[name]
+		switch {
+		case !ok:
+			d.Removed = append(d.Removed, name)
+		case sum != a[name]:
+			d.Modified = append(d.Modified, name)
+		}
+	}
+
+	for _, name := range b.Names() {
+		if _, ok := a[name]; !ok {
+			d.Added = append(d.Added, name)
+		}
+	}
+	return d
+}
@@ -0,0 +1,82 @@
+package checksum
+
+import (
+	"bytes"
+	"crypto/md5"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompute(t *testing.T) {
+	a := fstest.MapFS{
+		"same.txt":    {Data: []byte("same")},
+		"changed.txt": {Data: []byte("old")},
+		"d/gone.txt":  {Data: []byte("gone")},
+	}
+	b := fstest.MapFS{
+		"same.txt":    {Data: []byte("same")},
+		"changed.txt": {Data: []byte("new")},
+		"d/new.txt":   {Data: []byte("new")},
+	}
+
+	ma, err := Compute(a, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mb, err := Compute(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Diff{
+		Added:    []string{"d/new.txt"},
+		Removed:  []string{"d/gone.txt"},
+		Modified: []string{"changed.txt"},
+	}
+	if d := Compare(ma, mb); !reflect.DeepEqual(d, expected) {
+		t.Errorf("ERROR: Compare → %+v (expected %+v)", d, expected)
+	}
+
+	if d := Compare(ma, ma); !d.Empty() {
+		t.Errorf("ERROR: Compare → %+v (expected empty)", d)
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	src := fstest.MapFS{
+		"a.txt":   {Data: []byte("a")},
+		"d/b.txt": {Data: []byte("b")},
+	}
+
+	m, err := Compute(src, md5.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "0cc175b9c0f1b6a831c399e269772661  a.txt\n" +
+		"92eb5ffee6ae2fec3ad71c777531578f  d/b.txt\n"
+	if s := buf.String(); s != expected {
+		t.Errorf("ERROR: WriteTo → %q (expected %q)", s, expected)
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, m) {
+		t.Errorf("ERROR: Parse → %v (expected %v)", parsed, m)
+	}
+
+	for _, s := range []string{"xyz  a.txt\n", "00  ../a.txt\n", "00\n"} {
+		if _, err := Parse(bytes.NewBufferString(s)); err == nil {
+			t.Errorf("ERROR: Parse(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+)
+
+const (
+	// copyFilePerm is the mode used for new files when
+	// permissions aren't preserved, subject to umask.
+	copyFilePerm FileMode = 0o666
+	// copyDirPerm is the mode used for new directories when
+	// permissions aren't preserved, subject to umask.
+	copyDirPerm FileMode = 0o777
+)
+
+// CopyOp is the kind of change done by [CopyTree] and [SyncTree].
+type CopyOp int
+
+const (
+	// CopyMkdir indicates a directory is created
+	CopyMkdir CopyOp = iota + 1
+	// CopyCreate indicates a file is created
+	CopyCreate
+	// CopyUpdate indicates the content of a file is replaced
+	CopyUpdate
+	// CopyChmod indicates only the permissions are changed
+	CopyChmod
+	// CopyRemove indicates an entry is removed
+	CopyRemove
+)
+
+func (op CopyOp) String() string {
+	switch op {
+	case CopyMkdir:
+		return "mkdir"
+	case CopyCreate:
+		return "create"
+	case CopyUpdate:
+		return "update"
+	case CopyChmod:
+		return "chmod"
+	case CopyRemove:
+		return "remove"
+	default:
+		return "unknown"
+	}
+}
+
+// CopyChange describes a change applied, or planned when
+// using [CopyOptions.DryRun], on the destination.
+type CopyChange struct {
+	Path string
+	Op   CopyOp
+	Mode FileMode
+}
+
+func (ch CopyChange) String() string {
+	return ch.Op.String() + " " + ch.Path
+}
+
+// CopyOptions describes how [CopyTree] and [SyncTree] work.
+type CopyOptions struct {
+	// Include restricts the files copied to those matching
+	// any of these patterns. Directories are always visited.
+	Include []string
+	// Exclude skips files and directories matching any of these
+	// patterns. Excluded entries on the destination are never
+	// removed.
+	Exclude []string
+	// PreservePerms makes the permissions of the destination
+	// match the source, when the destination implements [ChmodFS].
+	// Otherwise new entries are created subject to umask and existing
+	// ones are left untouched.
+	PreservePerms bool
+	// DryRun reports the changes without applying them.
+	DryRun bool
+}
+
+// CopyFS is the interface required from the destination
+// of [CopyTree].
+type CopyFS interface {
+	MkdirAllFS
+	WriteFileFS
+}
+
+// SyncFS is the interface required from the destination
+// of [SyncTree].
+type SyncFS interface {
+	CopyFS
+	RemoveAll(path string) error
+}
+
+// CopyTree copies all regular files and directories from src into dst,
+// overwriting existing files. Patterns are matched against the full
+// slash-separated path relative to the root, using [GlobCompile].
+// It returns the changes applied until the first error.
+func CopyTree(dst CopyFS, src FS, opts *CopyOptions) ([]CopyChange, error) {
+	c, err := newCopier(dst, src, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Copy()
+	return c.changes, err
+}
+
+// SyncTree makes dst mirror src. Only files whose content or permissions
+// differ are written, and entries on dst not present on src, and not
+// excluded, are removed.
+// It returns the changes applied until the first error.
+func SyncTree(dst SyncFS, src FS, opts *CopyOptions) ([]CopyChange, error) {
+	c, err := newCopier(dst, src, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.remover = dst
+	c.seen = make(map[string]bool)
+
+	err = c.Copy()
+	if err == nil {
+		err = c.prune()
+	}
+	return c.changes, err
+}
+
+type copier struct {
+	dst     CopyFS
+	src     FS
+	remover RemoveAllFS
+	chmod   ChmodFS
+	seen    map[string]bool
+	include []Matcher
+	exclude []Matcher
+	changes []CopyChange
+	opts    CopyOptions
+}
+
+func newCopier(dst CopyFS, src FS, opts *CopyOptions) (*copier, error) {
+	if dst == nil || src == nil {
+		return nil, &PathError{Op: "copy", Path: ".", Err: ErrInvalid}
+	}
+	if opts == nil {
+		opts = new(CopyOptions)
+	}
+
+	include, err := GlobCompile(opts.Include...)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude, err := GlobCompile(opts.Exclude...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &copier{
+		dst:     dst,
+		src:     src,
+		include: include,
+		exclude: exclude,
+		opts:    *opts,
+	}
+
+	if opts.PreservePerms {
+		c.chmod, _ = dst.(ChmodFS)
+	}
+	return c, nil
+}
+
+func (c *copier) Copy() error {
+	return fs.WalkDir(c.src, ".", func(name string, d DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case name == ".":
+			return nil
+		case matchAny(c.exclude, name):
+			return skipEntry(d)
+		}
+
+		if c.seen != nil {
+			c.seen[name] = true
+		}
+
+		if d.IsDir() {
+			return c.copyDir(name)
+		}
+		return c.copyFile(name)
+	})
+}
+
+func (c *copier) copyDir(name string) error {
+	fi, err := Stat(c.src, name)
+	if err != nil {
+		return err
+	}
+
+	cur, err := c.replaceable(name, true)
+	if err != nil {
+		return err
+	}
+
+	perm := fi.Mode().Perm()
+	if cur == nil {
+		return c.apply(CopyChange{Path: name, Op: CopyMkdir, Mode: perm}, nil)
+	} else if c.chmod != nil && cur.Mode().Perm() != perm {
+		return c.apply(CopyChange{Path: name, Op: CopyChmod, Mode: perm}, nil)
+	}
+	return nil
+}
+
+func (c *copier) copyFile(name string) error {
+	fi, err := Stat(c.src, name)
+	switch {
+	case err != nil:
+		return err
+	case !fi.Mode().IsRegular(), len(c.include) > 0 && !matchAny(c.include, name):
+		// skipped
+		return nil
+	}
+
+	data, err := ReadFile(c.src, name)
+	if err != nil {
+		return err
+	}
+
+	cur, err := c.replaceable(name, false)
+	if err != nil {
+		return err
+	}
+
+	ch := CopyChange{Path: name, Mode: fi.Mode().Perm()}
+	switch {
+	case cur == nil:
+		ch.Op = CopyCreate
+	case c.seen == nil, !c.sameContent(name, cur, data):
+		ch.Op = CopyUpdate
+	case c.chmod != nil && cur.Mode().Perm() != ch.Mode:
+		ch.Op = CopyChmod
+	default:
+		// unchanged
+		return nil
+	}
+
+	return c.apply(ch, data)
+}
+
+// replaceable returns the current state of an entry of the destination,
+// or nil if it doesn't exist. When syncing, entries of the wrong type are
+// removed, otherwise they cause an error.
+func (c *copier) replaceable(name string, isDir bool) (FileInfo, error) {
+	fi, err := Stat(c.dst, name)
+	switch {
+	case errors.Is(err, ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case fi.IsDir() == isDir:
+		return fi, nil
+	case c.remover == nil:
+		return nil, &PathError{Op: "copy", Path: name, Err: ErrExist}
+	default:
+		return nil, c.apply(CopyChange{Path: name, Op: CopyRemove}, nil)
+	}
+}
+
+func (c *copier) sameContent(name string, cur FileInfo, data []byte) bool {
+	if cur.Size() != int64(len(data)) {
+		return false
+	}
+
+	b, err := ReadFile(c.dst, name)
+	return err == nil && bytes.Equal(b, data)
+}
+
+// prune removes entries from the destination not present
+// on the source.
+func (c *copier) prune() error {
+	var names []string
+
+	err := fs.WalkDir(c.dst, ".", func(name string, d DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case name == ".", c.seen[name]:
+			return nil
+		case matchAny(c.exclude, name):
+			return skipEntry(d)
+		case !d.IsDir() && len(c.include) > 0 && !matchAny(c.include, name):
+			return nil
+		}
+
+		names = append(names, name)
+		return skipEntry(d)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := c.apply(CopyChange{Path: name, Op: CopyRemove}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *copier) apply(ch CopyChange, data []byte) error {
+	c.changes = append(c.changes, ch)
+	if c.opts.DryRun {
+		return nil
+	}
+
+	switch ch.Op {
+	case CopyMkdir:
+		if err := c.dst.MkdirAll(ch.Path, c.perm(ch.Mode, copyDirPerm)); err != nil {
+			return err
+		}
+	case CopyCreate, CopyUpdate:
+		if err := c.dst.WriteFile(ch.Path, data, c.perm(ch.Mode, copyFilePerm)); err != nil {
+			return err
+		}
+	case CopyRemove:
+		return c.remover.RemoveAll(ch.Path)
+	}
+
+	if c.chmod != nil {
+		// WriteFile and MkdirAll are subject to umask, and
+		// don't change the mode of existing entries.
+		return c.chmod.Chmod(ch.Path, ch.Mode)
+	}
+	return nil
+}
+
+func (c *copier) perm(mode, fallback FileMode) FileMode {
+	if c.opts.PreservePerms {
+		return mode
+	}
+	return fallback
+}
+
+func matchAny(globs []Matcher, name string) bool {
+	for _, g := range globs {
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func skipEntry(d DirEntry) error {
+	if d.IsDir() {
+		return fs.SkipDir
+	}
+	return nil
+}
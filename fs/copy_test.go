@@ -0,0 +1,136 @@
+package fs
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testDirFS is a writable [os.DirFS]
+type testDirFS struct {
+	fs.FS
+	root string
+}
+
+func newTestDirFS(t *testing.T) *testDirFS {
+	root := t.TempDir()
+	return &testDirFS{FS: os.DirFS(root), root: root}
+}
+
+func (d *testDirFS) path(name string) string {
+	return filepath.Join(d.root, filepath.FromSlash(name))
+}
+
+func (d *testDirFS) MkdirAll(name string, mode FileMode) error {
+	return os.MkdirAll(d.path(name), mode)
+}
+
+func (d *testDirFS) WriteFile(name string, data []byte, perm FileMode) error {
+	return os.WriteFile(d.path(name), data, perm)
+}
+
+func (d *testDirFS) Chmod(name string, mode FileMode) error {
+	return os.Chmod(d.path(name), mode)
+}
+
+func (d *testDirFS) RemoveAll(name string) error {
+	return os.RemoveAll(d.path(name))
+}
+
+func changesString(changes []CopyChange) string {
+	s := make([]string, len(changes))
+	for i, ch := range changes {
+		s[i] = ch.String()
+	}
+	return strings.Join(s, ",")
+}
+
+func TestCopyTree(t *testing.T) {
+	src := fstest.MapFS{
+		"a.txt":     {Data: []byte("a"), Mode: 0o600},
+		"b.tmp":     {Data: []byte("b")},
+		"d/c.txt":   {Data: []byte("c"), Mode: 0o640},
+		"skip/e.go": {Data: []byte("e")},
+	}
+
+	dst := newTestDirFS(t)
+	opts := &CopyOptions{
+		Exclude:       []string{"*.tmp", "skip"},
+		PreservePerms: true,
+	}
+
+	changes, err := CopyTree(dst, src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "create a.txt,mkdir d,create d/c.txt"
+	if s := changesString(changes); s != expected {
+		t.Errorf("ERROR: CopyTree → %q (expected %q)", s, expected)
+	}
+
+	fi, err := os.Stat(dst.path("d/c.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o640 {
+		t.Errorf("ERROR: mode %v (expected %v)", fi.Mode().Perm(), FileMode(0o640))
+	}
+}
+
+func TestSyncTree(t *testing.T) {
+	dst := newTestDirFS(t)
+	for name, data := range map[string]string{
+		"same.txt":    "same",
+		"changed.txt": "old",
+		"extra.txt":   "extra",
+		"keep.tmp":    "keep",
+		"old/x.txt":   "x",
+	} {
+		_ = dst.MkdirAll(filepath.Dir(name), 0o755)
+		if err := dst.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := fstest.MapFS{
+		"same.txt":    {Data: []byte("same"), Mode: 0o644},
+		"changed.txt": {Data: []byte("new"), Mode: 0o644},
+		"new.txt":     {Data: []byte("new"), Mode: 0o644},
+	}
+
+	tests := []struct {
+		expected string
+		dryRun   bool
+	}{
+		{"update changed.txt,create new.txt,remove extra.txt,remove old", true},
+		{"update changed.txt,create new.txt,remove extra.txt,remove old", false},
+		{"", false},
+	}
+
+	for i, tc := range tests {
+		opts := &CopyOptions{
+			Exclude: []string{"*.tmp"},
+			DryRun:  tc.dryRun,
+		}
+
+		changes, err := SyncTree(dst, src, opts)
+		if err != nil {
+			t.Fatalf("[%v/%v] ERROR: %v", i, len(tests), err)
+		}
+
+		if s := changesString(changes); s != tc.expected {
+			t.Errorf("[%v/%v] ERROR: SyncTree → %q (expected %q)",
+				i, len(tests), s, tc.expected)
+		}
+	}
+
+	for _, name := range []string{"keep.tmp", "new.txt"} {
+		if _, err := os.Stat(dst.path(name)); err != nil {
+			t.Errorf("ERROR: %v", err)
+		}
+	}
+}
@@ -16,6 +16,13 @@ type ChmodFS interface {
 	Chmod(path string, mode FileMode) error
 }
 
+// ChownFS is the interface implemented by a file system that
+// provides the functionality of [os.Chown].
+type ChownFS interface {
+	FS
+	Chown(path string, uid, gid int) error
+}
+
 // ChtimesFS is the interface implemented by a file system that
 // provides the functionality of [os.Chtimes].
 type ChtimesFS interface {
@@ -94,6 +101,16 @@ type SymlinkFS interface {
 	Symlink(target, path string) error
 }
 
+// XattrFS is the interface implemented by a file system that
+// provides access to extended attributes.
+type XattrFS interface {
+	FS
+	GetXattr(path, attr string) ([]byte, error)
+	SetXattr(path, attr string, data []byte) error
+	ListXattr(path string) ([]string, error)
+	RemoveXattr(path, attr string) error
+}
+
 // WriteFileFS is the interface implemented by a file system that
 // provides the functionality of [os.WriteFile].
 type WriteFileFS interface {
@@ -0,0 +1,119 @@
+package fs
+
+import (
+	"errors"
+)
+
+// ErrUnsupported is an alias of the standard [errors.ErrUnsupported]
+// returned when the file system or the platform doesn't support
+// an operation.
+var ErrUnsupported = errors.ErrUnsupported
+
+// Owner returns the user and group ids of a file, if the [FileInfo]
+// provides them.
+func Owner(fi FileInfo) (uid, gid int, ok bool) {
+	if fi == nil {
+		return 0, 0, false
+	}
+	return sysOwner(fi.Sys())
+}
+
+// Chown changes the owner of a file if the file system
+// implements [ChownFS], otherwise it fails with [ErrUnsupported].
+func Chown(fSys FS, name string, uid, gid int) error {
+	if x, ok := fSys.(ChownFS); ok {
+		return x.Chown(name, uid, gid)
+	}
+	return &PathError{Op: "chown", Path: name, Err: ErrUnsupported}
+}
+
+// GetXattr reads an extended attribute of a file if the file system
+// implements [XattrFS], otherwise it fails with [ErrUnsupported].
+func GetXattr(fSys FS, name, attr string) ([]byte, error) {
+	if x, ok := fSys.(XattrFS); ok {
+		return x.GetXattr(name, attr)
+	}
+	return nil, &PathError{Op: "getxattr", Path: name, Err: ErrUnsupported}
+}
+
+// SetXattr sets an extended attribute of a file if the file system
+// implements [XattrFS], otherwise it fails with [ErrUnsupported].
+func SetXattr(fSys FS, name, attr string, data []byte) error {
+	if x, ok := fSys.(XattrFS); ok {
+		return x.SetXattr(name, attr, data)
+	}
+	return &PathError{Op: "setxattr", Path: name, Err: ErrUnsupported}
+}
+
+// ListXattr lists the extended attributes of a file if the file system
+// implements [XattrFS], otherwise it fails with [ErrUnsupported].
+func ListXattr(fSys FS, name string) ([]string, error) {
+	if x, ok := fSys.(XattrFS); ok {
+		return x.ListXattr(name)
+	}
+	return nil, &PathError{Op: "listxattr", Path: name, Err: ErrUnsupported}
+}
+
+// RemoveXattr removes an extended attribute of a file if the file system
+// implements [XattrFS], otherwise it fails with [ErrUnsupported].
+func RemoveXattr(fSys FS, name, attr string) error {
+	if x, ok := fSys.(XattrFS); ok {
+		return x.RemoveXattr(name, attr)
+	}
+	return &PathError{Op: "removexattr", Path: name, Err: ErrUnsupported}
+}
+
+// CopyMetadata copies the permissions, ownership and extended attributes
+// of a file to another, as far as both file systems support them.
+// Unsupported operations are skipped, and so is changing the owner
+// without permission to do it.
+func CopyMetadata(dst FS, dstName string, src FS, srcName string) error {
+	fi, err := Stat(src, srcName)
+	if err != nil {
+		return err
+	}
+
+	if x, ok := dst.(ChmodFS); ok {
+		if err := x.Chmod(dstName, fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	if uid, gid, ok := Owner(fi); ok {
+		err := Chown(dst, dstName, uid, gid)
+		if err != nil && !isUnsupported(err) && !errors.Is(err, ErrPermission) {
+			return err
+		}
+	}
+
+	return copyXattrs(dst, dstName, src, srcName)
+}
+
+func copyXattrs(dst FS, dstName string, src FS, srcName string) error {
+	attrs, err := ListXattr(src, srcName)
+	if err != nil {
+		return ignoreUnsupported(err)
+	}
+
+	for _, attr := range attrs {
+		data, err := GetXattr(src, srcName, attr)
+		if err == nil {
+			err = SetXattr(dst, dstName, attr, data)
+		}
+		if err = ignoreUnsupported(err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isUnsupported(err error) bool {
+	return errors.Is(err, ErrUnsupported)
+}
+
+func ignoreUnsupported(err error) error {
+	if err == nil || isUnsupported(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMetadataUnsupported(t *testing.T) {
+	fSys := fstest.MapFS{"a.txt": {Data: []byte("a")}}
+
+	if err := Chown(fSys, "a.txt", 0, 0); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ERROR: Chown → %v (expected %v)", err, ErrUnsupported)
+	}
+	if _, err := ListXattr(fSys, "a.txt"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ERROR: ListXattr → %v (expected %v)", err, ErrUnsupported)
+	}
+
+	// degrades gracefully
+	dst := newTestDirFS(t)
+	if err := dst.WriteFile("b.txt", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyMetadata(dst, "b.txt", fSys, "a.txt"); err != nil {
+		t.Errorf("ERROR: CopyMetadata → %v", err)
+	}
+}
+
+func TestCopyMetadata(t *testing.T) {
+	src := newTestSandbox(t)
+	dst := newTestSandbox(t)
+
+	if err := src.WriteFile("a.txt", []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.WriteFile("b.txt", []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	withXattr := true
+	err := src.SetXattr("a.txt", "user.test", []byte("value"))
+	switch {
+	case errors.Is(err, ErrUnsupported), errors.Is(err, ErrPermission):
+		withXattr = false
+	case err != nil:
+		t.Fatal(err)
+	}
+
+	if err := CopyMetadata(dst, "b.txt", src, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(filepath.Join(dst.Root(), "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("ERROR: mode %v (expected %v)", fi.Mode().Perm(), FileMode(0o600))
+	}
+
+	if withXattr {
+		data, err := dst.GetXattr("b.txt", "user.test")
+		if err != nil || string(data) != "value" {
+			t.Errorf("ERROR: GetXattr → %q, %v", data, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package mirror provides a write-through [fs.FS] wrapper replicating
+// the changes done to a primary file system on secondary targets.
+package mirror
+
+import (
+	"io/fs"
+	"time"
+
+	"darvaza.org/core"
+
+	xfs "darvaza.org/x/fs"
+)
+
+var (
+	_ xfs.ChmodFS     = (*FS)(nil)
+	_ xfs.ChtimesFS   = (*FS)(nil)
+	_ xfs.MkdirFS     = (*FS)(nil)
+	_ xfs.MkdirAllFS  = (*FS)(nil)
+	_ xfs.ReadDirFS   = (*FS)(nil)
+	_ xfs.ReadFileFS  = (*FS)(nil)
+	_ xfs.RemoveFS    = (*FS)(nil)
+	_ xfs.RemoveAllFS = (*FS)(nil)
+	_ xfs.RenameFS    = (*FS)(nil)
+	_ xfs.StatFS      = (*FS)(nil)
+	_ xfs.WriteFileFS = (*FS)(nil)
+)
+
+// FS is a file system wrapper applying write operations to the
+// primary file system and, if successful, mirroring them to
+// all targets in order. Reads only use the primary.
+type FS struct {
+	primary fs.FS
+	targets []Target
+
+	// OnError is called when a target fails. If not set,
+	// the error is returned by the operation, after all
+	// targets have been attempted.
+	OnError func(Op, error)
+}
+
+// New creates a mirroring [FS].
+func New(primary fs.FS, targets ...Target) (*FS, error) {
+	if primary == nil {
+		return nil, core.Wrap(fs.ErrInvalid, "primary not specified")
+	}
+
+	return &FS{
+		primary: primary,
+		targets: core.SliceCopy(targets),
+	}, nil
+}
+
+func (m *FS) do(op Op) error {
+	if err := Apply(m.primary, op); err != nil {
+		return err
+	}
+
+	op.Time = time.Now()
+
+	var errs core.CompoundError
+	for _, t := range m.targets {
+		if err := t.Apply(op); err != nil {
+			m.report(&errs, op, err)
+		}
+	}
+	return errs.AsError()
+}
+
+func (m *FS) report(errs *core.CompoundError, op Op, err error) {
+	if m.OnError != nil {
+		m.OnError(op, err)
+	} else {
+		errs.AppendError(core.Wrap(err, "mirror"))
+	}
+}
+
+// Open implements the [fs.FS] interface.
+func (m *FS) Open(name string) (fs.File, error) {
+	return m.primary.Open(name)
+}
+
+// ReadFile implements the [fs.ReadFileFS] interface.
+func (m *FS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(m.primary, name)
+}
+
+// ReadDir implements the [fs.ReadDirFS] interface.
+func (m *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(m.primary, name)
+}
+
+// Stat implements the [fs.StatFS] interface.
+func (m *FS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(m.primary, name)
+}
+
+// WriteFile implements the [xfs.WriteFileFS] interface.
+func (m *FS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return m.do(Op{Kind: WriteFile, Name: name, Data: data, Mode: perm})
+}
+
+// Mkdir implements the [xfs.MkdirFS] interface.
+func (m *FS) Mkdir(name string, perm fs.FileMode) error {
+	return m.do(Op{Kind: Mkdir, Name: name, Mode: perm})
+}
+
+// MkdirAll implements the [xfs.MkdirAllFS] interface.
+func (m *FS) MkdirAll(name string, perm fs.FileMode) error {
+	return m.do(Op{Kind: MkdirAll, Name: name, Mode: perm})
+}
+
+// Remove implements the [xfs.RemoveFS] interface.
+func (m *FS) Remove(name string) error {
+	return m.do(Op{Kind: Remove, Name: name})
+}
+
+// RemoveAll implements the [xfs.RemoveAllFS] interface.
+func (m *FS) RemoveAll(name string) error {
+	return m.do(Op{Kind: RemoveAll, Name: name})
+}
+
+// Rename implements the [xfs.RenameFS] interface.
+func (m *FS) Rename(oldName, newName string) error {
+	return m.do(Op{Kind: Rename, Name: oldName, NewName: newName})
+}
+
+// Chmod implements the [xfs.ChmodFS] interface.
+func (m *FS) Chmod(name string, mode fs.FileMode) error {
+	return m.do(Op{Kind: Chmod, Name: name, Mode: mode})
+}
+
+// Chtimes implements the [xfs.ChtimesFS] interface.
+func (m *FS) Chtimes(name string, atime, mtime time.Time) error {
+	return m.do(Op{Kind: Chtimes, Name: name, ATime: atime, MTime: mtime})
+}
@@ -0,0 +1,95 @@
+package mirror
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"darvaza.org/core"
+
+	xfs "darvaza.org/x/fs"
+)
+
+func newTestSandbox(t *testing.T) *xfs.Sandbox {
+	s, err := xfs.NewSandbox(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMirror(t *testing.T) {
+	var log bytes.Buffer
+
+	primary := newTestSandbox(t)
+	secondary := newTestSandbox(t)
+
+	m, err := New(primary, NewFSTarget(secondary), NewLogTarget(&log))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []func() error{
+		func() error { return m.MkdirAll("a/b", 0o755) },
+		func() error { return m.WriteFile("a/b/f.txt", []byte("hello"), 0o644) },
+		func() error { return m.Rename("a/b/f.txt", "a/g.txt") },
+		func() error { return m.Chmod("a/g.txt", 0o600) },
+		func() error { return m.RemoveAll("a/b") },
+	}
+	for i, fn := range steps {
+		if err := fn(); err != nil {
+			t.Fatalf("[%v/%v] ERROR: %v", i, len(steps), err)
+		}
+	}
+
+	if err := fstest.TestFS(secondary, "a/g.txt"); err != nil {
+		t.Error(err)
+	}
+
+	data, err := fs.ReadFile(secondary, "a/g.txt")
+	if err != nil || string(data) != "hello" {
+		t.Errorf("ERROR: ReadFile → %q, %v", data, err)
+	}
+
+	if n := strings.Count(log.String(), "\n"); n != len(steps) {
+		t.Errorf("ERROR: %v lines logged (expected %v):\n%s", n, len(steps), log.String())
+	}
+}
+
+func TestMirrorErrors(t *testing.T) {
+	var failed []Op
+
+	errTarget := errors.New("target failed")
+	target := TargetFunc(func(Op) error { return errTarget })
+
+	m, err := New(newTestSandbox(t), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// primary failing isn't mirrored
+	if err := m.Remove("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ERROR: Remove → %v (expected %v)", err, fs.ErrNotExist)
+	}
+
+	if err := m.Mkdir("a", 0o755); !errors.Is(err, errTarget) {
+		t.Errorf("ERROR: Mkdir → %v (expected %v)", err, errTarget)
+	}
+
+	m.OnError = func(op Op, _ error) { failed = append(failed, op) }
+	if err := m.Mkdir("b", 0o755); err != nil {
+		t.Errorf("ERROR: Mkdir → %v", err)
+	}
+	if len(failed) != 1 || failed[0].Name != "b" {
+		t.Errorf("ERROR: OnError → %v", failed)
+	}
+
+	// unsupported by the primary
+	m, _ = New(fstest.MapFS{})
+	if err := m.Mkdir("a", 0o755); !errors.Is(err, core.ErrNotImplemented) {
+		t.Errorf("ERROR: Mkdir → %v (expected %v)", err, core.ErrNotImplemented)
+	}
+}
@@ -0,0 +1,117 @@
+package mirror
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+
+	xfs "darvaza.org/x/fs"
+)
+
+// Kind identifies the operation done on a file system.
+type Kind int
+
+const (
+	// WriteFile is a call to WriteFile
+	WriteFile Kind = iota + 1
+	// Mkdir is a call to Mkdir
+	Mkdir
+	// MkdirAll is a call to MkdirAll
+	MkdirAll
+	// Remove is a call to Remove
+	Remove
+	// RemoveAll is a call to RemoveAll
+	RemoveAll
+	// Rename is a call to Rename
+	Rename
+	// Chmod is a call to Chmod
+	Chmod
+	// Chtimes is a call to Chtimes
+	Chtimes
+)
+
+var kindNames = map[Kind]string{
+	WriteFile: "write",
+	Mkdir:     "mkdir",
+	MkdirAll:  "mkdirall",
+	Remove:    "remove",
+	RemoveAll: "removeall",
+	Rename:    "rename",
+	Chmod:     "chmod",
+	Chtimes:   "chtimes",
+}
+
+func (k Kind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// Op describes a write operation done on the primary file system
+// to be mirrored.
+type Op struct {
+	Time    time.Time
+	ATime   time.Time
+	MTime   time.Time
+	Name    string
+	NewName string
+	Data    []byte
+	Kind    Kind
+	Mode    fs.FileMode
+}
+
+func (op Op) String() string {
+	var s strings.Builder
+
+	_, _ = fmt.Fprintf(&s, "%s %s", op.Kind, op.Name)
+	switch op.Kind {
+	case WriteFile:
+		_, _ = fmt.Fprintf(&s, " %#o %d", op.Mode.Perm(), len(op.Data))
+	case Mkdir, MkdirAll, Chmod:
+		_, _ = fmt.Fprintf(&s, " %#o", op.Mode.Perm())
+	case Rename:
+		_, _ = fmt.Fprintf(&s, " %s", op.NewName)
+	case Chtimes:
+		_, _ = fmt.Fprintf(&s, " %s", op.MTime.UTC().Format(time.RFC3339))
+	}
+	return s.String()
+}
+
+// Apply performs an [Op] on a file system implementing
+// the corresponding interface.
+func Apply(fSys fs.FS, op Op) error {
+	switch op.Kind {
+	case WriteFile:
+		return call(fSys, op, func(t xfs.WriteFileFS) error {
+			return t.WriteFile(op.Name, op.Data, op.Mode)
+		})
+	case Mkdir:
+		return call(fSys, op, func(t xfs.MkdirFS) error { return t.Mkdir(op.Name, op.Mode) })
+	case MkdirAll:
+		return call(fSys, op, func(t xfs.MkdirAllFS) error { return t.MkdirAll(op.Name, op.Mode) })
+	case Remove:
+		return call(fSys, op, func(t xfs.RemoveFS) error { return t.Remove(op.Name) })
+	case RemoveAll:
+		return call(fSys, op, func(t xfs.RemoveAllFS) error { return t.RemoveAll(op.Name) })
+	case Rename:
+		return call(fSys, op, func(t xfs.RenameFS) error { return t.Rename(op.Name, op.NewName) })
+	case Chmod:
+		return call(fSys, op, func(t xfs.ChmodFS) error { return t.Chmod(op.Name, op.Mode) })
+	case Chtimes:
+		return call(fSys, op, func(t xfs.ChtimesFS) error { return t.Chtimes(op.Name, op.ATime, op.MTime) })
+	default:
+		return &fs.PathError{Op: op.Kind.String(), Path: op.Name, Err: fs.ErrInvalid}
+	}
+}
+
+func call[T any](fSys fs.FS, op Op, fn func(T) error) error {
+	t, ok := fSys.(T)
+	if !ok {
+		return &fs.PathError{Op: op.Kind.String(), Path: op.Name, Err: core.ErrNotImplemented}
+	}
+	return fn(t)
+}
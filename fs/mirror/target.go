@@ -0,0 +1,46 @@
+package mirror
+
+import (
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// Target receives the operations mirrored by a [FS].
+type Target interface {
+	Apply(Op) error
+}
+
+// TargetFunc is a function implementing the [Target] interface.
+type TargetFunc func(Op) error
+
+// Apply calls the function.
+func (fn TargetFunc) Apply(op Op) error {
+	return fn(op)
+}
+
+// NewFSTarget creates a [Target] replicating the
+// operations on another file system.
+func NewFSTarget(fSys fs.FS) Target {
+	return TargetFunc(func(op Op) error {
+		return Apply(fSys, op)
+	})
+}
+
+// NewLogTarget creates a [Target] writing a line describing each
+// operation, prefixed by its time in RFC 3339 format. The content
+// of the files isn't included.
+func NewLogTarget(w io.Writer) Target {
+	var mu sync.Mutex
+
+	return TargetFunc(func(op Op) error {
+		line := op.Time.UTC().Format(time.RFC3339Nano) + " " + op.String() + "\n"
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, err := io.WriteString(w, line)
+		return err
+	})
+}
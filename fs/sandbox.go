@@ -0,0 +1,316 @@
+package fs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+var (
+	_ ChmodFS     = (*Sandbox)(nil)
+	_ ChownFS     = (*Sandbox)(nil)
+	_ ChtimesFS   = (*Sandbox)(nil)
+	_ MkdirFS     = (*Sandbox)(nil)
+	_ MkdirAllFS  = (*Sandbox)(nil)
+	_ ReadDirFS   = (*Sandbox)(nil)
+	_ ReadFileFS  = (*Sandbox)(nil)
+	_ RemoveFS    = (*Sandbox)(nil)
+	_ RemoveAllFS = (*Sandbox)(nil)
+	_ RenameFS    = (*Sandbox)(nil)
+	_ StatFS      = (*Sandbox)(nil)
+	_ SubFS       = (*Sandbox)(nil)
+	_ WriteFileFS = (*Sandbox)(nil)
+	_ SyncFS      = (*Sandbox)(nil)
+	_ XattrFS     = (*Sandbox)(nil)
+)
+
+// Sandbox is a writable [fs.FS] confined to a directory of the
+// operating system. Names are validated using [ValidPath] and resolved
+// using [SecureJoin], so neither ".." nor symbolic links can reach
+// anything outside of the root.
+type Sandbox struct {
+	root string
+}
+
+// NewSandbox creates a [Sandbox] on an existing directory.
+func NewSandbox(root string) (*Sandbox, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(root)
+	switch {
+	case err != nil:
+		return nil, err
+	case !fi.IsDir():
+		return nil, &PathError{Op: "sandbox", Path: root, Err: syscall.ENOTDIR}
+	default:
+		return &Sandbox{root: root}, nil
+	}
+}
+
+// Root returns the directory the [Sandbox] is confined to.
+func (s *Sandbox) Root() string {
+	return s.root
+}
+
+// resolve converts a [fs.FS] name into a path of the operating system
+// within the root. If the final element is a symbolic link, it's only
+// followed when requested.
+func (s *Sandbox) resolve(op, name string, follow bool) (string, error) {
+	if !ValidPath(name) {
+		return "", &PathError{Op: op, Path: name, Err: ErrInvalid}
+	}
+
+	switch {
+	case follow:
+		return s.join(op, name)
+	case name == ".":
+		// the root itself can't be removed or renamed
+		return "", &PathError{Op: op, Path: name, Err: ErrPermission}
+	}
+
+	dir, err := s.join(op, path.Dir(name))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path.Base(name)), nil
+}
+
+func (s *Sandbox) join(op, name string) (string, error) {
+	p, err := SecureJoin(s.root, filepath.FromSlash(name))
+	if err != nil {
+		return "", s.fixError(op, name, err)
+	}
+	return p, nil
+}
+
+// fixError replaces the paths of the operating system in errors
+// with the names relative to the root.
+func (*Sandbox) fixError(op, name string, err error) error {
+	var pe *PathError
+	var le *os.LinkError
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &pe):
+		return &PathError{Op: op, Path: name, Err: pe.Err}
+	case errors.As(err, &le):
+		return &PathError{Op: op, Path: name, Err: le.Err}
+	default:
+		return &PathError{Op: op, Path: name, Err: err}
+	}
+}
+
+// Open implements the [fs.FS] interface.
+func (s *Sandbox) Open(name string) (File, error) {
+	p, err := s.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, s.fixError("open", name, err)
+	}
+	return f, nil
+}
+
+// Stat implements the [fs.StatFS] interface.
+func (s *Sandbox) Stat(name string) (FileInfo, error) {
+	p, err := s.resolve("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, s.fixError("stat", name, err)
+	}
+	return fi, nil
+}
+
+// ReadFile implements the [fs.ReadFileFS] interface.
+func (s *Sandbox) ReadFile(name string) ([]byte, error) {
+	p, err := s.resolve("read", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, s.fixError("read", name, err)
+	}
+	return b, nil
+}
+
+// ReadDir implements the [fs.ReadDirFS] interface.
+func (s *Sandbox) ReadDir(name string) ([]DirEntry, error) {
+	p, err := s.resolve("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, s.fixError("readdir", name, err)
+	}
+	return entries, nil
+}
+
+// Sub implements the [fs.SubFS] interface, returning
+// a [Sandbox] confined to the given directory.
+func (s *Sandbox) Sub(dir string) (fs.FS, error) {
+	p, err := s.resolve("sub", dir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := NewSandbox(p)
+	if err != nil {
+		return nil, s.fixError("sub", dir, err)
+	}
+	return sub, nil
+}
+
+// Chmod implements the [ChmodFS] interface.
+func (s *Sandbox) Chmod(name string, mode FileMode) error {
+	p, err := s.resolve("chmod", name, true)
+	if err != nil {
+		return err
+	}
+	return s.fixError("chmod", name, os.Chmod(p, mode))
+}
+
+// Chown implements the [ChownFS] interface.
+func (s *Sandbox) Chown(name string, uid, gid int) error {
+	p, err := s.resolve("chown", name, true)
+	if err != nil {
+		return err
+	}
+	return s.fixError("chown", name, os.Chown(p, uid, gid))
+}
+
+// Chtimes implements the [ChtimesFS] interface.
+func (s *Sandbox) Chtimes(name string, atime, mtime time.Time) error {
+	p, err := s.resolve("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	return s.fixError("chtimes", name, os.Chtimes(p, atime, mtime))
+}
+
+// Mkdir implements the [MkdirFS] interface.
+func (s *Sandbox) Mkdir(name string, mode FileMode) error {
+	p, err := s.resolve("mkdir", name, false)
+	if err != nil {
+		return err
+	}
+	return s.fixError("mkdir", name, os.Mkdir(p, mode))
+}
+
+// MkdirAll implements the [MkdirAllFS] interface.
+func (s *Sandbox) MkdirAll(name string, mode FileMode) error {
+	p, err := s.resolve("mkdir", name, true)
+	if err != nil {
+		return err
+	}
+	return s.fixError("mkdir", name, os.MkdirAll(p, mode))
+}
+
+// WriteFile implements the [WriteFileFS] interface.
+func (s *Sandbox) WriteFile(name string, data []byte, perm FileMode) error {
+	p, err := s.resolve("write", name, true)
+	if err != nil {
+		return err
+	}
+	return s.fixError("write", name, os.WriteFile(p, data, perm))
+}
+
+// Remove implements the [RemoveFS] interface. Symbolic links
+// are removed, not their targets.
+func (s *Sandbox) Remove(name string) error {
+	p, err := s.resolve("remove", name, false)
+	if err != nil {
+		return err
+	}
+	return s.fixError("remove", name, os.Remove(p))
+}
+
+// RemoveAll implements the [RemoveAllFS] interface. Symbolic links
+// are removed, not their targets.
+func (s *Sandbox) RemoveAll(name string) error {
+	p, err := s.resolve("remove", name, false)
+	if err != nil {
+		return err
+	}
+	return s.fixError("remove", name, os.RemoveAll(p))
+}
+
+// Rename implements the [RenameFS] interface.
+func (s *Sandbox) Rename(oldName, newName string) error {
+	oldPath, err := s.resolve("rename", oldName, false)
+	if err != nil {
+		return err
+	}
+
+	newPath, err := s.resolve("rename", newName, false)
+	if err != nil {
+		return err
+	}
+
+	return s.fixError("rename", oldName, os.Rename(oldPath, newPath))
+}
+
+// GetXattr implements the [XattrFS] interface. Extended attributes
+// are only supported on Linux.
+func (s *Sandbox) GetXattr(name, attr string) ([]byte, error) {
+	p, err := s.resolve("getxattr", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := getxattr(p, attr)
+	if err != nil {
+		return nil, s.fixError("getxattr", name, err)
+	}
+	return data, nil
+}
+
+// SetXattr implements the [XattrFS] interface.
+func (s *Sandbox) SetXattr(name, attr string, data []byte) error {
+	p, err := s.resolve("setxattr", name, true)
+	if err != nil {
+		return err
+	}
+	return s.fixError("setxattr", name, setxattr(p, attr, data))
+}
+
+// ListXattr implements the [XattrFS] interface.
+func (s *Sandbox) ListXattr(name string) ([]string, error) {
+	p, err := s.resolve("listxattr", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := listxattr(p)
+	if err != nil {
+		return nil, s.fixError("listxattr", name, err)
+	}
+	return attrs, nil
+}
+
+// RemoveXattr implements the [XattrFS] interface.
+func (s *Sandbox) RemoveXattr(name, attr string) error {
+	p, err := s.resolve("removexattr", name, true)
+	if err != nil {
+		return err
+	}
+	return s.fixError("removexattr", name, removexattr(p, attr))
+}
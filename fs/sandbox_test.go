@@ -0,0 +1,128 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestSandbox(t *testing.T) *Sandbox {
+	root := t.TempDir()
+	for _, dir := range []string{"a/b", "c"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "a/b/f.txt"), []byte("f"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	links := map[string]string{
+		"c/up":    "../../..",
+		"c/abs":   "/a/b",
+		"c/rel":   "../a",
+		"c/etc":   "/etc/passwd",
+		"c/loop1": "loop2",
+		"c/loop2": "loop1",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skip(err)
+		}
+	}
+
+	s, err := NewSandbox(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSecureJoin(t *testing.T) {
+	s := newTestSandbox(t)
+	root := s.Root()
+
+	tests := []struct {
+		path string
+		out  string
+		err  bool
+	}{
+		{"", ".", false},
+		{"a/b", "a/b", false},
+		{"../../a", "a", false},
+		{"/a/../..", ".", false},
+		{"c/up", ".", false},
+		{"c/up/a/b/f.txt", "a/b/f.txt", false},
+		{"c/abs/f.txt", "a/b/f.txt", false},
+		{"c/rel/b", "a/b", false},
+		{"c/etc", "etc/passwd", false},
+		{"c/missing/../x", "c/x", false},
+		{"c/loop1", "", true},
+	}
+
+	for i, tc := range tests {
+		out, err := SecureJoin(root, filepath.FromSlash(tc.path))
+		switch {
+		case tc.err:
+			if err == nil {
+				t.Errorf("[%v/%v] ERROR: SecureJoin(%q) → %q (expected error)",
+					i, len(tests), tc.path, out)
+			}
+		case err != nil:
+			t.Errorf("[%v/%v] ERROR: SecureJoin(%q) → %v", i, len(tests), tc.path, err)
+		case out != filepath.Join(root, filepath.FromSlash(tc.out)):
+			t.Errorf("[%v/%v] ERROR: SecureJoin(%q) → %q (expected %q)",
+				i, len(tests), tc.path, out, tc.out)
+		}
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	s := newTestSandbox(t)
+
+	for _, name := range []string{"../x", "/a", "a/../b"} {
+		_, err := s.Open(name)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("ERROR: Open(%q) → %v (expected %v)", name, err, ErrInvalid)
+		}
+	}
+
+	data, err := s.ReadFile("c/up/a/b/f.txt")
+	if err != nil || string(data) != "f" {
+		t.Errorf("ERROR: ReadFile → %q, %v", data, err)
+	}
+
+	if _, err := s.ReadFile("c/etc"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("ERROR: ReadFile(%q) → %v (expected %v)", "c/etc", err, ErrNotExist)
+	}
+
+	// writing through a link stays within the root
+	if err := s.WriteFile("c/abs/g.txt", []byte("g"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Root(), "a/b/g.txt")); err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	// removing a link doesn't remove its target
+	if err := s.RemoveAll("c/abs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Stat("a/b/f.txt"); err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	if err := s.RemoveAll("."); !errors.Is(err, ErrPermission) {
+		t.Errorf("ERROR: RemoveAll(%q) → %v (expected %v)", ".", err, ErrPermission)
+	}
+
+	sub, err := s.Sub("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(sub, "b/f.txt", "b/g.txt"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,123 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+)
+
+// MaxSymlinks is the maximum number of symbolic links [SecureJoin]
+// follows before failing with [syscall.ELOOP].
+const MaxSymlinks = 255
+
+// SecureJoin joins an untrusted path to a root directory of the
+// operating system, guaranteeing the result stays within the root.
+// ".." elements and symbolic links are resolved as if root were
+// the root of the file system, so absolute link targets and
+// traversals beyond it are clamped to root. Missing components are
+// joined lexically.
+//
+// The result is only safe as long as the file system isn't modified
+// concurrently by someone else.
+func SecureJoin(root, unsafePath string) (string, error) {
+	r := &pathResolver{
+		root:  filepath.Clean(root),
+		name:  unsafePath,
+		parts: splitPath(unsafePath),
+	}
+
+	for len(r.parts) > 0 {
+		if err := r.step(); err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(r.root, r.resolved), nil
+}
+
+// pathResolver does the work of [SecureJoin]
+type pathResolver struct {
+	root     string
+	name     string
+	resolved string
+	parts    []string
+	links    int
+}
+
+// step resolves the next element of the path
+func (r *pathResolver) step() error {
+	part := r.parts[0]
+	r.parts = r.parts[1:]
+
+	if part == ".." {
+		r.resolved = parentPath(r.resolved)
+		return nil
+	}
+
+	next := filepath.Join(r.resolved, part)
+	target, isLink, err := readSymlink(filepath.Join(r.root, next))
+	switch {
+	case err != nil:
+		return err
+	case !isLink:
+		r.resolved = next
+		return nil
+	}
+
+	r.links++
+	if r.links > MaxSymlinks {
+		return &PathError{Op: "securejoin", Path: r.name, Err: syscall.ELOOP}
+	}
+
+	if filepath.IsAbs(target) {
+		r.resolved = ""
+	}
+	r.parts = append(splitPath(target), r.parts...)
+	return nil
+}
+
+// splitPath splits a path into its non-trivial elements
+func splitPath(s string) []string {
+	parts := strings.FieldsFunc(s, func(c rune) bool {
+		return c < utf8.RuneSelf && os.IsPathSeparator(uint8(c))
+	})
+
+	out := parts[:0]
+	for _, p := range parts {
+		if p != "." {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func parentPath(s string) string {
+	s = filepath.Dir(s)
+	if s == "." {
+		return ""
+	}
+	return s
+}
+
+// readSymlink returns the target of a symbolic link, and tells
+// if the name was a symbolic link. Missing entries aren't errors.
+func readSymlink(name string) (string, bool, error) {
+	fi, err := os.Lstat(name)
+	switch {
+	case errors.Is(err, ErrNotExist):
+		return "", false, nil
+	case err != nil:
+		return "", false, err
+	case fi.Mode()&os.ModeSymlink == 0:
+		return "", false, nil
+	}
+
+	target, err := os.Readlink(name)
+	if err != nil {
+		return "", false, err
+	}
+	return target, true, nil
+}
@@ -0,0 +1,38 @@
+//go:build unix && !aix && !solaris
+
+package tempdir
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockOwner creates the owner marker of a base directory, holding
+// an exclusive lock on it until closed.
+func lockOwner(name string) (*os.File, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// tryLockOwner locks the owner marker of a base directory without
+// waiting, failing if its owner is still alive.
+func tryLockOwner(name string) (*os.File, bool) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, false
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		return nil, false
+	}
+	return f, true
+}
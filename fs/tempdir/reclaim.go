@@ -0,0 +1,83 @@
+package tempdir
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ownerName is the marker within base directories, naming their
+// namespace and locked while the [Manager] that created them lives.
+const ownerName = ".owner"
+
+// maxOwnerSize limits how much of a marker is read.
+const maxOwnerSize = 4096
+
+// newOwner creates and locks the owner marker of a base directory.
+func newOwner(base, ns string) (*os.File, error) {
+	f, err := lockOwner(filepath.Join(base, ownerName))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.WriteString(ns); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// reclaim removes base directories of the namespace whose owner
+// is gone.
+func reclaim(parent, ns string) {
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return
+	}
+
+	for _, e := range entries {
+		if e.IsDir() && isBase(e.Name(), ns) {
+			reclaimBase(filepath.Join(parent, e.Name()), ns)
+		}
+	}
+}
+
+// reclaimBase removes a base directory if its owner marker is of
+// the namespace and no longer locked.
+func reclaimBase(dir, ns string) {
+	f, ok := tryLockOwner(filepath.Join(dir, ownerName))
+	if !ok {
+		// no marker, or owner alive
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxOwnerSize))
+	if err == nil && string(data) == ns {
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// isBase tells if a name looks like a base directory of the
+// namespace, `<ns>-<pid>-<random>`, of another process. The pid
+// must be all digits.
+func isBase(name, ns string) bool {
+	s, ok := strings.CutPrefix(name, ns+"-")
+	if !ok {
+		return false
+	}
+
+	// the random suffix has no dashes, so prefixes of other
+	// namespaces don't match
+	pid, suffix, ok := strings.Cut(s, "-")
+	switch {
+	case !ok, pid == "", suffix == "", strings.Contains(suffix, "-"):
+		return false
+	case strings.Trim(pid, "0123456789") != "":
+		return false
+	}
+
+	return pid != strconv.Itoa(os.Getpid())
+}
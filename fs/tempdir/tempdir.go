@@ -0,0 +1,252 @@
+// Package tempdir manages temporary directories, guaranteeing
+// their removal when no longer needed.
+package tempdir
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"darvaza.org/core"
+
+	"darvaza.org/x/fs"
+)
+
+var (
+	// ErrClosed indicates the [Manager] has been closed.
+	ErrClosed = fs.ErrClosed
+	// ErrInvalidNamespace indicates the namespace is missing or
+	// contains path separators.
+	ErrInvalidNamespace = core.Wrap(fs.ErrInvalid, "invalid namespace")
+)
+
+// Config describes a [Manager].
+type Config struct {
+	// Namespace prefixes the name of the base directory, and
+	// identifies leftovers of previous processes.
+	Namespace string
+	// Dir is the parent of the base directory. Default is
+	// [os.TempDir].
+	Dir string
+	// Signals enables removing everything when the process
+	// receives [os.Interrupt] or [syscall.SIGTERM], before
+	// raising the signal again.
+	Signals bool
+}
+
+// SetDefaults fills any gap in the [Config].
+func (cfg *Config) SetDefaults() {
+	if cfg.Dir == "" {
+		cfg.Dir = os.TempDir()
+	}
+}
+
+// Manager creates temporary directories inside its own base
+// directory, and removes them all when closed.
+//
+// Each base directory holds an owner marker locked for the lifetime
+// of its [Manager]. Base directories left behind by processes that
+// crashed or were killed, and whose marker is no longer locked, are
+// removed when a new [Manager] is created on the same namespace.
+type Manager struct {
+	dirs    map[string]*Dir
+	sandbox *fs.Sandbox
+	signals chan os.Signal
+	owner   *os.File
+	base    string
+	mu      sync.Mutex
+	closed  bool
+}
+
+// New creates a [Manager] and its base directory.
+func New(cfg *Config) (*Manager, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	cfg.SetDefaults()
+
+	ns := cfg.Namespace
+	if ns == "" || strings.ContainsFunc(ns, isPathSeparator) {
+		return nil, ErrInvalidNamespace
+	}
+
+	reclaim(cfg.Dir, ns)
+
+	base, err := os.MkdirTemp(cfg.Dir, fmt.Sprintf("%s-%d-", ns, os.Getpid()))
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := newOwner(base, ns)
+	if err != nil {
+		_ = os.RemoveAll(base)
+		return nil, err
+	}
+
+	sandbox, err := fs.NewSandbox(base)
+	if err != nil {
+		_ = owner.Close()
+		_ = os.RemoveAll(base)
+		return nil, err
+	}
+
+	m := &Manager{
+		dirs:    make(map[string]*Dir),
+		sandbox: sandbox,
+		owner:   owner,
+		base:    base,
+	}
+
+	if cfg.Signals {
+		m.watchSignals()
+	}
+	return m, nil
+}
+
+// Path returns the base directory.
+func (m *Manager) Path() string {
+	return m.base
+}
+
+// FS returns a view of the base directory.
+func (m *Manager) FS() *fs.Sandbox {
+	return m.sandbox
+}
+
+// Create creates a new temporary directory within the base directory.
+// The pattern follows the rules of [os.MkdirTemp].
+func (m *Manager) Create(pattern string) (*Dir, error) {
+	if m == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrClosed
+	}
+
+	name, err := os.MkdirTemp(m.base, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	sandbox, err := fs.NewSandbox(name)
+	if err != nil {
+		_ = os.RemoveAll(name)
+		return nil, err
+	}
+
+	d := &Dir{m: m, sandbox: sandbox, path: name}
+	m.dirs[name] = d
+	return d, nil
+}
+
+// Dirs returns the temporary directories currently tracked.
+func (m *Manager) Dirs() []*Dir {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]*Dir, 0, len(m.dirs))
+	for _, d := range m.dirs {
+		out = append(out, d)
+	}
+	return out
+}
+
+// Close removes the base directory and everything within it.
+func (m *Manager) Close() error {
+	if m == nil {
+		return core.ErrNilReceiver
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	m.closed = true
+	m.dirs = nil
+	if m.signals != nil {
+		signal.Stop(m.signals)
+		close(m.signals)
+	}
+
+	err := os.RemoveAll(m.base)
+	_ = m.owner.Close()
+	return err
+}
+
+func (m *Manager) forget(d *Dir) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.dirs, d.path)
+}
+
+// watchSignals removes everything when the process is interrupted,
+// and raises the signal again so the default behaviour applies.
+func (m *Manager) watchSignals() {
+	m.signals = make(chan os.Signal, 1)
+	signal.Notify(m.signals, os.Interrupt, syscall.SIGTERM)
+
+	go func(ch <-chan os.Signal) {
+		sig, ok := <-ch
+		if !ok {
+			// closed
+			return
+		}
+
+		_ = m.Close()
+		raise(sig)
+	}(m.signals)
+}
+
+func raise(sig os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// Dir is a temporary directory created by a [Manager].
+type Dir struct {
+	m       *Manager
+	sandbox *fs.Sandbox
+	path    string
+}
+
+// Path returns the location of the temporary directory.
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// FS returns a view of the temporary directory.
+func (d *Dir) FS() *fs.Sandbox {
+	return d.sandbox
+}
+
+// Remove removes the temporary directory before the
+// [Manager] is closed.
+func (d *Dir) Remove() error {
+	if d == nil {
+		return core.ErrNilReceiver
+	}
+
+	d.m.forget(d)
+	return os.RemoveAll(d.path)
+}
+
+func isPathSeparator(c rune) bool {
+	return c == '/' || c == filepath.Separator
+}
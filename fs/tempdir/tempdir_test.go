@@ -0,0 +1,117 @@
+package tempdir
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	parent := t.TempDir()
+
+	m, err := New(&Config{Namespace: "test", Dir: parent})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := m.Create("work-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.FS().WriteFile("a.txt", []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(m.FS(), filepath.Base(d.Path())+"/a.txt")
+	if err != nil || string(data) != "a" {
+		t.Errorf("ERROR: ReadFile → %q, %v", data, err)
+	}
+
+	if n := len(m.Dirs()); n != 1 {
+		t.Errorf("ERROR: %v directories (expected 1)", n)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(m.Path()); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ERROR: base directory not removed: %v", err)
+	}
+
+	if _, err := m.Create("x-*"); !errors.Is(err, ErrClosed) {
+		t.Errorf("ERROR: Create → %v (expected %v)", err, ErrClosed)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, ns := range []string{"", "a/b"} {
+		if _, err := New(&Config{Namespace: ns, Dir: t.TempDir()}); !errors.Is(err, ErrInvalidNamespace) {
+			t.Errorf("ERROR: New(%q) → %v (expected %v)", ns, err, ErrInvalidNamespace)
+		}
+	}
+}
+
+// mkBase creates a fake leftover base directory, with an unlocked
+// owner marker unless owner is empty.
+func mkBase(t *testing.T, parent, name, owner string) string {
+	t.Helper()
+
+	dir := filepath.Join(parent, name)
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if owner != "" {
+		if err := os.WriteFile(filepath.Join(dir, ownerName), []byte(owner), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReclaim(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("owner markers can't be locked")
+	}
+
+	parent := t.TempDir()
+
+	live, err := New(&Config{Namespace: "app", Dir: parent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+
+	tests := []struct {
+		name     string
+		dir      string
+		expected bool
+	}{
+		{"stale", mkBase(t, parent, "app-123-1", "app"), false},
+		{"live", live.Path(), true},
+		{"no marker", mkBase(t, parent, "app-124-1", ""), true},
+		{"foreign marker", mkBase(t, parent, "app-125-1", "app-2"), true},
+		{"foreign prefix", mkBase(t, parent, "app-2-126-1", "app-2"), true},
+		{"foreign prefix spoofed", mkBase(t, parent, "app-2-127-1", "app"), true},
+		{"signed pid", mkBase(t, parent, "app-+128-1", "app"), true},
+		{"no pid", mkBase(t, parent, "app--1", "app"), true},
+	}
+
+	m, err := New(&Config{Namespace: "app", Dir: parent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	for _, tc := range tests {
+		_, err := os.Stat(tc.dir)
+		if exists := err == nil; exists != tc.expected {
+			t.Errorf("ERROR: %s: %s exists → %v (expected %v)",
+				tc.name, filepath.Base(tc.dir), exists, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// Files follows a set of files and directories. Files are watched
+// through their directories so replacements by rename are noticed,
+// and only the events affecting the given names are delivered.
+type Files struct {
+	w     *Watcher
+	files map[string]struct{}
+	dirs  map[string]struct{}
+}
+
+// NewFiles creates a [Files] watcher using the given [Config], or
+// the defaults when nil. Directories are followed along with their
+// direct children, and names not found are followed as files so
+// their creation is noticed.
+func NewFiles(cfg *Config, names ...string) (*Files, error) {
+	w, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Files{
+		w:     w,
+		files: make(map[string]struct{}),
+		dirs:  make(map[string]struct{}),
+	}
+
+	if err := f.init(names); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Files) init(names []string) error {
+	watched := make(map[string]struct{})
+
+	for _, s := range names {
+		name, err := filepath.Abs(s)
+		if err != nil {
+			return err
+		}
+
+		dir := filepath.Dir(name)
+		if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+			dir = name
+			f.dirs[name] = struct{}{}
+		} else {
+			f.files[name] = struct{}{}
+		}
+
+		if _, ok := watched[dir]; !ok {
+			if err := f.w.Add(dir); err != nil {
+				return err
+			}
+			watched[dir] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// Existing returns the names that exist, skipping anything else,
+// like values that can be either a path or raw content.
+func Existing(names ...string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := os.Stat(name); err == nil {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// Match tells if an [Event] affects the followed names.
+func (f *Files) Match(ev Event) bool {
+	name := filepath.Clean(ev.Name)
+	if _, ok := f.files[name]; ok {
+		return true
+	}
+	if _, ok := f.dirs[name]; ok {
+		return true
+	}
+	_, ok := f.dirs[filepath.Dir(name)]
+	return ok
+}
+
+// Filter returns the events affecting the followed names.
+func (f *Files) Filter(events []Event) []Event {
+	var out []Event
+	for _, ev := range events {
+		if f.Match(ev) {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// Run calls fn with every batch of events affecting the followed
+// names, until the context is cancelled or the watcher closed.
+// Backend errors are passed to onError, if set.
+func (f *Files) Run(ctx context.Context, fn func(context.Context, []Event), onError func(error)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case err := <-f.w.Errors():
+			if onError != nil {
+				onError(err)
+			}
+		case events, ok := <-f.w.Events():
+			if !ok {
+				return ErrClosed
+			}
+
+			if events = f.Filter(events); len(events) > 0 {
+				fn(ctx, events)
+			}
+		}
+	}
+}
+
+// Close stops watching.
+func (f *Files) Close() error {
+	return f.w.Close()
+}
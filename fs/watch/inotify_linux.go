@@ -0,0 +1,178 @@
+//go:build linux
+
+package watch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var _ backend = (*inotify)(nil)
+
+const inotifyMask = syscall.IN_ATTRIB |
+	syscall.IN_CREATE |
+	syscall.IN_DELETE |
+	syscall.IN_DELETE_SELF |
+	syscall.IN_MODIFY |
+	syscall.IN_MOVE_SELF |
+	syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO
+
+// inotify is the native backend on Linux.
+type inotify struct {
+	w     *Watcher
+	f     *os.File
+	fd    int
+	paths map[int32]string
+	wds   map[string]int32
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+}
+
+func newNative(w *Watcher) (backend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	// using a non-blocking *os.File lets the runtime poller
+	// handle reads, and Close interrupts them. f.Fd() can't be
+	// used as it would switch the descriptor to blocking mode.
+	n := &inotify{
+		w:     w,
+		f:     os.NewFile(uintptr(fd), "inotify"),
+		fd:    fd,
+		paths: make(map[int32]string),
+		wds:   make(map[string]int32),
+	}
+
+	n.wg.Add(1)
+	go n.run()
+	return n, nil
+}
+
+func (n *inotify) Add(name string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	wd, err := syscall.InotifyAddWatch(n.fd, name, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: name, Err: err}
+	}
+
+	n.paths[int32(wd)] = name
+	n.wds[name] = int32(wd)
+	return nil
+}
+
+func (n *inotify) Remove(name string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	wd, ok := n.wds[name]
+	if !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+
+	delete(n.wds, name)
+	delete(n.paths, wd)
+
+	if _, err := syscall.InotifyRmWatch(n.fd, uint32(wd)); err != nil {
+		return &os.PathError{Op: "inotify_rm_watch", Path: name, Err: err}
+	}
+	return nil
+}
+
+func (n *inotify) Close() error {
+	err := n.f.Close()
+	n.wg.Wait()
+	return err
+}
+
+func (n *inotify) run() {
+	defer n.wg.Done()
+
+	var buf [64 * (syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1)]byte
+
+	for {
+		l, err := n.f.Read(buf[:])
+		switch {
+		case errors.Is(err, os.ErrClosed):
+			return
+		case err != nil:
+			n.w.report(err)
+			return
+		}
+
+		n.parse(buf[:l])
+	}
+}
+
+func (n *inotify) parse(buf []byte) {
+	for len(buf) >= syscall.SizeofInotifyEvent {
+		raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[0]))
+		end := syscall.SizeofInotifyEvent + int(raw.Len)
+		if end > len(buf) {
+			// truncated
+			return
+		}
+
+		name := n.eventPath(raw, buf[syscall.SizeofInotifyEvent:end])
+		if name != "" {
+			n.w.emit(name, inotifyOp(raw.Mask))
+		}
+
+		buf = buf[end:]
+	}
+}
+
+// eventPath returns the full name of the event's subject, and forgets
+// watches removed by the kernel.
+func (n *inotify) eventPath(raw *syscall.InotifyEvent, b []byte) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	dir, ok := n.paths[raw.Wd]
+	if ok && raw.Mask&syscall.IN_IGNORED != 0 {
+		delete(n.paths, raw.Wd)
+		delete(n.wds, dir)
+		return ""
+	}
+
+	for i, c := range b {
+		if c == 0 {
+			b = b[:i]
+			break
+		}
+	}
+
+	if len(b) > 0 {
+		return filepath.Join(dir, string(b))
+	}
+	return dir
+}
+
+func inotifyOp(mask uint32) Op {
+	var op Op
+
+	if mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		op |= Create
+	}
+	if mask&syscall.IN_MODIFY != 0 {
+		op |= Write
+	}
+	if mask&(syscall.IN_DELETE|syscall.IN_DELETE_SELF) != 0 {
+		op |= Remove
+	}
+	if mask&(syscall.IN_MOVED_FROM|syscall.IN_MOVE_SELF) != 0 {
+		op |= Rename
+	}
+	if mask&syscall.IN_ATTRIB != 0 {
+		op |= Chmod
+	}
+	return op
+}
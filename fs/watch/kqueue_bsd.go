@@ -0,0 +1,227 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package watch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var _ backend = (*kqueue)(nil)
+
+const kqueueFflags = syscall.NOTE_ATTRIB |
+	syscall.NOTE_DELETE |
+	syscall.NOTE_EXTEND |
+	syscall.NOTE_RENAME |
+	syscall.NOTE_WRITE
+
+// kqueueTimeout is how often the event loop checks
+// if the backend has been closed.
+const kqueueTimeout = 100 * time.Millisecond
+
+// kqueue is the native backend on BSD systems, including macOS.
+// As kqueue only reports that a directory changed, its entries are
+// compared to find out which children were created or removed.
+type kqueue struct {
+	w     *Watcher
+	paths map[int]string
+	fds   map[string]int
+	dirs  map[string]map[string]bool
+	done  chan struct{}
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	kq    int
+}
+
+func newNative(w *Watcher) (backend, error) {
+	kq, err := syscall.Kqueue()
+	if err != nil {
+		return nil, os.NewSyscallError("kqueue", err)
+	}
+	syscall.CloseOnExec(kq)
+
+	k := &kqueue{
+		w:     w,
+		kq:    kq,
+		paths: make(map[int]string),
+		fds:   make(map[string]int),
+		dirs:  make(map[string]map[string]bool),
+		done:  make(chan struct{}),
+	}
+
+	k.wg.Add(1)
+	go k.run()
+	return k, nil
+}
+
+func (k *kqueue) Add(name string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.fds[name]; ok {
+		// already watched
+		return nil
+	}
+
+	fd, err := syscall.Open(name, syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	var ev syscall.Kevent_t
+	syscall.SetKevent(&ev, fd, syscall.EVFILT_VNODE, syscall.EV_ADD|syscall.EV_CLEAR|syscall.EV_ENABLE)
+	ev.Fflags = kqueueFflags
+
+	if _, err := syscall.Kevent(k.kq, []syscall.Kevent_t{ev}, nil, nil); err != nil {
+		_ = syscall.Close(fd)
+		return &os.PathError{Op: "kevent", Path: name, Err: err}
+	}
+
+	k.paths[fd] = name
+	k.fds[name] = fd
+	if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+		k.dirs[name] = readDirNames(name)
+	}
+	return nil
+}
+
+func (k *kqueue) Remove(name string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	fd, ok := k.fds[name]
+	if !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+
+	k.unsafeForget(name, fd)
+	return nil
+}
+
+// unsafeForget closes the descriptor, which also removes
+// the kevent.
+func (k *kqueue) unsafeForget(name string, fd int) {
+	delete(k.paths, fd)
+	delete(k.fds, name)
+	delete(k.dirs, name)
+	_ = syscall.Close(fd)
+}
+
+func (k *kqueue) Close() error {
+	close(k.done)
+	k.wg.Wait()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for name, fd := range k.fds {
+		k.unsafeForget(name, fd)
+	}
+	return syscall.Close(k.kq)
+}
+
+func (k *kqueue) run() {
+	defer k.wg.Done()
+
+	events := make([]syscall.Kevent_t, 64)
+	ts := syscall.NsecToTimespec(int64(kqueueTimeout))
+
+	for {
+		select {
+		case <-k.done:
+			return
+		default:
+		}
+
+		n, err := syscall.Kevent(k.kq, nil, events, &ts)
+		switch {
+		case errors.Is(err, syscall.EINTR):
+			continue
+		case err != nil:
+			k.w.report(os.NewSyscallError("kevent", err))
+			return
+		}
+
+		for _, ev := range events[:n] {
+			k.handle(int(ev.Ident), ev.Fflags)
+		}
+	}
+}
+
+func (k *kqueue) handle(fd int, fflags uint32) {
+	k.mu.Lock()
+	name, ok := k.paths[fd]
+	children, isDir := k.dirs[name]
+	k.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	k.w.emit(name, kqueueOp(fflags))
+
+	switch {
+	case fflags&(syscall.NOTE_DELETE|syscall.NOTE_RENAME) != 0:
+		// the watched entry is gone
+		k.mu.Lock()
+		k.unsafeForget(name, fd)
+		k.mu.Unlock()
+	case isDir && fflags&syscall.NOTE_WRITE != 0:
+		k.scanDir(name, children)
+	}
+}
+
+// scanDir compares the entries of a directory with the previous
+// listing to report created and removed children.
+func (k *kqueue) scanDir(dir string, prev map[string]bool) {
+	next := readDirNames(dir)
+
+	for name := range prev {
+		if !next[name] {
+			k.w.emit(filepath.Join(dir, name), Remove)
+		}
+	}
+	for name := range next {
+		if !prev[name] {
+			k.w.emit(filepath.Join(dir, name), Create)
+		}
+	}
+
+	k.mu.Lock()
+	if _, ok := k.dirs[dir]; ok {
+		k.dirs[dir] = next
+	}
+	k.mu.Unlock()
+}
+
+func readDirNames(dir string) map[string]bool {
+	out := make(map[string]bool)
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		out[e.Name()] = true
+	}
+	return out
+}
+
+func kqueueOp(fflags uint32) Op {
+	var op Op
+
+	if fflags&(syscall.NOTE_WRITE|syscall.NOTE_EXTEND) != 0 {
+		op |= Write
+	}
+	if fflags&syscall.NOTE_DELETE != 0 {
+		op |= Remove
+	}
+	if fflags&syscall.NOTE_RENAME != 0 {
+		op |= Rename
+	}
+	if fflags&syscall.NOTE_ATTRIB != 0 {
+		op |= Chmod
+	}
+	return op
+}
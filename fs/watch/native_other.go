@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package watch
+
+// newNative fails on platforms without a native backend,
+// so the poller is used instead.
+func newNative(*Watcher) (backend, error) {
+	return nil, ErrNotSupported
+}
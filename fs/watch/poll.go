@@ -0,0 +1,174 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var _ backend = (*poller)(nil)
+
+// poller is a portable backend comparing snapshots
+// of the watched entries periodically.
+type poller struct {
+	w       *Watcher
+	entries map[string]map[string]fileState
+	done    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+}
+
+// fileState is the information compared between polls
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    os.FileMode
+}
+
+func newPoller(w *Watcher, interval time.Duration) *poller {
+	p := &poller{
+		w:       w,
+		entries: make(map[string]map[string]fileState),
+		done:    make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.run(interval)
+	return p
+}
+
+func (p *poller) Add(name string) error {
+	snap, err := snapshot(name)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.entries[name]; !ok {
+		p.entries[name] = snap
+	}
+	return nil
+}
+
+func (p *poller) Remove(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.entries[name]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+
+	delete(p.entries, name)
+	return nil
+}
+
+func (p *poller) Close() error {
+	close(p.done)
+	p.wg.Wait()
+	return nil
+}
+
+func (p *poller) run(interval time.Duration) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.poll()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *poller) poll() {
+	p.mu.Lock()
+	names := make([]string, 0, len(p.entries))
+	for name := range p.entries {
+		names = append(names, name)
+	}
+	p.mu.Unlock()
+
+	for _, name := range names {
+		snap, err := snapshot(name)
+		if err != nil {
+			p.w.report(err)
+		}
+
+		p.mu.Lock()
+		prev, ok := p.entries[name]
+		if ok {
+			p.entries[name] = snap
+		}
+		p.mu.Unlock()
+
+		if ok {
+			p.compare(prev, snap)
+		}
+	}
+}
+
+// compare emits the differences between two snapshots
+func (p *poller) compare(prev, next map[string]fileState) {
+	for name, a := range prev {
+		b, ok := next[name]
+		switch {
+		case !ok:
+			p.w.emit(name, Remove)
+		case a.mode != b.mode:
+			p.w.emit(name, Chmod)
+		case a.size != b.size, !a.modTime.Equal(b.modTime):
+			p.w.emit(name, Write)
+		}
+	}
+
+	for name := range next {
+		if _, ok := prev[name]; !ok {
+			p.w.emit(name, Create)
+		}
+	}
+}
+
+// snapshot returns the state of a file, or a directory and
+// its direct children. A missing entry gives an empty snapshot.
+func snapshot(name string) (map[string]fileState, error) {
+	out := make(map[string]fileState)
+
+	fi, err := os.Stat(name)
+	switch {
+	case os.IsNotExist(err):
+		return out, nil
+	case err != nil:
+		return out, err
+	}
+
+	out[name] = newFileState(fi)
+	if fi.IsDir() {
+		entries, err := os.ReadDir(name)
+		if err != nil {
+			return out, err
+		}
+
+		for _, e := range entries {
+			if fi, err := e.Info(); err == nil {
+				out[filepath.Join(name, e.Name())] = newFileState(fi)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func newFileState(fi os.FileInfo) fileState {
+	return fileState{
+		modTime: fi.ModTime(),
+		size:    fi.Size(),
+		mode:    fi.Mode(),
+	}
+}
@@ -0,0 +1,292 @@
+// Package watch monitors files and directories for changes,
+// using the native notification mechanism of the platform when
+// available, and polling otherwise.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+const (
+	// DefaultDebounce is the default time without new events
+	// before a batch is delivered.
+	DefaultDebounce = 100 * time.Millisecond
+	// DefaultInterval is the default polling interval.
+	DefaultInterval = time.Second
+)
+
+var (
+	// ErrClosed indicates the [Watcher] has been closed.
+	ErrClosed = os.ErrClosed
+	// ErrNotSupported indicates the native backend isn't
+	// available on this platform.
+	ErrNotSupported = core.Wrap(core.ErrNotImplemented, "native watcher not supported")
+)
+
+// Op describes the kind of change observed.
+type Op uint32
+
+const (
+	// Create indicates a new file or directory
+	Create Op = 1 << iota
+	// Write indicates the content changed
+	Write
+	// Remove indicates the entry was removed
+	Remove
+	// Rename indicates the entry was renamed or moved
+	Rename
+	// Chmod indicates the attributes changed
+	Chmod
+)
+
+// Has tells if the given bits are set.
+func (op Op) Has(bits Op) bool { return op&bits == bits }
+
+func (op Op) String() string {
+	var s []string
+
+	for i, name := range []string{"CREATE", "WRITE", "REMOVE", "RENAME", "CHMOD"} {
+		if op.Has(1 << i) {
+			s = append(s, name)
+		}
+	}
+
+	if len(s) == 0 {
+		return "NONE"
+	}
+	return strings.Join(s, "|")
+}
+
+// Event describes the changes observed on a file since
+// the previous batch.
+type Event struct {
+	Name string
+	Op   Op
+}
+
+func (ev Event) String() string {
+	return ev.Name + ": " + ev.Op.String()
+}
+
+// backend is the mechanism used to detect changes
+type backend interface {
+	Add(name string) error
+	Remove(name string) error
+	Close() error
+}
+
+// Config describes how a [Watcher] works.
+type Config struct {
+	// Debounce is how long to wait without new events before
+	// delivering a batch. Default is [DefaultDebounce].
+	Debounce time.Duration
+	// Interval is how often to poll when the native backend
+	// isn't used. Default is [DefaultInterval].
+	Interval time.Duration
+	// Polling forces the use of the polling backend.
+	Polling bool
+}
+
+// SetDefaults fills any gap in the [Config].
+func (cfg *Config) SetDefaults() {
+	if cfg.Debounce <= 0 {
+		cfg.Debounce = DefaultDebounce
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+}
+
+// New creates a [Watcher] using the given [Config], or the defaults
+// when nil.
+func New(cfg *Config) (*Watcher, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	cfg.SetDefaults()
+
+	w := &Watcher{
+		cfg:    *cfg,
+		raw:    make(chan Event),
+		events: make(chan []Event),
+		errors: make(chan error, 16),
+		done:   make(chan struct{}),
+	}
+
+	var err error
+	if !cfg.Polling {
+		w.b, err = newNative(w)
+	}
+	if cfg.Polling || err != nil {
+		w.b = newPoller(w, cfg.Interval)
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Watcher delivers debounced batches of change events of
+// the watched files and directories. Directories are watched
+// along with their direct children, but not recursively.
+type Watcher struct {
+	b      backend
+	raw    chan Event
+	events chan []Event
+	errors chan error
+	done   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+	cfg    Config
+}
+
+// Add starts watching a file or directory.
+func (w *Watcher) Add(name string) error {
+	if w.isClosed() {
+		return ErrClosed
+	}
+	return w.b.Add(filepath.Clean(name))
+}
+
+// Remove stops watching a file or directory.
+func (w *Watcher) Remove(name string) error {
+	if w.isClosed() {
+		return ErrClosed
+	}
+	return w.b.Remove(filepath.Clean(name))
+}
+
+// Events returns the channel delivering batches of events.
+// It's closed when the [Watcher] is closed.
+func (w *Watcher) Events() <-chan []Event {
+	return w.events
+}
+
+// Errors returns the channel delivering backend errors. Errors
+// are dropped if not consumed.
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops the [Watcher].
+func (w *Watcher) Close() error {
+	var err error
+
+	if w == nil {
+		return core.ErrNilReceiver
+	}
+
+	closed := true
+	w.once.Do(func() {
+		closed = false
+		close(w.done)
+		err = w.b.Close()
+		w.wg.Wait()
+	})
+
+	if closed {
+		return ErrClosed
+	}
+	return err
+}
+
+func (w *Watcher) isClosed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// emit is used by backends to report a change.
+func (w *Watcher) emit(name string, op Op) {
+	if op != 0 {
+		select {
+		case w.raw <- Event{Name: name, Op: op}:
+		case <-w.done:
+		}
+	}
+}
+
+// report is used by backends to report a failure.
+func (w *Watcher) report(err error) {
+	select {
+	case w.errors <- err:
+	default:
+		// dropped
+	}
+}
+
+// run coalesces raw events until there is a quiet period
+// and delivers them as a batch.
+func (w *Watcher) run() {
+	defer w.wg.Done()
+	defer close(w.events)
+
+	var b batcher
+	var out chan []Event
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case ev := <-w.raw:
+			b.Add(ev)
+			resetTimer(timer, w.cfg.Debounce)
+		case <-timer.C:
+			b.Flush()
+			out = w.events
+		case out <- b.ready:
+			b.ready, out = nil, nil
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		// drain
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+// batcher combines events by name, preserving the order of
+// first appearance.
+type batcher struct {
+	pending map[string]int
+	events  []Event
+	ready   []Event
+}
+
+func (b *batcher) Add(ev Event) {
+	if i, ok := b.pending[ev.Name]; ok {
+		b.events[i].Op |= ev.Op
+		return
+	}
+
+	if b.pending == nil {
+		b.pending = make(map[string]int)
+	}
+	b.pending[ev.Name] = len(b.events)
+	b.events = append(b.events, ev)
+}
+
+func (b *batcher) Flush() {
+	b.ready = append(b.ready, b.events...)
+	b.events = nil
+	b.pending = nil
+}
@@ -0,0 +1,126 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	for _, polling := range []bool{false, true} {
+		testWatcher(t, polling)
+	}
+}
+
+func testWatcher(t *testing.T, polling bool) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "foo")
+
+	w, err := New(&Config{
+		Debounce: 20 * time.Millisecond,
+		Interval: 10 * time.Millisecond,
+		Polling:  polling,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Close() }()
+
+	if err := w.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// several writes, one event
+	for i := range 3 {
+		if err := os.WriteFile(name, []byte{byte(i)}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case events := <-w.Events():
+			for _, ev := range events {
+				t.Logf("polling:%v %s", polling, ev)
+				if ev.Name == name && ev.Op.Has(Create) {
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatalf("polling:%v ERROR: no create event for %q", polling, name)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	name := filepath.Join(dir, "foo")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewFiles(&Config{
+		Debounce: 20 * time.Millisecond,
+		Interval: 10 * time.Millisecond,
+	}, name, sub, filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	for _, tc := range []struct {
+		name     string
+		expected bool
+	}{
+		{name, true},
+		{filepath.Join(dir, "missing"), true},
+		{filepath.Join(dir, "other"), false},
+		{sub, true},
+		{filepath.Join(sub, "bar"), true},
+		{filepath.Join(sub, "bar", "baz"), false},
+	} {
+		if ok := f.Match(Event{Name: tc.name}); ok != tc.expected {
+			t.Errorf("ERROR: Match(%q) → %v (expected %v)", tc.name, ok, tc.expected)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	batches := make(chan []Event, 4)
+	go func() {
+		_ = f.Run(ctx, func(_ context.Context, events []Event) { batches <- events }, nil)
+	}()
+
+	// unrelated files in the directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "other"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(name, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case events := <-batches:
+		for _, ev := range events {
+			if ev.Name != name {
+				t.Errorf("ERROR: unexpected event %s", ev)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("ERROR: no event for %q", name)
+	}
+}
+
+func TestExisting(t *testing.T) {
+	dir := t.TempDir()
+	names := Existing(dir, filepath.Join(dir, "missing"), "-----BEGIN CERTIFICATE-----")
+	if len(names) != 1 || names[0] != dir {
+		t.Errorf("ERROR: Existing() → %q (expected %q)", names, dir)
+	}
+}
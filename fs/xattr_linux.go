@@ -0,0 +1,81 @@
+//go:build linux
+
+package fs
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+)
+
+func getxattr(path, attr string) ([]byte, error) {
+	for {
+		sz, err := syscall.Getxattr(path, attr, nil)
+		if err != nil {
+			return nil, xattrError("getxattr", path, err)
+		}
+
+		buf := make([]byte, sz)
+		n, err := syscall.Getxattr(path, attr, buf)
+		switch {
+		case errors.Is(err, syscall.ERANGE):
+			// grew in between
+			continue
+		case err != nil:
+			return nil, xattrError("getxattr", path, err)
+		default:
+			return buf[:n], nil
+		}
+	}
+}
+
+func setxattr(path, attr string, data []byte) error {
+	return xattrError("setxattr", path, syscall.Setxattr(path, attr, data, 0))
+}
+
+func listxattr(path string) ([]string, error) {
+	for {
+		sz, err := syscall.Listxattr(path, nil)
+		if err != nil {
+			return nil, xattrError("listxattr", path, err)
+		}
+
+		buf := make([]byte, sz)
+		n, err := syscall.Listxattr(path, buf)
+		switch {
+		case errors.Is(err, syscall.ERANGE):
+			// grew in between
+			continue
+		case err != nil:
+			return nil, xattrError("listxattr", path, err)
+		default:
+			return splitXattrNames(buf[:n]), nil
+		}
+	}
+}
+
+func removexattr(path, attr string) error {
+	return xattrError("removexattr", path, syscall.Removexattr(path, attr))
+}
+
+func splitXattrNames(buf []byte) []string {
+	var out []string
+	for _, s := range strings.Split(string(buf), "\x00") {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// xattrError reports file systems not supporting extended
+// attributes as [ErrUnsupported].
+func xattrError(op, path string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, syscall.ENOTSUP):
+		err = ErrUnsupported
+	}
+	return &PathError{Op: op, Path: path, Err: err}
+}
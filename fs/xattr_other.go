@@ -0,0 +1,19 @@
+//go:build !linux
+
+package fs
+
+func getxattr(path, _ string) ([]byte, error) {
+	return nil, &PathError{Op: "getxattr", Path: path, Err: ErrUnsupported}
+}
+
+func setxattr(path, _ string, _ []byte) error {
+	return &PathError{Op: "setxattr", Path: path, Err: ErrUnsupported}
+}
+
+func listxattr(path string) ([]string, error) {
+	return nil, &PathError{Op: "listxattr", Path: path, Err: ErrUnsupported}
+}
+
+func removexattr(path, _ string) error {
+	return &PathError{Op: "removexattr", Path: path, Err: ErrUnsupported}
+}
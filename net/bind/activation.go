@@ -0,0 +1,260 @@
+package bind
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd
+const listenFDsStart = 3
+
+// ErrNotActivated indicates the process didn't receive sockets
+// via LISTEN_FDS.
+var ErrNotActivated = errors.New("not socket activated")
+
+var _ TCPUDPListener = (*Activation)(nil)
+
+// Activation holds the sockets received via systemd's socket
+// activation protocol. As a [TCPUDPListener] it hands out the
+// inherited socket matching the requested address, or falls
+// back to the given listener.
+type Activation struct {
+	// Fallback is used when no inherited socket matches.
+	Fallback TCPUDPListener
+
+	stream []*activated[net.Listener]
+	packet []*activated[net.PacketConn]
+	mu     sync.Mutex
+}
+
+type activated[T any] struct {
+	conn T
+	name string
+	used bool
+}
+
+// NewActivation collects the sockets passed via LISTEN_FDS, named
+// by LISTEN_FDNAMES. If unsetEnv is true, the variables are removed
+// so child processes don't inherit them. [ErrNotActivated] is returned
+// when the process wasn't socket activated.
+func NewActivation(unsetEnv bool) (*Activation, error) {
+	files, err := activationFiles()
+	if unsetEnv {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newActivation(files)
+}
+
+func activationFiles() ([]*os.File, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, ErrNotActivated
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, ErrNotActivated
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	files := make([]*os.File, n)
+	for i := range files {
+		name := "LISTEN_FD_" + strconv.Itoa(listenFDsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		files[i] = os.NewFile(uintptr(listenFDsStart+i), name)
+	}
+	return files, nil
+}
+
+// newActivation converts the given files into listeners, closing
+// the files. Files that aren't sockets are ignored.
+func newActivation(files []*os.File) (*Activation, error) {
+	a := new(Activation)
+	for _, f := range files {
+		a.add(f)
+		_ = f.Close()
+	}
+
+	if len(a.stream)+len(a.packet) == 0 {
+		return nil, ErrNotActivated
+	}
+	return a, nil
+}
+
+func (a *Activation) add(f *os.File) {
+	if ln, err := net.FileListener(f); err == nil {
+		a.stream = append(a.stream, &activated[net.Listener]{conn: ln, name: f.Name()})
+	} else if pc, err := net.FilePacketConn(f); err == nil {
+		a.packet = append(a.packet, &activated[net.PacketConn]{conn: pc, name: f.Name()})
+	}
+}
+
+// Listeners returns the inherited stream listeners with the given
+// name, or all of them if empty.
+func (a *Activation) Listeners(name string) []net.Listener {
+	return filterActivated(a, a.stream, name)
+}
+
+// PacketConns returns the inherited packet connections with the given
+// name, or all of them if empty.
+func (a *Activation) PacketConns(name string) []net.PacketConn {
+	return filterActivated(a, a.packet, name)
+}
+
+func filterActivated[T any](a *Activation, s []*activated[T], name string) []T {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var out []T
+	for _, p := range s {
+		if name == "" || p.name == name {
+			p.used = true
+			out = append(out, p.conn)
+		}
+	}
+	return out
+}
+
+// ListenTCP returns the unused inherited TCP listener matching the
+// address, or uses the Fallback. An unspecified IP matches any,
+// and a zero port too.
+func (a *Activation) ListenTCP(network string, laddr *net.TCPAddr) (*net.TCPListener, error) {
+	if ln, ok := takeActivated(a, a.stream, network, laddr, func(ln net.Listener) net.Addr {
+		return ln.Addr()
+	}); ok {
+		if tcp, ok := ln.(*net.TCPListener); ok {
+			return tcp, nil
+		}
+	}
+
+	if a.Fallback == nil {
+		return net.ListenTCP(network, laddr)
+	}
+	return a.Fallback.ListenTCP(network, laddr)
+}
+
+// ListenUDP returns the unused inherited UDP connection matching the
+// address, or uses the Fallback. An unspecified IP matches any,
+// and a zero port too.
+func (a *Activation) ListenUDP(network string, laddr *net.UDPAddr) (*net.UDPConn, error) {
+	if pc, ok := takeActivated(a, a.packet, network, laddr, func(pc net.PacketConn) net.Addr {
+		return pc.LocalAddr()
+	}); ok {
+		if udp, ok := pc.(*net.UDPConn); ok {
+			return udp, nil
+		}
+	}
+
+	if a.Fallback == nil {
+		return net.ListenUDP(network, laddr)
+	}
+	return a.Fallback.ListenUDP(network, laddr)
+}
+
+func takeActivated[T any](a *Activation, s []*activated[T], network string, laddr net.Addr,
+	addrOf func(T) net.Addr) (T, bool) {
+	//
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, p := range s {
+		if !p.used && matchAddr(network, laddr, addrOf(p.conn)) {
+			p.used = true
+			return p.conn, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+func matchAddr(network string, want, have net.Addr) bool {
+	w, ok1 := addrIPPort(want)
+	h, ok2 := addrIPPort(have)
+
+	switch {
+	case !ok1 || !ok2:
+		return false
+	case !strings.HasPrefix(have.Network(), strings.TrimRight(network, "46")):
+		return false
+	case w.Port != 0 && w.Port != h.Port:
+		return false
+	default:
+		return len(w.IP) == 0 || w.IP.IsUnspecified() || w.IP.Equal(h.IP)
+	}
+}
+
+func addrIPPort(addr net.Addr) (*net.TCPAddr, bool) {
+	switch v := addr.(type) {
+	case *net.TCPAddr:
+		return v, v != nil
+	case *net.UDPAddr:
+		return &net.TCPAddr{IP: v.IP, Port: v.Port, Zone: v.Zone}, v != nil
+	default:
+		return nil, false
+	}
+}
+
+// Close closes the inherited sockets that weren't handed out.
+func (a *Activation) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, p := range a.stream {
+		if !p.used {
+			_ = p.conn.Close()
+			p.used = true
+		}
+	}
+	for _, p := range a.packet {
+		if !p.used {
+			_ = p.conn.Close()
+			p.used = true
+		}
+	}
+	return nil
+}
+
+// UseActivation makes Bind use the inherited sockets matching
+// the configured addresses, binding the rest normally. A nil
+// [Activation] is ignored.
+func (cfg *Config) UseActivation(a *Activation) {
+	if a == nil {
+		return
+	}
+
+	if a.Fallback == nil {
+		if cfg.ListenTCP == nil || cfg.ListenUDP == nil {
+			cfg.setDefaultListener()
+		}
+		a.Fallback = &listenFuncs{tcp: cfg.ListenTCP, udp: cfg.ListenUDP}
+	}
+
+	cfg.UseListener(a)
+}
+
+// listenFuncs is a [TCPUDPListener] made of callbacks
+type listenFuncs struct {
+	tcp func(string, *net.TCPAddr) (*net.TCPListener, error)
+	udp func(string, *net.UDPAddr) (*net.UDPConn, error)
+}
+
+func (lf *listenFuncs) ListenTCP(network string, laddr *net.TCPAddr) (*net.TCPListener, error) {
+	return lf.tcp(network, laddr)
+}
+
+func (lf *listenFuncs) ListenUDP(network string, laddr *net.UDPAddr) (*net.UDPConn, error) {
+	return lf.udp(network, laddr)
+}
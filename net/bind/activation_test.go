@@ -0,0 +1,229 @@
+//go:build unix
+
+package bind
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// socketFile returns a named file for the socket, like the ones
+// inherited via LISTEN_FDS, closing the original.
+func socketFile(t *testing.T, sock interface {
+	File() (*os.File, error)
+	Close() error
+}, name string) *os.File {
+	t.Helper()
+	defer sock.Close()
+
+	f, err := sock.File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.NewFile(uintptr(fd), name)
+}
+
+func newTestActivation(t *testing.T) (*Activation, *net.TCPAddr, *net.UDPAddr) {
+	t.Helper()
+
+	web, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dns, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adminAddr, _ := admin.Addr().(*net.TCPAddr)
+	dnsAddr, _ := dns.LocalAddr().(*net.UDPAddr)
+
+	// not a socket, ignored
+	regular, err := os.Create(filepath.Join(t.TempDir(), "regular"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := newActivation([]*os.File{
+		socketFile(t, web, "web"),
+		socketFile(t, admin, "admin"),
+		socketFile(t, dns, "dns"),
+		regular,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, adminAddr, dnsAddr
+}
+
+// countingListener is a fallback counting its calls.
+type countingListener struct {
+	ListenConfig
+	tcp, udp int
+}
+
+func (cl *countingListener) ListenTCP(network string, laddr *net.TCPAddr) (*net.TCPListener, error) {
+	cl.tcp++
+	return cl.ListenConfig.ListenTCP(network, laddr)
+}
+
+func (cl *countingListener) ListenUDP(network string, laddr *net.UDPAddr) (*net.UDPConn, error) {
+	cl.udp++
+	return cl.ListenConfig.ListenUDP(network, laddr)
+}
+
+func TestActivation(t *testing.T) {
+	a, adminAddr, dnsAddr := newTestActivation(t)
+	defer a.Close()
+
+	fallback := new(countingListener)
+	a.Fallback = fallback
+
+	if n := len(a.stream) + len(a.packet); n != 3 {
+		t.Fatalf("ERROR: %v sockets inherited (expected 3)", n)
+	}
+
+	// specific address
+	ln, err := a.ListenTCP("tcp", adminAddr)
+	if err != nil {
+		t.Fatalf("ERROR: ListenTCP(%v) → %v", adminAddr, err)
+	}
+	if ln.Addr().String() != adminAddr.String() || fallback.tcp != 0 {
+		t.Errorf("ERROR: ListenTCP(%v) → %v (fallback %v)", adminAddr, ln.Addr(), fallback.tcp)
+	}
+
+	// wildcard, the only one left
+	pc, err := a.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil || pc.LocalAddr().String() != dnsAddr.String() || fallback.udp != 0 {
+		t.Errorf("ERROR: ListenUDP(wildcard) → %v, %v (fallback %v)", pc, err, fallback.udp)
+	}
+
+	// nothing left matching
+	ln2, err := a.ListenTCP("tcp", adminAddr)
+	if err == nil {
+		_ = ln2.Close()
+		t.Error("ERROR: ListenTCP() of a taken port succeeded")
+	}
+	if fallback.tcp != 1 {
+		t.Errorf("ERROR: fallback used %v times (expected 1)", fallback.tcp)
+	}
+
+	pc2, err := a.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc2.Close()
+	if fallback.udp != 1 {
+		t.Errorf("ERROR: fallback used %v times (expected 1)", fallback.udp)
+	}
+}
+
+func TestActivationNames(t *testing.T) {
+	a, _, _ := newTestActivation(t)
+	defer a.Close()
+
+	tests := []struct {
+		name string
+		tcp  int
+		udp  int
+	}{
+		{"web", 1, 0},
+		{"dns", 0, 1},
+		{"nope", 0, 0},
+		{"", 2, 1},
+	}
+
+	for _, tc := range tests {
+		if n := len(a.Listeners(tc.name)); n != tc.tcp {
+			t.Errorf("ERROR: Listeners(%q) → %v (expected %v)", tc.name, n, tc.tcp)
+		}
+		if n := len(a.PacketConns(tc.name)); n != tc.udp {
+			t.Errorf("ERROR: PacketConns(%q) → %v (expected %v)", tc.name, n, tc.udp)
+		}
+	}
+}
+
+func TestActivationClose(t *testing.T) {
+	a, adminAddr, _ := newTestActivation(t)
+
+	taken, err := a.ListenTCP("tcp", adminAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	_ = a.Close()
+
+	for _, p := range a.stream {
+		ln, _ := p.conn.(*net.TCPListener)
+		_ = ln.SetDeadline(time.Now())
+		_, err := ln.Accept()
+
+		switch {
+		case ln == taken && errors.Is(err, net.ErrClosed):
+			t.Error("ERROR: Close() closed a listener in use")
+		case ln != taken && !errors.Is(err, net.ErrClosed):
+			t.Errorf("ERROR: unused listener not closed: %v", err)
+		}
+	}
+}
+
+func TestActivationNone(t *testing.T) {
+	if _, err := newActivation(nil); err != ErrNotActivated {
+		t.Errorf("ERROR: newActivation(nil) → %v (expected %v)", err, ErrNotActivated)
+	}
+
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	if _, err := NewActivation(true); err != ErrNotActivated {
+		t.Errorf("ERROR: NewActivation() for another pid → %v (expected %v)", err, ErrNotActivated)
+	}
+	if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+		t.Error("ERROR: LISTEN_FDS not unset")
+	}
+}
+
+func TestMatchAddr(t *testing.T) {
+	have := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}
+	haveUDP := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}
+
+	tests := []struct {
+		network  string
+		want     net.Addr
+		have     net.Addr
+		expected bool
+	}{
+		{"tcp", &net.TCPAddr{}, have, true},
+		{"tcp", &net.TCPAddr{IP: net.IPv4zero}, have, true},
+		{"tcp", &net.TCPAddr{IP: net.IPv6unspecified, Port: 80}, have, true},
+		{"tcp4", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}, have, true},
+		{"tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 80}, have, false},
+		{"tcp", &net.TCPAddr{Port: 81}, have, false},
+		{"udp", &net.UDPAddr{Port: 80}, haveUDP, true},
+		{"udp", &net.UDPAddr{Port: 80}, have, false},
+		{"tcp", &net.TCPAddr{Port: 80}, haveUDP, false},
+		{"tcp", &net.UnixAddr{Name: "x"}, have, false},
+	}
+
+	for _, tc := range tests {
+		if got := matchAddr(tc.network, tc.want, tc.have); got != tc.expected {
+			t.Errorf("ERROR: matchAddr(%q, %v, %v) → %v (expected %v)",
+				tc.network, tc.want, tc.have, got, tc.expected)
+		}
+	}
+}
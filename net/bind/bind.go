@@ -70,15 +70,7 @@ func (cfg *Config) SetDefaults() error {
 		cfg.PortAttempts = DefaultPortAttempts
 	}
 
-	// UDP
-	if cfg.MaxRecvBufferSize < MinimumMaxRecvBufferSize {
-		cfg.MaxRecvBufferSize = DefaultMaxRecvBufferSize
-	}
-
-	// Callbacks
-	if cfg.ListenTCP == nil || cfg.ListenUDP == nil {
-		cfg.setDefaultListener()
-	}
+	cfg.setListenDefaults()
 
 	// Addresses
 	if len(cfg.Addresses) == 0 {
@@ -91,6 +83,20 @@ func (cfg *Config) SetDefaults() error {
 	return nil
 }
 
+// setListenDefaults fills the gaps of the settings used to listen
+// each address.
+func (cfg *Config) setListenDefaults() {
+	// UDP
+	if cfg.MaxRecvBufferSize < MinimumMaxRecvBufferSize {
+		cfg.MaxRecvBufferSize = DefaultMaxRecvBufferSize
+	}
+
+	// Callbacks
+	if cfg.ListenTCP == nil || cfg.ListenUDP == nil {
+		cfg.setDefaultListener()
+	}
+}
+
 func (cfg *Config) setDefaultListener() {
 	if cfg.Context == nil {
 		cfg.Context = context.Background()
@@ -0,0 +1,299 @@
+package bind
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// Family restricts the IP versions used by [Multi].
+type Family int
+
+const (
+	// AnyFamily uses both IPv4 and IPv6.
+	AnyFamily Family = iota
+	// IPv4Only uses only IPv4 addresses.
+	IPv4Only
+	// IPv6Only uses only IPv6 addresses.
+	IPv6Only
+)
+
+// FailurePolicy tells [Multi] when failing to listen an address
+// is an error.
+type FailurePolicy int
+
+const (
+	// FailOnAny fails if any address can't be listened, closing
+	// the listeners opened.
+	FailOnAny FailurePolicy = iota
+	// FailOnAll only fails if no address could be listened.
+	FailOnAll
+	// FailNever never fails, leaving the errors in the
+	// [MultiResult].
+	FailNever
+)
+
+// AddrError describes the failure to listen an address.
+type AddrError struct {
+	Err     error
+	Network string
+	Address string
+}
+
+func (e *AddrError) Error() string {
+	// the errors of net and its resolver are descriptive already
+	return e.Err.Error()
+}
+
+// Unwrap returns the cause of the failure.
+func (e *AddrError) Unwrap() error {
+	return e.Err
+}
+
+// MultiConfig describes the listeners created by [Multi].
+type MultiConfig struct {
+	// Config provides the listening settings, including the
+	// ListenTCP and ListenUDP helpers, so [Config.UseListener] and
+	// [Config.UseActivation] apply to [Multi] too.
+	// Addresses can also be host names, and if empty the addresses
+	// of the Interfaces are used, or the unspecified address of
+	// the family otherwise. Port is used when Ports is empty.
+	// PortStrict, PortAttempts and DefaultPort are ignored.
+	Config
+
+	// Ports is the list of ports to listen on every address.
+	Ports []uint16
+
+	// Family restricts the IP versions used.
+	Family Family
+	// Policy tells when failing to listen an address is an error.
+	Policy FailurePolicy
+}
+
+// MultiResult is the set of listeners created by [Multi].
+type MultiResult struct {
+	TCP    []*net.TCPListener
+	UDP    []*net.UDPConn
+	Errors []*AddrError
+}
+
+// Close closes all listeners.
+func (r *MultiResult) Close() error {
+	closeAll(r.TCP)
+	closeAll(r.UDP)
+	return nil
+}
+
+// Err returns the errors combined, or nil if there were none.
+func (r *MultiResult) Err() error {
+	var errs core.CompoundError
+	for _, err := range r.Errors {
+		errs.AppendError(err)
+	}
+	return errs.AsError()
+}
+
+func (r *MultiResult) addError(err error, network, addr string) {
+	r.Errors = append(r.Errors, &AddrError{Err: err, Network: network, Address: addr})
+}
+
+// Multi listens every combination of the given addresses and ports,
+// reporting failures per address. Depending on the [FailurePolicy]
+// an error is returned, and on error no listeners remain open.
+func Multi(cfg *MultiConfig) (*MultiResult, error) {
+	if cfg == nil {
+		cfg = new(MultiConfig)
+	}
+	cfg.setListenDefaults()
+
+	r := new(MultiResult)
+	for _, addr := range cfg.resolve(r) {
+		cfg.listen(r, addr)
+	}
+
+	if err := cfg.check(r); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (cfg *MultiConfig) check(r *MultiResult) error {
+	listened := len(r.TCP) + len(r.UDP)
+
+	switch {
+	case len(r.Errors) == 0, cfg.Policy == FailNever:
+		return nil
+	case cfg.Policy == FailOnAll && listened > 0:
+		return nil
+	default:
+		return r.Err()
+	}
+}
+
+func (cfg *MultiConfig) listen(r *MultiResult, addr *net.TCPAddr) {
+	if !cfg.OnlyUDP {
+		network := cfg.network("tcp")
+		if ln, err := cfg.ListenTCP(network, addr); err != nil {
+			r.addError(err, network, addr.String())
+		} else {
+			r.TCP = append(r.TCP, ln)
+		}
+	}
+
+	if !cfg.OnlyTCP {
+		network := cfg.network("udp")
+		if pc, err := cfg.listenUDP(network, addr); err != nil {
+			r.addError(err, network, addr.String())
+		} else {
+			r.UDP = append(r.UDP, pc)
+		}
+	}
+}
+
+func (cfg *MultiConfig) listenUDP(network string, addr *net.TCPAddr) (*net.UDPConn, error) {
+	pc, err := cfg.ListenUDP(network, &net.UDPAddr{IP: addr.IP, Port: addr.Port, Zone: addr.Zone})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := cfg.setUDPRecvBuffer(pc); err != nil {
+		_ = pc.Close()
+		return nil, err
+	}
+	return pc, nil
+}
+
+func (cfg *MultiConfig) network(proto string) string {
+	switch cfg.Family {
+	case IPv4Only:
+		return proto + "4"
+	case IPv6Only:
+		return proto + "6"
+	default:
+		return proto
+	}
+}
+
+// resolve returns all the address and port combinations to listen,
+// recording the addresses that couldn't be resolved.
+func (cfg *MultiConfig) resolve(r *MultiResult) []*net.TCPAddr {
+	ctx := cfg.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	hosts := cfg.resolveHosts(ctx, r)
+
+	ports := cfg.Ports
+	if len(ports) == 0 {
+		ports = []uint16{cfg.Port}
+	}
+
+	out := make([]*net.TCPAddr, 0, len(hosts)*len(ports))
+	for _, host := range hosts {
+		for _, port := range ports {
+			out = append(out, tcpAddr(host, port))
+		}
+	}
+	return out
+}
+
+// tcpAddr builds the [net.TCPAddr] to listen, keeping the zone of
+// scoped addresses. The invalid [netip.Addr] gives a nil IP, used
+// for dual-stack.
+func tcpAddr(addr netip.Addr, port uint16) *net.TCPAddr {
+	if !addr.IsValid() {
+		return &net.TCPAddr{Port: int(port)}
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(addr, port))
+}
+
+func (cfg *MultiConfig) resolveHosts(ctx context.Context, r *MultiResult) []netip.Addr {
+	switch {
+	case len(cfg.Addresses) > 0:
+		return cfg.lookupAll(ctx, r)
+	case len(cfg.Interfaces) > 0:
+		return cfg.interfaceHosts(r)
+	default:
+		return []netip.Addr{cfg.unspecified()}
+	}
+}
+
+func (cfg *MultiConfig) lookupAll(ctx context.Context, r *MultiResult) []netip.Addr {
+	var out []netip.Addr
+	for _, s := range cfg.Addresses {
+		addrs, err := cfg.lookup(ctx, s)
+		if err != nil {
+			r.addError(err, cfg.network("ip"), s)
+			continue
+		}
+
+		out = append(out, addrs...)
+	}
+	return out
+}
+
+// interfaceHosts returns the addresses of the interfaces, skipping
+// those of other families.
+func (cfg *MultiConfig) interfaceHosts(r *MultiResult) []netip.Addr {
+	addrs, err := core.GetIPAddresses(cfg.Interfaces...)
+	if err != nil {
+		r.addError(err, cfg.network("ip"), strings.Join(cfg.Interfaces, ","))
+		return nil
+	}
+
+	var out []netip.Addr
+	for _, addr := range addrs {
+		if cfg.allowed(addr) {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+func (cfg *MultiConfig) unspecified() netip.Addr {
+	switch cfg.Family {
+	case IPv4Only:
+		return netip.IPv4Unspecified()
+	case IPv6Only:
+		return netip.IPv6Unspecified()
+	default:
+		// dual-stack
+		return netip.Addr{}
+	}
+}
+
+// lookup parses or resolves an address, filtered by family.
+func (cfg *MultiConfig) lookup(ctx context.Context, s string) ([]netip.Addr, error) {
+	if addr, err := core.ParseAddr(s); err == nil {
+		if !cfg.allowed(addr) {
+			return nil, &net.OpError{
+				Op:  "listen",
+				Net: cfg.network("ip"),
+				Err: &net.AddrError{Err: "address family not allowed", Addr: s},
+			}
+		}
+		return []netip.Addr{addr}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, cfg.network("ip"), s)
+	if err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+func (cfg *MultiConfig) allowed(addr netip.Addr) bool {
+	switch cfg.Family {
+	case IPv4Only:
+		return addr.Unmap().Is4()
+	case IPv6Only:
+		return addr.Is6() && !addr.Is4In6()
+	default:
+		return true
+	}
+}
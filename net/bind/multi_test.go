@@ -0,0 +1,167 @@
+package bind
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+// unassigned is an address of TEST-NET-1, so it can't be listened.
+const unassigned = "192.0.2.1"
+
+func TestMultiPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    FailurePolicy
+		addresses []string
+		fails     bool
+		listeners int
+	}{
+		{"FailOnAny", FailOnAny, []string{"127.0.0.1", unassigned}, true, 0},
+		{"FailOnAny good", FailOnAny, []string{"127.0.0.1"}, false, 2},
+		{"FailOnAll some", FailOnAll, []string{"127.0.0.1", unassigned}, false, 2},
+		{"FailOnAll none", FailOnAll, []string{unassigned}, true, 0},
+		{"FailNever", FailNever, []string{unassigned}, false, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &MultiConfig{
+				Config: Config{Addresses: tc.addresses},
+				Family: IPv4Only,
+				Policy: tc.policy,
+			}
+
+			r, err := Multi(cfg)
+			switch {
+			case tc.fails && err == nil:
+				_ = r.Close()
+				t.Fatal("ERROR: Multi succeeded")
+			case tc.fails:
+				return
+			case err != nil:
+				t.Fatalf("ERROR: Multi → %v", err)
+			}
+			defer r.Close()
+
+			if n := len(r.TCP) + len(r.UDP); n != tc.listeners {
+				t.Errorf("ERROR: %v listeners (expected %v)", n, tc.listeners)
+			}
+			if tc.listeners == 0 && len(r.Errors) == 0 {
+				t.Error("ERROR: failures not reported")
+			}
+		})
+	}
+}
+
+func TestMultiListenHooks(t *testing.T) {
+	var tcpCalls, udpCalls int
+
+	lc := NewListenConfig(context.Background(), 0)
+	cfg := &MultiConfig{
+		Config: Config{
+			Addresses: []string{"127.0.0.1"},
+			ListenTCP: func(network string, laddr *net.TCPAddr) (*net.TCPListener, error) {
+				tcpCalls++
+				return lc.ListenTCP(network, laddr)
+			},
+			ListenUDP: func(network string, laddr *net.UDPAddr) (*net.UDPConn, error) {
+				udpCalls++
+				return lc.ListenUDP(network, laddr)
+			},
+		},
+		Ports:  []uint16{0, 0},
+		Family: IPv4Only,
+	}
+
+	r, err := Multi(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if tcpCalls != 2 || udpCalls != 2 {
+		t.Errorf("ERROR: hooks called %v/%v times (expected 2/2)", tcpCalls, udpCalls)
+	}
+	if cfg.MaxRecvBufferSize != DefaultMaxRecvBufferSize {
+		t.Errorf("ERROR: MaxRecvBufferSize → %v (expected %v)",
+			cfg.MaxRecvBufferSize, DefaultMaxRecvBufferSize)
+	}
+}
+
+func TestMultiFamily(t *testing.T) {
+	tests := []struct {
+		family Family
+		addr   string
+		ok     bool
+	}{
+		{AnyFamily, "::1", true},
+		{AnyFamily, "127.0.0.1", true},
+		{IPv4Only, "127.0.0.1", true},
+		{IPv4Only, "::ffff:127.0.0.1", true},
+		{IPv4Only, "::1", false},
+		{IPv6Only, "::1", true},
+		{IPv6Only, "127.0.0.1", false},
+		{IPv6Only, "::ffff:127.0.0.1", false},
+	}
+
+	for _, tc := range tests {
+		cfg := &MultiConfig{Family: tc.family}
+		addrs, err := cfg.lookup(context.Background(), tc.addr)
+		switch {
+		case tc.ok && (err != nil || len(addrs) != 1):
+			t.Errorf("ERROR: lookup(%v, %q) → %v, %v", tc.family, tc.addr, addrs, err)
+		case !tc.ok && err == nil:
+			t.Errorf("ERROR: lookup(%v, %q) → %v (expected error)", tc.family, tc.addr, addrs)
+		}
+	}
+
+	// names are resolved within the family
+	cfg := &MultiConfig{Family: IPv4Only}
+	addrs, err := cfg.lookup(context.Background(), "localhost")
+	if err != nil {
+		t.Skip("localhost can't be resolved:", err)
+	}
+	for _, addr := range addrs {
+		if !addr.Unmap().Is4() {
+			t.Errorf("ERROR: lookup(IPv4Only, localhost) → %v", addr)
+		}
+	}
+}
+
+func TestMultiAddresses(t *testing.T) {
+	tests := []struct {
+		addr     netip.Addr
+		expected string
+	}{
+		{netip.Addr{}, ":80"},
+		{netip.IPv4Unspecified(), "0.0.0.0:80"},
+		{netip.IPv6Unspecified(), "[::]:80"},
+		{netip.MustParseAddr("127.0.0.1"), "127.0.0.1:80"},
+		{netip.MustParseAddr("::1"), "[::1]:80"},
+		{netip.MustParseAddr("fe80::1%lo"), "[fe80::1%lo]:80"},
+	}
+
+	for i, tc := range tests {
+		if s := tcpAddr(tc.addr, 80).String(); s != tc.expected {
+			t.Errorf("[%v] ERROR: tcpAddr(%v) → %q (expected %q)", i, tc.addr, s, tc.expected)
+		}
+	}
+
+	// specific addresses are never widened to the wildcard
+	r, err := Multi(&MultiConfig{
+		Config: Config{Addresses: []string{"127.0.0.1"}},
+		Policy: FailOnAny,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for _, ln := range r.TCP {
+		if addr := ln.Addr().(*net.TCPAddr); !addr.IP.IsLoopback() {
+			t.Errorf("ERROR: listening %v (expected 127.0.0.1)", addr)
+		}
+	}
+}
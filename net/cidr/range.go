@@ -0,0 +1,180 @@
+package cidr
+
+import (
+	"net/netip"
+	"slices"
+)
+
+// ipRange is an inclusive range of addresses of the same family
+type ipRange struct {
+	from, to netip.Addr
+}
+
+func newRange(from, to netip.Addr) (ipRange, bool) {
+	from, to = from.Unmap(), to.Unmap()
+
+	switch {
+	case !from.IsValid(), !to.IsValid():
+		return ipRange{}, false
+	case from.BitLen() != to.BitLen(), from.Compare(to) > 0:
+		return ipRange{}, false
+	default:
+		return ipRange{from: from, to: to}, true
+	}
+}
+
+func prefixRange(p netip.Prefix) (ipRange, bool) {
+	if !p.IsValid() {
+		return ipRange{}, false
+	}
+
+	addr, bits := p.Addr(), p.Bits()
+	if addr.Is4In6() {
+		if bits < 96 {
+			// spans beyond the mapped block
+			return ipRange{}, false
+		}
+		addr, bits = addr.Unmap(), bits-96
+	}
+
+	p = netip.PrefixFrom(addr, bits).Masked()
+	return ipRange{from: p.Addr(), to: lastAddr(p)}, true
+}
+
+// lastAddr returns the last address of a masked prefix.
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// appendPrefixes appends the minimal prefixes covering the range.
+func (r ipRange) appendPrefixes(out []netip.Prefix) []netip.Prefix {
+	from := r.from
+	for from.IsValid() && from.Compare(r.to) <= 0 {
+		p := r.largestPrefix(from)
+		out = append(out, p)
+		from = lastAddr(p).Next()
+	}
+	return out
+}
+
+// largestPrefix returns the largest prefix starting at from
+// that fits within the range.
+func (r ipRange) largestPrefix(from netip.Addr) netip.Prefix {
+	for bits := 0; bits < from.BitLen(); bits++ {
+		p := netip.PrefixFrom(from, bits)
+		if p.Masked().Addr() == from && lastAddr(p).Compare(r.to) <= 0 {
+			return p
+		}
+	}
+	return netip.PrefixFrom(from, from.BitLen())
+}
+
+// adjacent tells if b starts right after a ends.
+func (r ipRange) adjacent(b ipRange) bool {
+	next := r.to.Next()
+	return next.IsValid() && next == b.from
+}
+
+// normalize sorts the ranges and merges those overlapping
+// or adjacent.
+func normalize(ranges []ipRange) []ipRange {
+	if len(ranges) < 2 {
+		return ranges
+	}
+
+	slices.SortFunc(ranges, func(a, b ipRange) int {
+		return a.from.Compare(b.from)
+	})
+
+	out := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &out[len(out)-1]
+		switch {
+		case r.from.Compare(last.to) <= 0, last.adjacent(r):
+			if r.to.Compare(last.to) > 0 {
+				last.to = r.to
+			}
+		default:
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// intersect returns the ranges common to two normalized lists.
+func intersect(a, b []ipRange) []ipRange {
+	var out []ipRange
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		lo := maxAddr(a[i].from, b[j].from)
+		hi := minAddr(a[i].to, b[j].to)
+		if lo.Compare(hi) <= 0 {
+			out = append(out, ipRange{from: lo, to: hi})
+		}
+
+		if a[i].to.Compare(b[j].to) < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	return out
+}
+
+// subtract returns the ranges of a not in b, both normalized.
+func subtract(a, b []ipRange) []ipRange {
+	var out []ipRange
+
+	j := 0
+	for _, r := range a {
+		// skip what ends before this range
+		for j < len(b) && b[j].to.Compare(r.from) < 0 {
+			j++
+		}
+
+		out = subtractRange(out, r, b[j:])
+	}
+	return out
+}
+
+// subtractRange appends what remains of r after removing
+// the ranges of b, which are sorted and don't end before r.
+func subtractRange(out []ipRange, r ipRange, b []ipRange) []ipRange {
+	for _, x := range b {
+		if x.from.Compare(r.to) > 0 {
+			break
+		}
+
+		if x.from.Compare(r.from) > 0 {
+			out = append(out, ipRange{from: r.from, to: x.from.Prev()})
+		}
+
+		next := x.to.Next()
+		if !next.IsValid() || x.to.Compare(r.to) >= 0 {
+			// nothing left
+			return out
+		}
+		r.from = next
+	}
+	return append(out, r)
+}
+
+func minAddr(a, b netip.Addr) netip.Addr {
+	if a.Compare(b) < 0 {
+		return a
+	}
+	return b
+}
+
+func maxAddr(a, b netip.Addr) netip.Addr {
+	if a.Compare(b) > 0 {
+		return a
+	}
+	return b
+}
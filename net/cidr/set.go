@@ -0,0 +1,172 @@
+// Package cidr provides sets of IP addresses built from
+// CIDR prefixes and ranges.
+package cidr
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Set is a set of IP addresses, stored as sorted non-overlapping
+// ranges. IPv4-mapped IPv6 addresses are treated as IPv4.
+// The zero value is an empty set ready to use. A Set isn't safe
+// for concurrent modification.
+type Set struct {
+	ranges []ipRange
+}
+
+// New creates a [Set] containing the given prefixes.
+func New(prefixes ...netip.Prefix) *Set {
+	ranges := make([]ipRange, 0, len(prefixes))
+	for _, p := range prefixes {
+		if r, ok := prefixRange(p); ok {
+			ranges = append(ranges, r)
+		}
+	}
+	return &Set{ranges: normalize(ranges)}
+}
+
+// Parse creates a [Set] from CIDR prefixes or single addresses.
+func Parse(values ...string) (*Set, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		p, err := parsePrefix(v)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, p)
+	}
+	return New(prefixes...), nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Add adds all addresses of a prefix. Invalid prefixes are ignored.
+func (s *Set) Add(p netip.Prefix) {
+	if r, ok := prefixRange(p); ok {
+		s.ranges = normalize(append(s.ranges, r))
+	}
+}
+
+// AddAddr adds a single address.
+func (s *Set) AddAddr(addr netip.Addr) {
+	s.AddRange(addr, addr)
+}
+
+// AddRange adds all addresses between from and to, inclusive.
+// Ranges mixing families or reversed are ignored.
+func (s *Set) AddRange(from, to netip.Addr) {
+	if r, ok := newRange(from, to); ok {
+		s.ranges = normalize(append(s.ranges, r))
+	}
+}
+
+// Remove removes all addresses of a prefix.
+func (s *Set) Remove(p netip.Prefix) {
+	if r, ok := prefixRange(p); ok {
+		s.ranges = subtract(s.ranges, []ipRange{r})
+	}
+}
+
+// Contains tells if the address belongs to the set.
+func (s *Set) Contains(addr netip.Addr) bool {
+	if s == nil || !addr.IsValid() {
+		return false
+	}
+
+	addr = addr.Unmap()
+	i, found := slices.BinarySearchFunc(s.ranges, addr, func(r ipRange, a netip.Addr) int {
+		return r.to.Compare(a)
+	})
+	return found || (i < len(s.ranges) && s.ranges[i].from.Compare(addr) <= 0)
+}
+
+// ContainsPrefix tells if all addresses of the prefix belong
+// to the set.
+func (s *Set) ContainsPrefix(p netip.Prefix) bool {
+	r, ok := prefixRange(p)
+	if s == nil || !ok {
+		return false
+	}
+
+	for _, x := range s.ranges {
+		if x.from.Compare(r.from) <= 0 && r.to.Compare(x.to) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty tells if the set contains no addresses.
+func (s *Set) IsEmpty() bool {
+	return s == nil || len(s.ranges) == 0
+}
+
+// Clone returns a copy of the set.
+func (s *Set) Clone() *Set {
+	if s == nil {
+		return new(Set)
+	}
+	return &Set{ranges: slices.Clone(s.ranges)}
+}
+
+// Union returns a new [Set] with the addresses in either set.
+func (s *Set) Union(other *Set) *Set {
+	out := make([]ipRange, 0, s.len()+other.len())
+	out = append(out, s.list()...)
+	out = append(out, other.list()...)
+	return &Set{ranges: normalize(out)}
+}
+
+// Intersect returns a new [Set] with the addresses in both sets.
+func (s *Set) Intersect(other *Set) *Set {
+	return &Set{ranges: intersect(s.list(), other.list())}
+}
+
+// Subtract returns a new [Set] with the addresses of this set
+// not in the other.
+func (s *Set) Subtract(other *Set) *Set {
+	return &Set{ranges: subtract(s.list(), other.list())}
+}
+
+// Prefixes returns the minimal list of prefixes covering
+// the set, in order.
+func (s *Set) Prefixes() []netip.Prefix {
+	var out []netip.Prefix
+	for _, r := range s.list() {
+		out = r.appendPrefixes(out)
+	}
+	return out
+}
+
+// String returns the prefixes of the set separated by commas.
+func (s *Set) String() string {
+	prefixes := s.Prefixes()
+	parts := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		parts[i] = p.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s *Set) list() []ipRange {
+	if s == nil {
+		return nil
+	}
+	return s.ranges
+}
+
+func (s *Set) len() int {
+	return len(s.list())
+}
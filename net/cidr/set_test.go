@@ -0,0 +1,82 @@
+package cidr
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, values ...string) *Set {
+	t.Helper()
+
+	s, err := Parse(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestContains(t *testing.T) {
+	s := mustParse(t, "10.0.0.0/8", "192.168.1.1", "2001:db8::/32")
+
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{"10.1.2.3", true},
+		{"11.0.0.0", false},
+		{"192.168.1.1", true},
+		{"192.168.1.2", false},
+		{"::ffff:10.0.0.1", true},
+		{"2001:db8:1::1", true},
+		{"2001:db9::", false},
+	}
+
+	for i, tc := range tests {
+		got := s.Contains(netip.MustParseAddr(tc.addr))
+		if got != tc.expected {
+			t.Errorf("[%v/%v] ERROR: %s: %v (expected %v)", i, len(tests), tc.addr, got, tc.expected)
+		}
+	}
+}
+
+func TestOperations(t *testing.T) {
+	a := mustParse(t, "10.0.0.0/24", "10.0.1.0/24", "2001:db8::/126")
+	b := mustParse(t, "10.0.0.128/25", "10.0.2.0/24", "2001:db8::1")
+
+	tests := []struct {
+		name     string
+		set      *Set
+		expected string
+	}{
+		{"a", a, "10.0.0.0/23,2001:db8::/126"},
+		{"new", New(netip.MustParsePrefix("10.0.1.0/24"), netip.Prefix{},
+			netip.MustParsePrefix("10.0.0.0/24")), "10.0.0.0/23"},
+		{"union", a.Union(b), "10.0.0.0/23,10.0.2.0/24,2001:db8::/126"},
+		{"intersect", a.Intersect(b), "10.0.0.128/25,2001:db8::1/128"},
+		{"subtract", a.Subtract(b), "10.0.0.0/25,10.0.1.0/24,2001:db8::/128,2001:db8::2/127"},
+	}
+
+	for i, tc := range tests {
+		if got := tc.set.String(); got != tc.expected {
+			t.Errorf("[%v/%v] ERROR: %s: %q (expected %q)", i, len(tests), tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	s := mustParse(t, "0.0.0.0/0")
+	s.Remove(netip.MustParsePrefix("0.0.0.0/1"))
+	s.Remove(netip.MustParsePrefix("255.255.255.255/32"))
+
+	if got, expected := s.String(), "128.0.0.0/2,192.0.0.0/3,224.0.0.0/4"; !strings.HasPrefix(got, expected) {
+		t.Errorf("ERROR: %q", got)
+	}
+
+	if s.Contains(netip.MustParseAddr("255.255.255.255")) {
+		t.Error("ERROR: removed address still contained")
+	}
+	if !s.ContainsPrefix(netip.MustParsePrefix("200.0.0.0/8")) {
+		t.Error("ERROR: 200.0.0.0/8 not contained")
+	}
+}
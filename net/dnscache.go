@@ -0,0 +1,210 @@
+package net
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultCacheTTL is the default time a [CachingResolver]
+	// keeps addresses when the upstream doesn't tell.
+	DefaultCacheTTL = 30 * time.Second
+	// DefaultNegativeCacheTTL is the default time a [CachingResolver]
+	// remembers a host doesn't exist.
+	DefaultNegativeCacheTTL = 5 * time.Second
+	// DefaultCacheMaxEntries is the default limit of entries
+	// a [CachingResolver] keeps.
+	DefaultCacheMaxEntries = 4096
+)
+
+var _ Resolver = (*CachingResolver)(nil)
+
+// TTLResolver is a [Resolver] also telling for how long the
+// addresses found are valid.
+type TTLResolver interface {
+	Resolver
+
+	LookupNetIPTTL(ctx context.Context, network, host string) ([]netip.Addr, time.Duration, error)
+}
+
+// CachingResolver is a [Resolver] caching the lookups of its upstream.
+// Failures to find a host are cached too, while other errors aren't.
+// Concurrent lookups of the same host are done only once.
+type CachingResolver struct {
+	// Upstream does the actual lookups. If it implements
+	// [TTLResolver] its TTLs are honoured. Default is
+	// [net.DefaultResolver].
+	Upstream Resolver
+
+	entries map[dnsCacheKey]*dnsCacheEntry
+	lru     *list.List
+	group   singleflight.Group[dnsCacheKey, []netip.Addr]
+	mu      sync.Mutex
+
+	// TTL is the time addresses are cached when the upstream
+	// doesn't tell. Default is [DefaultCacheTTL].
+	TTL time.Duration
+	// MaxTTL limits the TTLs given by the upstream. Zero
+	// means no limit.
+	MaxTTL time.Duration
+	// NegativeTTL is the time unknown hosts are cached.
+	// Default is [DefaultNegativeCacheTTL].
+	NegativeTTL time.Duration
+	// MaxEntries limits the number of cached lookups, evicting
+	// the least recently used when exceeded.
+	// Default is [DefaultCacheMaxEntries].
+	MaxEntries int
+}
+
+type dnsCacheKey struct {
+	network string
+	host    string
+}
+
+type dnsCacheEntry struct {
+	expires time.Time
+	elem    *list.Element
+	key     dnsCacheKey
+	addrs   []netip.Addr
+	err     error
+}
+
+// LookupNetIP implements the [Resolver] interface.
+func (r *CachingResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	if r == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	key := dnsCacheKey{network: network, host: strings.ToLower(host)}
+	if e, ok := r.get(key); ok {
+		return core.SliceCopy(e.addrs), e.err
+	}
+
+	addrs, err := r.group.Do(ctx, key, func(ctx context.Context) ([]netip.Addr, error) {
+		return r.lookup(ctx, key)
+	})
+	return core.SliceCopy(addrs), err
+}
+
+// Forget removes a host from the cache.
+func (r *CachingResolver) Forget(host string) {
+	host = strings.ToLower(host)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, e := range r.entries {
+		if key.host == host {
+			r.unsafeRemove(e)
+		}
+	}
+}
+
+// Flush removes all entries from the cache.
+func (r *CachingResolver) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+	r.lru = nil
+}
+
+func (r *CachingResolver) get(key dnsCacheKey) (dnsCacheEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[key]
+	switch {
+	case !ok:
+		return dnsCacheEntry{}, false
+	case time.Now().After(e.expires):
+		r.unsafeRemove(e)
+		return dnsCacheEntry{}, false
+	default:
+		r.lru.MoveToFront(e.elem)
+		return *e, true
+	}
+}
+
+func (r *CachingResolver) lookup(ctx context.Context, key dnsCacheKey) ([]netip.Addr, error) {
+	addrs, ttl, err := r.upstream(ctx, key)
+
+	switch {
+	case err == nil && len(addrs) > 0:
+		r.store(key, dnsCacheEntry{addrs: addrs}, r.ttl(ttl))
+	case isNotFound(err):
+		r.store(key, dnsCacheEntry{err: err}, core.Coalesce(r.NegativeTTL, DefaultNegativeCacheTTL))
+	}
+
+	return addrs, err
+}
+
+func (r *CachingResolver) upstream(ctx context.Context, key dnsCacheKey) ([]netip.Addr, time.Duration, error) {
+	switch u := r.Upstream.(type) {
+	case nil:
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, key.network, key.host)
+		return addrs, 0, err
+	case TTLResolver:
+		return u.LookupNetIPTTL(ctx, key.network, key.host)
+	default:
+		addrs, err := u.LookupNetIP(ctx, key.network, key.host)
+		return addrs, 0, err
+	}
+}
+
+func (r *CachingResolver) ttl(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		ttl = core.Coalesce(r.TTL, DefaultCacheTTL)
+	}
+	if r.MaxTTL > 0 {
+		ttl = min(ttl, r.MaxTTL)
+	}
+	return ttl
+}
+
+func (r *CachingResolver) store(key dnsCacheKey, e dnsCacheEntry, ttl time.Duration) {
+	e.key = key
+	e.expires = time.Now().Add(ttl)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.entries == nil {
+		r.entries = make(map[dnsCacheKey]*dnsCacheEntry)
+		r.lru = list.New()
+	}
+	if old, ok := r.entries[key]; ok {
+		r.unsafeRemove(old)
+	}
+
+	e.elem = r.lru.PushFront(&e)
+	r.entries[key] = &e
+
+	limit := r.MaxEntries
+	if limit <= 0 {
+		limit = DefaultCacheMaxEntries
+	}
+	for len(r.entries) > limit {
+		oldest, _ := r.lru.Back().Value.(*dnsCacheEntry)
+		r.unsafeRemove(oldest)
+	}
+}
+
+func (r *CachingResolver) unsafeRemove(e *dnsCacheEntry) {
+	r.lru.Remove(e.elem)
+	delete(r.entries, e.key)
+}
+
+func isNotFound(err error) bool {
+	var e *net.DNSError
+	return errors.As(err, &e) && e.IsNotFound
+}
@@ -0,0 +1,102 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachingResolver(t *testing.T) {
+	var calls atomic.Int32
+	upstream := resolverFunc(func(_ context.Context, _, host string) ([]netip.Addr, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+
+		if host == "missing.example" {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return []netip.Addr{netip.MustParseAddr("192.0.2.1")}, nil
+	})
+
+	r := &CachingResolver{Upstream: upstream, TTL: 50 * time.Millisecond}
+	ctx := context.Background()
+
+	// concurrent lookups are deduplicated
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.LookupNetIP(ctx, "ip", "host.example"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// cached, including the case
+	if _, err := r.LookupNetIP(ctx, "ip", "HOST.example"); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ERROR: %v upstream calls (expected 1)", n)
+	}
+
+	// negative caching
+	for range 2 {
+		var e *net.DNSError
+		if _, err := r.LookupNetIP(ctx, "ip", "missing.example"); !errors.As(err, &e) {
+			t.Errorf("ERROR: unexpected %v", err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("ERROR: %v upstream calls (expected 2)", n)
+	}
+
+	// expiration
+	time.Sleep(60 * time.Millisecond)
+	if _, err := r.LookupNetIP(ctx, "ip", "host.example"); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("ERROR: %v upstream calls (expected 3)", n)
+	}
+}
+
+func TestCachingResolverMaxEntries(t *testing.T) {
+	var calls atomic.Int32
+	upstream := resolverFunc(func(_ context.Context, _, host string) ([]netip.Addr, error) {
+		calls.Add(1)
+		if host == "missing.example" {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return []netip.Addr{netip.MustParseAddr("192.0.2.1")}, nil
+	})
+
+	r := &CachingResolver{Upstream: upstream, MaxEntries: 2}
+	ctx := context.Background()
+
+	for _, host := range []string{"a.example", "missing.example", "a.example", "b.example"} {
+		_, _ = r.LookupNetIP(ctx, "ip", host)
+	}
+
+	// missing.example was the least recently used
+	if n := len(r.entries); n != 2 {
+		t.Errorf("ERROR: %v entries (expected 2)", n)
+	}
+	if n := r.lru.Len(); n != 2 {
+		t.Errorf("ERROR: %v entries on the LRU (expected 2)", n)
+	}
+
+	before := calls.Load()
+	_, _ = r.LookupNetIP(ctx, "ip", "a.example")
+	_, _ = r.LookupNetIP(ctx, "ip", "missing.example")
+	if n := calls.Load() - before; n != 1 {
+		t.Errorf("ERROR: %v upstream calls (expected 1)", n)
+	}
+}
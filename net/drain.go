@@ -0,0 +1,151 @@
+package net
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ net.Listener = (*DrainListener)(nil)
+	_ net.Conn     = (*drainConn)(nil)
+)
+
+// DrainListener is a [net.Listener] tracking the connections it
+// accepted to allow graceful shutdowns.
+type DrainListener struct {
+	net.Listener
+
+	conns    map[*drainConn]struct{}
+	idle     chan struct{}
+	mu       sync.Mutex
+	draining bool
+}
+
+// NewDrainListener wraps a [net.Listener] to track its
+// connections.
+func NewDrainListener(l net.Listener) *DrainListener {
+	return &DrainListener{
+		Listener: l,
+		conns:    make(map[*drainConn]struct{}),
+	}
+}
+
+// Accept waits for and returns the next connection. Once draining,
+// it fails with [net.ErrClosed].
+func (l *DrainListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.draining {
+		_ = conn.Close()
+		return nil, net.ErrClosed
+	}
+
+	c := &drainConn{Conn: conn, l: l}
+	l.conns[c] = struct{}{}
+	return c, nil
+}
+
+// Len returns the number of connections still open.
+func (l *DrainListener) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.conns)
+}
+
+// Drain closes the listener and waits for the connections to be
+// closed. When the context is cancelled before that, the remaining
+// connections are closed forcefully and the cause of the cancellation
+// is returned.
+//
+// Drain can be used as the Run function of a [shutdown.Hook].
+//
+// [shutdown.Hook]: https://pkg.go.dev/darvaza.org/x/sync/shutdown#Hook
+func (l *DrainListener) Drain(ctx context.Context) error {
+	if l == nil {
+		return core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	idle, err := l.startDraining()
+	if idle == nil {
+		return err
+	}
+
+	select {
+	case <-idle:
+		return err
+	case <-ctx.Done():
+		l.closeAll()
+		return context.Cause(ctx)
+	}
+}
+
+// startDraining closes the listener and returns a channel closed
+// when no connections remain, or nil if there are none already.
+func (l *DrainListener) startDraining() (<-chan struct{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var err error
+	if !l.draining {
+		l.draining = true
+		err = l.Listener.Close()
+	}
+
+	switch {
+	case len(l.conns) == 0:
+		return nil, err
+	case l.idle == nil:
+		l.idle = make(chan struct{})
+	}
+	return l.idle, err
+}
+
+func (l *DrainListener) closeAll() {
+	l.mu.Lock()
+	conns := make([]*drainConn, 0, len(l.conns))
+	for c := range l.conns {
+		conns = append(conns, c)
+	}
+	l.mu.Unlock()
+
+	for _, c := range conns {
+		_ = c.Close()
+	}
+}
+
+func (l *DrainListener) forget(c *drainConn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.conns, c)
+	if len(l.conns) == 0 && l.idle != nil {
+		close(l.idle)
+		l.idle = nil
+	}
+}
+
+// drainConn is a connection tracked by a [DrainListener]
+type drainConn struct {
+	net.Conn
+
+	l    *DrainListener
+	once sync.Once
+}
+
+func (c *drainConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { c.l.forget(c) })
+	return err
+}
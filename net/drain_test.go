@@ -0,0 +1,57 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDrainListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewDrainListener(ln)
+	var server []net.Conn
+	for range 2 {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = c.Close() }()
+
+		s, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		server = append(server, s)
+	}
+
+	// one finishes in time, the other doesn't
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = server[0].Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := l.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ERROR: Drain → %v (expected %v)", err, context.DeadlineExceeded)
+	}
+
+	if n := l.Len(); n != 0 {
+		t.Errorf("ERROR: %v connections left", n)
+	}
+
+	if _, err := l.Accept(); err == nil {
+		t.Error("ERROR: Accept succeeded after Drain")
+	}
+
+	if err := l.Drain(context.Background()); err != nil {
+		t.Errorf("ERROR: Drain → %v", err)
+	}
+}
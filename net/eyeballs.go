@@ -0,0 +1,217 @@
+package net
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// DefaultFallbackDelay is the default time a [HappyDialer] waits
+// for an attempt before starting the next, as recommended by
+// RFC 8305.
+const DefaultFallbackDelay = 250 * time.Millisecond
+
+var _ Dialer = (*HappyDialer)(nil)
+
+// Resolver looks up the addresses of a host.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// HappyDialer is a [Dialer] implementing the Happy Eyeballs algorithm
+// of RFC 8305. The addresses of the host are interleaved by family, and
+// attempted with staggered starts. The first connection established
+// is returned and the rest are cancelled.
+type HappyDialer struct {
+	// Dialer does the individual attempts. Default
+	// is a [net.Dialer].
+	Dialer Dialer
+	// Resolver looks up the addresses of the host. Default
+	// is [net.DefaultResolver].
+	Resolver Resolver
+	// FallbackDelay is how long to wait for an attempt before
+	// starting the next one. Default is [DefaultFallbackDelay].
+	FallbackDelay time.Duration
+}
+
+// SetDefaults fills any gap in the [HappyDialer].
+func (d *HappyDialer) SetDefaults() {
+	if d.Dialer == nil {
+		d.Dialer = &net.Dialer{}
+	}
+	if d.Resolver == nil {
+		d.Resolver = net.DefaultResolver
+	}
+	if d.FallbackDelay <= 0 {
+		d.FallbackDelay = DefaultFallbackDelay
+	}
+}
+
+// DialContext implements the [Dialer] interface. If all attempts
+// fail, the error of the first is returned.
+func (d *HappyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	cfg := *d
+	cfg.SetDefaults()
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := netip.ParseAddr(host); err == nil {
+		// nothing to race
+		return cfg.Dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := cfg.Resolver.LookupNetIP(ctx, lookupNetwork(network), host)
+	switch {
+	case err != nil:
+		return nil, err
+	case len(addrs) == 0:
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	r := &eyeballsRacer{
+		d:       &cfg,
+		network: network,
+		addrs:   interleaveAddrs(addrs, port),
+	}
+	return r.Run(ctx)
+}
+
+func lookupNetwork(network string) string {
+	switch network {
+	case "tcp4", "udp4":
+		return "ip4"
+	case "tcp6", "udp6":
+		return "ip6"
+	default:
+		return "ip"
+	}
+}
+
+// interleaveAddrs alternates address families, starting with the
+// family of the first address, and appends the port.
+func interleaveAddrs(addrs []netip.Addr, port string) []string {
+	var first, second []netip.Addr
+
+	for _, addr := range addrs {
+		if addr.Unmap().Is4() == addrs[0].Unmap().Is4() {
+			first = append(first, addr)
+		} else {
+			second = append(second, addr)
+		}
+	}
+
+	out := make([]string, 0, len(addrs))
+	for i := range max(len(first), len(second)) {
+		out = appendHostPort(out, first, i, port)
+		out = appendHostPort(out, second, i, port)
+	}
+	return out
+}
+
+func appendHostPort(out []string, addrs []netip.Addr, i int, port string) []string {
+	if i < len(addrs) {
+		out = append(out, net.JoinHostPort(addrs[i].Unmap().String(), port))
+	}
+	return out
+}
+
+type eyeballsResult struct {
+	conn net.Conn
+	err  error
+}
+
+// eyeballsRacer runs the attempts of a [HappyDialer]
+type eyeballsRacer struct {
+	d       *HappyDialer
+	results chan eyeballsResult
+	timer   *time.Timer
+	err     error
+	network string
+	addrs   []string
+	next    int
+	pending int
+}
+
+func (r *eyeballsRacer) Run(ctx context.Context) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered so attempts never block
+	r.results = make(chan eyeballsResult, len(r.addrs))
+	r.timer = time.NewTimer(r.d.FallbackDelay)
+	defer r.timer.Stop()
+
+	r.start(ctx)
+	for r.pending > 0 {
+		select {
+		case <-r.timer.C:
+			r.start(ctx)
+		case res := <-r.results:
+			if conn, ok := r.done(ctx, res); ok {
+				return conn, nil
+			}
+		}
+	}
+
+	return nil, r.err
+}
+
+// start starts the next attempt, if any.
+func (r *eyeballsRacer) start(ctx context.Context) {
+	if r.next >= len(r.addrs) {
+		return
+	}
+
+	address := r.addrs[r.next]
+	r.next++
+	r.pending++
+
+	go func() {
+		conn, err := r.d.Dialer.DialContext(ctx, r.network, address)
+		r.results <- eyeballsResult{conn: conn, err: err}
+	}()
+
+	resetTimer(r.timer, r.d.FallbackDelay)
+}
+
+// done handles the result of an attempt, starting the next
+// immediately on failure.
+func (r *eyeballsRacer) done(ctx context.Context, res eyeballsResult) (net.Conn, bool) {
+	r.pending--
+
+	if res.err == nil {
+		go closeLosers(r.results, r.pending)
+		return res.conn, true
+	}
+
+	r.err = core.CoalesceError(r.err, res.err)
+	r.start(ctx)
+	return nil, false
+}
+
+// closeLosers closes connections established after
+// the race was won.
+func closeLosers(results <-chan eyeballsResult, pending int) {
+	for range pending {
+		if res := <-results; res.conn != nil {
+			_ = res.conn.Close()
+		}
+	}
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		// drain
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
@@ -0,0 +1,82 @@
+package net
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type resolverFunc func(context.Context, string, string) ([]netip.Addr, error)
+
+func (fn resolverFunc) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	return fn(ctx, network, host)
+}
+
+func TestInterleaveAddrs(t *testing.T) {
+	addrs := []netip.Addr{
+		netip.MustParseAddr("2001:db8::1"),
+		netip.MustParseAddr("2001:db8::2"),
+		netip.MustParseAddr("2001:db8::3"),
+		netip.MustParseAddr("192.0.2.1"),
+	}
+
+	expected := []string{
+		"[2001:db8::1]:80",
+		"192.0.2.1:80",
+		"[2001:db8::2]:80",
+		"[2001:db8::3]:80",
+	}
+	if s := interleaveAddrs(addrs, "80"); !reflect.DeepEqual(s, expected) {
+		t.Errorf("ERROR: %q (expected %q)", s, expected)
+	}
+}
+
+func TestHappyDialer(t *testing.T) {
+	resolver := resolverFunc(func(context.Context, string, string) ([]netip.Addr, error) {
+		return []netip.Addr{
+			netip.MustParseAddr("2001:db8::1"),
+			netip.MustParseAddr("2001:db8::2"),
+			netip.MustParseAddr("192.0.2.1"),
+		}, nil
+	})
+
+	cancelled := make(chan string, 3)
+	dialer := dialerFunc(func(ctx context.Context, _, address string) (net.Conn, error) {
+		switch address {
+		case "[2001:db8::1]:80":
+			// black hole
+			<-ctx.Done()
+			cancelled <- address
+			return nil, ctx.Err()
+		case "[2001:db8::2]:80":
+			// fails immediately
+			return nil, syscall.ECONNREFUSED
+		default:
+			c, _ := net.Pipe()
+			return c, nil
+		}
+	})
+
+	d := &HappyDialer{
+		Dialer:        dialer,
+		Resolver:      resolver,
+		FallbackDelay: 10 * time.Millisecond,
+	}
+
+	conn, err := d.DialContext(context.Background(), "tcp", "example.org:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+
+	select {
+	case s := <-cancelled:
+		t.Logf("%s cancelled", s)
+	case <-time.After(time.Second):
+		t.Error("ERROR: slow attempt not cancelled")
+	}
+}
@@ -7,6 +7,7 @@ require (
 	darvaza.org/slog v0.6.0
 	darvaza.org/slog/handlers/discard v0.5.0
 	darvaza.org/x/fs v0.4.0
+	darvaza.org/x/sync v0.1.0
 	github.com/amery/defaults v0.1.0
 )
 
@@ -19,3 +20,8 @@ require (
 	github.com/gobwas/glob v0.2.3 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
+
+replace (
+	darvaza.org/x/fs => ../fs
+	darvaza.org/x/sync => ../sync
+)
@@ -0,0 +1,255 @@
+package net
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	_ net.Conn     = (*LimitedConn)(nil)
+	_ net.Listener = (*LimitedListener)(nil)
+)
+
+// Limiter is a token bucket limiting the bytes transferred per
+// second. It's safe for concurrent use, so it can be shared by
+// many connections. A nil or zero Limiter doesn't limit.
+type Limiter struct {
+	mu     sync.Mutex
+	last   time.Time
+	tokens float64
+	rate   float64
+	burst  int
+}
+
+// NewLimiter creates a [Limiter] allowing rate bytes per second,
+// in bursts of up to burst bytes. If burst isn't positive, the
+// rate is used. A rate that isn't positive means no limit.
+func NewLimiter(rate, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = rate
+	}
+
+	return &Limiter{
+		last:   time.Now(),
+		tokens: float64(burst),
+		rate:   float64(rate),
+		burst:  burst,
+	}
+}
+
+// Burst returns the maximum burst, or zero if there is no limit.
+func (l *Limiter) Burst() int {
+	if l.unlimited() {
+		return 0
+	}
+	return l.burst
+}
+
+// unlimited tells if the Limiter lets everything through, as
+// the zero value does.
+func (l *Limiter) unlimited() bool {
+	return l == nil || l.burst <= 0 || l.rate <= 0
+}
+
+// WaitN blocks until n bytes can be transferred, or the context
+// is cancelled.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l.unlimited() {
+		return nil
+	}
+
+	for n > 0 {
+		chunk := min(n, l.burst)
+		if err := l.wait(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+func (l *Limiter) wait(ctx context.Context, n int) error {
+	d := l.reserve(n)
+	if d <= 0 {
+		return nil
+	}
+
+	if err := sleepContext(ctx, d); err != nil {
+		l.cancel(n)
+		return err
+	}
+	return nil
+}
+
+// reserve takes n tokens and returns how long to wait for them.
+func (l *Limiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+
+	l.tokens = min(l.tokens+elapsed*l.rate, float64(l.burst))
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns the tokens of an abandoned reservation.
+func (l *Limiter) cancel(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = min(l.tokens+float64(n), float64(l.burst))
+}
+
+// LimitedConn is a [net.Conn] limiting its bandwidth on each direction.
+type LimitedConn struct {
+	net.Conn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	read   []*Limiter
+	write  []*Limiter
+}
+
+// NewLimitedConn wraps a [net.Conn] to limit its bandwidth. Nil
+// limiters mean no limit on that direction.
+func NewLimitedConn(conn net.Conn, read, write *Limiter) *LimitedConn {
+	return newLimitedConn(conn, []*Limiter{read}, []*Limiter{write})
+}
+
+func newLimitedConn(conn net.Conn, read, write []*Limiter) *LimitedConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &LimitedConn{
+		Conn:   conn,
+		ctx:    ctx,
+		cancel: cancel,
+		read:   compactLimiters(read),
+		write:  compactLimiters(write),
+	}
+}
+
+func compactLimiters(limiters []*Limiter) []*Limiter {
+	var out []*Limiter
+	for _, l := range limiters {
+		if l != nil {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Read implements the [net.Conn] interface. Data is read first,
+// and then the limiters are paid.
+func (c *LimitedConn) Read(b []byte) (int, error) {
+	if size := chunkSize(c.read, len(b)); size < len(b) {
+		b = b[:size]
+	}
+
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		if err2 := c.pay(c.read, n); err2 != nil && err == nil {
+			err = err2
+		}
+	}
+	return n, err
+}
+
+// Write implements the [net.Conn] interface. Large writes
+// are split to fit in the bursts of the limiters.
+func (c *LimitedConn) Write(b []byte) (int, error) {
+	var total int
+
+	for len(b) > 0 {
+		size := chunkSize(c.write, len(b))
+		if err := c.pay(c.write, size); err != nil {
+			return total, err
+		}
+
+		n, err := c.Conn.Write(b[:size])
+		total += n
+		if err != nil {
+			return total, err
+		}
+		b = b[n:]
+	}
+	return total, nil
+}
+
+// Close closes the connection, interrupting any wait.
+func (c *LimitedConn) Close() error {
+	c.cancel()
+	return c.Conn.Close()
+}
+
+func (c *LimitedConn) pay(limiters []*Limiter, n int) error {
+	for _, l := range limiters {
+		if err := l.WaitN(c.ctx, n); err != nil {
+			return net.ErrClosed
+		}
+	}
+	return nil
+}
+
+// chunkSize returns the largest transfer allowed by all the
+// limiters at once.
+func chunkSize(limiters []*Limiter, n int) int {
+	for _, l := range limiters {
+		if burst := l.Burst(); burst > 0 {
+			n = min(n, burst)
+		}
+	}
+	return n
+}
+
+// LimitedListener is a [net.Listener] limiting the bandwidth of the
+// connections it accepts.
+type LimitedListener struct {
+	net.Listener
+
+	// Read and Write are shared by all connections, limiting
+	// the bandwidth of the listener as a whole.
+	Read  *Limiter
+	Write *Limiter
+
+	// PerConn, if set, returns limiters for each new connection,
+	// applied in addition to the shared.
+	PerConn func() (read, write *Limiter)
+}
+
+// NewLimitedListener wraps a [net.Listener] to limit the bandwidth
+// of its connections as a whole.
+func NewLimitedListener(l net.Listener, read, write *Limiter) *LimitedListener {
+	return &LimitedListener{
+		Listener: l,
+		Read:     read,
+		Write:    write,
+	}
+}
+
+// Accept waits for and returns the next connection wrapped in
+// a [LimitedConn].
+func (l *LimitedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	var read, write *Limiter
+	if l.PerConn != nil {
+		read, write = l.PerConn()
+	}
+
+	return newLimitedConn(conn,
+		[]*Limiter{l.Read, read},
+		[]*Limiter{l.Write, write}), nil
+}
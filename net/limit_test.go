@@ -0,0 +1,61 @@
+package net
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(10000, 1000)
+
+	start := time.Now()
+	if err := l.WaitN(context.Background(), 3000); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first burst is free
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("ERROR: waited only %v", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.WaitN(ctx, 1000); err == nil {
+		t.Error("ERROR: cancellation ignored")
+	}
+
+	if err := (*Limiter)(nil).WaitN(ctx, 1<<20); err != nil {
+		t.Errorf("ERROR: nil limiter: %v", err)
+	}
+	if err := new(Limiter).WaitN(ctx, 1<<20); err != nil {
+		t.Errorf("ERROR: zero limiter: %v", err)
+	}
+	if n := chunkSize([]*Limiter{new(Limiter), l}, 1<<20); n != 1000 {
+		t.Errorf("ERROR: chunkSize with zero limiter → %v (expected 1000)", n)
+	}
+}
+
+func TestLimitedConn(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewLimitedConn(client, nil, NewLimiter(10000, 1000))
+
+	go func() {
+		_, _ = c.Write(make([]byte, 3000))
+		_ = c.Close()
+	}()
+
+	start := time.Now()
+	b, err := io.ReadAll(server)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(b) != 3000 {
+		t.Errorf("ERROR: %v bytes (expected 3000)", len(b))
+	}
+
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("ERROR: took only %v", d)
+	}
+}
@@ -0,0 +1,120 @@
+package net
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"darvaza.org/x/sync/semaphore"
+)
+
+var (
+	_ net.Listener = (*MaxConnsListener)(nil)
+	_ net.Conn     = (*maxConnsConn)(nil)
+)
+
+// MaxConnsListener is a [net.Listener] limiting how many of the
+// connections it accepted can be open at once.
+type MaxConnsListener struct {
+	net.Listener
+
+	slots  *semaphore.Semaphore
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// OnReject is called, if set, before closing a connection
+	// rejected for exceeding the limit.
+	OnReject func(net.Conn)
+	// Reject tells the listener to accept and close connections
+	// beyond the limit, instead of waiting for a slot before
+	// accepting.
+	Reject bool
+}
+
+// NewMaxConnsListener wraps a [net.Listener] to allow up to n
+// open connections. If n isn't positive, there is no limit.
+func NewMaxConnsListener(l net.Listener, n int) *MaxConnsListener {
+	ml := &MaxConnsListener{Listener: l}
+	ml.ctx, ml.cancel = context.WithCancel(context.Background())
+	if n > 0 {
+		ml.slots, _ = semaphore.New(n)
+	}
+	return ml
+}
+
+// Accept waits for and returns the next connection, once the
+// number of open connections is below the limit.
+func (l *MaxConnsListener) Accept() (net.Conn, error) {
+	for {
+		if !l.Reject && !l.acquire() {
+			return nil, net.ErrClosed
+		}
+
+		conn, err := l.Listener.Accept()
+		switch {
+		case err != nil:
+			if !l.Reject {
+				l.release()
+			}
+			return nil, err
+		case l.Reject && !l.tryAcquire():
+			l.reject(conn)
+		default:
+			return l.wrap(conn), nil
+		}
+	}
+}
+
+// Len returns the number of connections open.
+func (l *MaxConnsListener) Len() int {
+	return l.slots.Len()
+}
+
+// Close closes the listener, interrupting any wait for a slot.
+// Connections already accepted remain open.
+func (l *MaxConnsListener) Close() error {
+	l.cancel()
+	return l.Listener.Close()
+}
+
+func (l *MaxConnsListener) acquire() bool {
+	return l.slots == nil || l.slots.Acquire(l.ctx) == nil
+}
+
+func (l *MaxConnsListener) tryAcquire() bool {
+	return l.slots == nil || l.slots.TryAcquire()
+}
+
+func (l *MaxConnsListener) release() {
+	if l.slots != nil {
+		l.slots.Release()
+	}
+}
+
+func (l *MaxConnsListener) reject(conn net.Conn) {
+	if l.OnReject != nil {
+		l.OnReject(conn)
+	}
+	_ = conn.Close()
+}
+
+func (l *MaxConnsListener) wrap(conn net.Conn) net.Conn {
+	if l.slots == nil {
+		return conn
+	}
+	return &maxConnsConn{Conn: conn, release: l.release}
+}
+
+// maxConnsConn frees its slot when closed
+type maxConnsConn struct {
+	net.Conn
+
+	release func()
+	once    sync.Once
+}
+
+func (c *maxConnsConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
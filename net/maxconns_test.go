@@ -0,0 +1,70 @@
+package net
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMaxConnsListener(t *testing.T) {
+	for _, reject := range []bool{false, true} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l := NewMaxConnsListener(ln, 1)
+		l.Reject = reject
+
+		c1 := dialTest(t, ln.Addr().String())
+		s1, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c2 := dialTest(t, ln.Addr().String())
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			if s, err := l.Accept(); err == nil {
+				accepted <- s
+			}
+		}()
+
+		select {
+		case <-accepted:
+			t.Errorf("ERROR: reject=%v: limit exceeded", reject)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		if n := l.Len(); n != 1 {
+			t.Errorf("ERROR: reject=%v: %v open (expected 1)", reject, n)
+		}
+
+		_ = s1.Close()
+		if reject {
+			// c2 was dropped, a new one gets the slot
+			c2 = dialTest(t, ln.Addr().String())
+		}
+
+		select {
+		case s := <-accepted:
+			_ = s.Close()
+		case <-time.After(time.Second):
+			t.Errorf("ERROR: reject=%v: slot not freed", reject)
+		}
+
+		_ = c1.Close()
+		_ = c2.Close()
+		_ = l.Close()
+	}
+}
+
+func dialTest(t *testing.T, addr string) net.Conn {
+	t.Helper()
+
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
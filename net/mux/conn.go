@@ -0,0 +1,53 @@
+package mux
+
+import (
+	"io"
+	"net"
+)
+
+var _ net.Conn = (*sniffConn)(nil)
+
+// sniffConn is a connection replaying the data read
+// by the matchers.
+type sniffConn struct {
+	net.Conn
+	buf []byte
+}
+
+// sniffer returns a reader starting from the beginning of
+// the connection.
+func (c *sniffConn) sniffer() io.Reader {
+	return &sniffReader{c: c}
+}
+
+func (c *sniffConn) Read(p []byte) (int, error) {
+	if len(c.buf) > 0 {
+		n := copy(p, c.buf)
+		c.buf = c.buf[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
+
+// sniffReader reads data from the buffer of the connection,
+// extending it as needed.
+type sniffReader struct {
+	c   *sniffConn
+	pos int
+}
+
+func (r *sniffReader) Read(p []byte) (int, error) {
+	if r.pos == len(r.c.buf) {
+		var tmp [512]byte
+
+		n, err := r.c.Conn.Read(tmp[:])
+		r.c.buf = append(r.c.buf, tmp[:n]...)
+		if n == 0 {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.c.buf[r.pos:])
+	r.pos += n
+	return n, nil
+}
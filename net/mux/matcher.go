@@ -0,0 +1,95 @@
+package mux
+
+import (
+	"io"
+	"strings"
+)
+
+// Matcher tells if a connection belongs to a route by reading
+// its first bytes. Each [Matcher] reads from the beginning of
+// the connection.
+type Matcher func(io.Reader) bool
+
+// Any matches all connections.
+func Any() Matcher {
+	return func(io.Reader) bool { return true }
+}
+
+// Prefix matches connections starting with any of the given
+// prefixes. Data is read only as long as any prefix could
+// still match.
+func Prefix(prefixes ...string) Matcher {
+	return func(r io.Reader) bool {
+		return matchPrefix(r, prefixes)
+	}
+}
+
+func matchPrefix(r io.Reader, prefixes []string) bool {
+	var buf []byte
+	var b [1]byte
+
+	for {
+		matched, candidates := checkPrefixes(prefixes, buf)
+		switch {
+		case matched:
+			return true
+		case !candidates:
+			return false
+		}
+
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return false
+		}
+		buf = append(buf, b[0])
+	}
+}
+
+// checkPrefixes tells if any prefix has been matched, or
+// if any could still match with more data.
+func checkPrefixes(prefixes []string, buf []byte) (matched, candidates bool) {
+	for _, p := range prefixes {
+		if strings.HasPrefix(p, string(buf)) {
+			if len(p) == len(buf) {
+				return true, true
+			}
+			candidates = true
+		}
+	}
+	return false, candidates
+}
+
+// TLS matches TLS connections, by the handshake record header
+// of the ClientHello.
+func TLS() Matcher {
+	return func(r io.Reader) bool {
+		var hdr [3]byte
+
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return false
+		}
+		// handshake, version 3.x
+		return hdr[0] == 0x16 && hdr[1] == 0x03 && hdr[2] <= 0x04
+	}
+}
+
+// HTTP1 matches HTTP/1.x requests by their method.
+func HTTP1() Matcher {
+	return Prefix(
+		"GET ", "HEAD ", "POST ", "PUT ", "DELETE ",
+		"CONNECT ", "OPTIONS ", "TRACE ", "PATCH ",
+	)
+}
+
+// HTTP2Preface is the connection preface of HTTP/2 without TLS.
+const HTTP2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// HTTP2 matches HTTP/2 connections without TLS ("h2c")
+// using prior knowledge.
+func HTTP2() Matcher {
+	return Prefix(HTTP2Preface)
+}
+
+// SSH matches SSH connections.
+func SSH() Matcher {
+	return Prefix("SSH-")
+}
@@ -0,0 +1,182 @@
+// Package mux multiplexes the connections of a listener between
+// protocols, by sniffing their first bytes.
+package mux
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// DefaultReadTimeout is the default time given to a new
+// connection to send enough data to be matched.
+const DefaultReadTimeout = 5 * time.Second
+
+// ErrNoMatch indicates a connection wasn't matched by any route.
+var ErrNoMatch = errors.New("no matching route")
+
+// Mux routes the connections of a [net.Listener] between virtual
+// listeners, by the first [Matcher] that accepts their initial bytes.
+type Mux struct {
+	l      net.Listener
+	routes []*route
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+
+	// ReadTimeout limits how long matching a connection can take.
+	// Default is [DefaultReadTimeout].
+	ReadTimeout time.Duration
+	// OnError is called, if set, when a connection isn't routed.
+	OnError func(net.Conn, error)
+}
+
+// New creates a [Mux] on the given [net.Listener].
+func New(l net.Listener) *Mux {
+	return &Mux{
+		l:    l,
+		done: make(chan struct{}),
+	}
+}
+
+// Match returns a [net.Listener] receiving the connections
+// matched by any of the given matchers. Routes are attempted
+// in the order they were added.
+func (m *Mux) Match(matchers ...Matcher) net.Listener {
+	r := &route{
+		m:        m,
+		matchers: core.SliceCopy(matchers),
+		conns:    make(chan net.Conn),
+		closed:   make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.routes = append(m.routes, r)
+	m.mu.Unlock()
+	return r
+}
+
+// Serve accepts connections and routes them until the
+// listener fails or the [Mux] is closed.
+func (m *Mux) Serve() error {
+	defer m.shutdown()
+
+	for {
+		conn, err := m.l.Accept()
+		if err != nil {
+			return m.filterError(err)
+		}
+
+		go m.serveConn(conn)
+	}
+}
+
+func (m *Mux) filterError(err error) error {
+	select {
+	case <-m.done:
+		return nil
+	default:
+		return err
+	}
+}
+
+// Close closes the listener and all virtual listeners.
+func (m *Mux) Close() error {
+	m.shutdown()
+	return m.l.Close()
+}
+
+func (m *Mux) shutdown() {
+	m.once.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *Mux) serveConn(conn net.Conn) {
+	c := &sniffConn{Conn: conn}
+
+	timeout := core.Coalesce(m.ReadTimeout, DefaultReadTimeout)
+	_ = c.SetReadDeadline(time.Now().Add(timeout))
+	r := m.match(c)
+	_ = c.SetReadDeadline(time.Time{})
+
+	if r == nil {
+		m.reject(conn, ErrNoMatch)
+	} else if err := r.deliver(c); err != nil {
+		m.reject(conn, err)
+	}
+}
+
+func (m *Mux) match(c *sniffConn) *route {
+	m.mu.Lock()
+	routes := m.routes
+	m.mu.Unlock()
+
+	for _, r := range routes {
+		for _, match := range r.matchers {
+			if match(c.sniffer()) {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Mux) reject(conn net.Conn, err error) {
+	if m.OnError != nil {
+		m.OnError(conn, err)
+	}
+	_ = conn.Close()
+}
+
+var _ net.Listener = (*route)(nil)
+
+// route is a virtual listener
+type route struct {
+	m        *Mux
+	conns    chan net.Conn
+	closed   chan struct{}
+	matchers []Matcher
+	once     sync.Once
+}
+
+// deliver waits for the connection to be accepted.
+func (r *route) deliver(conn net.Conn) error {
+	select {
+	case r.conns <- conn:
+		return nil
+	case <-r.closed:
+		return net.ErrClosed
+	case <-r.m.done:
+		return net.ErrClosed
+	}
+}
+
+// Accept implements the [net.Listener] interface.
+func (r *route) Accept() (net.Conn, error) {
+	select {
+	case conn := <-r.conns:
+		return conn, nil
+	case <-r.closed:
+		return nil, net.ErrClosed
+	case <-r.m.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements the [net.Listener] interface. Connections
+// matched afterwards are closed.
+func (r *route) Close() error {
+	r.once.Do(func() {
+		close(r.closed)
+	})
+	return nil
+}
+
+// Addr implements the [net.Listener] interface.
+func (r *route) Addr() net.Addr {
+	return r.m.l.Addr()
+}
@@ -0,0 +1,58 @@
+package mux
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func TestMux(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(ln)
+	routes := map[string]net.Listener{
+		"tls":  m.Match(TLS()),
+		"ssh":  m.Match(SSH()),
+		"http": m.Match(HTTP2(), HTTP1()),
+	}
+	defer func() { _ = m.Close() }()
+
+	go func() { _ = m.Serve() }()
+
+	tests := []struct {
+		route string
+		data  string
+	}{
+		{"ssh", "SSH-2.0-test\r\n"},
+		{"http", "GET / HTTP/1.1\r\nHost: x\r\n\r\n"},
+		{"http", HTTP2Preface},
+		{"tls", "\x16\x03\x01\x00\x05hello"},
+	}
+
+	for i, tc := range tests {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := io.WriteString(c, tc.data); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := routes[tc.route].Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]byte, len(tc.data))
+		if _, err := io.ReadFull(s, buf); err != nil || string(buf) != tc.data {
+			t.Errorf("[%v/%v] ERROR: %q, %v (expected %q)", i, len(tests), buf, err, tc.data)
+		}
+
+		_ = s.Close()
+		_ = c.Close()
+	}
+}
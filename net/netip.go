@@ -0,0 +1,158 @@
+package net
+
+import (
+	"net"
+	"net/netip"
+	"strconv"
+
+	"darvaza.org/core"
+)
+
+// AddrClass is the scope of an IP address.
+type AddrClass int
+
+const (
+	// InvalidAddr is the class of the zero [netip.Addr].
+	InvalidAddr AddrClass = iota
+	// UnspecifiedAddr is the class of 0.0.0.0 and ::.
+	UnspecifiedAddr
+	// LoopbackAddr is the class of addresses of the local host.
+	LoopbackAddr
+	// LinkLocalAddr is the class of link-local unicast addresses.
+	LinkLocalAddr
+	// PrivateAddr is the class of private networks, including
+	// RFC 1918, RFC 4193 and the shared space of RFC 6598.
+	PrivateAddr
+	// MulticastAddr is the class of multicast addresses.
+	MulticastAddr
+	// GlobalAddr is the class of the remaining unicast addresses.
+	GlobalAddr
+)
+
+var addrClassNames = map[AddrClass]string{
+	InvalidAddr:     "invalid",
+	UnspecifiedAddr: "unspecified",
+	LoopbackAddr:    "loopback",
+	LinkLocalAddr:   "link-local",
+	PrivateAddr:     "private",
+	MulticastAddr:   "multicast",
+	GlobalAddr:      "global",
+}
+
+func (c AddrClass) String() string {
+	if s, ok := addrClassNames[c]; ok {
+		return s
+	}
+	return "AddrClass(" + strconv.Itoa(int(c)) + ")"
+}
+
+// sharedAddrSpace is the carrier-grade NAT range of RFC 6598
+var sharedAddrSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// ClassifyAddr returns the [AddrClass] of an address.
+// IPv4-mapped IPv6 addresses are classified as IPv4.
+func ClassifyAddr(addr netip.Addr) AddrClass {
+	addr = addr.Unmap()
+
+	switch {
+	case !addr.IsValid():
+		return InvalidAddr
+	case addr.IsUnspecified():
+		return UnspecifiedAddr
+	case addr.IsLoopback():
+		return LoopbackAddr
+	case addr.IsLinkLocalUnicast():
+		return LinkLocalAddr
+	case addr.IsMulticast():
+		return MulticastAddr
+	case addr.IsPrivate(), sharedAddrSpace.Contains(addr):
+		return PrivateAddr
+	default:
+		return GlobalAddr
+	}
+}
+
+// IsPublicAddr tells if an address is globally routable.
+func IsPublicAddr(addr netip.Addr) bool {
+	return ClassifyAddr(addr) == GlobalAddr
+}
+
+// NormalizeAddr unmaps IPv4-mapped IPv6 addresses, and drops the zone
+// of addresses that aren't link-local.
+func NormalizeAddr(addr netip.Addr) netip.Addr {
+	addr = addr.Unmap()
+	if addr.Zone() != "" && !addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() {
+		addr = addr.WithZone("")
+	}
+	return addr
+}
+
+// ParseAddr parses an IP address, optionally in brackets and
+// with zone, and normalises it.
+func ParseAddr(s string) (netip.Addr, error) {
+	addr, err := core.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return NormalizeAddr(addr), nil
+}
+
+// ParseAddrPort parses an IP address with optional port. IPv6
+// addresses with port need brackets. When the port is missing,
+// the given default is used.
+func ParseAddrPort(s string, defaultPort uint16) (netip.AddrPort, error) {
+	host, port, err := SplitHostPort(s)
+	if err != nil {
+		// maybe an IPv6 address without brackets
+		if addr, err2 := ParseAddr(s); err2 == nil {
+			return netip.AddrPortFrom(addr, defaultPort), nil
+		}
+		return netip.AddrPort{}, err
+	}
+
+	addr, err := ParseAddr(host)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+
+	if port == 0 {
+		port = defaultPort
+	}
+	return netip.AddrPortFrom(addr, port), nil
+}
+
+// JoinAddrPort combines an address and a port, using brackets
+// for IPv6.
+func JoinAddrPort(addr netip.Addr, port uint16) string {
+	return netip.AddrPortFrom(NormalizeAddr(addr), port).String()
+}
+
+// AddrPortFromNet extracts the normalised [netip.AddrPort] of a
+// TCP, UDP or IP [net.Addr].
+func AddrPortFromNet(a net.Addr) (netip.AddrPort, bool) {
+	var ap netip.AddrPort
+
+	switch v := a.(type) {
+	case *net.TCPAddr:
+		ap = v.AddrPort()
+	case *net.UDPAddr:
+		ap = v.AddrPort()
+	case *net.IPAddr:
+		addr, _ := netip.AddrFromSlice(v.IP)
+		ap = netip.AddrPortFrom(addr.WithZone(v.Zone), 0)
+	default:
+		return ap, false
+	}
+
+	if !ap.Addr().IsValid() {
+		return netip.AddrPort{}, false
+	}
+	return netip.AddrPortFrom(NormalizeAddr(ap.Addr()), ap.Port()), true
+}
+
+// AddrFromNet extracts the normalised [netip.Addr] of a TCP, UDP
+// or IP [net.Addr].
+func AddrFromNet(a net.Addr) (netip.Addr, bool) {
+	ap, ok := AddrPortFromNet(a)
+	return ap.Addr(), ok
+}
@@ -0,0 +1,83 @@
+package net
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestClassifyAddr(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected AddrClass
+	}{
+		{"0.0.0.0", UnspecifiedAddr},
+		{"::", UnspecifiedAddr},
+		{"127.0.0.1", LoopbackAddr},
+		{"::ffff:127.0.0.1", LoopbackAddr},
+		{"169.254.1.1", LinkLocalAddr},
+		{"fe80::1%eth0", LinkLocalAddr},
+		{"10.1.2.3", PrivateAddr},
+		{"100.64.0.1", PrivateAddr},
+		{"fd00::1", PrivateAddr},
+		{"224.0.0.1", MulticastAddr},
+		{"8.8.8.8", GlobalAddr},
+		{"2001:4860::8888", GlobalAddr},
+	}
+
+	for i, tc := range tests {
+		got := ClassifyAddr(netip.MustParseAddr(tc.addr))
+		if got != tc.expected {
+			t.Errorf("[%v/%v] ERROR: %s: %v (expected %v)", i, len(tests), tc.addr, got, tc.expected)
+		}
+	}
+
+	if s := ClassifyAddr(netip.Addr{}).String(); s != "invalid" {
+		t.Errorf("ERROR: %q", s)
+	}
+}
+
+func TestParseAddrPort(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"192.0.2.1", "192.0.2.1:53", true},
+		{"192.0.2.1:80", "192.0.2.1:80", true},
+		{"::1", "[::1]:53", true},
+		{"[::1]", "[::1]:53", true},
+		{"[::1]:80", "[::1]:80", true},
+		{"[::ffff:192.0.2.1]:80", "192.0.2.1:80", true},
+		{"[fe80::1%eth0]:80", "[fe80::1%eth0]:80", true},
+		{"example.org:80", "", false},
+		{"192.0.2.1:99999", "", false},
+	}
+
+	for i, tc := range tests {
+		ap, err := ParseAddrPort(tc.input, 53)
+		switch {
+		case tc.ok && err != nil:
+			t.Errorf("[%v/%v] ERROR: %q: %v", i, len(tests), tc.input, err)
+		case !tc.ok && err == nil:
+			t.Errorf("[%v/%v] ERROR: %q: %v (expected failure)", i, len(tests), tc.input, ap)
+		case tc.ok && ap.String() != tc.expected:
+			t.Errorf("[%v/%v] ERROR: %q: %v (expected %v)", i, len(tests), tc.input, ap, tc.expected)
+		}
+	}
+}
+
+func TestAddrPortFromNet(t *testing.T) {
+	a := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 80}
+	if ap, ok := AddrPortFromNet(a); !ok || ap.String() != "192.0.2.1:80" {
+		t.Errorf("ERROR: %v, %v", ap, ok)
+	}
+
+	if _, ok := AddrFromNet(&net.UnixAddr{Name: "/tmp/x", Net: "unix"}); ok {
+		t.Error("ERROR: unix address accepted")
+	}
+
+	if s := JoinAddrPort(netip.MustParseAddr("2001:db8::1"), 443); s != "[2001:db8::1]:443" {
+		t.Errorf("ERROR: %q", s)
+	}
+}
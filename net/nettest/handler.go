@@ -0,0 +1,75 @@
+package nettest
+
+import (
+	"bytes"
+	"io"
+	"net"
+)
+
+// Step is an exchange of a [Script].
+type Step struct {
+	// Expect is the data the client has to send. If empty,
+	// the Reply is sent without reading.
+	Expect []byte
+	// Reply is the data sent back after the expected.
+	Reply []byte
+}
+
+// Echo is a [Handler] sending back all it receives.
+func Echo(conn net.Conn) {
+	_, _ = io.Copy(conn, conn)
+}
+
+// Discard is a [Handler] reading and dropping all it receives.
+func Discard(conn net.Conn) {
+	_, _ = io.Copy(io.Discard, conn)
+}
+
+// Script returns a [Handler] following the given steps in order.
+// The connection is closed when the client deviates from the
+// script or after the last step.
+func Script(steps ...Step) Handler {
+	return func(conn net.Conn) {
+		for _, step := range steps {
+			if !runStep(conn, step) {
+				return
+			}
+		}
+	}
+}
+
+func runStep(conn net.Conn, step Step) bool {
+	if n := len(step.Expect); n > 0 {
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return false
+		} else if !bytes.Equal(buf, step.Expect) {
+			return false
+		}
+	}
+
+	if len(step.Reply) > 0 {
+		if _, err := conn.Write(step.Reply); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// EchoPacket is a [PacketHandler] sending back each datagram.
+func EchoPacket(req []byte, _ net.Addr) []byte {
+	return bytes.Clone(req)
+}
+
+// PacketScript returns a [PacketHandler] replying to datagrams
+// matching the Expect of a [Step]. Others are ignored.
+func PacketScript(steps ...Step) PacketHandler {
+	return func(req []byte, _ net.Addr) []byte {
+		for _, step := range steps {
+			if bytes.Equal(req, step.Expect) {
+				return step.Reply
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,103 @@
+package nettest
+
+import (
+	"net"
+	"sync"
+	"testing"
+)
+
+// MaxPacketSize is the size of the buffer used to receive
+// datagrams.
+const MaxPacketSize = 65535
+
+// PacketHandler returns the reply to a datagram received by a
+// [PacketServer], or nil to send none.
+type PacketHandler func(req []byte, addr net.Addr) []byte
+
+// PacketServer is a datagram server.
+type PacketServer struct {
+	// Conn is the connection receiving the datagrams.
+	Conn net.PacketConn
+
+	handler PacketHandler
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// NewPacketServer starts a [PacketServer] listening on the given
+// address. An empty address represents an ephemeral one.
+func NewPacketServer(network, address string, h PacketHandler) (*PacketServer, error) {
+	if address == "" {
+		address = ephemeral(network)
+	}
+
+	pc, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PacketServer{
+		Conn:    pc,
+		handler: h,
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// NewUDPServer starts a [PacketServer] on an ephemeral loopback
+// UDP port, closed when the test finishes.
+func NewUDPServer(t testing.TB, h PacketHandler) *PacketServer {
+	t.Helper()
+
+	s, err := NewPacketServer("udp", "", h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Network returns the network of the connection.
+func (s *PacketServer) Network() string {
+	return s.Conn.LocalAddr().Network()
+}
+
+// Addr returns the local address of the connection.
+func (s *PacketServer) Addr() string {
+	return s.Conn.LocalAddr().String()
+}
+
+// Dial connects to the [PacketServer].
+func (s *PacketServer) Dial() (net.Conn, error) {
+	return net.Dial(s.Network(), s.Addr())
+}
+
+// Close closes the connection and waits for the handler to finish.
+func (s *PacketServer) Close() {
+	s.once.Do(func() {
+		_ = s.Conn.Close()
+		s.wg.Wait()
+	})
+}
+
+func (s *PacketServer) serve() {
+	defer s.wg.Done()
+
+	buf := make([]byte, MaxPacketSize)
+	for {
+		n, addr, err := s.Conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if s.handler == nil {
+			continue
+		}
+
+		if reply := s.handler(buf[:n], addr); reply != nil {
+			_, _ = s.Conn.WriteTo(reply, addr)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package nettest provides in-process servers listening on
+// ephemeral addresses, for testing clients.
+package nettest
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Handler serves a connection accepted by a [Server]. The connection
+// is closed when the Handler returns.
+type Handler func(net.Conn)
+
+// Server is a stream server handling each connection on its
+// own goroutine.
+type Server struct {
+	// Listener is the listener accepting the connections.
+	Listener net.Listener
+
+	handler Handler
+	conns   map[net.Conn]struct{}
+	cleanup func()
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	once    sync.Once
+	closed  bool
+}
+
+// NewServer starts a [Server] listening on the given address. An
+// empty address represents an ephemeral one.
+func NewServer(network, address string, h Handler) (*Server, error) {
+	if address == "" {
+		address = ephemeral(network)
+	}
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Listener: ln,
+		handler:  h,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// NewTCPServer starts a [Server] on an ephemeral loopback TCP port,
+// closed when the test finishes.
+func NewTCPServer(t testing.TB, h Handler) *Server {
+	t.Helper()
+
+	s, err := NewServer("tcp", "", h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewUnixServer starts a [Server] on a Unix socket on a temporary
+// directory, both removed when the test finishes.
+func NewUnixServer(t testing.TB, h Handler) *Server {
+	t.Helper()
+
+	// t.TempDir() may exceed the limits of socket paths
+	dir, err := os.MkdirTemp("", "nettest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer("unix", filepath.Join(dir, "sock"), h)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	s.cleanup = func() { _ = os.RemoveAll(dir) }
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Network returns the network of the listener.
+func (s *Server) Network() string {
+	return s.Listener.Addr().Network()
+}
+
+// Addr returns the address of the listener.
+func (s *Server) Addr() string {
+	return s.Listener.Addr().String()
+}
+
+// Dial connects to the [Server].
+func (s *Server) Dial() (net.Conn, error) {
+	return net.Dial(s.Network(), s.Addr())
+}
+
+// Close stops the listener, closes all connections and waits
+// for their handlers to finish.
+func (s *Server) Close() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		_ = s.Listener.Close()
+		for c := range s.conns {
+			_ = c.Close()
+		}
+		s.mu.Unlock()
+
+		s.wg.Wait()
+		if s.cleanup != nil {
+			s.cleanup()
+		}
+	})
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return
+		}
+
+		if s.track(conn) {
+			go s.serveConn(conn)
+		}
+	}
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		_ = conn.Close()
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer s.forget(conn)
+
+	if s.handler != nil {
+		s.handler(conn)
+	}
+}
+
+func (s *Server) forget(conn net.Conn) {
+	_ = conn.Close()
+
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+}
+
+func ephemeral(network string) string {
+	switch network {
+	case "tcp6", "udp6":
+		return "[::1]:0"
+	default:
+		return "127.0.0.1:0"
+	}
+}
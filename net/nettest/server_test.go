@@ -0,0 +1,88 @@
+package nettest
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func TestStreamServers(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(testing.TB, Handler) *Server
+	}{
+		{"tcp", NewTCPServer},
+		{"unix", NewUnixServer},
+	}
+
+	for i, tc := range tests {
+		s := tc.new(t, Echo)
+
+		c, err := s.Dial()
+		if err != nil {
+			t.Fatalf("[%v/%v] ERROR: %s: %v", i, len(tests), tc.name, err)
+		}
+
+		got := roundTrip(t, c, "hello")
+		if got != "hello" {
+			t.Errorf("[%v/%v] ERROR: %s: %q (expected %q)", i, len(tests), tc.name, got, "hello")
+		}
+		_ = c.Close()
+		s.Close()
+	}
+}
+
+func TestScript(t *testing.T) {
+	s := NewTCPServer(t, Script(
+		Step{Reply: []byte("220 ready\r\n")},
+		Step{Expect: []byte("QUIT\r\n"), Reply: []byte("221 bye\r\n")},
+	))
+
+	c, err := s.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if _, err := io.WriteString(c, "QUIT\r\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatal(err)
+	} else if s := string(b); s != "220 ready\r\n221 bye\r\n" {
+		t.Errorf("ERROR: %q", s)
+	}
+}
+
+func TestUDPServer(t *testing.T) {
+	s := NewUDPServer(t, PacketScript(
+		Step{Expect: []byte("ping"), Reply: []byte("pong")},
+	))
+
+	c, err := s.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if got := roundTrip(t, c, "ping"); got != "pong" {
+		t.Errorf("ERROR: %q (expected %q)", got, "pong")
+	}
+}
+
+func roundTrip(t *testing.T, c net.Conn, data string) string {
+	t.Helper()
+
+	if _, err := io.WriteString(c, data); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 64)
+	n, err := c.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
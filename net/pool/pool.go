@@ -0,0 +1,278 @@
+// Package pool provides a generic pool of connections.
+package pool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// DefaultMaxIdle is the default number of idle connections kept.
+const DefaultMaxIdle = 2
+
+var (
+	// ErrClosed indicates the [Pool] was closed.
+	ErrClosed = net.ErrClosed
+	// ErrNoDial indicates the [Config] has no Dial function.
+	ErrNoDial = errors.New("dial function not provided")
+)
+
+// Config describes a [Pool].
+type Config[T io.Closer] struct {
+	// Dial opens a new connection.
+	Dial func(context.Context) (T, error)
+	// Check, if set, tells if an idle connection is still good
+	// before reusing it.
+	Check func(T) error
+
+	// MaxIdle is the maximum number of idle connections kept.
+	// Default is [DefaultMaxIdle], and negative means none.
+	MaxIdle int
+	// MaxActive is the maximum number of open connections,
+	// idle or not. Zero means no limit.
+	MaxActive int
+	// MaxLifetime is the maximum time a connection is reused
+	// since dialled. Zero means forever.
+	MaxLifetime time.Duration
+	// IdleTimeout is the maximum time a connection is kept
+	// idle. Zero means forever.
+	IdleTimeout time.Duration
+}
+
+// SetDefaults fills any gap in the [Config].
+func (cfg *Config[T]) SetDefaults() {
+	if cfg.MaxIdle == 0 {
+		cfg.MaxIdle = DefaultMaxIdle
+	}
+}
+
+// Validate tells if the [Config] is usable.
+func (cfg *Config[T]) Validate() error {
+	if cfg.Dial == nil {
+		return ErrNoDial
+	}
+	return nil
+}
+
+// Stats describes the state of a [Pool].
+type Stats struct {
+	Open int
+	Idle int
+}
+
+// Pool keeps connections for reuse.
+// The zero value isn't usable, use [New].
+type Pool[T io.Closer] struct {
+	cfg     Config[T]
+	idle    []*entry[T]
+	changed chan struct{}
+	mu      sync.Mutex
+	open    int
+	closed  bool
+}
+
+// New creates a [Pool] using the given [Config].
+func New[T io.Closer](cfg Config[T]) (*Pool[T], error) {
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Pool[T]{
+		cfg:     cfg,
+		changed: make(chan struct{}),
+	}, nil
+}
+
+// Get returns an idle connection or dials a new one, waiting
+// for the context if the limit of open connections was reached.
+func (p *Pool[T]) Get(ctx context.Context) (*Lease[T], error) {
+	if p == nil {
+		return nil, core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		e, wait, err := p.tryGet()
+		switch {
+		case err != nil:
+			return nil, err
+		case e != nil:
+			if p.healthy(e) {
+				return p.lease(e), nil
+			}
+			p.destroy(e)
+		case wait != nil:
+			select {
+			case <-wait:
+			case <-ctx.Done():
+				return nil, context.Cause(ctx)
+			}
+		default:
+			return p.dial(ctx)
+		}
+	}
+}
+
+// tryGet returns an idle connection, or a channel to wait on if
+// nothing can be dialled. If both are nil, a slot for dialling
+// was taken.
+func (p *Pool[T]) tryGet() (*entry[T], <-chan struct{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.closed:
+		return nil, nil, ErrClosed
+	case len(p.idle) > 0:
+		n := len(p.idle) - 1
+		e := p.idle[n]
+		p.idle[n] = nil
+		p.idle = p.idle[:n]
+		return e, nil, nil
+	case p.cfg.MaxActive > 0 && p.open >= p.cfg.MaxActive:
+		return nil, p.changed, nil
+	default:
+		p.open++
+		return nil, nil, nil
+	}
+}
+
+func (p *Pool[T]) dial(ctx context.Context) (*Lease[T], error) {
+	v, err := p.cfg.Dial(ctx)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+
+	return p.lease(&entry[T]{value: v, created: time.Now()}), nil
+}
+
+func (p *Pool[T]) lease(e *entry[T]) *Lease[T] {
+	return &Lease[T]{Value: e.value, pool: p, e: e}
+}
+
+func (p *Pool[T]) healthy(e *entry[T]) bool {
+	now := time.Now()
+
+	switch {
+	case p.expired(e, now):
+		return false
+	case p.cfg.IdleTimeout > 0 && now.Sub(e.idleSince) > p.cfg.IdleTimeout:
+		return false
+	case p.cfg.Check != nil:
+		return p.cfg.Check(e.value) == nil
+	default:
+		return true
+	}
+}
+
+func (p *Pool[T]) expired(e *entry[T], now time.Time) bool {
+	return p.cfg.MaxLifetime > 0 && now.Sub(e.created) > p.cfg.MaxLifetime
+}
+
+// put returns a connection to the idle list, or closes it.
+func (p *Pool[T]) put(e *entry[T]) {
+	now := time.Now()
+
+	p.mu.Lock()
+	keep := !p.closed && len(p.idle) < p.cfg.MaxIdle && !p.expired(e, now)
+	if keep {
+		e.idleSince = now
+		p.idle = append(p.idle, e)
+		p.unsafeNotify()
+	}
+	p.mu.Unlock()
+
+	if !keep {
+		p.destroy(e)
+	}
+}
+
+// destroy closes a connection and frees its slot.
+func (p *Pool[T]) destroy(e *entry[T]) {
+	_ = e.value.Close()
+	p.release()
+}
+
+// release frees the slot of a connection.
+func (p *Pool[T]) release() {
+	p.mu.Lock()
+	p.open--
+	p.unsafeNotify()
+	p.mu.Unlock()
+}
+
+// unsafeNotify wakes up those waiting for a change.
+func (p *Pool[T]) unsafeNotify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// Stats returns the current state of the [Pool].
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return Stats{Open: p.open, Idle: len(p.idle)}
+}
+
+// Close closes the idle connections and prevents new ones.
+// Connections in use are closed when released.
+func (p *Pool[T]) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.unsafeNotify()
+	p.mu.Unlock()
+
+	for _, e := range idle {
+		p.destroy(e)
+	}
+	return nil
+}
+
+// entry is a connection known by a [Pool]
+type entry[T io.Closer] struct {
+	value     T
+	created   time.Time
+	idleSince time.Time
+}
+
+// Lease is a connection taken from a [Pool]. Either Release
+// or Close must be called once done with it.
+type Lease[T io.Closer] struct {
+	Value T
+
+	pool *Pool[T]
+	e    *entry[T]
+	once sync.Once
+}
+
+// Release returns the connection to the [Pool].
+func (l *Lease[T]) Release() {
+	l.once.Do(func() { l.pool.put(l.e) })
+}
+
+// Close closes the connection instead of returning it to
+// the [Pool], as should be done after errors.
+func (l *Lease[T]) Close() error {
+	var err error
+	l.once.Do(func() {
+		err = l.Value.Close()
+		l.pool.release()
+	})
+	return err
+}
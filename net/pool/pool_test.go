@@ -0,0 +1,118 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConn struct {
+	id     int
+	closed atomic.Bool
+}
+
+func (c *testConn) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func newTestPool(t *testing.T, cfg Config[*testConn]) *Pool[*testConn] {
+	t.Helper()
+
+	var next int
+	cfg.Dial = func(context.Context) (*testConn, error) {
+		next++
+		return &testConn{id: next}, nil
+	}
+
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPoolReuse(t *testing.T) {
+	p := newTestPool(t, Config[*testConn]{})
+	ctx := context.Background()
+
+	l, err := p.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := l.Value
+	l.Release()
+	l.Release() // no-op
+
+	l, err = p.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if l.Value != first {
+		t.Errorf("ERROR: connection %v not reused", first.id)
+	}
+
+	_ = l.Close()
+	if !first.closed.Load() {
+		t.Error("ERROR: connection not closed")
+	}
+
+	if s := p.Stats(); s != (Stats{}) {
+		t.Errorf("ERROR: unexpected %+v", s)
+	}
+}
+
+func TestPoolMaxActive(t *testing.T) {
+	p := newTestPool(t, Config[*testConn]{MaxActive: 1})
+
+	l, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ERROR: unexpected %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		l.Release()
+	}()
+
+	l2, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	} else if l2.Value != l.Value {
+		t.Error("ERROR: released connection not handed over")
+	}
+}
+
+func TestPoolHealth(t *testing.T) {
+	p := newTestPool(t, Config[*testConn]{
+		Check: func(c *testConn) error {
+			if c.id == 1 {
+				return errors.New("broken")
+			}
+			return nil
+		},
+	})
+
+	l, _ := p.Get(context.Background())
+	l.Release()
+
+	l, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	} else if l.Value.id != 2 {
+		t.Errorf("ERROR: unhealthy connection reused")
+	}
+
+	_ = p.Close()
+	l.Release()
+	if !l.Value.closed.Load() {
+		t.Error("ERROR: connection not closed after the pool")
+	}
+}
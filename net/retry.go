@@ -0,0 +1,150 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"darvaza.org/x/sync"
+	"darvaza.org/x/sync/errors"
+)
+
+const (
+	// DefaultRetryAttempts is the default number of attempts
+	// done by a [RetryDialer].
+	DefaultRetryAttempts = 3
+	// DefaultRetryBackoff is the default pause after the first
+	// failed attempt of a [RetryDialer].
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// DefaultRetryMaxBackoff is the default limit of the pause
+	// between attempts of a [RetryDialer].
+	DefaultRetryMaxBackoff = 5 * time.Second
+)
+
+var (
+	_ Dialer = (*RetryDialer)(nil)
+	_ error  = (*RetryError)(nil)
+)
+
+// RetryError is the error returned by a [RetryDialer] after
+// giving up.
+type RetryError struct {
+	Err      error
+	Network  string
+	Address  string
+	Attempts int
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("dial %s %s: %d attempts: %v", e.Network, e.Address, e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// RetryDialer is a [Dialer] retrying failed attempts with exponential
+// backoff and jitter.
+type RetryDialer struct {
+	// Dialer is the [Dialer] doing the attempts. Default
+	// is a [net.Dialer].
+	Dialer Dialer
+	// Retry tells if an error is worth retrying. By default
+	// temporary errors are retried, as defined by
+	// [errors.IsTemporary].
+	Retry func(error) bool
+
+	// Attempts is the maximum number of attempts. Default
+	// is [DefaultRetryAttempts].
+	Attempts int
+	// Timeout limits each attempt. Zero means only the context
+	// applies.
+	Timeout time.Duration
+	// Backoff is the pause after the first failed attempt,
+	// doubled after each subsequent one. Default is
+	// [DefaultRetryBackoff].
+	Backoff time.Duration
+	// MaxBackoff is the limit of the pause between attempts.
+	// Default is [DefaultRetryMaxBackoff].
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the pause to be randomised,
+	// between 0 and 1.
+	Jitter float64
+}
+
+// SetDefaults fills any gap in the [RetryDialer].
+func (d *RetryDialer) SetDefaults() {
+	if d.Dialer == nil {
+		d.Dialer = &net.Dialer{}
+	}
+	if d.Retry == nil {
+		d.Retry = errors.IsTemporary
+	}
+	if d.Attempts < 1 {
+		d.Attempts = DefaultRetryAttempts
+	}
+	if d.Backoff <= 0 {
+		d.Backoff = DefaultRetryBackoff
+	}
+	if d.MaxBackoff <= 0 {
+		d.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	d.Jitter = min(max(d.Jitter, 0), 1)
+}
+
+// DialContext implements the [Dialer] interface. When giving up,
+// the error of the last attempt is returned wrapped in a [RetryError].
+// Cancellations of the context aren't retried.
+func (d *RetryDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	cfg := *d
+	cfg.SetDefaults()
+
+	backoff := sync.ExponentialBackoff(cfg.Backoff, cfg.MaxBackoff)
+
+	for attempt := 1; ; attempt++ {
+		conn, err := cfg.dial(ctx, network, address)
+		switch {
+		case err == nil:
+			return conn, nil
+		case attempt >= cfg.Attempts, ctx.Err() != nil, !cfg.Retry(err):
+			return nil, &RetryError{Err: err, Network: network, Address: address, Attempts: attempt}
+		}
+
+		if sleepContext(ctx, cfg.jitter(backoff(attempt))) != nil {
+			return nil, &RetryError{Err: err, Network: network, Address: address, Attempts: attempt}
+		}
+	}
+}
+
+func (d *RetryDialer) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+
+	return d.Dialer.DialContext(ctx, network, address)
+}
+
+func (d *RetryDialer) jitter(t time.Duration) time.Duration {
+	if d.Jitter > 0 && t > 0 {
+		delta := time.Duration(d.Jitter * float64(t))
+		t += time.Duration(rand.Int64N(int64(2*delta)+1)) - delta
+	}
+	return t
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,69 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type dialerFunc func(context.Context, string, string) (net.Conn, error)
+
+func (fn dialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return fn(ctx, network, address)
+}
+
+func newFailingDialer(failures int, err error) (Dialer, *int) {
+	var calls int
+	fn := func(context.Context, string, string) (net.Conn, error) {
+		calls++
+		if calls <= failures {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: err}
+		}
+		c, _ := net.Pipe()
+		return c, nil
+	}
+	return dialerFunc(fn), &calls
+}
+
+func TestRetryDialer(t *testing.T) {
+	tests := []struct {
+		err      error
+		failures int
+		calls    int
+		ok       bool
+	}{
+		{syscall.ECONNREFUSED, 0, 1, true},
+		{syscall.ECONNREFUSED, 2, 3, true},
+		{syscall.ECONNREFUSED, 3, 3, false},
+		{syscall.EACCES, 1, 1, false},
+	}
+
+	for i, tc := range tests {
+		dialer, calls := newFailingDialer(tc.failures, tc.err)
+		d := &RetryDialer{
+			Dialer:  dialer,
+			Backoff: time.Millisecond,
+			Jitter:  0.5,
+		}
+
+		conn, err := d.DialContext(context.Background(), "tcp", "example.org:80")
+		if conn != nil {
+			_ = conn.Close()
+		}
+
+		var re *RetryError
+		switch {
+		case *calls != tc.calls:
+			t.Errorf("[%v/%v] ERROR: %v calls (expected %v)", i, len(tests), *calls, tc.calls)
+		case tc.ok && err != nil:
+			t.Errorf("[%v/%v] ERROR: %v", i, len(tests), err)
+		case !tc.ok && !errors.As(err, &re):
+			t.Errorf("[%v/%v] ERROR: %v (expected %T)", i, len(tests), err, re)
+		case !tc.ok && (re.Attempts != tc.calls || !errors.Is(err, tc.err)):
+			t.Errorf("[%v/%v] ERROR: %v", i, len(tests), err)
+		}
+	}
+}
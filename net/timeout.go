@@ -0,0 +1,205 @@
+package net
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrIdleTimeout indicates a connection was closed after
+	// being idle for too long.
+	ErrIdleTimeout = errors.New("idle timeout")
+	// ErrLifetimeExceeded indicates a connection was closed after
+	// reaching its maximum lifetime.
+	ErrLifetimeExceeded = errors.New("maximum lifetime exceeded")
+)
+
+var (
+	_ net.Conn     = (*TimeoutConn)(nil)
+	_ net.Listener = (*TimeoutListener)(nil)
+)
+
+// TimeoutConfig describes the limits enforced by a [TimeoutConn].
+// Zero values mean no limit.
+type TimeoutConfig struct {
+	// OnExpire is called, if set, when the connection is closed
+	// by ErrIdleTimeout or ErrLifetimeExceeded.
+	OnExpire func(net.Conn, error)
+
+	// IdleTimeout closes the connection when no data is
+	// transferred for this long.
+	IdleTimeout time.Duration
+	// MaxLifetime closes the connection once it's been open
+	// for this long.
+	MaxLifetime time.Duration
+	// ReadTimeout limits each call to Read.
+	ReadTimeout time.Duration
+	// WriteTimeout limits each call to Write.
+	WriteTimeout time.Duration
+}
+
+// TimeoutConn is a [net.Conn] enforcing the limits of a [TimeoutConfig].
+// The per-call timeouts replace any deadline set explicitly.
+type TimeoutConn struct {
+	net.Conn
+
+	cfg      TimeoutConfig
+	idle     *time.Timer
+	lifetime *time.Timer
+	cause    atomic.Pointer[error]
+	last     atomic.Int64
+	closed   atomic.Bool
+	once     sync.Once
+}
+
+// NewTimeoutConn wraps a [net.Conn] to enforce the given limits.
+func NewTimeoutConn(conn net.Conn, cfg TimeoutConfig) *TimeoutConn {
+	c := &TimeoutConn{
+		Conn: conn,
+		cfg:  cfg,
+	}
+	c.touch()
+
+	if d := cfg.IdleTimeout; d > 0 {
+		c.idle = newStoppedTimer(c.checkIdle)
+		c.idle.Reset(d)
+	}
+	if d := cfg.MaxLifetime; d > 0 {
+		c.lifetime = newStoppedTimer(func() { c.expire(ErrLifetimeExceeded) })
+		c.lifetime.Reset(d)
+	}
+	return c
+}
+
+// Read implements the [net.Conn] interface.
+func (c *TimeoutConn) Read(b []byte) (int, error) {
+	if d := c.cfg.ReadTimeout; d > 0 {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(d))
+	}
+
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.touch()
+	}
+	return n, c.filterError(err)
+}
+
+// Write implements the [net.Conn] interface.
+func (c *TimeoutConn) Write(b []byte) (int, error) {
+	if d := c.cfg.WriteTimeout; d > 0 {
+		_ = c.Conn.SetWriteDeadline(time.Now().Add(d))
+	}
+
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.touch()
+	}
+	return n, c.filterError(err)
+}
+
+// Close closes the connection and stops its timers.
+func (c *TimeoutConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// Err returns the reason the connection expired, if it did.
+func (c *TimeoutConn) Err() error {
+	if p := c.cause.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+func (c *TimeoutConn) touch() {
+	c.last.Store(time.Now().UnixNano())
+}
+
+// checkIdle expires the connection if it's been idle long enough,
+// or waits for the remaining time otherwise.
+func (c *TimeoutConn) checkIdle() {
+	if c.closed.Load() {
+		return
+	}
+
+	idle := time.Since(time.Unix(0, c.last.Load()))
+	if left := c.cfg.IdleTimeout - idle; left > 0 {
+		c.idle.Reset(left)
+		return
+	}
+
+	c.expire(ErrIdleTimeout)
+}
+
+func (c *TimeoutConn) expire(err error) {
+	if !c.cause.CompareAndSwap(nil, &err) {
+		return
+	}
+
+	c.stop()
+	_ = c.Conn.Close()
+
+	if fn := c.cfg.OnExpire; fn != nil {
+		fn(c, err)
+	}
+}
+
+func (c *TimeoutConn) stop() {
+	c.once.Do(func() {
+		c.closed.Store(true)
+		if c.idle != nil {
+			c.idle.Stop()
+		}
+		if c.lifetime != nil {
+			c.lifetime.Stop()
+		}
+	})
+}
+
+// filterError replaces errors caused by expiration with its reason.
+func (c *TimeoutConn) filterError(err error) error {
+	if err != nil {
+		if cause := c.Err(); cause != nil {
+			return cause
+		}
+	}
+	return err
+}
+
+// newStoppedTimer creates a timer to be started once stored,
+// so fn can't run before.
+func newStoppedTimer(fn func()) *time.Timer {
+	t := time.AfterFunc(time.Hour, fn)
+	t.Stop()
+	return t
+}
+
+// TimeoutListener is a [net.Listener] wrapping the connections it
+// accepts in a [TimeoutConn].
+type TimeoutListener struct {
+	net.Listener
+
+	Config TimeoutConfig
+}
+
+// NewTimeoutListener wraps a [net.Listener] to enforce the given
+// limits on its connections.
+func NewTimeoutListener(l net.Listener, cfg TimeoutConfig) *TimeoutListener {
+	return &TimeoutListener{
+		Listener: l,
+		Config:   cfg,
+	}
+}
+
+// Accept waits for and returns the next connection wrapped in
+// a [TimeoutConn].
+func (l *TimeoutListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeoutConn(conn, l.Config), nil
+}
@@ -0,0 +1,65 @@
+package net
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTimeoutConn(t *testing.T) {
+	tests := []struct {
+		cfg      TimeoutConfig
+		expected error
+	}{
+		{TimeoutConfig{IdleTimeout: 20 * time.Millisecond}, ErrIdleTimeout},
+		{TimeoutConfig{MaxLifetime: 20 * time.Millisecond}, ErrLifetimeExceeded},
+		{TimeoutConfig{ReadTimeout: 20 * time.Millisecond}, nil},
+	}
+
+	for i, tc := range tests {
+		client, server := net.Pipe()
+
+		expired := make(chan error, 1)
+		tc.cfg.OnExpire = func(_ net.Conn, err error) { expired <- err }
+		c := NewTimeoutConn(server, tc.cfg)
+
+		_, err := c.Read(make([]byte, 1))
+		switch {
+		case tc.expected == nil:
+			var ne net.Error
+			if !errors.As(err, &ne) || !ne.Timeout() {
+				t.Errorf("[%v/%v] ERROR: %v (expected timeout)", i, len(tests), err)
+			}
+		case !errors.Is(err, tc.expected):
+			t.Errorf("[%v/%v] ERROR: %v (expected %v)", i, len(tests), err, tc.expected)
+		case !errors.Is(<-expired, tc.expected):
+			t.Errorf("[%v/%v] ERROR: OnExpire not called", i, len(tests))
+		}
+
+		_ = c.Close()
+		_ = client.Close()
+	}
+}
+
+func TestTimeoutConnActivity(t *testing.T) {
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+
+	c := NewTimeoutConn(server, TimeoutConfig{IdleTimeout: 30 * time.Millisecond})
+	defer func() { _ = c.Close() }()
+
+	go func() {
+		for range 5 {
+			time.Sleep(10 * time.Millisecond)
+			_, _ = client.Write([]byte("x"))
+		}
+	}()
+
+	buf := make([]byte, 1)
+	for i := range 5 {
+		if _, err := c.Read(buf); err != nil {
+			t.Fatalf("[%v] ERROR: %v", i, err)
+		}
+	}
+}
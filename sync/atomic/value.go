@@ -0,0 +1,76 @@
+// Package atomic provides type-safe wrappers around
+// the standard [sync/atomic] package.
+package atomic
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Value provides atomic access to a value of type T,
+// without the type assertions required by [atomic.Value].
+//
+// The zero value is ready for use and Load returns
+// the zero value of T until something is stored. A nil
+// Value acts as an empty one that can't be modified.
+type Value[T comparable] struct {
+	v atomic.Value
+}
+
+type box[T comparable] struct {
+	v T
+}
+
+// Load returns the stored value, or the zero value of T
+// if nothing was stored yet.
+func (v *Value[T]) Load() T {
+	if v != nil {
+		if b, ok := v.v.Load().(box[T]); ok {
+			return b.v
+		}
+	}
+
+	var zero T
+	return zero
+}
+
+// Store atomically sets the value.
+func (v *Value[T]) Store(val T) {
+	if v != nil {
+		v.v.Store(box[T]{val})
+	}
+}
+
+// Swap atomically stores a new value and returns the previous one.
+func (v *Value[T]) Swap(val T) (old T) {
+	if v == nil {
+		return old
+	}
+
+	if b, ok := v.v.Swap(box[T]{val}).(box[T]); ok {
+		old = b.v
+	}
+	return old
+}
+
+// CompareAndSwap atomically stores the new value if the current
+// one is equal to old. An unset Value is considered equal to the
+// zero value of T.
+//
+// When T is, or contains, an interface type, old could hold a value
+// that can't be compared, like a slice or a map. Unlike
+// [atomic.Value.CompareAndSwap], it doesn't panic then, and
+// returns false.
+func (v *Value[T]) CompareAndSwap(old, val T) bool {
+	var zero T
+
+	if v == nil || !reflect.ValueOf(&old).Elem().Comparable() {
+		return false
+	}
+
+	if old == zero && v.v.CompareAndSwap(nil, box[T]{val}) {
+		// first store
+		return true
+	}
+	return v.v.CompareAndSwap(box[T]{old}, box[T]{val})
+}
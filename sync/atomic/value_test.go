@@ -0,0 +1,123 @@
+package atomic
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	var v Value[string]
+
+	if s := v.Load(); s != "" {
+		t.Errorf("ERROR: Load() on unset → %q (expected %q)", s, "")
+	}
+
+	// an unset value matches the zero value
+	if v.CompareAndSwap("x", "a") {
+		t.Error("ERROR: CompareAndSwap(x, a) succeeded on unset")
+	}
+	if !v.CompareAndSwap("", "a") {
+		t.Error("ERROR: CompareAndSwap(\"\", a) failed on unset")
+	}
+
+	if old := v.Swap("b"); old != "a" {
+		t.Errorf("ERROR: Swap(b) → %q (expected %q)", old, "a")
+	}
+	if v.CompareAndSwap("a", "c") {
+		t.Error("ERROR: CompareAndSwap(a, c) succeeded on b")
+	}
+	if !v.CompareAndSwap("b", "c") {
+		t.Error("ERROR: CompareAndSwap(b, c) failed on b")
+	}
+
+	v.Store("")
+	if v.CompareAndSwap("", "d"); v.Load() != "d" {
+		t.Errorf("ERROR: Load() → %q (expected %q)", v.Load(), "d")
+	}
+}
+
+func TestValueNil(t *testing.T) {
+	var v *Value[int]
+
+	v.Store(1)
+	if n := v.Swap(2); n != 0 {
+		t.Errorf("ERROR: Swap() on nil → %v (expected 0)", n)
+	}
+	if v.CompareAndSwap(0, 3) {
+		t.Error("ERROR: CompareAndSwap() on nil succeeded")
+	}
+	if n := v.Load(); n != 0 {
+		t.Errorf("ERROR: Load() on nil → %v (expected 0)", n)
+	}
+}
+
+func TestValueUncomparable(t *testing.T) {
+	type holder struct {
+		v any
+	}
+
+	var v Value[any]
+	var h Value[holder]
+
+	slice := []int{1}
+	v.Store(slice)
+	h.Store(holder{v: map[string]int{}})
+
+	for _, tc := range []struct {
+		name string
+		cas  func() bool
+	}{
+		{"slice", func() bool { return v.CompareAndSwap(slice, 2) }},
+		{"other slice", func() bool { return v.CompareAndSwap([]int{2}, 2) }},
+		{"nested map", func() bool { return h.CompareAndSwap(holder{v: map[string]int{}}, holder{}) }},
+	} {
+		var swapped bool
+
+		func() {
+			defer func() {
+				if e := recover(); e != nil {
+					t.Errorf("ERROR: %s: CompareAndSwap() panicked: %v", tc.name, e)
+				}
+			}()
+			swapped = tc.cas()
+		}()
+
+		if swapped {
+			t.Errorf("ERROR: %s: CompareAndSwap() succeeded", tc.name)
+		}
+	}
+
+	// comparable dynamic types still work
+	v.Store(1)
+	if !v.CompareAndSwap(1, "one") || v.Load() != "one" {
+		t.Errorf("ERROR: CompareAndSwap(1, one) → %v", v.Load())
+	}
+}
+
+func TestValueConcurrent(t *testing.T) {
+	const workers = 8
+	const n = 1000
+
+	var v Value[int]
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range n {
+				for {
+					old := v.Load()
+					if v.CompareAndSwap(old, old+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := v.Load(); got != workers*n {
+		t.Errorf("ERROR: Load() → %v (expected %v)", got, workers*n)
+	}
+}
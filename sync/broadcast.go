@@ -0,0 +1,175 @@
+package sync
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// ErrClosed indicates the object has already been closed.
+var ErrClosed = fs.ErrClosed
+
+// BroadcastPolicy determines how a [Broadcaster] deals with
+// subscribers that aren't keeping up.
+type BroadcastPolicy int
+
+const (
+	// BroadcastDrop discards the message for subscribers whose
+	// buffer is full, so slow subscribers never stall the publisher.
+	BroadcastDrop BroadcastPolicy = iota
+	// BroadcastBlock makes the publisher wait until every subscriber
+	// has room for the message, or the context is cancelled.
+	BroadcastBlock
+)
+
+// Broadcaster delivers published values to every subscriber
+// through buffered channels.
+type Broadcaster[T any] struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription[T]]struct{}
+	done   chan struct{}
+	once   sync.Once
+	size   int
+	policy BroadcastPolicy
+}
+
+// NewBroadcaster creates a [Broadcaster] whose subscribers get channels
+// buffering size values, using the given policy when they are full.
+func NewBroadcaster[T any](size int, policy BroadcastPolicy) *Broadcaster[T] {
+	return &Broadcaster[T]{
+		subs:   make(map[*Subscription[T]]struct{}),
+		done:   make(chan struct{}),
+		size:   max(size, 0),
+		policy: policy,
+	}
+}
+
+// Subscribe registers a new [Subscription]. Subscribing to a closed
+// [Broadcaster] returns [ErrClosed].
+func (b *Broadcaster[T]) Subscribe() (*Subscription[T], error) {
+	if b == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	s := &Subscription[T]{
+		b:    b,
+		ch:   make(chan T, b.size),
+		done: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs == nil {
+		return nil, ErrClosed
+	}
+
+	b.subs[s] = struct{}{}
+	return s, nil
+}
+
+// Publish sends a value to all subscribers. With [BroadcastBlock]
+// the call waits for slow subscribers until the context is cancelled.
+func (b *Broadcaster[T]) Publish(ctx context.Context, v T) error {
+	if b == nil {
+		return core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.subs == nil {
+		return ErrClosed
+	}
+
+	for s := range b.subs {
+		if err := b.send(ctx, s, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Broadcaster[T]) send(ctx context.Context, s *Subscription[T], v T) error {
+	if b.policy != BroadcastBlock {
+		select {
+		case s.ch <- v:
+		default:
+			// dropped
+		}
+		return nil
+	}
+
+	select {
+	case s.ch <- v:
+		return nil
+	case <-s.done:
+		// unsubscribed
+		return nil
+	case <-b.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// Close unsubscribes everyone, closing their channels.
+func (b *Broadcaster[T]) Close() error {
+	if b == nil {
+		return core.ErrNilReceiver
+	}
+
+	// wake up blocked publishers before taking the lock
+	b.once.Do(func() { close(b.done) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs {
+		s.once.Do(func() { close(s.done) })
+		close(s.ch)
+	}
+	b.subs = nil
+	return nil
+}
+
+func (b *Broadcaster[T]) unsubscribe(s *Subscription[T]) {
+	// wake up blocked publishers before taking the lock
+	s.once.Do(func() { close(s.done) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// Subscription represents a subscriber of a [Broadcaster].
+type Subscription[T any] struct {
+	b    *Broadcaster[T]
+	ch   chan T
+	done chan struct{}
+	once sync.Once
+}
+
+// C returns the channel receiving the published values. It's closed
+// when the subscription ends.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Close ends the subscription.
+func (s *Subscription[T]) Close() error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.b.unsubscribe(s)
+	return nil
+}
@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBroadcasterDrop(t *testing.T) {
+	b := NewBroadcaster[int](1, BroadcastDrop)
+	s, err := b.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 3 {
+		if err := b.Publish(context.Background(), i); err != nil {
+			t.Errorf("ERROR: Publish(%v) → %v", i, err)
+		}
+	}
+
+	if v := <-s.C(); v != 0 {
+		t.Errorf("ERROR: received %v (expected %v)", v, 0)
+	}
+
+	_ = b.Close()
+	if _, ok := <-s.C(); ok {
+		t.Error("ERROR: channel not closed after Close()")
+	}
+	if err := b.Publish(context.Background(), 0); err != ErrClosed {
+		t.Errorf("ERROR: Publish after Close() → %v (expected %v)", err, ErrClosed)
+	}
+}
+
+func TestBroadcasterBlock(t *testing.T) {
+	b := NewBroadcaster[int](0, BroadcastBlock)
+	s, _ := b.Subscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := b.Publish(ctx, 1); err == nil {
+		t.Error("ERROR: Publish didn't block on a slow subscriber")
+	}
+
+	// unsubscribing releases blocked publishers
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = s.Close()
+	}()
+
+	if err := b.Publish(context.Background(), 2); err != nil {
+		t.Errorf("ERROR: Publish → %v", err)
+	}
+}
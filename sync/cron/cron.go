@@ -0,0 +1,203 @@
+// Package cron implements a lightweight in-process job scheduler.
+package cron
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+
+	xerrors "darvaza.org/x/sync/errors"
+)
+
+// Overlap determines what happens when a job is due while
+// a previous run is still in progress.
+type Overlap int
+
+const (
+	// OverlapSkip skips the activation
+	OverlapSkip Overlap = iota
+	// OverlapQueue runs the job again after the current run finishes
+	OverlapQueue
+	// OverlapConcurrent runs the job in parallel
+	OverlapConcurrent
+)
+
+// Job describes a scheduled task.
+type Job struct {
+	// Schedule determines when the job runs
+	Schedule Schedule
+	// Run is the function called on each activation
+	Run func(context.Context) error
+	// Name identifies the job for error handlers
+	Name string
+	// Overlap determines how to deal with overlapping runs
+	Overlap Overlap
+}
+
+// Scheduler dispatches jobs into a [core.ErrGroup] according to
+// their [Schedule]. Errors and panics on job runs are passed to
+// OnError and don't stop the scheduler. Without OnError, the first
+// failure cancels the group and is returned by [Scheduler.Wait].
+type Scheduler struct {
+	eg core.ErrGroup
+
+	// err holds the first failure, as the [core.ErrGroup]
+	// reports them asynchronously
+	err xerrors.FirstError
+
+	// OnError is called when a job run fails
+	OnError func(job Job, err error)
+}
+
+// New creates a [Scheduler] that stops when the context
+// is cancelled.
+func New(ctx context.Context) *Scheduler {
+	s := &Scheduler{}
+	s.eg.Parent = ctx
+	s.eg.SetDefaults()
+	return s
+}
+
+// Add schedules a job.
+func (s *Scheduler) Add(job Job) error {
+	switch {
+	case s == nil:
+		return core.ErrNilReceiver
+	case job.Schedule == nil:
+		return core.Wrap(core.ErrInvalid, "schedule not specified")
+	case job.Run == nil:
+		return core.Wrap(core.ErrInvalid, "run function not specified")
+	case s.eg.IsCancelled():
+		return core.Wrap(context.Canceled, "scheduler cancelled")
+	}
+
+	r := &runner{s: s, job: job}
+	s.eg.Go(r.loop, nil)
+	return nil
+}
+
+// AddFunc parses a spec and schedules a function with
+// the [OverlapSkip] policy.
+func (s *Scheduler) AddFunc(spec string, fn func(context.Context) error) error {
+	sched, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	return s.Add(Job{
+		Name:     spec,
+		Schedule: sched,
+		Run:      fn,
+	})
+}
+
+// Cancel stops the scheduler. Runs in progress are cancelled
+// through their context.
+func (s *Scheduler) Cancel() {
+	if s != nil {
+		s.eg.Cancel(nil)
+	}
+}
+
+// Done returns a channel closed once the scheduler and all
+// runs have finished.
+func (s *Scheduler) Done() <-chan struct{} {
+	return s.eg.Done()
+}
+
+// Wait waits until the scheduler and all runs have finished.
+func (s *Scheduler) Wait() error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	err := s.eg.Wait()
+
+	if e := s.err.Err(); e != nil {
+		err = e
+	}
+
+	if core.IsError(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+type runner struct {
+	s       *Scheduler
+	job     Job
+	mu      sync.Mutex
+	pending int
+	running bool
+}
+
+func (r *runner) loop(ctx context.Context) error {
+	for {
+		next := r.job.Schedule.Next(time.Now())
+		if next.IsZero() {
+			// no more activations
+			return nil
+		}
+
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-t.C:
+			r.fire()
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		}
+	}
+}
+
+func (r *runner) fire() {
+	if r.job.Overlap == OverlapConcurrent {
+		r.s.eg.Go(r.runOnce, nil)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case !r.running:
+		r.running = true
+		r.s.eg.Go(r.drain, nil)
+	case r.job.Overlap == OverlapQueue:
+		r.pending++
+	}
+}
+
+func (r *runner) drain(ctx context.Context) error {
+	for {
+		err := r.runOnce(ctx)
+
+		r.mu.Lock()
+		if err != nil || r.pending == 0 || ctx.Err() != nil {
+			r.pending = 0
+			r.running = false
+			r.mu.Unlock()
+			return err
+		}
+		r.pending--
+		r.mu.Unlock()
+	}
+}
+
+// runOnce runs the job, returning the error to the group only
+// when there is no OnError handler.
+func (r *runner) runOnce(ctx context.Context) error {
+	err := core.Catch(func() error { return r.job.Run(ctx) })
+	switch {
+	case err == nil:
+		return nil
+	case r.s.OnError != nil:
+		r.s.OnError(r.job, err)
+		return nil
+	default:
+		r.s.err.Set(err)
+		return err
+	}
+}
@@ -0,0 +1,310 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+var errJob = errors.New("job failed")
+
+// burst is a [Schedule] firing n times at the given interval,
+// closing done once exhausted.
+type burst struct {
+	mu   sync.Mutex
+	n    int
+	d    time.Duration
+	done chan struct{}
+}
+
+func newBurst(n int, d time.Duration) *burst {
+	return &burst{n: n, d: d, done: make(chan struct{})}
+}
+
+func (b *burst) Next(t time.Time) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.n == 0 {
+		select {
+		case <-b.done:
+		default:
+			close(b.done)
+		}
+		return time.Time{}
+	}
+
+	b.n--
+	return t.Add(b.d)
+}
+
+// tick is a [Schedule] firing at intervals shorter than
+// [Every] allows.
+type tick time.Duration
+
+func (d tick) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// counter tracks the runs of a job and their concurrency.
+type counter struct {
+	runs    atomic.Int32
+	running atomic.Int32
+	max     atomic.Int32
+}
+
+// blocking returns a Run function blocking until release
+// is closed or the context cancelled.
+func (c *counter) blocking(release <-chan struct{}) func(context.Context) error {
+	return func(ctx context.Context) error {
+		c.runs.Add(1)
+		n := c.running.Add(1)
+		defer c.running.Add(-1)
+
+		for {
+			m := c.max.Load()
+			if n <= m || c.max.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+}
+
+// eventually polls a condition until it's true or a second passes.
+func eventually(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	tests := []struct {
+		name    string
+		overlap Overlap
+		runs    int32
+		max     int32
+	}{
+		{"skip", OverlapSkip, 1, 1},
+		{"queue", OverlapQueue, 3, 1},
+		{"concurrent", OverlapConcurrent, 3, 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var c counter
+
+			release := make(chan struct{})
+			b := newBurst(3, 5*time.Millisecond)
+
+			s := New(context.Background())
+			err := s.Add(Job{
+				Name:     tc.name,
+				Schedule: b,
+				Run:      c.blocking(release),
+				Overlap:  tc.overlap,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// all activations happen while the first run blocks
+			<-b.done
+			if !eventually(func() bool { return c.running.Load() == tc.max }) {
+				t.Errorf("ERROR: %v runs in progress (expected %v)", c.running.Load(), tc.max)
+			}
+			close(release)
+
+			if err := s.Wait(); err != nil {
+				t.Errorf("ERROR: Wait() → %v", err)
+			}
+
+			if n := c.runs.Load(); n != tc.runs {
+				t.Errorf("ERROR: %v runs (expected %v)", n, tc.runs)
+			}
+			if n := c.max.Load(); n != tc.max {
+				t.Errorf("ERROR: %v concurrent runs (expected %v)", n, tc.max)
+			}
+		})
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	var c counter
+
+	s := New(context.Background())
+	err := s.Add(Job{
+		Schedule: tick(time.Millisecond),
+		Run:      c.blocking(nil),
+		Overlap:  OverlapConcurrent,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !eventually(func() bool { return c.running.Load() > 1 }) {
+		t.Fatal("ERROR: job didn't run")
+	}
+
+	s.Cancel()
+
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("ERROR: Cancel() didn't stop the runs")
+	}
+
+	if err := s.Wait(); err != nil {
+		t.Errorf("ERROR: Wait() → %v", err)
+	}
+	if n := c.running.Load(); n != 0 {
+		t.Errorf("ERROR: %v runs still in progress", n)
+	}
+
+	err = s.AddFunc("@every 1s", func(context.Context) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ERROR: Add() after Cancel() → %v", err)
+	}
+}
+
+func TestSchedulerParentCancel(t *testing.T) {
+	var c counter
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := New(ctx)
+	err := s.Add(Job{
+		Schedule: tick(time.Millisecond),
+		Run:      c.blocking(nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !eventually(func() bool { return c.running.Load() == 1 }) {
+		t.Fatal("ERROR: job didn't run")
+	}
+
+	cancel()
+	if err := s.Wait(); err != nil {
+		t.Errorf("ERROR: Wait() → %v", err)
+	}
+}
+
+func TestSchedulerError(t *testing.T) {
+	var runs atomic.Int32
+
+	s := New(context.Background())
+	err := s.Add(Job{
+		Schedule: tick(time.Millisecond),
+		Run: func(context.Context) error {
+			if runs.Add(1) == 2 {
+				return errJob
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Wait(); !errors.Is(err, errJob) {
+		t.Errorf("ERROR: Wait() → %v (expected %v)", err, errJob)
+	}
+	if n := runs.Load(); n < 2 {
+		t.Errorf("ERROR: %v runs (expected at least 2)", n)
+	}
+}
+
+func TestSchedulerOnError(t *testing.T) {
+	var runs atomic.Int32
+	var mu sync.Mutex
+	var errs []error
+
+	s := New(context.Background())
+	s.OnError = func(job Job, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if job.Name != "failing" {
+			t.Errorf("ERROR: unexpected job %q", job.Name)
+		}
+		errs = append(errs, err)
+	}
+
+	err := s.Add(Job{
+		Name:     "failing",
+		Schedule: tick(time.Millisecond),
+		Run: func(context.Context) error {
+			if runs.Add(1)%2 == 0 {
+				panic(errJob)
+			}
+			return errJob
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !eventually(func() bool { return runs.Load() > 3 }) {
+		t.Error("ERROR: failures stopped the scheduler")
+	}
+
+	s.Cancel()
+	if err := s.Wait(); err != nil {
+		t.Errorf("ERROR: Wait() → %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, err := range errs {
+		if !errors.Is(err, errJob) {
+			t.Errorf("[%v] ERROR: OnError() ← %v (expected %v)", i, err, errJob)
+		}
+	}
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	var nilScheduler *Scheduler
+
+	run := func(context.Context) error { return nil }
+
+	s := New(context.Background())
+	defer s.Cancel()
+
+	tests := []struct {
+		s        *Scheduler
+		job      Job
+		expected error
+	}{
+		{nilScheduler, Job{Schedule: Every(time.Hour), Run: run}, core.ErrNilReceiver},
+		{s, Job{Run: run}, core.ErrInvalid},
+		{s, Job{Schedule: Every(time.Hour)}, core.ErrInvalid},
+		{s, Job{Schedule: Every(time.Hour), Run: run}, nil},
+	}
+
+	for i, tc := range tests {
+		err := tc.s.Add(tc.job)
+		switch {
+		case tc.expected == nil && err != nil:
+			t.Errorf("[%v] ERROR: Add() → %v (expected nil)", i, err)
+		case tc.expected != nil && !errors.Is(err, tc.expected):
+			t.Errorf("[%v] ERROR: Add() → %v (expected %v)", i, err, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package cron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ Schedule = Every(0)
+	_ Schedule = (*Spec)(nil)
+)
+
+// ErrInvalidSpec indicates a schedule specification couldn't be parsed.
+var ErrInvalidSpec = core.Wrap(core.ErrInvalid, "invalid schedule")
+
+// Schedule describes when a job runs.
+type Schedule interface {
+	// Next returns the next activation time after the given one,
+	// or the zero time if there is none.
+	Next(time.Time) time.Time
+}
+
+// Every is a [Schedule] running at fixed intervals.
+type Every time.Duration
+
+// Next returns the given time plus the interval. Intervals
+// shorter than a second are rounded up.
+func (d Every) Next(t time.Time) time.Time {
+	return t.Add(max(time.Duration(d), time.Second))
+}
+
+// Spec is a [Schedule] parsed from a cron-like specification.
+type Spec struct {
+	minute, hour, dom, month, dow uint64
+
+	// any day-of-month or day-of-week
+	anyDOM, anyDOW bool
+}
+
+// field describes the valid range of a cron field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 7 is also Sunday
+}
+
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule specification. It accepts the five fields of
+// the standard cron format (minute, hour, day of month, month and day
+// of week) with `*`, `a-b`, `a,b` and `/step` forms, the `@daily`-like
+// shortcuts, and `@every <duration>`. Both 0 and 7 mean Sunday.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if s, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil || d <= 0 {
+			return nil, core.QuietWrap(ErrInvalidSpec, "invalid interval: %q", s)
+		}
+		return Every(d), nil
+	}
+
+	if s, ok := shortcuts[spec]; ok {
+		spec = s
+	}
+
+	return parseSpec(spec)
+}
+
+func parseSpec(spec string) (*Spec, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, core.QuietWrap(ErrInvalidSpec, "%q: expected %v fields", spec, len(fields))
+	}
+
+	var bits [5]uint64
+	for i, s := range parts {
+		b, err := parseField(s, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = b
+	}
+
+	if bits[4]&(1<<7) != 0 {
+		// after expanding ranges, so 5-7 works
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	out := &Spec{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDOM: parts[2] == "*",
+		anyDOW: parts[4] == "*",
+	}
+	return out, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, step, err := parseRange(part, f)
+		if err != nil {
+			return 0, err
+		}
+
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func parseRange(s string, f field) (lo, hi, step int, err error) {
+	rng, stepStr, hasStep := strings.Cut(s, "/")
+
+	lo, hi, err = parseBounds(rng, f)
+	switch {
+	case err != nil:
+		return 0, 0, 0, err
+	case !hasStep:
+		return lo, hi, 1, nil
+	}
+
+	step, err = strconv.Atoi(stepStr)
+	if err != nil || step < 1 {
+		return 0, 0, 0, core.QuietWrap(ErrInvalidSpec, "%s: invalid step: %q", f.name, s)
+	}
+
+	if !strings.Contains(rng, "-") && rng != "*" {
+		// a/n means a-max/n
+		hi = f.max
+	}
+	return lo, hi, step, nil
+}
+
+func parseBounds(s string, f field) (lo, hi int, err error) {
+	if s == "*" {
+		return f.min, f.max, nil
+	}
+
+	loStr, hiStr, isRange := strings.Cut(s, "-")
+	lo, err = parseValue(loStr, f)
+	if err == nil {
+		hi = lo
+		if isRange {
+			hi, err = parseValue(hiStr, f)
+		}
+	}
+
+	switch {
+	case err != nil:
+		return 0, 0, err
+	case hi < lo:
+		return 0, 0, core.QuietWrap(ErrInvalidSpec, "%s: invalid range: %q", f.name, s)
+	default:
+		return lo, hi, nil
+	}
+}
+
+func parseValue(s string, f field) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, core.QuietWrap(ErrInvalidSpec, "%s: invalid value: %q", f.name, s)
+	}
+	return n, nil
+}
+
+// searchLimit is how far in the future [Spec.Next] looks for
+// a matching time.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Next returns the first matching minute after the given time,
+// or the zero time if there are none within the next five years.
+func (s *Spec) Next(t time.Time) time.Time {
+	limit := t.Add(searchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *Spec) matchDay(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+
+	switch {
+	case s.anyDOM && s.anyDOW:
+		return true
+	case s.anyDOM:
+		return dow
+	case s.anyDOW:
+		return dom
+	default:
+		// either, like cron
+		return dom || dow
+	}
+}
+
+func has(bits uint64, i int) bool {
+	return bits&(1<<uint(i)) != 0
+}
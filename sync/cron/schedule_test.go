@@ -0,0 +1,54 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpecNext(t *testing.T) {
+	base := time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", base.Add(time.Minute)},
+		{"*/15 * * * *", base.Add(15 * time.Minute)},
+		{"0 * * * *", base.Add(30 * time.Minute)},
+		{"@daily", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 0", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 5-7", time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 6-7", time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * */7", time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1/3", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for i, tc := range tests {
+		s, err := Parse(tc.spec)
+		if err != nil {
+			t.Errorf("[%v/%v] ERROR: Parse(%q) → %v", i, len(tests), tc.spec, err)
+			continue
+		}
+
+		if next := s.Next(base); next.Equal(tc.next) {
+			t.Logf("[%v/%v] %q → %v", i, len(tests), tc.spec, next)
+		} else {
+			t.Errorf("[%v/%v] ERROR: %q → %v (expected %v)", i, len(tests), tc.spec,
+				next, tc.next)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *",
+		"* * * * 8", "* * * * 7-5", "@every x"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("ERROR: Parse(%q) succeeded", spec)
+		}
+	}
+}
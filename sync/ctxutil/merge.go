@@ -0,0 +1,70 @@
+// Package ctxutil provides helpers to work with [context.Context].
+package ctxutil
+
+import (
+	"context"
+	"time"
+)
+
+var _ context.Context = (*merged)(nil)
+
+// Merge returns a context cancelled when either parent is, carrying
+// the values of both. Values from a take precedence, and the earliest
+// deadline is used. The returned cancel function releases the
+// resources associated with the merge, and should be called once
+// the context is no longer needed.
+func Merge(a, b context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(a)
+	stop := context.AfterFunc(b, func() {
+		cancel(context.Cause(b))
+	})
+
+	m := &merged{Context: ctx, a: a, b: b}
+	return m, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+type merged struct {
+	context.Context
+
+	a, b context.Context
+}
+
+func (m *merged) Deadline() (time.Time, bool) {
+	da, okA := m.a.Deadline()
+	db, okB := m.b.Deadline()
+
+	switch {
+	case okA && okB:
+		if db.Before(da) {
+			return db, true
+		}
+		return da, true
+	case okB:
+		return db, true
+	default:
+		return da, okA
+	}
+}
+
+func (m *merged) Err() error {
+	err := m.Context.Err()
+	if err != nil {
+		// prefer the parents' reason
+		if e := m.a.Err(); e != nil {
+			return e
+		} else if e := m.b.Err(); e != nil {
+			return e
+		}
+	}
+	return err
+}
+
+func (m *merged) Value(key any) any {
+	if v := m.Context.Value(key); v != nil {
+		return v
+	}
+	return m.b.Value(key)
+}
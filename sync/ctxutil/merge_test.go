@@ -0,0 +1,44 @@
+package ctxutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testKey string
+
+func TestMerge(t *testing.T) {
+	a := context.WithValue(context.Background(), testKey("a"), "a")
+	b, cancelB := context.WithTimeout(context.WithValue(context.Background(), testKey("b"), "b"),
+		10*time.Millisecond)
+	defer cancelB()
+
+	ctx, cancel := Merge(a, b)
+	defer cancel()
+
+	for _, k := range []string{"a", "b"} {
+		if v := ctx.Value(testKey(k)); v != k {
+			t.Errorf("ERROR: Value(%q) → %v", k, v)
+		}
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("ERROR: Deadline() not inherited")
+	}
+
+	// children follow the merged context too
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+
+	select {
+	case <-child.Done():
+	case <-time.After(time.Second):
+		t.Fatal("ERROR: not cancelled after b's deadline")
+	}
+
+	if err := ctx.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ERROR: Err() → %v (expected %v)", err, context.DeadlineExceeded)
+	}
+}
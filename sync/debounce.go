@@ -0,0 +1,126 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Debounce returns a function that delays calling fn until d has
+// elapsed since it was last invoked, coalescing bursts of calls
+// into one. Once the context is cancelled pending calls are
+// discarded and new ones ignored. If ctx is nil, [context.Background]
+// is used.
+func Debounce(ctx context.Context, d time.Duration, fn func()) func() {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var mu sync.Mutex
+	var timer *time.Timer
+
+	run := func() {
+		if ctx.Err() == nil {
+			fn()
+		}
+	}
+
+	context.AfterFunc(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+		}
+	})
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case ctx.Err() != nil:
+			// cancelled
+		case timer == nil:
+			timer = time.AfterFunc(d, run)
+		default:
+			timer.Reset(d)
+		}
+	}
+}
+
+// Throttle returns a function that calls fn at most once per interval.
+// The first call runs immediately, and further calls within the interval
+// are coalesced into a single trailing call. Once the context is
+// cancelled pending calls are discarded and new ones ignored. If ctx
+// is nil, [context.Background] is used.
+func Throttle(ctx context.Context, interval time.Duration, fn func()) func() {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	t := &throttle{ctx: ctx, fn: fn, interval: interval}
+
+	context.AfterFunc(ctx, t.stop)
+	return t.call
+}
+
+type throttle struct {
+	ctx      context.Context
+	fn       func()
+	timer    *time.Timer
+	last     time.Time
+	mu       sync.Mutex
+	interval time.Duration
+	pending  bool
+}
+
+func (t *throttle) call() {
+	if t.schedule() {
+		t.fn()
+	}
+}
+
+// schedule tells if fn should be called right away, otherwise
+// it arranges a trailing call if needed.
+func (t *throttle) schedule() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case t.ctx.Err() != nil, t.pending:
+		// cancelled or already scheduled
+		return false
+	}
+
+	wait := t.interval - time.Since(t.last)
+	if wait <= 0 {
+		t.last = time.Now()
+		return true
+	}
+
+	t.pending = true
+	t.timer = time.AfterFunc(wait, t.trailing)
+	return false
+}
+
+func (t *throttle) trailing() {
+	t.mu.Lock()
+	t.pending = false
+	t.last = time.Now()
+	t.mu.Unlock()
+
+	if t.ctx.Err() == nil {
+		t.fn()
+	}
+}
+
+func (t *throttle) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.pending = false
+}
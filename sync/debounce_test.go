@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const tick = 20 * time.Millisecond
+
+func TestDebounce(t *testing.T) {
+	var calls atomic.Int32
+
+	// nil context means never cancelled
+	f := Debounce(nil, 2*tick, func() { calls.Add(1) })
+
+	// a burst is coalesced into one call, after the last
+	for range 5 {
+		f()
+		time.Sleep(tick / 4)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("ERROR: %v calls during the burst (expected 0)", n)
+	}
+
+	time.Sleep(4 * tick)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ERROR: %v calls after the burst (expected 1)", n)
+	}
+
+	// a new burst is a new call
+	f()
+	time.Sleep(4 * tick)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("ERROR: %v calls after a second burst (expected 2)", n)
+	}
+}
+
+func TestDebounceCancel(t *testing.T) {
+	var calls atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := Debounce(ctx, 2*tick, func() { calls.Add(1) })
+
+	f()
+	cancel()
+	f()
+
+	time.Sleep(4 * tick)
+	if n := calls.Load(); n != 0 {
+		t.Errorf("ERROR: %v calls after cancel (expected 0)", n)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	var calls atomic.Int32
+
+	// nil context means never cancelled
+	f := Throttle(nil, 4*tick, func() { calls.Add(1) })
+
+	// the first runs right away
+	f()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ERROR: %v calls after the first (expected 1)", n)
+	}
+
+	// the rest of the interval is coalesced into one trailing call
+	for range 3 {
+		f()
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ERROR: %v calls within the interval (expected 1)", n)
+	}
+
+	time.Sleep(6 * tick)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("ERROR: %v calls after the interval (expected 2)", n)
+	}
+}
+
+func TestThrottleCancel(t *testing.T) {
+	var calls atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := Throttle(ctx, 2*tick, func() { calls.Add(1) })
+
+	f()
+	f() // trailing, discarded
+	cancel()
+	f()
+
+	time.Sleep(4 * tick)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ERROR: %v calls (expected 1)", n)
+	}
+}
@@ -0,0 +1,87 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+
+	"darvaza.org/core"
+)
+
+// IsCancel tells if the error indicates the operation was cancelled,
+// by a [context.Context] or by closing the network connection
+// or listener it was using.
+func IsCancel(err error) bool {
+	is, _ := core.IsErrorFn2(CheckIsCancel, err)
+	return is
+}
+
+// CheckIsCancel tests an error for cancellation without unwrapping.
+func CheckIsCancel(err error) (is, known bool) {
+	switch err {
+	case nil:
+		return false, true
+	case context.Canceled, net.ErrClosed:
+		return true, true
+	case context.DeadlineExceeded:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// IsTimeout tells if the error indicates a timeout, including
+// [context.DeadlineExceeded] and errors implementing
+// Timeout() or IsTimeout(), like [net.Error].
+func IsTimeout(err error) bool {
+	is, _ := core.IsErrorFn2(CheckIsTimeout, err)
+	return is
+}
+
+// CheckIsTimeout tests an error for timeouts without unwrapping.
+func CheckIsTimeout(err error) (is, known bool) {
+	switch err {
+	case nil:
+		return false, true
+	case context.DeadlineExceeded:
+		return true, true
+	case context.Canceled:
+		return false, true
+	default:
+		return core.CheckIsTimeout(err)
+	}
+}
+
+// IsTemporary tells if the error indicates a condition that
+// could go away if the operation is retried. Timeouts and
+// refused, reset or aborted connections are considered temporary,
+// cancellations aren't.
+func IsTemporary(err error) bool {
+	is, _ := core.IsErrorFn2(CheckIsTemporary, err)
+	return is
+}
+
+// CheckIsTemporary tests an error for Temporary(), IsTemporary(),
+// Timeout() and IsTimeout() without unwrapping.
+func CheckIsTemporary(err error) (is, known bool) {
+	switch err {
+	case nil:
+		return false, true
+	case context.DeadlineExceeded,
+		syscall.ECONNABORTED,
+		syscall.ECONNREFUSED,
+		syscall.ECONNRESET:
+		return true, true
+	case context.Canceled, net.ErrClosed:
+		return false, true
+	default:
+		is, known := core.CheckIsTemporary(err)
+		if !is && errors.Unwrap(err) != nil {
+			// wrappers like net.OpError report syscall errors
+			// as not temporary, let the cause decide.
+			return false, false
+		}
+		return is, known
+	}
+}
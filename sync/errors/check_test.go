@@ -0,0 +1,47 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err       error
+		cancel    bool
+		timeout   bool
+		temporary bool
+	}{
+		{nil, false, false, false},
+		{errors.New("foo"), false, false, false},
+		{context.Canceled, true, false, false},
+		{core.Wrap(context.Canceled, "foo"), true, false, false},
+		{context.DeadlineExceeded, false, true, true},
+		{os.ErrDeadlineExceeded, false, true, true},
+		{core.NewTimeoutError(errors.New("foo")), false, true, true},
+		{core.NewTemporaryError(errors.New("foo")), false, false, true},
+		{&net.OpError{Op: "read", Err: net.ErrClosed}, true, false, false},
+		{core.Wrap(syscall.ECONNRESET, "read"), false, false, true},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, false, false, true},
+		{&net.OpError{Op: "dial", Err: syscall.EACCES}, false, false, false},
+	}
+
+	for i, tc := range tests {
+		cancel, timeout, temporary := IsCancel(tc.err), IsTimeout(tc.err), IsTemporary(tc.err)
+
+		if cancel == tc.cancel && timeout == tc.timeout && temporary == tc.temporary {
+			t.Logf("[%v/%v] %v → %v %v %v", i, len(tests), tc.err,
+				cancel, timeout, temporary)
+		} else {
+			t.Errorf("[%v/%v] ERROR: %v → %v %v %v (expected %v %v %v)", i, len(tests), tc.err,
+				cancel, timeout, temporary,
+				tc.cancel, tc.timeout, tc.temporary)
+		}
+	}
+}
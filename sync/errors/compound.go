@@ -0,0 +1,115 @@
+// Package errors provides error types and helpers
+// for concurrent code.
+package errors
+
+import (
+	"sync"
+
+	"darvaza.org/core"
+)
+
+var _ core.Errors = (*CompoundError)(nil)
+
+// CompoundError is a thread-safe collection of errors, allowing
+// goroutines to report their failures concurrently.
+//
+// The zero value is ready for use.
+type CompoundError struct {
+	mu   sync.Mutex
+	errs core.CompoundError
+}
+
+// Error returns the combined text of the collected errors.
+func (ce *CompoundError) Error() string {
+	if ce == nil {
+		return ""
+	}
+
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	return ce.errs.Error()
+}
+
+// Errors returns a copy of the collected errors
+func (ce *CompoundError) Errors() []error {
+	if ce == nil {
+		return nil
+	}
+
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	return core.SliceCopy(ce.errs.Errs)
+}
+
+// Unwrap returns a copy of the collected errors
+func (ce *CompoundError) Unwrap() []error {
+	return ce.Errors()
+}
+
+// Len returns the number of collected errors
+func (ce *CompoundError) Len() int {
+	if ce == nil {
+		return 0
+	}
+
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	return len(ce.errs.Errs)
+}
+
+// Ok tells when there are no errors stored
+func (ce *CompoundError) Ok() bool {
+	return ce.Len() == 0
+}
+
+// AsError returns a snapshot of the collected errors as
+// a [core.CompoundError], or nil if there are none.
+func (ce *CompoundError) AsError() error {
+	if errs := ce.Errors(); len(errs) > 0 {
+		return &core.CompoundError{Errs: errs}
+	}
+	return nil
+}
+
+// AppendError adds errors to the collection,
+// unwrapping other implementers of the [core.Errors]
+// interface when possible
+func (ce *CompoundError) AppendError(errs ...error) {
+	if ce != nil {
+		ce.mu.Lock()
+		defer ce.mu.Unlock()
+
+		ce.errs.AppendError(errs...)
+	}
+}
+
+// Append adds an error to the collection optionally annotated by a formatted string.
+// if err is nil a new error is created unless the note is empty.
+func (ce *CompoundError) Append(err error, note string, args ...any) {
+	if ce != nil {
+		ce.mu.Lock()
+		defer ce.mu.Unlock()
+
+		ce.errs.Append(err, note, args...)
+	}
+}
+
+// Collect adds an error to the collection. It can be used
+// directly as [core.ErrGroup.OnError] handler to gather all
+// the errors of a group instead of only the first.
+func (ce *CompoundError) Collect(err error) {
+	ce.AppendError(err)
+}
+
+// Reset removes all collected errors
+func (ce *CompoundError) Reset() {
+	if ce != nil {
+		ce.mu.Lock()
+		defer ce.mu.Unlock()
+
+		ce.errs.Errs = nil
+	}
+}
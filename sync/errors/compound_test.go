@@ -0,0 +1,102 @@
+package errors
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+var errTest = errors.New("test error")
+
+func TestCompoundErrorEmpty(t *testing.T) {
+	var ce CompoundError
+
+	if err := ce.AsError(); err != nil {
+		t.Errorf("ERROR: AsError() on empty → %v (expected nil)", err)
+	}
+	if !ce.Ok() || ce.Len() != 0 {
+		t.Error("ERROR: empty collection not Ok()")
+	}
+
+	ce.AppendError(nil)
+	ce.Append(nil, "")
+	if err := ce.AsError(); err != nil {
+		t.Errorf("ERROR: AsError() after appending nil → %v (expected nil)", err)
+	}
+
+	ce.Append(nil, "note %v", 1)
+	if ce.Len() != 1 {
+		t.Errorf("ERROR: Append(nil, note) → %v errors (expected 1)", ce.Len())
+	}
+
+	ce.Reset()
+	if err := ce.AsError(); err != nil {
+		t.Errorf("ERROR: AsError() after Reset() → %v (expected nil)", err)
+	}
+
+	var nilErrs *CompoundError
+	nilErrs.AppendError(errors.New("x"))
+	if nilErrs.AsError() != nil || nilErrs.Len() != 0 || nilErrs.Error() != "" {
+		t.Error("ERROR: nil collection not empty")
+	}
+}
+
+func TestCompoundErrorIs(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	errC := errors.New("c")
+
+	var ce CompoundError
+	ce.AppendError(errA)
+	ce.Append(errB, "while doing %s", "b")
+	ce.AppendError(&core.CompoundError{Errs: []error{core.Wrap(errC, "c")}})
+
+	if n := ce.Len(); n != 3 {
+		t.Errorf("ERROR: Len() → %v (expected 3)", n)
+	}
+
+	for _, target := range []error{errA, errB, errC} {
+		if !errors.Is(&ce, target) {
+			t.Errorf("ERROR: errors.Is(collection, %v) failed", target)
+		}
+		if !errors.Is(ce.AsError(), target) {
+			t.Errorf("ERROR: errors.Is(AsError(), %v) failed", target)
+		}
+	}
+	if errors.Is(&ce, core.ErrInvalid) {
+		t.Error("ERROR: errors.Is matched an error not collected")
+	}
+}
+
+func TestCompoundErrorConcurrent(t *testing.T) {
+	const workers = 8
+	const n = 100
+
+	var ce CompoundError
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				switch i % 3 {
+				case 0:
+					ce.Append(errTest, "worker %v", w)
+				case 1:
+					ce.AppendError(errTest)
+				default:
+					ce.Collect(errTest)
+				}
+				_ = ce.Error()
+				_ = ce.Errors()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := ce.Len(); got != workers*n {
+		t.Errorf("ERROR: Len() → %v (expected %v)", got, workers*n)
+	}
+}
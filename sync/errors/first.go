@@ -0,0 +1,41 @@
+package errors
+
+import "sync"
+
+// FirstError records the first error reported by concurrent
+// workers, ignoring nil and any later ones.
+//
+// The zero value is ready for use.
+type FirstError struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Set records err unless it's nil or an error was already recorded.
+// It returns true if err was recorded.
+func (fe *FirstError) Set(err error) bool {
+	if fe == nil || err == nil {
+		return false
+	}
+
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+
+	if fe.err != nil {
+		return false
+	}
+	fe.err = err
+	return true
+}
+
+// Err returns the recorded error, if any.
+func (fe *FirstError) Err() error {
+	if fe == nil {
+		return nil
+	}
+
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+
+	return fe.err
+}
@@ -0,0 +1,55 @@
+package errors
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestFirstError(t *testing.T) {
+	var fe FirstError
+
+	if fe.Set(nil) || fe.Err() != nil {
+		t.Error("ERROR: nil recorded")
+	}
+
+	other := errors.New("other error")
+	if !fe.Set(errTest) {
+		t.Error("ERROR: first error not recorded")
+	}
+	if fe.Set(other) {
+		t.Error("ERROR: second error recorded")
+	}
+	if err := fe.Err(); err != errTest {
+		t.Errorf("ERROR: Err() → %v (expected %v)", err, errTest)
+	}
+
+	var nilFE *FirstError
+	if nilFE.Set(errTest) || nilFE.Err() != nil {
+		t.Error("ERROR: nil receiver recorded an error")
+	}
+}
+
+func TestFirstErrorConcurrent(t *testing.T) {
+	var fe FirstError
+	var wg sync.WaitGroup
+	var count int
+	var mu sync.Mutex
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if fe.Set(errTest) {
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count != 1 {
+		t.Errorf("ERROR: %v errors recorded (expected 1)", count)
+	}
+}
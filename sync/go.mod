@@ -0,0 +1,10 @@
+module darvaza.org/x/sync
+
+go 1.22
+
+require darvaza.org/core v0.16.0
+
+require (
+	golang.org/x/net v0.34.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
@@ -0,0 +1,42 @@
+package sync
+
+import (
+	"context"
+
+	"darvaza.org/core"
+
+	"darvaza.org/x/sync/once"
+)
+
+// Lazy holds a value computed on first use, and cached until
+// it's invalidated. Failed attempts aren't cached.
+type Lazy[T any] struct {
+	v once.Value[T]
+}
+
+// NewLazy creates a [Lazy] value using the given function to
+// compute it.
+func NewLazy[T any](fn func(context.Context) (T, error)) *Lazy[T] {
+	l := new(Lazy[T])
+	l.v.New = fn
+	return l
+}
+
+// Get returns the cached value, or computes it if needed.
+// Concurrent callers wait for the same computation, or until
+// their context is cancelled.
+func (l *Lazy[T]) Get(ctx context.Context) (T, error) {
+	if l == nil {
+		var zero T
+		return zero, core.ErrNilReceiver
+	}
+	return l.v.Get(ctx)
+}
+
+// Invalidate discards the cached value so it's computed again on
+// the next call to [Lazy.Get].
+func (l *Lazy[T]) Invalidate() {
+	if l != nil {
+		l.v.Reset()
+	}
+}
@@ -0,0 +1,93 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLazyConcurrent(t *testing.T) {
+	var calls atomic.Int32
+
+	l := NewLazy(func(context.Context) (int32, error) {
+		n := calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return n, nil
+	})
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := l.Get(context.Background()); err != nil || v != 1 {
+				t.Errorf("ERROR: Get() → %v, %v (expected 1)", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ERROR: computed %v times (expected 1)", n)
+	}
+}
+
+func TestLazyError(t *testing.T) {
+	var calls int
+
+	l := NewLazy(func(context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errTask
+		}
+		return calls, nil
+	})
+
+	if _, err := l.Get(context.Background()); !errors.Is(err, errTask) {
+		t.Errorf("ERROR: Get() → %v (expected %v)", err, errTask)
+	}
+	for range 2 {
+		if v, err := l.Get(context.Background()); err != nil || v != 2 {
+			t.Errorf("ERROR: Get() after failure → %v, %v (expected 2)", v, err)
+		}
+	}
+
+	l.Invalidate()
+	if v, _ := l.Get(context.Background()); v != 3 {
+		t.Errorf("ERROR: Get() after Invalidate() → %v (expected 3)", v)
+	}
+	if calls != 3 {
+		t.Errorf("ERROR: computed %v times (expected 3)", calls)
+	}
+}
+
+func TestLazyCancel(t *testing.T) {
+	release := make(chan struct{})
+	l := NewLazy(func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+
+	go func() { _, _ = l.Get(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+
+	// waiters give up with their context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ERROR: Get() → %v (expected %v)", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if v, err := l.Get(context.Background()); err != nil || v != 1 {
+		t.Errorf("ERROR: Get() → %v, %v (expected 1)", v, err)
+	}
+
+	var nilLazy *Lazy[int]
+	if _, err := nilLazy.Get(context.Background()); err == nil {
+		t.Error("ERROR: Get() on nil succeeded")
+	}
+}
@@ -0,0 +1,95 @@
+package mutex
+
+import (
+	"context"
+)
+
+var (
+	_ MutexContext   = mutexContext{}
+	_ RWMutexContext = rwMutexContext{}
+)
+
+// AsMutexContext returns a [MutexContext] view of a standard
+// [Mutex], like [sync.Mutex]. If the given lock already
+// implements [MutexContext] it is returned as-is.
+func AsMutexContext(mu Mutex) MutexContext {
+	switch m := mu.(type) {
+	case nil:
+		return nil
+	case MutexContext:
+		return m
+	default:
+		return mutexContext{m}
+	}
+}
+
+// AsRWMutexContext returns a [RWMutexContext] view of a standard
+// [RWMutex], like [sync.RWMutex]. If the given lock already
+// implements [RWMutexContext] it is returned as-is.
+func AsRWMutexContext(mu RWMutex) RWMutexContext {
+	switch m := mu.(type) {
+	case nil:
+		return nil
+	case RWMutexContext:
+		return m
+	default:
+		return rwMutexContext{m}
+	}
+}
+
+type mutexContext struct {
+	Mutex
+}
+
+func (m mutexContext) LockContext(ctx context.Context) error {
+	return lockContext(ctx, m.TryLock, m.Lock, m.Unlock)
+}
+
+type rwMutexContext struct {
+	RWMutex
+}
+
+func (m rwMutexContext) LockContext(ctx context.Context) error {
+	return lockContext(ctx, m.TryLock, m.Lock, m.Unlock)
+}
+
+func (m rwMutexContext) RLockContext(ctx context.Context) error {
+	return lockContext(ctx, m.TryRLock, m.RLock, m.RUnlock)
+}
+
+// lockContext acquires a lock honouring the context.
+// If the fast path fails, a helper goroutine blocks on the
+// standard lock and hands it over via a channel. When the context
+// is cancelled first, the helper releases the lock as soon as it
+// gets it.
+func lockContext(ctx context.Context, try func() bool, lock, unlock func()) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return context.Cause(ctx)
+	case try():
+		return nil
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		lock()
+		select {
+		case acquired <- struct{}{}:
+			// handed over
+		case <-ctx.Done():
+			// abandoned
+			unlock()
+		}
+	}()
+
+	select {
+	case <-acquired:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
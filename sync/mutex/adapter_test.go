@@ -0,0 +1,60 @@
+package mutex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockContext(t *testing.T) {
+	var mu sync.Mutex
+
+	m := AsMutexContext(&mu)
+	if err := m.LockContext(context.Background()); err != nil {
+		t.Fatalf("LockContext: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := m.LockContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LockContext on held lock: %v (expected %v)", err, context.DeadlineExceeded)
+	}
+
+	m.Unlock()
+
+	// the abandoned attempt must not keep the lock
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+
+	if err := m.LockContext(ctx2); err != nil {
+		t.Errorf("LockContext after release: %v", err)
+	} else {
+		m.Unlock()
+	}
+}
+
+func TestRLockContext(t *testing.T) {
+	var mu sync.RWMutex
+
+	m := AsRWMutexContext(&mu)
+	if err := m.RLockContext(context.Background()); err != nil {
+		t.Fatalf("RLockContext: %v", err)
+	}
+	if err := m.RLockContext(context.Background()); err != nil {
+		t.Fatalf("RLockContext shared: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := m.LockContext(ctx); err == nil {
+		t.Errorf("LockContext succeeded while read-locked")
+	}
+
+	m.RUnlock()
+	m.RUnlock()
+}
@@ -0,0 +1,61 @@
+package mutex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	xerrors "darvaza.org/x/sync/errors"
+)
+
+func TestTryLock(t *testing.T) {
+	var a, b, c sync.Mutex
+
+	b.Lock()
+	if TryLock(&a, &b, &c) {
+		t.Fatal("ERROR: TryLock() succeeded with a held lock")
+	}
+
+	// a must have been released
+	if !a.TryLock() {
+		t.Error("ERROR: TryLock() didn't release the acquired locks")
+	} else {
+		a.Unlock()
+	}
+	b.Unlock()
+
+	if !TryLock(&a, &b, &c) {
+		t.Fatal("ERROR: TryLock() failed with free locks")
+	}
+	Unlock(&a, &b, &c)
+
+	Lock(&a, &b)
+	if !TryRLock[Mutex](&c) {
+		t.Error("ERROR: TryRLock() failed on a free lock")
+	}
+	Unlock(&a, &b)
+	RUnlock[Mutex](&c)
+}
+
+func TestSafeLockNil(t *testing.T) {
+	var nilMutex *sync.Mutex
+	var nilLocker Mutex
+
+	if _, err := SafeLock(nilLocker); !errors.Is(err, xerrors.ErrNilMutex) {
+		t.Errorf("ERROR: SafeLock(nil) → %v (expected %v)", err, xerrors.ErrNilMutex)
+	}
+	if _, err := SafeLockContext[MutexContext](nil, nil); !errors.Is(err, xerrors.ErrNilContext) {
+		t.Errorf("ERROR: SafeLockContext(nil) → %v (expected %v)", err, xerrors.ErrNilContext)
+	}
+
+	// a typed nil panics, reported as an error
+	if ok, err := SafeLock(nilMutex); ok || err == nil {
+		t.Errorf("ERROR: SafeLock(typed nil) → %v, %v", ok, err)
+	}
+
+	ok, err := SafeLockContext(context.Background(), AsMutexContext(new(sync.Mutex)))
+	if !ok || err != nil {
+		t.Errorf("ERROR: SafeLockContext() → %v, %v", ok, err)
+	}
+}
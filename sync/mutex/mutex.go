@@ -0,0 +1,50 @@
+// Package mutex provides interfaces, adapters and helpers
+// for standard and context-aware locks.
+package mutex
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	_ Mutex   = (*sync.Mutex)(nil)
+	_ RWMutex = (*sync.RWMutex)(nil)
+)
+
+// Mutex represents a standard mutual exclusion lock.
+type Mutex interface {
+	Lock()
+	TryLock() bool
+	Unlock()
+}
+
+// MutexContext is a [Mutex] that can also be acquired
+// while observing a [context.Context].
+type MutexContext interface {
+	Mutex
+
+	// LockContext attempts to acquire the lock until
+	// the context is cancelled.
+	LockContext(context.Context) error
+}
+
+// RWMutex represents a standard reader/writer mutual exclusion lock.
+type RWMutex interface {
+	Mutex
+
+	RLock()
+	TryRLock() bool
+	RUnlock()
+}
+
+// RWMutexContext is a [RWMutex] that can also be acquired
+// while observing a [context.Context].
+type RWMutexContext interface {
+	RWMutex
+	MutexContext
+
+	// RLockContext attempts to acquire a read lock until
+	// the context is cancelled.
+	RLockContext(context.Context) error
+}
@@ -0,0 +1,142 @@
+package mutex
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	_ Mutex   = (*Checked)(nil)
+	_ RWMutex = (*RWChecked)(nil)
+)
+
+// LockOrderError indicates two locks have been acquired
+// in opposite orders, a potential deadlock.
+type LockOrderError struct {
+	// Held is the lock already held
+	Held string
+	// Acquired is the lock being acquired
+	Acquired string
+}
+
+func (e *LockOrderError) Error() string {
+	return fmt.Sprintf("lock order inversion: %q acquired while holding %q, previously acquired the other way around",
+		e.Acquired, e.Held)
+}
+
+var lockOrderHandler atomic.Pointer[func(error)]
+
+// SetLockOrderHandler sets the function called when a lock order
+// inversion is detected, like [testing.T.Error]. If nil, the
+// default handler panics.
+//
+// Checks are only done when built with the `lockorder` tag.
+func SetLockOrderHandler(fn func(error)) {
+	if fn == nil {
+		lockOrderHandler.Store(nil)
+	} else {
+		lockOrderHandler.Store(&fn)
+	}
+}
+
+func reportLockOrder(held, acquired string) {
+	err := &LockOrderError{Held: held, Acquired: acquired}
+	if fn := lockOrderHandler.Load(); fn != nil {
+		(*fn)(err)
+	} else {
+		panic(err)
+	}
+}
+
+// Checked is a [sync.Mutex] instrumented to detect lock order
+// inversions when built with the `lockorder` tag. Otherwise it's
+// a plain mutex.
+type Checked struct {
+	name string
+	mu   sync.Mutex
+}
+
+// NewChecked creates a named [Checked] mutex.
+func NewChecked(name string) *Checked {
+	return &Checked{name: name}
+}
+
+// Lock locks the mutex.
+func (m *Checked) Lock() {
+	lockOrderAcquire(m, m.name)
+	m.mu.Lock()
+	lockOrderAcquired(m)
+}
+
+// TryLock tries to lock the mutex without blocking.
+func (m *Checked) TryLock() bool {
+	if m.mu.TryLock() {
+		lockOrderAcquired(m)
+		return true
+	}
+	return false
+}
+
+// Unlock unlocks the mutex.
+func (m *Checked) Unlock() {
+	lockOrderRelease(m)
+	m.mu.Unlock()
+}
+
+// RWChecked is a [sync.RWMutex] instrumented to detect lock order
+// inversions when built with the `lockorder` tag. Otherwise it's
+// a plain reader/writer mutex.
+type RWChecked struct {
+	name string
+	mu   sync.RWMutex
+}
+
+// NewRWChecked creates a named [RWChecked] mutex.
+func NewRWChecked(name string) *RWChecked {
+	return &RWChecked{name: name}
+}
+
+// Lock locks the mutex for writing.
+func (m *RWChecked) Lock() {
+	lockOrderAcquire(m, m.name)
+	m.mu.Lock()
+	lockOrderAcquired(m)
+}
+
+// TryLock tries to lock the mutex for writing without blocking.
+func (m *RWChecked) TryLock() bool {
+	if m.mu.TryLock() {
+		lockOrderAcquired(m)
+		return true
+	}
+	return false
+}
+
+// Unlock unlocks the mutex for writing.
+func (m *RWChecked) Unlock() {
+	lockOrderRelease(m)
+	m.mu.Unlock()
+}
+
+// RLock locks the mutex for reading.
+func (m *RWChecked) RLock() {
+	lockOrderAcquire(m, m.name)
+	m.mu.RLock()
+	lockOrderAcquired(m)
+}
+
+// TryRLock tries to lock the mutex for reading without blocking.
+func (m *RWChecked) TryRLock() bool {
+	if m.mu.TryRLock() {
+		lockOrderAcquired(m)
+		return true
+	}
+	return false
+}
+
+// RUnlock unlocks the mutex for reading.
+func (m *RWChecked) RUnlock() {
+	lockOrderRelease(m)
+	m.mu.RUnlock()
+}
@@ -0,0 +1,116 @@
+//go:build lockorder
+
+package mutex
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// LockOrderCheck tells if lock order checks are enabled
+const LockOrderCheck = true
+
+type lockEdge struct {
+	from, to any
+}
+
+// lockOrder records the locks held by each goroutine
+// and the order in which locks have been taken.
+var lockOrder = struct {
+	held  map[uint64][]any
+	names map[any]string
+	edges map[lockEdge]bool
+	mu    sync.Mutex
+}{
+	held:  make(map[uint64][]any),
+	names: make(map[any]string),
+	edges: make(map[lockEdge]bool),
+}
+
+// lockOrderAcquire checks the locks already held by the current
+// goroutine were never taken after this one.
+func lockOrderAcquire(id any, name string) {
+	var inverted []string
+
+	gid := goroutineID()
+
+	lockOrder.mu.Lock()
+	lockOrder.names[id] = name
+	for _, h := range lockOrder.held[gid] {
+		switch {
+		case h == id:
+			// recursive, the mutex will catch it
+		case lockOrder.edges[lockEdge{id, h}]:
+			inverted = append(inverted, lockOrder.names[h])
+		default:
+			lockOrder.edges[lockEdge{h, id}] = true
+		}
+	}
+	lockOrder.mu.Unlock()
+
+	// report outside the lock
+	for _, held := range inverted {
+		reportLockOrder(held, name)
+	}
+}
+
+// lockOrderAcquired adds a lock to the current goroutine's list
+func lockOrderAcquired(id any) {
+	gid := goroutineID()
+
+	lockOrder.mu.Lock()
+	lockOrder.held[gid] = append(lockOrder.held[gid], id)
+	lockOrder.mu.Unlock()
+}
+
+// lockOrderRelease removes a lock from the current goroutine's list,
+// or from any other if it was locked elsewhere.
+func lockOrderRelease(id any) {
+	gid := goroutineID()
+
+	lockOrder.mu.Lock()
+	defer lockOrder.mu.Unlock()
+
+	if unsafeLockOrderRemove(gid, id) {
+		return
+	}
+
+	for g := range lockOrder.held {
+		if unsafeLockOrderRemove(g, id) {
+			return
+		}
+	}
+}
+
+func unsafeLockOrderRemove(gid uint64, id any) bool {
+	held := lockOrder.held[gid]
+	for i := len(held) - 1; i >= 0; i-- {
+		if held[i] == id {
+			held = append(held[:i], held[i+1:]...)
+			if len(held) == 0 {
+				delete(lockOrder.held, gid)
+			} else {
+				lockOrder.held[gid] = held
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// goroutineID extracts the current goroutine's id from
+// its stack trace header.
+func goroutineID() uint64 {
+	var buf [64]byte
+
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
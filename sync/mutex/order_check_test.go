@@ -0,0 +1,41 @@
+//go:build lockorder
+
+package mutex
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLockOrderInversion(t *testing.T) {
+	var reported []error
+
+	SetLockOrderHandler(func(err error) { reported = append(reported, err) })
+	defer SetLockOrderHandler(nil)
+
+	a, b := NewChecked("a"), NewRWChecked("b")
+
+	a.Lock()
+	b.Lock()
+	b.Unlock()
+	a.Unlock()
+
+	if len(reported) != 0 {
+		t.Fatalf("ERROR: unexpected report: %v", reported)
+	}
+
+	b.RLock()
+	a.Lock()
+	a.Unlock()
+	b.RUnlock()
+
+	var e *LockOrderError
+	switch {
+	case len(reported) != 1:
+		t.Fatalf("ERROR: %v reports (expected 1)", len(reported))
+	case !errors.As(reported[0], &e):
+		t.Fatalf("ERROR: unexpected report type: %T", reported[0])
+	case e.Held != "b" || e.Acquired != "a":
+		t.Errorf("ERROR: unexpected report: %v", e)
+	}
+}
@@ -0,0 +1,84 @@
+// Package once provides variants of [sync.Once] for
+// initialisations that can fail or need to be repeated.
+package once
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"darvaza.org/core"
+)
+
+// Resettable is like [sync.Once] but it can be reset
+// to allow reinitialisation, and [Resettable.DoErr]
+// only considers the task done when it succeeds.
+//
+// The zero value is ready for use.
+type Resettable struct {
+	mu   sync.Mutex
+	done atomic.Bool
+}
+
+// Done tells if the task has been successfully done
+// since the last reset.
+func (o *Resettable) Done() bool {
+	if o == nil {
+		return false
+	}
+	return o.done.Load()
+}
+
+// Do calls fn if the task wasn't done yet. Like [sync.Once.Do],
+// a panicking fn is considered done.
+func (o *Resettable) Do(fn func()) {
+	if o == nil {
+		core.Panic(core.ErrNilReceiver)
+	} else if o.done.Load() {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.done.Load() {
+		defer o.done.Store(true)
+		fn()
+	}
+}
+
+// DoErr calls fn if the task wasn't done yet, returning its error.
+// The task is only marked as done when fn returns nil, allowing
+// future calls to try again after a failure. If fn panics
+// the task isn't marked as done either.
+func (o *Resettable) DoErr(fn func() error) error {
+	switch {
+	case o == nil:
+		return core.ErrNilReceiver
+	case o.done.Load():
+		return nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.done.Load() {
+		return nil
+	}
+
+	err := fn()
+	if err == nil {
+		o.done.Store(true)
+	}
+	return err
+}
+
+// Reset marks the task as not done so the next call
+// to [Resettable.Do] or [Resettable.DoErr] runs again.
+// Reset waits for any in-progress call to finish.
+func (o *Resettable) Reset() {
+	if o != nil {
+		o.mu.Lock()
+		o.done.Store(false)
+		o.mu.Unlock()
+	}
+}
@@ -0,0 +1,141 @@
+package once
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResettableDoErr(t *testing.T) {
+	var o Resettable
+	var calls int
+
+	fn := func() error {
+		calls++
+		if calls == 1 {
+			return errTest
+		}
+		return nil
+	}
+
+	if err := o.DoErr(fn); err != errTest {
+		t.Errorf("ERROR: DoErr() → %v (expected %v)", err, errTest)
+	}
+	if err := o.DoErr(fn); err != nil || !o.Done() {
+		t.Errorf("ERROR: DoErr() → %v, done:%v", err, o.Done())
+	}
+	_ = o.DoErr(fn)
+
+	o.Reset()
+	_ = o.DoErr(fn)
+
+	if calls != 3 {
+		t.Errorf("ERROR: fn called %v times (expected %v)", calls, 3)
+	}
+}
+
+func TestResettableDoErrPanic(t *testing.T) {
+	var o Resettable
+
+	// a panic isn't done
+	func() {
+		defer func() { _ = recover() }()
+		_ = o.DoErr(func() error { panic("boom") })
+	}()
+	if o.Done() {
+		t.Error("ERROR: DoErr() panicking marked as done")
+	}
+
+	if err := o.DoErr(func() error { return nil }); err != nil || !o.Done() {
+		t.Errorf("ERROR: DoErr() after panic → %v, done:%v", err, o.Done())
+	}
+
+	var nilOnce *Resettable
+	if err := nilOnce.DoErr(func() error { return nil }); err == nil {
+		t.Error("ERROR: DoErr() on nil succeeded")
+	}
+}
+
+func TestResettableWaits(t *testing.T) {
+	var o Resettable
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go o.Do(func() {
+		calls.Add(1)
+		close(started)
+		<-release
+	})
+	<-started
+
+	// callers block until the first run finishes
+	var wg sync.WaitGroup
+	var returned atomic.Int32
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.Do(func() { calls.Add(1) })
+			returned.Add(1)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if n := returned.Load(); n != 0 {
+		t.Errorf("ERROR: %v callers returned during the first run", n)
+	}
+
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ERROR: %v calls (expected 1)", n)
+	}
+}
+
+func TestResettableReset(t *testing.T) {
+	var o Resettable
+	var calls, running atomic.Int32
+
+	fn := func() {
+		if running.Add(1) > 1 {
+			t.Error("ERROR: concurrent runs")
+		}
+		calls.Add(1)
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+	}
+
+	o.Do(fn)
+	o.Reset()
+	if o.Done() {
+		t.Error("ERROR: Done() after Reset()")
+	}
+	o.Do(fn)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("ERROR: %v calls (expected 2)", n)
+	}
+
+	// Reset while callers run
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			o.Do(fn)
+		}()
+		go func() {
+			defer wg.Done()
+			o.Reset()
+		}()
+	}
+	wg.Wait()
+
+	if !o.Done() {
+		o.Do(fn)
+	}
+	if !o.Done() {
+		t.Error("ERROR: not done after Do()")
+	}
+}
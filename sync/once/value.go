@@ -0,0 +1,147 @@
+package once
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// ErrNoNew indicates a [Value] without a New function.
+var ErrNoNew = core.Wrap(core.ErrInvalid, "New function not provided")
+
+// Value lazily computes a value once, caching the successful
+// result. Failed attempts are retried on the next call unless
+// Retry says otherwise.
+//
+// The New and Retry fields must be set before the first call
+// to [Value.Get].
+type Value[T any] struct {
+	// New computes the value using the context of the
+	// first caller.
+	New func(context.Context) (T, error)
+
+	// Retry decides if a failed attempt can be retried. When
+	// it returns false the error is cached as the result.
+	// If nil, all errors are retried. Context errors, panics and
+	// attempts whose caller's context was cancelled are always
+	// retried.
+	Retry func(error) bool
+
+	mu   sync.Mutex
+	call *valueCall[T]
+	val  T
+	err  error
+	done bool
+}
+
+type valueCall[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+
+	// cancelled indicates the attempt failed after the context
+	// of its caller was cancelled
+	cancelled bool
+}
+
+// Get returns the cached value, or computes it. Callers arriving while
+// the value is being computed wait for that attempt to finish, or
+// until their context is cancelled. If the attempt fails because of
+// the context of the caller computing it, waiters whose contexts are
+// still alive try again with their own.
+func (v *Value[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+
+	if v == nil {
+		return zero, core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	v.mu.Lock()
+	switch {
+	case v.done:
+		val, err := v.val, v.err
+		v.mu.Unlock()
+		return val, err
+	case v.call != nil:
+		c := v.call
+		v.mu.Unlock()
+		return v.wait(ctx, c)
+	case v.New == nil:
+		v.mu.Unlock()
+		return zero, ErrNoNew
+	default:
+		c := &valueCall[T]{done: make(chan struct{})}
+		v.call = c
+		v.mu.Unlock()
+		return v.run(ctx, c)
+	}
+}
+
+func (v *Value[T]) wait(ctx context.Context, c *valueCall[T]) (T, error) {
+	var zero T
+
+	select {
+	case <-c.done:
+		if !c.cancelled {
+			return c.val, c.err
+		}
+	case <-ctx.Done():
+		return zero, context.Cause(ctx)
+	}
+
+	// the attempt was cancelled through someone else's context
+	if ctx.Err() != nil {
+		return zero, context.Cause(ctx)
+	}
+	return v.Get(ctx)
+}
+
+func (v *Value[T]) run(ctx context.Context, c *valueCall[T]) (T, error) {
+	var catcher core.Catcher
+
+	c.err = catcher.Do(func() error {
+		var err error
+		c.val, err = v.New(ctx)
+		return err
+	})
+
+	c.cancelled = c.err != nil && ctx.Err() != nil
+
+	v.mu.Lock()
+	v.call = nil
+	if c.err == nil || !v.canRetry(c.err, catcher.Recovered() != nil || c.cancelled) {
+		v.val, v.err, v.done = c.val, c.err, true
+	}
+	close(c.done)
+	v.mu.Unlock()
+
+	return c.val, c.err
+}
+
+func (v *Value[T]) canRetry(err error, force bool) bool {
+	switch {
+	case force, v.Retry == nil:
+		return true
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return true
+	default:
+		return v.Retry(err)
+	}
+}
+
+// Reset forgets the cached result, if any, so the next call to
+// [Value.Get] computes it again. An attempt in progress isn't
+// affected.
+func (v *Value[T]) Reset() {
+	if v != nil {
+		var zero T
+
+		v.mu.Lock()
+		v.val, v.err, v.done = zero, nil, false
+		v.mu.Unlock()
+	}
+}
@@ -0,0 +1,131 @@
+package once
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTest = errors.New("test error")
+
+func TestValueRetry(t *testing.T) {
+	var calls int
+
+	v := &Value[int]{
+		New: func(context.Context) (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, errTest
+			}
+			return calls, nil
+		},
+	}
+
+	for i, expected := range []error{errTest, errTest, nil, nil} {
+		n, err := v.Get(context.Background())
+		switch {
+		case err != expected:
+			t.Errorf("[%v] ERROR: Get() → %v, %v (expected %v)", i, n, err, expected)
+		case err == nil && n != 3:
+			t.Errorf("[%v] ERROR: Get() → %v (expected %v)", i, n, 3)
+		}
+	}
+
+	if calls != 3 {
+		t.Errorf("ERROR: New called %v times (expected %v)", calls, 3)
+	}
+}
+
+func TestValueNoRetry(t *testing.T) {
+	var calls int
+
+	v := &Value[int]{
+		New: func(context.Context) (int, error) {
+			calls++
+			return 0, errTest
+		},
+		Retry: func(error) bool { return false },
+	}
+
+	for range 3 {
+		if _, err := v.Get(context.Background()); err != errTest {
+			t.Errorf("ERROR: Get() → %v (expected %v)", err, errTest)
+		}
+	}
+
+	v.Reset()
+	_, _ = v.Get(context.Background())
+
+	if calls != 2 {
+		t.Errorf("ERROR: New called %v times (expected %v)", calls, 2)
+	}
+}
+
+func TestValueWaiterContext(t *testing.T) {
+	errFirst := errors.New("first cancelled")
+	errSecond := errors.New("second cancelled")
+
+	for _, tc := range []struct {
+		name     string
+		cancel   bool
+		expected error
+	}{
+		{"alive", false, nil},
+		{"cancelled", true, errSecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int
+			started := make(chan struct{})
+
+			v := &Value[int]{
+				New: func(ctx context.Context) (int, error) {
+					calls++
+					if calls > 1 {
+						return calls, nil
+					}
+
+					close(started)
+					<-ctx.Done()
+					return 0, context.Cause(ctx)
+				},
+			}
+
+			ctx1, cancel1 := context.WithCancelCause(context.Background())
+			ctx2, cancel2 := context.WithCancelCause(context.Background())
+			defer cancel2(nil)
+
+			first := make(chan error, 1)
+			go func() {
+				_, err := v.Get(ctx1)
+				first <- err
+			}()
+			<-started
+
+			second := make(chan error, 1)
+			go func() {
+				_, err := v.Get(ctx2)
+				second <- err
+			}()
+
+			// let the second caller start waiting
+			time.Sleep(10 * time.Millisecond)
+			if tc.cancel {
+				cancel2(errSecond)
+			}
+			cancel1(errFirst)
+
+			if err := <-first; !errors.Is(err, errFirst) {
+				t.Errorf("ERROR: first Get() → %v (expected %v)", err, errFirst)
+			}
+
+			err := <-second
+			switch {
+			case tc.expected == nil && err != nil:
+				t.Errorf("ERROR: second Get() → %v (expected nil)", err)
+			case tc.expected != nil && !errors.Is(err, tc.expected):
+				t.Errorf("ERROR: second Get() → %v (expected %v)", err, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package queue
+
+import (
+	"context"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// Bounded is a blocking FIFO queue with a fixed capacity.
+type Bounded[T any] struct {
+	mu       sync.Mutex
+	items    []T
+	notEmpty signal
+	notFull  signal
+	head     int
+	count    int
+	closed   bool
+}
+
+// NewBounded creates a [Bounded] queue able to hold the given
+// number of items.
+func NewBounded[T any](capacity int) (*Bounded[T], error) {
+	if capacity < 1 {
+		return nil, ErrInvalidCapacity
+	}
+
+	q := &Bounded[T]{
+		items: make([]T, capacity),
+	}
+	return q, nil
+}
+
+// Cap returns the capacity of the queue.
+func (q *Bounded[T]) Cap() int {
+	if q == nil {
+		return 0
+	}
+	return len(q.items)
+}
+
+// Len returns the number of items in the queue.
+func (q *Bounded[T]) Len() int {
+	if q == nil {
+		return 0
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.count
+}
+
+// Push adds an item to the end of the queue, waiting for room
+// if full until the context is cancelled. Push fails with
+// [ErrClosed] if the queue is closed.
+func (q *Bounded[T]) Push(ctx context.Context, v T) error {
+	if q == nil {
+		return core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		q.mu.Lock()
+		switch {
+		case q.closed:
+			q.mu.Unlock()
+			return ErrClosed
+		case q.unsafePush(v):
+			q.mu.Unlock()
+			return nil
+		}
+
+		ch := q.notFull.Wait()
+		q.mu.Unlock()
+
+		select {
+		case <-ch:
+			// try again
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+// TryPush adds an item to the end of the queue if there is room,
+// and it's not closed.
+func (q *Bounded[T]) TryPush(v T) bool {
+	if q == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return !q.closed && q.unsafePush(v)
+}
+
+func (q *Bounded[T]) unsafePush(v T) bool {
+	if q.count == len(q.items) {
+		return false
+	}
+
+	q.items[(q.head+q.count)%len(q.items)] = v
+	q.count++
+	q.notEmpty.Broadcast()
+	return true
+}
+
+// Pop removes an item from the front of the queue, waiting for one
+// to be available until the context is cancelled. Once closed, the
+// remaining items can still be popped before Pop fails with [ErrClosed].
+func (q *Bounded[T]) Pop(ctx context.Context) (T, error) {
+	var zero T
+
+	if q == nil {
+		return zero, core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		q.mu.Lock()
+		if v, ok := q.unsafePop(); ok {
+			q.mu.Unlock()
+			return v, nil
+		} else if q.closed {
+			q.mu.Unlock()
+			return zero, ErrClosed
+		}
+
+		ch := q.notEmpty.Wait()
+		q.mu.Unlock()
+
+		select {
+		case <-ch:
+			// try again
+		case <-ctx.Done():
+			return zero, context.Cause(ctx)
+		}
+	}
+}
+
+// TryPop removes an item from the front of the queue if
+// there is any.
+func (q *Bounded[T]) TryPop() (T, bool) {
+	if q == nil {
+		var zero T
+		return zero, false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.unsafePop()
+}
+
+func (q *Bounded[T]) unsafePop() (T, bool) {
+	var zero T
+
+	if q.count == 0 {
+		return zero, false
+	}
+
+	v := q.items[q.head]
+	q.items[q.head] = zero
+	q.head = (q.head + 1) % len(q.items)
+	q.count--
+	q.notFull.Broadcast()
+	return v, true
+}
+
+// Close prevents further pushes and wakes up all waiters.
+// Items already in the queue can still be popped.
+func (q *Bounded[T]) Close() error {
+	if q == nil {
+		return core.ErrNilReceiver
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	return nil
+}
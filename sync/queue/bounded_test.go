@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBounded(t *testing.T) {
+	q, err := NewBounded[int](2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !q.TryPush(1) || !q.TryPush(2) {
+		t.Fatal("ERROR: TryPush failed on empty queue")
+	}
+	if q.TryPush(3) {
+		t.Error("ERROR: TryPush succeeded on full queue")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Push(ctx, 3); err == nil {
+		t.Error("ERROR: Push didn't block on full queue")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = q.Close()
+	}()
+
+	for _, expected := range []int{1, 2} {
+		if v, err := q.Pop(context.Background()); err != nil || v != expected {
+			t.Errorf("ERROR: Pop() → %v, %v (expected %v)", v, err, expected)
+		}
+	}
+
+	if _, err := q.Pop(context.Background()); err != ErrClosed {
+		t.Errorf("ERROR: Pop() on closed queue → %v (expected %v)", err, ErrClosed)
+	}
+}
@@ -0,0 +1,140 @@
+package queue
+
+import (
+	"sync/atomic"
+)
+
+const minDequeCapacity = 16
+
+// Deque is a lock-free work-stealing double-ended queue, following the
+// Chase-Lev algorithm. A single owner goroutine pushes and pops at the
+// bottom, in LIFO order, while any other goroutine can steal from the
+// top, in FIFO order. The buffer grows as needed.
+type Deque[T any] struct {
+	top    atomic.Int64
+	bottom atomic.Int64
+	ring   atomic.Pointer[dequeRing[T]]
+}
+
+type dequeRing[T any] struct {
+	items []atomic.Pointer[T]
+	mask  int64
+}
+
+func newDequeRing[T any](size int64) *dequeRing[T] {
+	return &dequeRing[T]{
+		items: make([]atomic.Pointer[T], size),
+		mask:  size - 1,
+	}
+}
+
+func (r *dequeRing[T]) Len() int64 { return int64(len(r.items)) }
+
+func (r *dequeRing[T]) Get(i int64) *T { return r.items[i&r.mask].Load() }
+
+func (r *dequeRing[T]) Put(i int64, v *T) { r.items[i&r.mask].Store(v) }
+
+// Clear empties a slot unless it has been reused already.
+func (r *dequeRing[T]) Clear(i int64, v *T) { r.items[i&r.mask].CompareAndSwap(v, nil) }
+
+func (r *dequeRing[T]) Grow(top, bottom int64) *dequeRing[T] {
+	out := newDequeRing[T](2 * r.Len())
+	for i := top; i < bottom; i++ {
+		out.Put(i, r.Get(i))
+	}
+	return out
+}
+
+// NewDeque creates a [Deque] with an initial capacity, rounded up to
+// a power of two.
+func NewDeque[T any](capacity int) *Deque[T] {
+	size := int64(minDequeCapacity)
+	for size < int64(capacity) {
+		size <<= 1
+	}
+
+	q := new(Deque[T])
+	q.ring.Store(newDequeRing[T](size))
+	return q
+}
+
+// Len returns the approximate number of items in the deque.
+func (q *Deque[T]) Len() int {
+	n := q.bottom.Load() - q.top.Load()
+	return int(max(n, 0))
+}
+
+// Push adds an item at the bottom. Only the owner can call it.
+func (q *Deque[T]) Push(v T) {
+	b := q.bottom.Load()
+	t := q.top.Load()
+	r := q.ring.Load()
+
+	if b-t >= r.Len()-1 {
+		r = r.Grow(t, b)
+		q.ring.Store(r)
+	}
+
+	r.Put(b, &v)
+	q.bottom.Store(b + 1)
+}
+
+// Pop removes the item at the bottom, the most recently pushed.
+// Only the owner can call it.
+func (q *Deque[T]) Pop() (T, bool) {
+	var zero T
+
+	b := q.bottom.Load() - 1
+	r := q.ring.Load()
+	q.bottom.Store(b)
+
+	t := q.top.Load()
+	switch {
+	case t > b:
+		// empty
+		q.bottom.Store(b + 1)
+		return zero, false
+	case t == b:
+		// last item, race against thieves
+		p := r.Get(b)
+		won := q.top.CompareAndSwap(t, t+1)
+		q.bottom.Store(b + 1)
+		if !won {
+			return zero, false
+		}
+		r.Clear(b, p)
+		return *p, true
+	default:
+		p := r.Get(b)
+		r.Clear(b, p)
+		return *p, true
+	}
+}
+
+// Steal removes the item at the top, the oldest pushed. It's safe
+// to call from any goroutine. It can fail when racing against the
+// owner or other thieves even if the deque isn't empty.
+func (q *Deque[T]) Steal() (T, bool) {
+	var zero T
+
+	t := q.top.Load()
+	b := q.bottom.Load()
+	if t >= b {
+		// empty
+		return zero, false
+	}
+
+	r := q.ring.Load()
+	p := r.Get(t)
+	if !q.top.CompareAndSwap(t, t+1) {
+		// lost the race
+		return zero, false
+	}
+
+	// don't retain the item, in whichever ring holds it now
+	r.Clear(t, p)
+	if r2 := q.ring.Load(); r2 != r {
+		r2.Clear(t, p)
+	}
+	return *p, true
+}
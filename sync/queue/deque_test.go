@@ -0,0 +1,112 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDeque(t *testing.T) {
+	q := NewDeque[int](0)
+	const n = 3 * minDequeCapacity // forces growth
+
+	for i := 0; i < n; i++ {
+		q.Push(i)
+	}
+	if l := q.Len(); l != n {
+		t.Errorf("ERROR: Len() → %v (expected %v)", l, n)
+	}
+
+	if v, ok := q.Steal(); !ok || v != 0 {
+		t.Errorf("ERROR: Steal() → %v, %v (expected %v)", v, ok, 0)
+	}
+	if v, ok := q.Pop(); !ok || v != n-1 {
+		t.Errorf("ERROR: Pop() → %v, %v (expected %v)", v, ok, n-1)
+	}
+
+	for i := n - 2; i > 0; i-- {
+		if v, ok := q.Pop(); !ok || v != i {
+			t.Fatalf("ERROR: Pop() → %v, %v (expected %v)", v, ok, i)
+		}
+	}
+
+	if v, ok := q.Pop(); ok {
+		t.Errorf("ERROR: Pop() on empty deque → %v", v)
+	}
+	if v, ok := q.Steal(); ok {
+		t.Errorf("ERROR: Steal() on empty deque → %v", v)
+	}
+
+	// nothing retained
+	r := q.ring.Load()
+	for i := range r.items {
+		if p := r.items[i].Load(); p != nil {
+			t.Errorf("ERROR: slot %v retains %v", i, *p)
+		}
+	}
+}
+
+func popAll(q *Deque[int], out []int, limit int) []int {
+	for ; limit > 0; limit-- {
+		v, ok := q.Pop()
+		if !ok {
+			break
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestDequeConcurrent(t *testing.T) {
+	const (
+		n       = 50000
+		thieves = 4
+	)
+
+	q := NewDeque[int](0)
+	seen := make([][]int, thieves+1)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 1; i <= thieves; i++ {
+		wg.Add(1)
+		go func(out *[]int) {
+			defer wg.Done()
+			for {
+				if v, ok := q.Steal(); ok {
+					*out = append(*out, v)
+					continue
+				}
+
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		}(&seen[i])
+	}
+
+	// the owner pushes in bursts larger than the ring, so it grows
+	// while being stolen from, popping some of them back
+	for i := 0; i < n; i++ {
+		q.Push(i)
+		if i%(4*minDequeCapacity) == 0 {
+			seen[0] = popAll(q, seen[0], minDequeCapacity)
+		}
+	}
+	seen[0] = popAll(q, seen[0], n)
+	close(done)
+	wg.Wait()
+
+	count := make([]int, n)
+	for _, s := range seen {
+		for _, v := range s {
+			count[v]++
+		}
+	}
+	for v, c := range count {
+		if c != 1 {
+			t.Errorf("ERROR: %v taken %v times (expected 1)", v, c)
+		}
+	}
+}
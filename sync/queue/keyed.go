@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// Keyed is a work queue where items are identified by key, and
+// duplicates are coalesced. A key added while already pending is
+// ignored, and a key added while being processed is queued again
+// only once that processing is [Keyed.Done].
+type Keyed[K comparable] struct {
+	mu         sync.Mutex
+	limiter    RateLimiter[K]
+	queue      []K
+	dirty      map[K]struct{}
+	processing map[K]struct{}
+	timers     map[*time.Timer]struct{}
+	notEmpty   signal
+	closed     bool
+}
+
+// NewKeyed creates a new [Keyed] work queue using the given
+// [RateLimiter] for [Keyed.AddRateLimited]. If nil, an exponential
+// backoff from 5ms to 1000s is used.
+func NewKeyed[K comparable](limiter RateLimiter[K]) *Keyed[K] {
+	if limiter == nil {
+		limiter = NewExponentialBackoff[K](5*time.Millisecond, 1000*time.Second)
+	}
+
+	return &Keyed[K]{
+		limiter:    limiter,
+		dirty:      make(map[K]struct{}),
+		processing: make(map[K]struct{}),
+		timers:     make(map[*time.Timer]struct{}),
+	}
+}
+
+// Len returns the number of keys waiting to be processed.
+func (q *Keyed[K]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.queue)
+}
+
+// Add queues a key for processing unless it's already pending.
+func (q *Keyed[K]) Add(key K) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.unsafeAdd(key)
+}
+
+func (q *Keyed[K]) unsafeAdd(key K) {
+	if q.closed {
+		return
+	} else if _, ok := q.dirty[key]; ok {
+		// already pending
+		return
+	}
+
+	q.dirty[key] = struct{}{}
+	if _, ok := q.processing[key]; !ok {
+		q.queue = append(q.queue, key)
+		q.notEmpty.Broadcast()
+	}
+}
+
+// AddAfter queues a key for processing after the given delay.
+func (q *Keyed[K]) AddAfter(key K, d time.Duration) {
+	if d <= 0 {
+		q.Add(key)
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		delete(q.timers, t)
+		q.unsafeAdd(key)
+	})
+	q.timers[t] = struct{}{}
+}
+
+// AddRateLimited queues a key for processing after the delay
+// given by the [RateLimiter].
+func (q *Keyed[K]) AddRateLimited(key K) {
+	q.AddAfter(key, q.limiter.When(key))
+}
+
+// Forget tells the [RateLimiter] to stop tracking the key,
+// typically after a successful processing.
+func (q *Keyed[K]) Forget(key K) {
+	q.limiter.Forget(key)
+}
+
+// Retries returns how many times the key has been rate limited.
+func (q *Keyed[K]) Retries(key K) int {
+	return q.limiter.Retries(key)
+}
+
+// Get waits until there is a key to process, or the context is
+// cancelled. [Keyed.Done] must be called once the key has been
+// processed. Get returns [ErrClosed] once the queue has been
+// closed and drained.
+func (q *Keyed[K]) Get(ctx context.Context) (K, error) {
+	var zero K
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		q.mu.Lock()
+		if len(q.queue) > 0 {
+			key := q.unsafeGet()
+			q.mu.Unlock()
+			return key, nil
+		} else if q.closed {
+			q.mu.Unlock()
+			return zero, ErrClosed
+		}
+
+		ch := q.notEmpty.Wait()
+		q.mu.Unlock()
+
+		select {
+		case <-ch:
+			// try again
+		case <-ctx.Done():
+			return zero, context.Cause(ctx)
+		}
+	}
+}
+
+func (q *Keyed[K]) unsafeGet() K {
+	var zero K
+
+	key := q.queue[0]
+	q.queue[0] = zero
+	q.queue = q.queue[1:]
+
+	q.processing[key] = struct{}{}
+	delete(q.dirty, key)
+	return key
+}
+
+// Done marks a key as processed. If it was added again
+// while being processed, it's queued again.
+func (q *Keyed[K]) Done(key K) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.processing, key)
+	if _, ok := q.dirty[key]; ok && !q.closed {
+		q.queue = append(q.queue, key)
+		q.notEmpty.Broadcast()
+	}
+}
+
+// Close stops accepting new keys, cancels delayed additions and
+// wakes up all waiters. Keys already queued can still be taken
+// with [Keyed.Get].
+func (q *Keyed[K]) Close() error {
+	if q == nil {
+		return core.ErrNilReceiver
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	q.closed = true
+	for t := range q.timers {
+		t.Stop()
+	}
+	q.timers = nil
+	q.notEmpty.Broadcast()
+	return nil
+}
@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeyedDedup(t *testing.T) {
+	q := NewKeyed[string](nil)
+	ctx := context.Background()
+
+	q.Add("a")
+	q.Add("b")
+	q.Add("a")
+	if n := q.Len(); n != 2 {
+		t.Fatalf("ERROR: Len() → %v (expected %v)", n, 2)
+	}
+
+	key, _ := q.Get(ctx)
+	if key != "a" {
+		t.Fatalf("ERROR: Get() → %q (expected %q)", key, "a")
+	}
+
+	// re-added while processing, deferred until Done()
+	q.Add("a")
+	q.Add("a")
+	if n := q.Len(); n != 1 {
+		t.Errorf("ERROR: Len() → %v (expected %v)", n, 1)
+	}
+
+	q.Done("a")
+	if n := q.Len(); n != 2 {
+		t.Errorf("ERROR: Len() after Done() → %v (expected %v)", n, 2)
+	}
+
+	_ = q.Close()
+	for _, expected := range []string{"b", "a"} {
+		if key, err := q.Get(ctx); key != expected || err != nil {
+			t.Errorf("ERROR: Get() → %q, %v (expected %q)", key, err, expected)
+		}
+	}
+	if _, err := q.Get(ctx); err != ErrClosed {
+		t.Errorf("ERROR: Get() → %v (expected %v)", err, ErrClosed)
+	}
+}
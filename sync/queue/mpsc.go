@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"sync/atomic"
+)
+
+// MPSC is a lock-free unbounded multi-producer single-consumer
+// FIFO queue. Push can be called concurrently, but only one
+// goroutine can consume at any given time.
+//
+// The zero value is ready for use, but an MPSC must not be
+// copied after first use.
+type MPSC[T any] struct {
+	head atomic.Pointer[mpscNode[T]] // producers side
+	tail *mpscNode[T]                // consumer side
+	size atomic.Int64
+
+	// stub is the initial node, standing in for head
+	// and tail while they are nil
+	stub mpscNode[T]
+}
+
+type mpscNode[T any] struct {
+	next  atomic.Pointer[mpscNode[T]]
+	value T
+}
+
+// NewMPSC creates a new [MPSC] queue.
+func NewMPSC[T any]() *MPSC[T] {
+	return new(MPSC[T])
+}
+
+// Len returns the approximate number of items in the queue.
+func (q *MPSC[T]) Len() int {
+	return int(q.size.Load())
+}
+
+// Push adds an item to the end of the queue. It's safe
+// for concurrent use.
+func (q *MPSC[T]) Push(v T) {
+	n := &mpscNode[T]{value: v}
+
+	q.size.Add(1)
+	prev := q.head.Swap(n)
+	if prev == nil {
+		// first Push
+		prev = &q.stub
+	}
+	prev.next.Store(n)
+}
+
+// Pop removes an item from the front of the queue. It returns
+// false if the queue is empty, or the next producer hasn't finished
+// linking its item yet. Only one goroutine can call Pop or
+// PopBatch at any given time.
+func (q *MPSC[T]) Pop() (T, bool) {
+	var zero T
+
+	if q.tail == nil {
+		// first Pop
+		q.tail = &q.stub
+	}
+
+	next := q.tail.next.Load()
+	if next == nil {
+		return zero, false
+	}
+
+	v := next.value
+	next.value = zero
+	// unlink, as the stub would otherwise keep
+	// every node reachable
+	q.tail.next.Store(nil)
+	q.tail = next
+	q.size.Add(-1)
+	return v, true
+}
+
+// PopBatch removes up to len(dst) items from the front of the queue
+// into dst, returning how many were taken. Only one goroutine can
+// call Pop or PopBatch at any given time.
+func (q *MPSC[T]) PopBatch(dst []T) int {
+	var n int
+
+	for n < len(dst) {
+		v, ok := q.Pop()
+		if !ok {
+			break
+		}
+
+		dst[n] = v
+		n++
+	}
+	return n
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestMPSC(t *testing.T) {
+	const producers = 4
+	const items = 1000
+
+	var wg sync.WaitGroup
+
+	q := NewMPSC[int]()
+	for p := range producers {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := range items {
+				q.Push(p*items + i)
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	last := make([]int, producers)
+	for i := range last {
+		last[i] = -1
+	}
+
+	buf := make([]int, 64)
+	var total int
+	for {
+		n := q.PopBatch(buf)
+		if n == 0 {
+			break
+		}
+
+		for _, v := range buf[:n] {
+			p, i := v/items, v%items
+			if i <= last[p] {
+				t.Errorf("ERROR: producer %v out of order: %v after %v", p, i, last[p])
+			}
+			last[p] = i
+		}
+		total += n
+	}
+
+	if total != producers*items {
+		t.Errorf("ERROR: received %v items (expected %v)", total, producers*items)
+	}
+}
+
+func BenchmarkMPSC(b *testing.B) {
+	q := NewMPSC[int]()
+	done := make(chan struct{})
+
+	go func() {
+		buf := make([]int, 128)
+		for received := 0; received < b.N; {
+			n := q.PopBatch(buf)
+			if n == 0 {
+				runtime.Gosched()
+			}
+			received += n
+		}
+		close(done)
+	}()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Push(1)
+		}
+	})
+	<-done
+}
+
+func BenchmarkChannel(b *testing.B) {
+	ch := make(chan int, 128)
+	done := make(chan struct{})
+
+	go func() {
+		for range b.N {
+			<-ch
+		}
+		close(done)
+	}()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ch <- 1
+		}
+	})
+	<-done
+}
+
+func TestMPSCZero(t *testing.T) {
+	var q MPSC[string]
+
+	if v, ok := q.Pop(); ok {
+		t.Errorf("ERROR: Pop() → %q on an empty queue", v)
+	}
+
+	q.Push("a")
+	q.Push("b")
+	if n := q.Len(); n != 2 {
+		t.Errorf("ERROR: Len() → %v (expected 2)", n)
+	}
+
+	for _, expected := range []string{"a", "b"} {
+		if v, ok := q.Pop(); !ok || v != expected {
+			t.Errorf("ERROR: Pop() → %q, %v (expected %q)", v, ok, expected)
+		}
+	}
+
+	if v, ok := q.Pop(); ok {
+		t.Errorf("ERROR: Pop() → %q on a drained queue", v)
+	}
+}
@@ -0,0 +1,40 @@
+// Package queue provides generic concurrent queues.
+package queue
+
+import (
+	"io/fs"
+
+	"darvaza.org/core"
+)
+
+var (
+	// ErrClosed indicates the queue has been closed.
+	ErrClosed = fs.ErrClosed
+	// ErrInvalidCapacity indicates the requested capacity
+	// isn't valid.
+	ErrInvalidCapacity = core.Wrap(core.ErrInvalid, "invalid capacity")
+)
+
+// signal is a condition that can be waited on with a select,
+// allowing context cancellations. It must be used with the
+// owner's lock held.
+type signal struct {
+	ch chan struct{}
+}
+
+// Wait returns a channel that will be closed on the next
+// broadcast.
+func (s *signal) Wait() <-chan struct{} {
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+// Broadcast wakes up all waiters.
+func (s *signal) Broadcast() {
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+var _ RateLimiter[string] = (*ExponentialBackoff[string])(nil)
+
+// RateLimiter decides how long a key has to wait before
+// being processed again.
+type RateLimiter[K comparable] interface {
+	// When returns how long to wait before the next attempt
+	When(K) time.Duration
+	// Forget stops tracking the key
+	Forget(K)
+	// Retries returns how many times the key has been tracked
+	Retries(K) int
+}
+
+// ExponentialBackoff is a [RateLimiter] doubling the delay of a
+// key on each failure.
+type ExponentialBackoff[K comparable] struct {
+	mu       sync.Mutex
+	failures map[K]int
+	base     time.Duration
+	limit    time.Duration
+}
+
+// NewExponentialBackoff creates a [ExponentialBackoff] rate limiter
+// starting at base, up to limit.
+func NewExponentialBackoff[K comparable](base, limit time.Duration) *ExponentialBackoff[K] {
+	return &ExponentialBackoff[K]{
+		failures: make(map[K]int),
+		base:     base,
+		limit:    limit,
+	}
+}
+
+// When returns the delay for the next attempt of the key.
+func (r *ExponentialBackoff[K]) When(key K) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := r.failures[key]
+	r.failures[key] = n + 1
+
+	d := r.base
+	for ; n > 0 && d < r.limit; n-- {
+		d *= 2
+	}
+	return min(d, r.limit)
+}
+
+// Forget stops tracking the key.
+func (r *ExponentialBackoff[K]) Forget(key K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.failures, key)
+}
+
+// Retries returns how many times the key has failed.
+func (r *ExponentialBackoff[K]) Retries(key K) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.failures[key]
+}
@@ -0,0 +1,160 @@
+// Package semaphore provides a counting semaphore, bounding how
+// many goroutines can hold a resource at once.
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"darvaza.org/core"
+)
+
+// ErrNotAcquired indicates a [Semaphore] was released more times
+// than acquired.
+var ErrNotAcquired = core.Wrap(core.ErrInvalid, "semaphore released without being acquired")
+
+var _ sync.Locker = locker{}
+
+// Semaphore is a counting semaphore with a fixed number of slots.
+// The zero value isn't usable.
+type Semaphore struct {
+	slots   chan struct{}
+	waiting atomic.Int32
+}
+
+// Stats is a snapshot of the state of a [Semaphore]. As the values
+// are read independently, they are only approximate while the
+// [Semaphore] is in use.
+type Stats struct {
+	// Held is the number of slots taken.
+	Held int
+	// Waiting is the number of callers blocked in Acquire.
+	Waiting int
+	// Size is the number of slots.
+	Size int
+}
+
+// New creates a [Semaphore] with n slots.
+func New(n int) (*Semaphore, error) {
+	if n < 1 {
+		return nil, core.Wrap(core.ErrInvalid, "semaphore requires at least one slot")
+	}
+
+	s := &Semaphore{
+		slots: make(chan struct{}, n),
+	}
+	return s, nil
+}
+
+// Acquire takes a slot, waiting for one to be released until the
+// context is cancelled. If ctx is nil, [context.Background] is used.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	s.waiting.Add(1)
+	defer s.waiting.Add(-1)
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// TryAcquire takes a slot if one is free, without waiting.
+func (s *Semaphore) TryAcquire() bool {
+	if s == nil {
+		return false
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release frees a slot. Releasing a slot that wasn't acquired
+// panics with [ErrNotAcquired].
+func (s *Semaphore) Release() {
+	if s == nil {
+		core.Panic(core.ErrNilReceiver)
+	}
+
+	select {
+	case <-s.slots:
+	default:
+		core.Panic(ErrNotAcquired)
+	}
+}
+
+// Len returns the number of slots taken.
+func (s *Semaphore) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.slots)
+}
+
+// Cap returns the number of slots.
+func (s *Semaphore) Cap() int {
+	if s == nil {
+		return 0
+	}
+	return cap(s.slots)
+}
+
+// Waiting returns the number of callers blocked in Acquire.
+func (s *Semaphore) Waiting() int {
+	if s == nil {
+		return 0
+	}
+	return int(s.waiting.Load())
+}
+
+// Stats returns a snapshot of the state of the [Semaphore]
+// for diagnostics.
+func (s *Semaphore) Stats() Stats {
+	if s == nil {
+		return Stats{}
+	}
+
+	return Stats{
+		Held:    s.Len(),
+		Waiting: s.Waiting(),
+		Size:    s.Cap(),
+	}
+}
+
+// Locker returns a [sync.Locker] view of the [Semaphore], where
+// Lock waits for a slot and Unlock releases it.
+func (s *Semaphore) Locker() sync.Locker {
+	return locker{s}
+}
+
+type locker struct {
+	s *Semaphore
+}
+
+func (l locker) Lock() {
+	if err := l.s.Acquire(context.Background()); err != nil {
+		core.Panic(err)
+	}
+}
+
+func (l locker) Unlock() {
+	l.s.Release()
+}
@@ -0,0 +1,189 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestSemaphore(t *testing.T) {
+	s, err := New(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !s.TryAcquire() {
+		t.Error("ERROR: TryAcquire() failed with a free slot")
+	}
+	if s.TryAcquire() {
+		t.Error("ERROR: TryAcquire() succeeded when full")
+	}
+	if s.Len() != 2 || s.Cap() != 2 {
+		t.Errorf("ERROR: Len/Cap → %v/%v (expected 2/2)", s.Len(), s.Cap())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ERROR: Acquire() when full → %v (expected %v)", err, context.DeadlineExceeded)
+	}
+
+	// a release wakes up a waiter
+	done := make(chan error, 1)
+	go func() { done <- s.Acquire(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	s.Release()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ERROR: Acquire() → %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ERROR: Acquire() not woken up by Release()")
+	}
+}
+
+func TestSemaphoreMisuse(t *testing.T) {
+	if _, err := New(0); !errors.Is(err, core.ErrInvalid) {
+		t.Errorf("ERROR: New(0) → %v (expected %v)", err, core.ErrInvalid)
+	}
+
+	s, _ := New(1)
+	err := core.Catch(func() error {
+		s.Release()
+		return nil
+	})
+	if !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("ERROR: Release() without Acquire() → %v (expected %v)", err, ErrNotAcquired)
+	}
+
+	var nilSem *Semaphore
+	if err := nilSem.Acquire(context.Background()); err != core.ErrNilReceiver {
+		t.Errorf("ERROR: Acquire() on nil → %v (expected %v)", err, core.ErrNilReceiver)
+	}
+	if nilSem.TryAcquire() {
+		t.Error("ERROR: TryAcquire() on nil succeeded")
+	}
+}
+
+func TestSemaphoreConcurrent(t *testing.T) {
+	const limit = 3
+
+	s, _ := New(limit)
+
+	var holders, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Acquire(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			defer s.Release()
+
+			n := holders.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			holders.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > limit {
+		t.Errorf("ERROR: %v holders at once (expected at most %v)", p, limit)
+	}
+}
+
+func TestSemaphoreStats(t *testing.T) {
+	s, _ := New(1)
+
+	if st := s.Stats(); st != (Stats{Size: 1}) {
+		t.Errorf("ERROR: Stats() → %+v when idle", st)
+	}
+
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Acquire(ctx) }()
+
+	for s.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if st := s.Stats(); st != (Stats{Held: 1, Waiting: 1, Size: 1}) {
+		t.Errorf("ERROR: Stats() → %+v with a waiter", st)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("ERROR: Acquire() → %v (expected %v)", err, context.Canceled)
+	}
+
+	if st := s.Stats(); st != (Stats{Held: 1, Size: 1}) {
+		t.Errorf("ERROR: Stats() → %+v after the waiter left", st)
+	}
+
+	var nilSem *Semaphore
+	if st := nilSem.Stats(); st != (Stats{}) {
+		t.Errorf("ERROR: Stats() on nil → %+v", st)
+	}
+}
+
+func TestSemaphoreLocker(t *testing.T) {
+	s, _ := New(1)
+	l := s.Locker()
+
+	l.Lock()
+	if s.TryAcquire() {
+		t.Error("ERROR: TryAcquire() succeeded while locked")
+	}
+	l.Unlock()
+
+	// as the lock of a sync.Cond
+	var ready bool
+	cond := sync.NewCond(l)
+	go func() {
+		l.Lock()
+		defer l.Unlock()
+		ready = true
+		cond.Broadcast()
+	}()
+
+	l.Lock()
+	for !ready {
+		cond.Wait()
+	}
+	l.Unlock()
+
+	if n := s.Len(); n != 0 {
+		t.Errorf("ERROR: %v slots held after Unlock()", n)
+	}
+
+	var nilSem *Semaphore
+	err := core.Catch(func() error {
+		nilSem.Locker().Lock()
+		return nil
+	})
+	if !errors.Is(err, core.ErrNilReceiver) {
+		t.Errorf("ERROR: Lock() on nil → %v (expected %v)", err, core.ErrNilReceiver)
+	}
+}
@@ -0,0 +1,123 @@
+// Package shutdown provides a registry to coordinate the graceful
+// shutdown of the subsystems of an application.
+package shutdown
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+
+	"darvaza.org/x/sync/errors"
+)
+
+// ErrShutdown indicates the [Registry] has already been shut down.
+var ErrShutdown = core.Wrap(core.ErrInvalid, "already shut down")
+
+// Hook is a shutdown function registered on a [Registry].
+type Hook struct {
+	// Run stops the subsystem, observing the context.
+	Run func(context.Context) error
+	// Name identifies the hook in errors.
+	Name string
+	// Timeout limits how long the hook can take. Zero means
+	// only the context passed to [Registry.Shutdown] applies.
+	Timeout time.Duration
+}
+
+// Registry holds shutdown hooks to be run in reverse order
+// of registration.
+//
+// The zero value is ready for use.
+type Registry struct {
+	mu    sync.Mutex
+	hooks []Hook
+	done  bool
+}
+
+// Register adds a shutdown function with an optional timeout.
+func (r *Registry) Register(name string, timeout time.Duration, fn func(context.Context) error) error {
+	return r.Add(Hook{Name: name, Timeout: timeout, Run: fn})
+}
+
+// Add adds a shutdown [Hook].
+func (r *Registry) Add(h Hook) error {
+	switch {
+	case r == nil:
+		return core.ErrNilReceiver
+	case h.Run == nil:
+		return core.Wrap(core.ErrInvalid, "run function not specified")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		return ErrShutdown
+	}
+
+	r.hooks = append(r.hooks, h)
+	return nil
+}
+
+// Shutdown runs the registered hooks in reverse order of
+// registration, one at a time, each limited by its own timeout
+// and the given context. A hook failing or timing out doesn't
+// prevent the others from running. The errors returned by the hooks
+// are combined, annotated with their names.
+//
+// Shutdown can only be called once, subsequent calls return
+// [ErrShutdown].
+func (r *Registry) Shutdown(ctx context.Context) error {
+	if r == nil {
+		return core.ErrNilReceiver
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	r.mu.Lock()
+	hooks, done := r.hooks, r.done
+	r.hooks, r.done = nil, true
+	r.mu.Unlock()
+
+	if done {
+		return ErrShutdown
+	}
+
+	var errs errors.CompoundError
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := runHook(ctx, h); err != nil {
+			errs.Append(err, "%s", core.Coalesce(h.Name, "unnamed"))
+		}
+	}
+	return errs.AsError()
+}
+
+// runHook runs a hook as the only worker of a [core.ErrGroup]
+// so a hook ignoring its context doesn't block the rest of the
+// shutdown. The error is taken from the worker itself, as the
+// [core.ErrGroup] reports them asynchronously.
+func runHook(ctx context.Context, h Hook) error {
+	var err error
+
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	eg := &core.ErrGroup{Parent: ctx}
+	eg.Go(func(ctx context.Context) error {
+		err = core.Catch(func() error { return h.Run(ctx) })
+		return err
+	}, nil)
+
+	select {
+	case <-eg.Done():
+		return err
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
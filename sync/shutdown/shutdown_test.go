@@ -0,0 +1,94 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	var r Registry
+	var order []string
+
+	for _, name := range []string{"a", "b", "c"} {
+		err := r.Register(name, 0, func(context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatalf("ERROR: Shutdown() → %v", err)
+	}
+
+	expected := []string{"c", "b", "a"}
+	if !slices.Equal(order, expected) {
+		t.Errorf("ERROR: hooks ran as %v (expected %v)", order, expected)
+	}
+
+	if err := r.Shutdown(context.Background()); err != ErrShutdown {
+		t.Errorf("ERROR: second Shutdown() → %v (expected %v)", err, ErrShutdown)
+	}
+	if err := r.Register("d", 0, func(context.Context) error { return nil }); err != ErrShutdown {
+		t.Errorf("ERROR: Register() after Shutdown() → %v (expected %v)", err, ErrShutdown)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	var r Registry
+	var ran bool
+
+	_ = r.Register("next", 0, func(context.Context) error {
+		ran = true
+		return nil
+	})
+	_ = r.Register("stuck", 10*time.Millisecond, func(context.Context) error {
+		// ignores the context
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	err := r.Shutdown(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("ERROR: Shutdown() took %v", elapsed)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ERROR: Shutdown() → %v (expected %v)", err, context.DeadlineExceeded)
+	}
+	if !ran {
+		t.Error("ERROR: hook after the timed out one didn't run")
+	}
+}
+
+func TestShutdownErrors(t *testing.T) {
+	var r Registry
+
+	errFoo := errors.New("foo failed")
+	_ = r.Register("foo", 0, func(context.Context) error { return errFoo })
+	_ = r.Register("ok", 0, func(context.Context) error { return nil })
+	_ = r.Register("bar", 0, func(context.Context) error { panic("bar panicked") })
+	_ = r.Add(Hook{Run: func(context.Context) error { return errors.New("anonymous") }})
+
+	err := r.Shutdown(context.Background())
+	if !errors.Is(err, errFoo) {
+		t.Errorf("ERROR: Shutdown() → %v (expected %v)", err, errFoo)
+	}
+
+	msg := err.Error()
+	for _, s := range []string{"foo: foo failed", "bar:", "bar panicked", "unnamed: anonymous"} {
+		if !strings.Contains(msg, s) {
+			t.Errorf("ERROR: %q missing from %q", s, msg)
+		}
+	}
+	if strings.Contains(msg, "ok:") {
+		t.Errorf("ERROR: successful hook reported in %q", msg)
+	}
+}
@@ -0,0 +1,164 @@
+// Package singleflight provides a duplicate call suppression
+// mechanism with context support.
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// Group represents a class of work and forms a namespace in
+// which units of work can be executed with duplicate suppression.
+//
+// The zero value is ready for use.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// Result holds the results of [Group.DoChan] so they can
+// be passed on a channel.
+type Result[V any] struct {
+	Val    V
+	Err    error
+	Shared bool
+}
+
+type call[V any] struct {
+	done   chan struct{}
+	cancel context.CancelCauseFunc
+	val    V
+	err    error
+	refs   int
+	dups   int
+}
+
+// Do executes and returns the results of the given function,
+// making sure that only one execution is in-flight for a given
+// key at a time. Duplicate callers wait for the original to
+// complete and receive the same results.
+//
+// The function receives a context that is only cancelled when
+// all interested callers have given up.
+//
+// If the function panics, Do panics for every caller with the
+// recovered [core.PanicError].
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error) {
+	r := g.do(ctx, key, fn)
+	if p, ok := r.Err.(*core.PanicError); ok {
+		panic(p)
+	}
+	return r.Val, r.Err
+}
+
+// DoChan is like [Group.Do] but returns a channel that will receive
+// the results when they are ready. Instead of panicking, a recovered
+// [core.PanicError] is returned as Err.
+func (g *Group[K, V]) DoChan(ctx context.Context, key K, fn func(context.Context) (V, error)) <-chan Result[V] {
+	ch := make(chan Result[V], 1)
+	go func() {
+		ch <- g.do(ctx, key, fn)
+	}()
+	return ch
+}
+
+func (g *Group[K, V]) do(ctx context.Context, key K, fn func(context.Context) (V, error)) Result[V] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c, shared := g.join(ctx, key, fn)
+
+	select {
+	case <-c.done:
+		return Result[V]{Val: c.val, Err: c.err, Shared: shared || c.dups > 0}
+	case <-ctx.Done():
+		g.leave(key, c)
+		return Result[V]{Err: context.Cause(ctx), Shared: shared}
+	}
+}
+
+// join finds the in-flight call for the key or starts a new one.
+func (g *Group[K, V]) join(ctx context.Context, key K, fn func(context.Context) (V, error)) (*call[V], bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c, ok := g.calls[key]; ok {
+		c.refs++
+		c.dups++
+		return c, true
+	}
+
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+
+	runCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	c := &call[V]{
+		done:   make(chan struct{}),
+		cancel: cancel,
+		refs:   1,
+	}
+	g.calls[key] = c
+
+	go g.run(runCtx, key, c, fn)
+	return c, false
+}
+
+// leave drops a caller's interest in a call, cancelling its
+// context when nobody else is waiting.
+func (g *Group[K, V]) leave(key K, c *call[V]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.refs--
+	if c.refs == 0 {
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		c.cancel(context.Canceled)
+	}
+}
+
+func (g *Group[K, V]) run(ctx context.Context, key K, c *call[V], fn func(context.Context) (V, error)) {
+	var catcher core.Catcher
+
+	err := catcher.Do(func() error {
+		var err error
+		c.val, err = fn(ctx)
+		return err
+	})
+
+	if p := catcher.Recovered(); p != nil {
+		err = asPanicError(p)
+	}
+
+	g.mu.Lock()
+	c.err = err
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	close(c.done)
+	c.cancel(nil)
+	g.mu.Unlock()
+}
+
+func asPanicError(p core.Recovered) *core.PanicError {
+	var pe *core.PanicError
+	if err, ok := p.(error); ok && errors.As(err, &pe) {
+		return pe
+	}
+	return core.NewPanicError(2, p.Recovered())
+}
+
+// Forget tells the [Group] to forget about a key. Future calls
+// to [Group.Do] for this key will call the function rather than
+// waiting for an earlier call to complete.
+func (g *Group[K, V]) Forget(key K) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+}
@@ -0,0 +1,84 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestDoShared(t *testing.T) {
+	var g Group[string, int]
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	results := make([]int, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = g.Do(context.Background(), "key", fn)
+		}(i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ERROR: fn called %v times (expected 1)", n)
+	}
+	for i, v := range results {
+		if v != 42 {
+			t.Errorf("[%v] ERROR: Do() → %v (expected 42)", i, v)
+		}
+	}
+}
+
+func TestDoCancel(t *testing.T) {
+	var g Group[string, int]
+
+	cancelled := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := g.Do(ctx, "key", fn); err == nil {
+		t.Error("ERROR: Do() succeeded after cancellation")
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("ERROR: fn context not cancelled after callers left")
+	}
+}
+
+func TestDoPanic(t *testing.T) {
+	var g Group[string, int]
+
+	defer func() {
+		if _, ok := recover().(*core.PanicError); !ok {
+			t.Error("ERROR: Do() didn't panic with a core.PanicError")
+		}
+	}()
+
+	_, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+		panic("oops")
+	})
+}
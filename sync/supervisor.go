@@ -0,0 +1,194 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"darvaza.org/core"
+
+	xerrors "darvaza.org/x/sync/errors"
+)
+
+// ErrTooManyRestarts indicates a supervised task reached its
+// restart limit.
+var ErrTooManyRestarts = errors.New("too many restarts")
+
+// RestartPolicy determines when a [Supervisor] restarts a task.
+type RestartPolicy int
+
+const (
+	// RestartAlways restarts the task whenever it finishes
+	RestartAlways RestartPolicy = iota
+	// RestartOnFailure restarts the task only when it fails
+	RestartOnFailure
+	// RestartNever lets the task finish
+	RestartNever
+)
+
+// Task describes a long-running function managed by a [Supervisor].
+type Task struct {
+	// Run is the function of the task. It must return when
+	// the context is cancelled.
+	Run func(context.Context) error
+
+	// Backoff returns how long to wait before the given restart,
+	// starting at 1. If nil, tasks are restarted immediately.
+	Backoff func(restart int) time.Duration
+
+	// Name identifies the task in errors.
+	Name string
+
+	// Policy determines when to restart the task.
+	Policy RestartPolicy
+
+	// MaxRestarts is the maximum number of restarts allowed. Zero
+	// means unlimited.
+	MaxRestarts int
+}
+
+// Supervisor runs long-running tasks, restarting them according
+// to their policies. Panics are caught and considered failures.
+//
+// Tasks that fail without being restarted, or reach their limit,
+// cancel the whole [Supervisor].
+type Supervisor struct {
+	eg core.ErrGroup
+
+	// err holds the first failure, as the [core.ErrGroup]
+	// reports them asynchronously
+	err xerrors.FirstError
+}
+
+// NewSupervisor creates a [Supervisor] whose tasks are cancelled
+// when the given context is.
+func NewSupervisor(ctx context.Context) *Supervisor {
+	s := &Supervisor{}
+	s.eg.Parent = ctx
+	s.eg.SetDefaults()
+	return s
+}
+
+// Go starts a supervised task.
+func (s *Supervisor) Go(t Task) error {
+	switch {
+	case s == nil:
+		return core.ErrNilReceiver
+	case t.Run == nil:
+		return core.Wrap(core.ErrInvalid, "run function not specified")
+	case s.eg.IsCancelled(), s.eg.Context().Err() != nil:
+		return core.Wrap(context.Canceled, "supervisor cancelled")
+	}
+
+	s.eg.Go(func(ctx context.Context) error {
+		err := s.run(ctx, t)
+		if err != nil {
+			s.err.Set(err)
+		}
+		return err
+	}, nil)
+	return nil
+}
+
+func (*Supervisor) run(ctx context.Context, t Task) error {
+	for restarts := 0; ; restarts++ {
+		err := core.Catch(func() error { return t.Run(ctx) })
+
+		switch {
+		case ctx.Err() != nil:
+			// shutdown
+			return nil
+		case t.Policy == RestartNever, t.Policy == RestartOnFailure && err == nil:
+			return taskError(t, err)
+		case t.MaxRestarts > 0 && restarts >= t.MaxRestarts:
+			return taskError(t, tooManyRestarts(err))
+		}
+
+		if err := sleepContext(ctx, taskBackoff(t, restarts+1)); err != nil {
+			// shutdown
+			return nil
+		}
+	}
+}
+
+func tooManyRestarts(err error) error {
+	if err == nil {
+		return ErrTooManyRestarts
+	}
+	return fmt.Errorf("%w: %w", ErrTooManyRestarts, err)
+}
+
+func taskBackoff(t Task, restart int) time.Duration {
+	if t.Backoff != nil {
+		return t.Backoff(restart)
+	}
+	return 0
+}
+
+func taskError(t Task, err error) error {
+	if err != nil && t.Name != "" {
+		err = core.Wrap(err, t.Name)
+	}
+	return err
+}
+
+// Cancel initiates the shutdown of all tasks.
+func (s *Supervisor) Cancel(cause error) {
+	if s != nil {
+		s.eg.Cancel(cause)
+	}
+}
+
+// Done returns a channel closed when all tasks have finished.
+func (s *Supervisor) Done() <-chan struct{} {
+	return s.eg.Done()
+}
+
+// Wait waits until all tasks have finished, and returns the
+// error that caused the shutdown, if any.
+func (s *Supervisor) Wait() error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	err := s.eg.Wait()
+
+	if e := s.err.Err(); e != nil {
+		err = e
+	}
+
+	if core.IsError(err, context.Canceled) {
+		// manual shutdown
+		return nil
+	}
+	return err
+}
+
+// ExponentialBackoff returns a Backoff function doubling the
+// delay on each restart from base, up to limit.
+func ExponentialBackoff(base, limit time.Duration) func(int) time.Duration {
+	return func(restart int) time.Duration {
+		d := base
+		for i := 1; i < restart && d < limit; i++ {
+			d *= 2
+		}
+		return min(d, limit)
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errTask = errors.New("task failed")
+
+// failing returns a Run function failing the first n times.
+func failing(runs *atomic.Int32, n int32) func(context.Context) error {
+	return func(context.Context) error {
+		if runs.Add(1) <= n {
+			return errTask
+		}
+		return nil
+	}
+}
+
+func TestSupervisorPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RestartPolicy
+		failures int32
+		max      int
+		runs     int32
+		expected error
+	}{
+		{"always", RestartAlways, 0, 2, 3, ErrTooManyRestarts},
+		{"always failing", RestartAlways, 5, 2, 3, errTask},
+		{"on failure", RestartOnFailure, 2, 0, 3, nil},
+		{"on failure limited", RestartOnFailure, 5, 1, 2, ErrTooManyRestarts},
+		{"never", RestartNever, 0, 0, 1, nil},
+		{"never failing", RestartNever, 1, 0, 1, errTask},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var runs atomic.Int32
+
+			s := NewSupervisor(context.Background())
+			err := s.Go(Task{
+				Name:        "foo",
+				Run:         failing(&runs, tc.failures),
+				Policy:      tc.policy,
+				MaxRestarts: tc.max,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.Wait()
+			switch {
+			case tc.expected == nil && err != nil:
+				t.Errorf("ERROR: Wait() → %v (expected nil)", err)
+			case tc.expected != nil && !errors.Is(err, tc.expected):
+				t.Errorf("ERROR: Wait() → %v (expected %v)", err, tc.expected)
+			case err != nil && !strings.Contains(err.Error(), "foo"):
+				t.Errorf("ERROR: Wait() → %q doesn't name the task", err)
+			}
+
+			if n := runs.Load(); n != tc.runs {
+				t.Errorf("ERROR: %v runs (expected %v)", n, tc.runs)
+			}
+		})
+	}
+}
+
+func TestSupervisorPanic(t *testing.T) {
+	var runs atomic.Int32
+
+	s := NewSupervisor(context.Background())
+	_ = s.Go(Task{
+		Run: func(context.Context) error {
+			runs.Add(1)
+			panic("boom")
+		},
+		Policy:      RestartOnFailure,
+		MaxRestarts: 1,
+	})
+
+	if err := s.Wait(); !errors.Is(err, ErrTooManyRestarts) {
+		t.Errorf("ERROR: Wait() → %v (expected %v)", err, ErrTooManyRestarts)
+	}
+	if n := runs.Load(); n != 2 {
+		t.Errorf("ERROR: %v runs (expected 2)", n)
+	}
+}
+
+func TestSupervisorBackoff(t *testing.T) {
+	var runs atomic.Int32
+	var restarts []int
+
+	const delay = 10 * time.Millisecond
+	s := NewSupervisor(context.Background())
+	_ = s.Go(Task{
+		Run:    failing(&runs, 2),
+		Policy: RestartOnFailure,
+		Backoff: func(restart int) time.Duration {
+			restarts = append(restarts, restart)
+			return delay
+		},
+	})
+
+	start := time.Now()
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 2*delay {
+		t.Errorf("ERROR: finished after %v (expected at least %v)", elapsed, 2*delay)
+	}
+	if len(restarts) != 2 || restarts[0] != 1 || restarts[1] != 2 {
+		t.Errorf("ERROR: Backoff called with %v (expected [1 2])", restarts)
+	}
+
+	backoff := ExponentialBackoff(time.Second, 5*time.Second)
+	for restart, expected := range []time.Duration{1, 1, 2, 4, 5, 5} {
+		if d := backoff(restart); d != expected*time.Second {
+			t.Errorf("ERROR: ExponentialBackoff(%v) → %v (expected %v)",
+				restart, d, expected*time.Second)
+		}
+	}
+}
+
+func TestSupervisorCancel(t *testing.T) {
+	var runs atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewSupervisor(ctx)
+	_ = s.Go(Task{
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Policy:  RestartAlways,
+		Backoff: func(int) time.Duration { return time.Hour },
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("ERROR: tasks not stopped after cancel")
+	}
+
+	if err := s.Wait(); err != nil {
+		t.Errorf("ERROR: Wait() → %v (expected nil)", err)
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("ERROR: %v runs (expected 1)", n)
+	}
+	if err := s.Go(Task{Run: failing(&runs, 0)}); err == nil {
+		t.Error("ERROR: Go() accepted a task after cancel")
+	}
+}
@@ -0,0 +1,3 @@
+// Package sync provides synchronisation primitives
+// complementing the standard [sync] package.
+package sync
@@ -0,0 +1,316 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/fs"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"darvaza.org/core"
+	xfs "darvaza.org/x/fs"
+	"darvaza.org/x/tls"
+)
+
+func newTestStorage(t *testing.T) *FSStorage {
+	t.Helper()
+
+	sb, err := xfs.NewSandbox(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFSStorage(sb, "acme")
+}
+
+// newTestCert creates a self-signed key pair for name valid for ttl.
+func newTestCert(t *testing.T, name string, ttl time.Duration) *tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(ttl),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+func TestFSStorage(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	if _, err := s.Get(ctx, "a"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("ERROR: unexpected %v", err)
+	}
+	if err := s.Put(ctx, "a", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := s.Get(ctx, "a"); err != nil || string(b) != "hello" {
+		t.Fatalf("ERROR: %q %v", b, err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatalf("ERROR: deleting twice: %v", err)
+	}
+
+	for _, name := range []string{"", ".", "..", "../a", "a/b", `a\b`} {
+		if err := s.Put(ctx, name, nil); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("ERROR: Put(%q) → %v (expected %v)", name, err, fs.ErrInvalid)
+		}
+	}
+}
+
+// countingStorage is a [Storage] counting how often it's used.
+type countingStorage struct {
+	Storage
+	calls atomic.Int32
+}
+
+func (s *countingStorage) Get(ctx context.Context, name string) ([]byte, error) {
+	s.calls.Add(1)
+	return s.Storage.Get(ctx, name)
+}
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"a.example.org", "a.example.org"},
+		{"A.Example.ORG.", "a.example.org"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example"},
+		{"", ""},
+		{"localhost", ""},
+		{"../a.example.org", ""},
+		{"a/b.example.org", ""},
+		{`a\b.example.org`, ""},
+		{"a..example.org", ""},
+		{"-a.example.org", ""},
+		{"a_b.example.org", ""},
+		{"acme_account.key", ""},
+	}
+
+	for _, tc := range tests {
+		name, err := checkName(tc.name)
+		switch {
+		case tc.expected == "" && !errors.Is(err, core.ErrInvalid):
+			t.Errorf("ERROR: checkName(%q) → %q, %v (expected %v)", tc.name, name, err, core.ErrInvalid)
+		case tc.expected != "" && (err != nil || name != tc.expected):
+			t.Errorf("ERROR: checkName(%q) → %q, %v (expected %q)", tc.name, name, err, tc.expected)
+		}
+	}
+}
+
+func TestManagerCheckBeforeStorage(t *testing.T) {
+	s := &countingStorage{Storage: newTestStorage(t)}
+	m := &Manager{
+		Storage:    s,
+		HostPolicy: HostWhitelist("a.example.org"),
+	}
+
+	for _, tc := range []struct {
+		name     string
+		expected error
+	}{
+		{"../../a.example.org", core.ErrInvalid},
+		{"b.example.org", ErrHostNotAllowed},
+	} {
+		_, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: tc.name})
+		if !errors.Is(err, tc.expected) {
+			t.Errorf("ERROR: %q: %v (expected %v)", tc.name, err, tc.expected)
+		}
+	}
+
+	if n := s.calls.Load(); n != 0 {
+		t.Errorf("ERROR: Storage used %v times", n)
+	}
+}
+
+func TestManagerRenewBackground(t *testing.T) {
+	var hits atomic.Int32
+	// a CA refusing every request
+	ca := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		rw.WriteHeader(http.StatusForbidden)
+	}))
+	defer ca.Close()
+
+	failures := make(chan error, 4)
+	m := &Manager{
+		Client:     &acme.Client{DirectoryURL: ca.URL},
+		Storage:    newTestStorage(t),
+		HostPolicy: HostWhitelist("a.example.org"),
+		OnError:    func(_ string, err error) { failures <- err },
+	}
+
+	// inside the renewal window, but still valid
+	cert := newTestCert(t, "a.example.org", 10*24*time.Hour)
+	if err := m.store(context.Background(), "a.example.org", cert); err != nil {
+		t.Fatal(err)
+	}
+
+	chi := &tls.ClientHelloInfo{ServerName: "a.example.org"}
+	if got, err := m.GetCertificate(chi); err != nil || !got.Leaf.Equal(cert.Leaf) {
+		t.Fatalf("ERROR: expiring certificate not served: %v", err)
+	}
+
+	select {
+	case <-failures:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ERROR: renewal didn't run")
+	}
+
+	// failed renewals back off instead of retrying every handshake
+	n := hits.Load()
+	if got, err := m.GetCertificate(chi); err != nil || !got.Leaf.Equal(cert.Leaf) {
+		t.Fatalf("ERROR: expiring certificate not served after failure: %v", err)
+	}
+
+	select {
+	case err := <-failures:
+		t.Errorf("ERROR: renewal retried immediately: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if hits.Load() != n {
+		t.Error("ERROR: CA contacted again")
+	}
+}
+
+func TestRenewBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{1, renewRetryMin},
+		{2, 2 * renewRetryMin},
+		{3, 4 * renewRetryMin},
+		{100, renewRetryMax},
+	}
+
+	for _, tc := range tests {
+		if d := renewBackoff(tc.failures); d != tc.expected {
+			t.Errorf("ERROR: renewBackoff(%v) → %v (expected %v)", tc.failures, d, tc.expected)
+		}
+	}
+}
+
+func TestManagerStored(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{
+		Storage:    newTestStorage(t),
+		HostPolicy: HostWhitelist("a.example.org"),
+	}
+
+	cert := newTestCert(t, "a.example.org", 90*24*time.Hour)
+	if err := m.store(ctx, "a.example.org", cert); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "A.example.org."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Leaf.Equal(cert.Leaf) {
+		t.Error("ERROR: different certificate")
+	}
+
+	// renewal only touches expiring certificates, none here
+	if err := m.Renew(ctx); err != nil {
+		t.Errorf("ERROR: renew: %v", err)
+	}
+}
+
+func TestManagerPolicy(t *testing.T) {
+	m := &Manager{
+		Storage:    newTestStorage(t),
+		HostPolicy: HostWhitelist("a.example.org"),
+	}
+
+	_, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "b.example.org"})
+	if !errors.Is(err, ErrHostNotAllowed) {
+		t.Fatalf("ERROR: unexpected %v", err)
+	}
+}
+
+func TestManagerALPNChallenge(t *testing.T) {
+	m := &Manager{Storage: newTestStorage(t)}
+	cert := newTestCert(t, "a.example.org", time.Hour)
+	m.setChallengeCert("a.example.org", cert)
+
+	chi := &tls.ClientHelloInfo{
+		ServerName:      "a.example.org",
+		SupportedProtos: []string{ALPNProto},
+	}
+	if got, err := m.GetCertificate(chi); err != nil || got != cert {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	m.setChallengeCert("a.example.org", nil)
+	if _, err := m.GetCertificate(chi); err == nil {
+		t.Fatal("ERROR: challenge still served")
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	m := &Manager{}
+	m.setToken(HTTP01Prefix+"token", []byte("token.thumbprint"))
+	h := m.HTTPHandler(nil)
+
+	for _, tc := range []struct {
+		path   string
+		code   int
+		body   string
+		target string
+	}{
+		{path: HTTP01Prefix + "token", code: http.StatusOK, body: "token.thumbprint"},
+		{path: HTTP01Prefix + "other", code: http.StatusNotFound},
+		{path: "/foo?bar", code: http.StatusFound, target: "https://example.org/foo?bar"},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.org:80"+tc.path, nil))
+
+		switch {
+		case rec.Code != tc.code:
+			t.Errorf("ERROR: %s: code %v (expected %v)", tc.path, rec.Code, tc.code)
+		case tc.body != "" && rec.Body.String() != tc.body:
+			t.Errorf("ERROR: %s: body %q", tc.path, rec.Body.String())
+		case tc.target != "" && rec.Header().Get("Location") != tc.target:
+			t.Errorf("ERROR: %s: location %q", tc.path, rec.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package acme
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls"
+)
+
+// HTTP01Prefix is the path prefix of HTTP-01 challenges.
+const HTTP01Prefix = "/.well-known/acme-challenge/"
+
+// HTTPHandler returns an [http.Handler] answering HTTP-01 challenges
+// and passing everything else to the fallback. Without fallback,
+// other GET and HEAD requests are redirected to https.
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasPrefix(req.URL.Path, HTTP01Prefix):
+			m.serveHTTP01(rw, req)
+		case fallback != nil:
+			fallback.ServeHTTP(rw, req)
+		default:
+			redirectHTTPS(rw, req)
+		}
+	})
+}
+
+func (m *Manager) serveHTTP01(rw http.ResponseWriter, req *http.Request) {
+	body, ok := m.getToken(req.URL.Path)
+	if !ok {
+		http.NotFound(rw, req)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain")
+	_, _ = rw.Write(body)
+}
+
+func redirectHTTPS(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(rw, "Use HTTPS", http.StatusBadRequest)
+		return
+	}
+
+	host, _, err := core.SplitHostPort(req.Host)
+	if err != nil || host == "" {
+		host = req.Host
+	}
+
+	target := "https://" + host + req.URL.RequestURI()
+	http.Redirect(rw, req, target, http.StatusFound)
+}
+
+// authorize satisfies one authorization of an order.
+func (m *Manager) authorize(ctx context.Context, client *acme.Client, authzURL string) error {
+	z, err := client.GetAuthorization(ctx, authzURL)
+	switch {
+	case err != nil:
+		return err
+	case z.Status == acme.StatusValid:
+		return nil
+	}
+
+	chal := m.pickChallenge(z.Challenges)
+	if chal == nil {
+		return ErrNoChallenge
+	}
+
+	cleanup, err := m.prepare(client, chal, z.Identifier.Value)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if _, err := client.Accept(ctx, chal); err != nil {
+		return err
+	}
+
+	_, err = client.WaitAuthorization(ctx, z.URI)
+	return err
+}
+
+func (m *Manager) pickChallenge(offered []*acme.Challenge) *acme.Challenge {
+	types := m.Challenges
+	if len(types) == 0 {
+		types = []string{TLSALPN01, HTTP01}
+	}
+
+	for _, typ := range types {
+		for _, chal := range offered {
+			if chal.Type == typ {
+				return chal
+			}
+		}
+	}
+	return nil
+}
+
+// prepare gets ready to answer a challenge, returning the function
+// to undo it once validated.
+func (m *Manager) prepare(client *acme.Client, chal *acme.Challenge, domain string) (func(), error) {
+	switch chal.Type {
+	case HTTP01:
+		body, err := client.HTTP01ChallengeResponse(chal.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		path := client.HTTP01ChallengePath(chal.Token)
+		m.setToken(path, []byte(body))
+		return func() { m.setToken(path, nil) }, nil
+	case TLSALPN01:
+		cert, err := client.TLSALPN01ChallengeCert(chal.Token, domain)
+		if err != nil {
+			return nil, err
+		}
+
+		m.setChallengeCert(domain, &cert)
+		return func() { m.setChallengeCert(domain, nil) }, nil
+	default:
+		return nil, ErrNoChallenge
+	}
+}
+
+func (m *Manager) getToken(path string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	body, ok := m.tokens[path]
+	return body, ok
+}
+
+// setToken sets the response of an HTTP-01 challenge,
+// or removes it if nil.
+func (m *Manager) setToken(path string, body []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case body == nil:
+		delete(m.tokens, path)
+	case m.tokens == nil:
+		m.tokens = map[string][]byte{path: body}
+	default:
+		m.tokens[path] = body
+	}
+}
+
+func (m *Manager) getChallengeCert(domain string) *tls.Certificate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.alpn[domain]
+}
+
+// setChallengeCert sets the certificate of a TLS-ALPN-01 challenge,
+// or removes it if nil.
+func (m *Manager) setChallengeCert(domain string, cert *tls.Certificate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case cert == nil:
+		delete(m.alpn, domain)
+	case m.alpn == nil:
+		m.alpn = map[string]*tls.Certificate{domain: cert}
+	default:
+		m.alpn[domain] = cert
+	}
+}
@@ -0,0 +1,593 @@
+// Package acme obtains and renews certificates automatically using
+// the ACME protocol, answering HTTP-01 and TLS-ALPN-01 challenges.
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"darvaza.org/core"
+	"darvaza.org/x/sync/cron"
+	"darvaza.org/x/sync/singleflight"
+	"darvaza.org/x/tls"
+	"darvaza.org/x/tls/x509utils"
+)
+
+const (
+	// DefaultRenewBefore is how long before expiration certificates
+	// are renewed when the [Manager] doesn't say.
+	DefaultRenewBefore = 30 * 24 * time.Hour
+
+	// DefaultRenewInterval is how often [Manager.Schedule] checks
+	// for certificates to renew.
+	DefaultRenewInterval = 12 * time.Hour
+
+	// renewTimeout limits background renewals started by
+	// [Manager.Get].
+	renewTimeout = 10 * time.Minute
+
+	// renewRetryMin and renewRetryMax bound the wait after a failed
+	// background renewal, doubling on every consecutive failure.
+	renewRetryMin = time.Minute
+	renewRetryMax = DefaultRenewInterval
+
+	// ALPNProto is the protocol negotiated by TLS-ALPN-01 challenges.
+	ALPNProto = acme.ALPNProto
+
+	// HTTP01 and TLSALPN01 are the supported challenge types.
+	HTTP01    = "http-01"
+	TLSALPN01 = "tls-alpn-01"
+
+	// accountKeyName is the [Storage] entry of the account key.
+	accountKeyName = "acme_account.key"
+)
+
+var (
+	// ErrHostNotAllowed indicates the HostPolicy rejected the name.
+	ErrHostNotAllowed = errors.New("host not allowed")
+
+	// ErrNoChallenge indicates the CA didn't offer any of the
+	// enabled challenge types.
+	ErrNoChallenge = errors.New("no supported challenge offered")
+
+	errNoStorage = core.Wrap(core.ErrInvalid, "storage not provided")
+)
+
+// HostPolicy decides if a certificate can be requested for a name.
+type HostPolicy func(ctx context.Context, host string) error
+
+// HostWhitelist returns a [HostPolicy] only accepting
+// the given names.
+func HostWhitelist(hosts ...string) HostPolicy {
+	allowed := make(map[string]bool, len(hosts))
+	for _, h := range hosts {
+		allowed[normalizeName(h)] = true
+	}
+
+	return func(_ context.Context, host string) error {
+		if !allowed[host] {
+			return core.Wrapf(ErrHostNotAllowed, "%q", host)
+		}
+		return nil
+	}
+}
+
+// Manager obtains certificates on demand from an ACME CA, keeps them
+// in a [Storage] and renews them before they expire.
+type Manager struct {
+	// Client talks to the CA. If nil, one for [acme.LetsEncryptURL]
+	// is created. Its Key, if not set, is loaded from Storage or
+	// generated and stored there.
+	Client *acme.Client
+
+	// Storage keeps the account key and certificates. Required.
+	Storage Storage
+
+	// HostPolicy is required, a Manager with no policy refuses
+	// every name.
+	HostPolicy HostPolicy
+
+	// OnError is called, if set, for every failed renewal,
+	// including those started in the background by [Manager.Get].
+	OnError func(host string, err error)
+
+	// Email is the optional contact of the account.
+	Email string
+
+	// Challenges lists the types to answer in order of preference,
+	// TLSALPN01 and HTTP01 if empty.
+	Challenges []string
+
+	// RenewBefore is how long before expiration certificates are
+	// renewed, DefaultRenewBefore if zero.
+	RenewBefore time.Duration
+
+	mu       sync.Mutex
+	regMu    sync.Mutex
+	certs    map[string]*tls.Certificate
+	alpn     map[string]*tls.Certificate
+	tokens   map[string][]byte
+	renewals map[string]*renewal
+	group    singleflight.Group[string, *tls.Certificate]
+	account  bool
+}
+
+// renewal tracks the background renewal of a name.
+type renewal struct {
+	next     time.Time
+	failures int
+	running  bool
+}
+
+// TLSConfig returns a [tls.Config] using the Manager for certificates
+// and able to answer TLS-ALPN-01 challenges.
+func (m *Manager) TLSConfig() *tls.Config {
+	// without a Store NewConfig can't fail
+	cfg, _ := tls.NewConfig(nil)
+	cfg.GetCertificate = m.GetCertificate
+	cfg.NextProtos = []string{"h2", "http/1.1", ALPNProto}
+	return cfg
+}
+
+// GetCertificate implements the [tls.Config] callback, answering
+// TLS-ALPN-01 challenges and obtaining certificates when needed.
+func (m *Manager) GetCertificate(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	ctx, name, err := tls.SplitClientHelloInfo(chi)
+	switch {
+	case err != nil:
+		return nil, err
+	case name == "" || strings.HasPrefix(name, "["):
+		return nil, core.Wrap(core.ErrInvalid, "server name required")
+	}
+
+	name, err = checkName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if isChallenge(chi) {
+		if cert := m.getChallengeCert(name); cert != nil {
+			return cert, nil
+		}
+		return nil, core.Wrapf(core.ErrNotExists, "no challenge for %q", name)
+	}
+
+	return m.Get(ctx, name)
+}
+
+func isChallenge(chi *tls.ClientHelloInfo) bool {
+	return len(chi.SupportedProtos) == 1 && chi.SupportedProtos[0] == ALPNProto
+}
+
+// Get returns a valid certificate for the name, from memory, from
+// Storage or obtaining a new one. The name is validated, and checked
+// against the HostPolicy before Storage is used.
+// Certificates about to expire but still valid are returned while
+// a renewal runs in the background, retried with backoff on failure.
+func (m *Manager) Get(ctx context.Context, name string) (*tls.Certificate, error) {
+	if m.Storage == nil {
+		return nil, errNoStorage
+	}
+
+	name, err := checkName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if cert := m.getCached(name); cert != nil && valid(cert) {
+		m.renewIfExpiring(name, cert)
+		return cert, nil
+	}
+
+	if err := m.checkHost(ctx, name); err != nil {
+		return nil, err
+	}
+
+	cert, err := m.group.Do(ctx, name, func(ctx context.Context) (*tls.Certificate, error) {
+		cert, err := m.load(ctx, name)
+		if err == nil && valid(cert) {
+			m.setCached(name, cert)
+			return cert, nil
+		}
+		return m.obtain(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.renewIfExpiring(name, cert)
+	return cert, nil
+}
+
+func valid(cert *tls.Certificate) bool {
+	return time.Now().Before(cert.Leaf.NotAfter)
+}
+
+func (m *Manager) renewIfExpiring(name string, cert *tls.Certificate) {
+	if m.expiring(cert) {
+		m.renewAsync(name)
+	}
+}
+
+// renewAsync renews a certificate in the background unless already
+// in progress or waiting after a failure.
+func (m *Manager) renewAsync(name string) {
+	if !m.startRenewal(name) {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), renewTimeout)
+		defer cancel()
+
+		err := m.renew(ctx, name)
+		m.endRenewal(name, err)
+	}()
+}
+
+func (m *Manager) startRenewal(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.renewals[name]
+	switch {
+	case !ok:
+		if m.renewals == nil {
+			m.renewals = make(map[string]*renewal)
+		}
+		r = new(renewal)
+		m.renewals[name] = r
+	case r.running, time.Now().Before(r.next):
+		return false
+	}
+
+	r.running = true
+	return true
+}
+
+func (m *Manager) endRenewal(name string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := m.renewals[name]
+	if err == nil {
+		delete(m.renewals, name)
+		return
+	}
+
+	r.running = false
+	r.failures++
+	r.next = time.Now().Add(renewBackoff(r.failures))
+}
+
+// renewBackoff returns how long to wait after the given number
+// of consecutive failures.
+func renewBackoff(failures int) time.Duration {
+	d := renewRetryMin
+	for i := 1; i < failures && d < renewRetryMax; i++ {
+		d *= 2
+	}
+	return min(d, renewRetryMax)
+}
+
+// Obtain requests a new certificate for the name, replacing the
+// current one if successful. Concurrent requests for the same
+// name, including those of [Manager.Get] and [Manager.Renew],
+// share a single order.
+func (m *Manager) Obtain(ctx context.Context, name string) (*tls.Certificate, error) {
+	if m.Storage == nil {
+		return nil, errNoStorage
+	}
+
+	name, err := checkName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.group.Do(ctx, name, func(ctx context.Context) (*tls.Certificate, error) {
+		return m.obtain(ctx, name)
+	})
+}
+
+func (m *Manager) obtain(ctx context.Context, name string) (*tls.Certificate, error) {
+	if err := m.checkHost(ctx, name); err != nil {
+		return nil, err
+	}
+
+	client, err := m.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := m.order(ctx, client, name)
+	if err != nil {
+		return nil, core.Wrapf(err, "%q", name)
+	}
+
+	if err := m.store(ctx, name, cert); err != nil {
+		return nil, err
+	}
+
+	m.setCached(name, cert)
+	return cert, nil
+}
+
+func (m *Manager) checkHost(ctx context.Context, name string) error {
+	if m.HostPolicy == nil {
+		return core.Wrapf(ErrHostNotAllowed, "%q", name)
+	}
+	return m.HostPolicy(ctx, name)
+}
+
+// order goes through the whole ACME order flow for a name.
+func (m *Manager) order(ctx context.Context, client *acme.Client, name string) (*tls.Certificate, error) {
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(name))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range order.AuthzURLs {
+		if err := m.authorize(ctx, client, u); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err = client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		DNSNames: []string{name},
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := parseLeaf(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Certificate{
+		Certificate: chain,
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+// Renew obtains new certificates for all those known to the
+// Manager that are about to expire.
+func (m *Manager) Renew(ctx context.Context) error {
+	var errs core.CompoundError
+
+	for name, cert := range m.cached() {
+		if !m.expiring(cert) {
+			continue
+		}
+
+		if err := m.renew(ctx, name); err != nil {
+			errs.AppendError(err)
+		}
+	}
+	return errs.AsError()
+}
+
+// renew obtains a new certificate for the name through the
+// same group as [Manager.Get], reporting failures to OnError.
+func (m *Manager) renew(ctx context.Context, name string) error {
+	obtain := func(ctx context.Context) (*tls.Certificate, error) {
+		return m.obtain(ctx, name)
+	}
+
+	cert, err := m.group.Do(ctx, name, obtain)
+	if err == nil && m.expiring(cert) {
+		// joined a [Manager.Get] that only loaded it
+		_, err = m.group.Do(ctx, name, obtain)
+	}
+
+	if err != nil {
+		if fn := m.OnError; fn != nil {
+			fn(name, err)
+		}
+	}
+	return err
+}
+
+// Schedule adds a job to the [cron.Scheduler] calling [Manager.Renew]
+// every interval, or DefaultRenewInterval if not positive.
+func (m *Manager) Schedule(s *cron.Scheduler, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultRenewInterval
+	}
+
+	return s.Add(cron.Job{
+		Name:     "acme-renew",
+		Schedule: cron.Every(interval),
+		Run:      m.Renew,
+	})
+}
+
+func (m *Manager) expiring(cert *tls.Certificate) bool {
+	renewBefore := core.Coalesce(m.RenewBefore, DefaultRenewBefore)
+	return time.Now().Add(renewBefore).After(cert.Leaf.NotAfter)
+}
+
+// client returns the [acme.Client] making sure the account
+// is registered.
+func (m *Manager) client(ctx context.Context) (*acme.Client, error) {
+	m.regMu.Lock()
+	defer m.regMu.Unlock()
+
+	if m.Client == nil {
+		m.Client = &acme.Client{DirectoryURL: acme.LetsEncryptURL}
+	}
+
+	if m.account {
+		return m.Client, nil
+	}
+
+	if m.Client.Key == nil {
+		key, err := m.accountKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.Client.Key = key
+	}
+
+	acct := &acme.Account{}
+	if m.Email != "" {
+		acct.Contact = []string{"mailto:" + m.Email}
+	}
+
+	_, err := m.Client.Register(ctx, acct, acme.AcceptTOS)
+	if err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, core.Wrap(err, "failed to register account")
+	}
+
+	m.account = true
+	return m.Client, nil
+}
+
+// accountKey loads the account key from Storage, or
+// generates and stores a new one.
+func (m *Manager) accountKey(ctx context.Context) (x509utils.PrivateKey, error) {
+	data, err := m.Storage.Get(ctx, accountKeyName)
+	switch {
+	case err == nil:
+		if block, _ := pem.Decode(data); block != nil {
+			return x509utils.BlockToPrivateKey(block)
+		}
+		return nil, core.Wrap(core.ErrInvalid, "bad account key")
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = x509utils.EncodePKCS8PrivateKey(key)
+	if err == nil {
+		err = m.Storage.Put(ctx, accountKeyName, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (m *Manager) load(ctx context.Context, name string) (*tls.Certificate, error) {
+	data, err := m.Storage.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return decodePair(data)
+}
+
+func (m *Manager) store(ctx context.Context, name string, cert *tls.Certificate) error {
+	key, ok := cert.PrivateKey.(x509utils.PrivateKey)
+	if !ok {
+		return x509utils.ErrNotSupported
+	}
+
+	data, err := encodePair(key, cert.Certificate)
+	if err != nil {
+		return err
+	}
+	return m.Storage.Put(ctx, name, data)
+}
+
+func (m *Manager) getCached(name string) *tls.Certificate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.certs[name]
+}
+
+func (m *Manager) setCached(name string, cert *tls.Certificate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.certs == nil {
+		m.certs = make(map[string]*tls.Certificate)
+	}
+	m.certs[name] = cert
+}
+
+func (m *Manager) cached() map[string]*tls.Certificate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]*tls.Certificate, len(m.certs))
+	for name, cert := range m.certs {
+		out[name] = cert
+	}
+	return out
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// checkName normalizes a server name, refusing anything but a valid
+// host name with at least two labels, so it's safe to use as
+// [Storage] key.
+func checkName(name string) (string, error) {
+	name = normalizeName(name)
+	if !isHostname(name) || !strings.Contains(name, ".") {
+		return "", core.Wrapf(core.ErrInvalid, "bad server name %q", name)
+	}
+	return name, nil
+}
+
+func isHostname(name string) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if !isHostnameLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHostnameLabel(label string) bool {
+	n := len(label)
+	if n == 0 || n > 63 || label[0] == '-' || label[n-1] == '-' {
+		return false
+	}
+
+	for _, c := range []byte(label) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
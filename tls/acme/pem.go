@@ -0,0 +1,86 @@
+package acme
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls"
+	"darvaza.org/x/tls/x509utils"
+)
+
+// encodePair encodes a key followed by its certificate chain.
+func encodePair(key x509utils.PrivateKey, chain [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if _, err := x509utils.WriteKey(&buf, key); err != nil {
+		return nil, err
+	}
+
+	for _, der := range chain {
+		buf.Write(x509utils.EncodeCertificate(der))
+	}
+	return buf.Bytes(), nil
+}
+
+// decodePair decodes what [encodePair] produced.
+func decodePair(b []byte) (*tls.Certificate, error) {
+	var out tls.Certificate
+
+	for {
+		var block *pem.Block
+
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+
+		if err := addBlock(&out, block); err != nil {
+			return nil, err
+		}
+	}
+
+	return checkPair(&out)
+}
+
+func addBlock(out *tls.Certificate, block *pem.Block) error {
+	cert, err := x509utils.BlockToCertificate(block)
+	switch {
+	case cert != nil:
+		out.Certificate = append(out.Certificate, cert.Raw)
+		if out.Leaf == nil {
+			out.Leaf = cert
+		}
+		return nil
+	case err != x509utils.ErrIgnored:
+		return err
+	}
+
+	key, err := x509utils.BlockToPrivateKey(block)
+	if key != nil {
+		out.PrivateKey = key
+	}
+	return err
+}
+
+func checkPair(out *tls.Certificate) (*tls.Certificate, error) {
+	switch {
+	case out.Leaf == nil:
+		return nil, core.Wrap(core.ErrInvalid, "certificate missing")
+	case out.PrivateKey == nil:
+		return nil, core.Wrap(core.ErrInvalid, "private key missing")
+	case !x509utils.ValidCertKeyPair(out.Leaf, out.PrivateKey):
+		return nil, core.Wrap(core.ErrInvalid, "private key doesn't match the certificate")
+	default:
+		return out, nil
+	}
+}
+
+// parseLeaf returns the certificate of a chain.
+func parseLeaf(chain [][]byte) (*x509.Certificate, error) {
+	if len(chain) == 0 {
+		return nil, core.Wrap(core.ErrInvalid, "empty chain")
+	}
+	return x509.ParseCertificate(chain[0])
+}
@@ -0,0 +1,102 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	xfs "darvaza.org/x/fs"
+)
+
+var _ Storage = (*FSStorage)(nil)
+
+// Storage keeps the account key and the certificates obtained.
+// Get must fail with [fs.ErrNotExist] for unknown names.
+type Storage interface {
+	Get(ctx context.Context, name string) ([]byte, error)
+	Put(ctx context.Context, name string, data []byte) error
+	Delete(ctx context.Context, name string) error
+}
+
+// StorageFS is the file system required by [FSStorage], as
+// provided by [xfs.Sandbox].
+type StorageFS interface {
+	xfs.WriteFileFS
+	xfs.RemoveFS
+}
+
+// FSStorage is a [Storage] keeping each entry as a file.
+type FSStorage struct {
+	fSys StorageFS
+	dir  string
+}
+
+// NewFSStorage creates a [FSStorage] using the given directory
+// of the file system.
+func NewFSStorage(fSys StorageFS, dir string) *FSStorage {
+	return &FSStorage{
+		fSys: fSys,
+		dir:  path.Clean(dir),
+	}
+}
+
+// Get reads an entry.
+func (s *FSStorage) Get(ctx context.Context, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fileName, err := s.path("read", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(s.fSys, fileName)
+}
+
+// Put writes an entry, readable only by the owner.
+func (s *FSStorage) Put(ctx context.Context, name string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fileName, err := s.path("write", name)
+	if err != nil {
+		return err
+	}
+
+	if m, ok := s.fSys.(xfs.MkdirAllFS); ok && s.dir != "." {
+		if err := m.MkdirAll(s.dir, 0o700); err != nil {
+			return err
+		}
+	}
+	return s.fSys.WriteFile(fileName, data, 0o600)
+}
+
+// Delete removes an entry. Unknown names are ignored.
+func (s *FSStorage) Delete(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fileName, err := s.path("remove", name)
+	if err != nil {
+		return err
+	}
+
+	err = s.fSys.Remove(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path returns the file name of an entry, refusing names
+// that aren't a single path element.
+func (s *FSStorage) path(op, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join(s.dir, name), nil
+}
@@ -6,6 +6,8 @@ require (
 	darvaza.org/core v0.16.0
 	darvaza.org/slog v0.6.0
 	darvaza.org/x/container v0.2.0
+	darvaza.org/x/fs v0.5.0
+	darvaza.org/x/sync v0.1.0
 )
 
 require (
@@ -14,8 +16,14 @@ require (
 )
 
 require (
+	github.com/gobwas/glob v0.2.3 // indirect
 	github.com/klauspost/cpuid/v2 v2.2.9 // indirect
 	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
+
+replace (
+	darvaza.org/x/fs => ../fs
+	darvaza.org/x/sync => ../sync
+)
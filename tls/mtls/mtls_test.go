@@ -0,0 +1,231 @@
+package mtls
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls/x509utils"
+)
+
+type testCA struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	key := newKey(t)
+	return &testCA{key: key, cert: mustCreate(t, tpl, tpl, key, key)}
+}
+
+// PEM returns the CA certificate PEM encoded.
+func (ca *testCA) PEM() string {
+	var buf bytes.Buffer
+	_, _ = x509utils.WriteCert(&buf, ca.cert)
+	return buf.String()
+}
+
+func (ca *testCA) issue(t *testing.T, tpl *x509.Certificate) *x509.Certificate {
+	t.Helper()
+
+	tpl.SerialNumber = big.NewInt(2)
+	tpl.NotBefore = time.Now().Add(-time.Hour)
+	tpl.NotAfter = time.Now().Add(time.Hour)
+	tpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	return mustCreate(t, tpl, ca.cert, newKey(t), ca.key)
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func mustCreate(t *testing.T, tpl, parent *x509.Certificate, key, signer *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestPolicy(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+
+	spiffe, _ := url.Parse("spiffe://example.org/service/a")
+	client := ca.issue(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "a", OrganizationalUnit: []string{"ops"}},
+		DNSNames: []string{"a.clients.example.org"},
+		URIs:     []*url.URL{spiffe},
+	})
+
+	var revoked bool
+	p, err := New(&Config{
+		Hosts: map[string]Rule{
+			"api.example.org": {
+				CAs:      []string{ca.PEM()},
+				DNSNames: []string{"*.clients.example.org"},
+				URIs:     []string{"spiffe://example.org/service/"},
+			},
+			"*.ops.example.org": {
+				CAs:                 []string{ca.PEM()},
+				OrganizationalUnits: []string{"dev"},
+			},
+			"other.example.org": {
+				CAs:      []string{other.PEM()},
+				Optional: true,
+			},
+		},
+		CheckRevocation: func([]*x509.Certificate) error {
+			if revoked {
+				return errors.New("revoked")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certs := []*x509.Certificate{client}
+	for _, tc := range []struct {
+		host  string
+		certs []*x509.Certificate
+		ok    bool
+	}{
+		{"API.example.org", certs, true},
+		{"api.example.org", nil, false},
+		{"x.ops.example.org", certs, false},
+		{"other.example.org", certs, false},
+		{"other.example.org", nil, true},
+		{"unknown.example.org", nil, false},
+		{"", certs, false},
+	} {
+		err := p.VerifyConnection(tls.ConnectionState{
+			ServerName:       tc.host,
+			PeerCertificates: tc.certs,
+		})
+		if (err == nil) != tc.ok {
+			t.Errorf("ERROR: %s with %v certs: %v", tc.host, len(tc.certs), err)
+		}
+	}
+
+	for _, tc := range []struct {
+		serverName string
+		host       string
+		expected   error
+	}{
+		{"api.example.org", "api.example.org", nil},
+		{"api.example.org", "API.example.org:8443", nil},
+		{"other.example.org", "api.example.org", ErrHostMismatch},
+		{"other.example.org", "unknown.example.org", ErrUnknownHost},
+		{"a.ops.example.org", "b.ops.example.org", nil},
+	} {
+		cs := tls.ConnectionState{ServerName: tc.serverName}
+		err := p.CheckHost(cs, tc.host)
+		switch {
+		case tc.expected == nil && err != nil:
+			t.Errorf("ERROR: CheckHost(%q, %q) → %v", tc.serverName, tc.host, err)
+		case tc.expected != nil && !errors.Is(err, tc.expected):
+			t.Errorf("ERROR: CheckHost(%q, %q) → %v (expected %v)",
+				tc.serverName, tc.host, err, tc.expected)
+		}
+	}
+
+	cfg := p.ConfigForHost(&tls.Config{}, "unknown.example.org")
+	if err := cfg.VerifyConnection(tls.ConnectionState{}); !errors.Is(err, ErrUnknownHost) {
+		t.Errorf("ERROR: ConfigForHost(unknown) → %v (expected %v)", err, ErrUnknownHost)
+	}
+
+	revoked = true
+	if err := p.Verifier("api.example.org").Verify(certs); err == nil {
+		t.Error("ERROR: revoked certificate accepted")
+	}
+}
+
+func TestPolicyDefault(t *testing.T) {
+	ca := newTestCA(t)
+	client := ca.issue(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "a"},
+	})
+
+	p, err := New(&Config{Default: &Rule{CAs: []string{ca.PEM()}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		host  string
+		certs []*x509.Certificate
+		ok    bool
+	}{
+		{"", []*x509.Certificate{client}, true},
+		{"", nil, false},
+		{"unknown.example.org", []*x509.Certificate{client}, true},
+		{"unknown.example.org", nil, false},
+	} {
+		err := p.VerifyConnection(tls.ConnectionState{
+			ServerName:       tc.host,
+			PeerCertificates: tc.certs,
+		})
+		if (err == nil) != tc.ok {
+			t.Errorf("ERROR: %q with %v certs: %v", tc.host, len(tc.certs), err)
+		}
+	}
+
+	// the system roots are never used
+	_, err = New(&Config{Hosts: map[string]Rule{"a.example.org": {Optional: true}}})
+	if !errors.Is(err, core.ErrInvalid) {
+		t.Errorf("ERROR: rule without CAs: %v (expected %v)", err, core.ErrInvalid)
+	}
+}
+
+func TestMatchDNSName(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, value string
+		ok             bool
+	}{
+		{"a.example.org", "A.example.org", true},
+		{"*.example.org", "a.example.org", true},
+		{"*.example.org", "a.b.example.org", false},
+		{"*.example.org", "example.org", false},
+	} {
+		if matchDNSName(tc.pattern, tc.value) != tc.ok {
+			t.Errorf("ERROR: %q vs %q", tc.pattern, tc.value)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package mtls builds client certificate verification callbacks
+// from declarative per-host rules.
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"strings"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls/x509utils"
+)
+
+var (
+	// ErrCertificateRequired indicates the client didn't present
+	// a certificate when one was required.
+	ErrCertificateRequired = errors.New("client certificate required")
+
+	// ErrRejected indicates the client certificate is valid but
+	// not allowed by the rule.
+	ErrRejected = errors.New("client certificate rejected")
+
+	// ErrUnknownHost indicates the server name, or its absence,
+	// doesn't match any [Rule] and there is no default.
+	ErrUnknownHost = errors.New("no client authentication rule for host")
+
+	// ErrHostMismatch indicates a request is for a host with a
+	// different [Rule] than the server name of its connection.
+	ErrHostMismatch = errors.New("host doesn't match the server name")
+)
+
+// Config describes the client authentication of each host.
+type Config struct {
+	// Hosts maps server names to their [Rule]. Names
+	// starting with "*." apply to direct subdomains.
+	Hosts map[string]Rule
+
+	// Default is the [Rule] of hosts not listed, and of clients
+	// not sending a server name. If nil, their connections are
+	// refused.
+	Default *Rule
+
+	// CheckRevocation is called, if set, with the verified chain
+	// of every accepted client certificate. It returns an error
+	// if any of them has been revoked.
+	CheckRevocation func(chain []*x509.Certificate) error
+}
+
+// Policy holds ready to use [Verifier]s for each host.
+type Policy struct {
+	hosts    map[string]*Verifier
+	patterns map[string]*Verifier
+	def      *Verifier
+}
+
+// New compiles a [Config] into a [Policy], loading the CAs
+// of each [Rule].
+func New(cfg *Config) (*Policy, error) {
+	if cfg == nil {
+		return nil, core.Wrap(core.ErrInvalid, "config not provided")
+	}
+
+	p := &Policy{
+		hosts:    make(map[string]*Verifier),
+		patterns: make(map[string]*Verifier),
+	}
+
+	for name, rule := range cfg.Hosts {
+		v, err := newVerifier(&rule, cfg.CheckRevocation)
+		if err != nil {
+			return nil, core.Wrapf(err, "%q", name)
+		}
+
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if suffix, ok := strings.CutPrefix(name, "*"); ok {
+			p.patterns[suffix] = v
+		} else {
+			p.hosts[name] = v
+		}
+	}
+
+	if cfg.Default != nil {
+		v, err := newVerifier(cfg.Default, cfg.CheckRevocation)
+		if err != nil {
+			return nil, core.Wrap(err, "default")
+		}
+		p.def = v
+	}
+	return p, nil
+}
+
+// Verifier returns the [Verifier] of a server name, or nil
+// if there is no [Rule] for it.
+func (p *Policy) Verifier(serverName string) *Verifier {
+	name := strings.ToLower(strings.TrimSuffix(serverName, "."))
+	if v, ok := p.hosts[name]; ok {
+		return v
+	}
+	if suffix, ok := x509utils.NameAsSuffix(name); ok {
+		if v, ok := p.patterns[suffix]; ok {
+			return v
+		}
+	}
+	return p.def
+}
+
+func (p *Policy) verifier(serverName string) (*Verifier, error) {
+	if v := p.Verifier(serverName); v != nil {
+		return v, nil
+	}
+	return nil, core.Wrapf(ErrUnknownHost, "%q", serverName)
+}
+
+// Apply sets the Policy as client authentication of a
+// [tls.Config] shared by all hosts. Certificates are requested
+// from every client and verified once the server name is known.
+//
+// The server name is chosen by the client, so servers handling
+// several hosts on the same connection, like HTTP, must also use
+// [Policy.CheckHost] on every request.
+func (p *Policy) Apply(cfg *tls.Config) {
+	cfg.ClientAuth = tls.RequestClientCert
+	cfg.VerifyConnection = p.VerifyConnection
+}
+
+// VerifyConnection implements the [tls.Config] callback using
+// the [Verifier] of the negotiated server name. Connections
+// without a server name, or for hosts without a [Rule], fail
+// with [ErrUnknownHost] unless there is a default.
+func (p *Policy) VerifyConnection(cs tls.ConnectionState) error {
+	v, err := p.verifier(cs.ServerName)
+	if err != nil {
+		return err
+	}
+	return v.Verify(cs.PeerCertificates)
+}
+
+// CheckHost verifies a request for the given host, optionally
+// with port, can use a connection authenticated for its server
+// name. It fails with [ErrHostMismatch] if the host is subject
+// to a different [Rule].
+func (p *Policy) CheckHost(cs tls.ConnectionState, host string) error {
+	name, _, err := core.SplitHostPort(host)
+	if err != nil {
+		return core.Wrap(ErrHostMismatch, host)
+	}
+
+	v, err := p.verifier(name)
+	switch {
+	case err != nil:
+		return err
+	case v != p.Verifier(cs.ServerName):
+		return core.Wrapf(ErrHostMismatch, "%q on %q", host, cs.ServerName)
+	default:
+		return nil
+	}
+}
+
+// ConfigForHost returns a copy of the base [tls.Config] with the
+// client authentication of the given host, ready to use with
+// a GetConfigForClient router. Hosts without a [Rule] get a
+// config refusing the connection.
+func (p *Policy) ConfigForHost(base *tls.Config, serverName string) *tls.Config {
+	cfg := base.Clone()
+
+	v, err := p.verifier(serverName)
+	if err != nil {
+		cfg.VerifyConnection = func(tls.ConnectionState) error { return err }
+		return cfg
+	}
+
+	v.Apply(cfg)
+	return cfg
+}
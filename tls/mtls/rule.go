@@ -0,0 +1,224 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/fs"
+	"strings"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls/x509utils"
+)
+
+// Rule declares which client certificates are accepted.
+// Each non-empty list of names must be matched by at least
+// one value of the certificate.
+type Rule struct {
+	// CAs is the list of PEM files, directories or raw PEM
+	// containing the trusted issuers. Required, the system
+	// roots are never trusted for client certificates.
+	CAs []string
+
+	// DNSNames accepted, "*." patterns match one level.
+	DNSNames []string
+	// EmailAddresses accepted, "@domain" matches any address
+	// of the domain.
+	EmailAddresses []string
+	// URIs accepted, entries ending in "/" match as prefix.
+	URIs []string
+	// OrganizationalUnits accepted in the subject.
+	OrganizationalUnits []string
+	// CommonNames accepted as subject.
+	CommonNames []string
+
+	// Optional allows clients without certificate. Those
+	// presenting one still need to satisfy the rule.
+	Optional bool
+}
+
+// Verifier checks client certificates against a [Rule].
+type Verifier struct {
+	roots  *x509.CertPool
+	revoke func([]*x509.Certificate) error
+	rule   Rule
+}
+
+func newVerifier(r *Rule, revoke func([]*x509.Certificate) error) (*Verifier, error) {
+	if len(r.CAs) == 0 {
+		return nil, core.Wrap(core.ErrInvalid, "no CAs provided")
+	}
+
+	roots, err := loadCAs(r.CAs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		roots:  roots,
+		rule:   *r,
+		revoke: revoke,
+	}, nil
+}
+
+func loadCAs(values []string) (*x509.CertPool, error) {
+	var count int
+
+	pool := x509.NewCertPool()
+	fn := func(_ fs.FS, _ string, block *pem.Block) bool {
+		if cert, _ := x509utils.BlockToCertificate(block); cert != nil {
+			pool.AddCert(cert)
+			count++
+		}
+		return true
+	}
+
+	for _, s := range values {
+		if err := x509utils.ReadStringPEM(s, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	if count == 0 {
+		return nil, core.Wrap(core.ErrInvalid, "no CA certificates found")
+	}
+	return pool, nil
+}
+
+// ClientAuth returns the [tls.ClientAuthType] to use with
+// the Verifier, which takes care of the chain verification.
+func (v *Verifier) ClientAuth() tls.ClientAuthType {
+	if v.rule.Optional {
+		return tls.RequestClientCert
+	}
+	return tls.RequireAnyClientCert
+}
+
+// Apply sets the Verifier as client authentication of
+// a [tls.Config].
+func (v *Verifier) Apply(cfg *tls.Config) {
+	cfg.ClientAuth = v.ClientAuth()
+	cfg.ClientCAs = v.roots
+	cfg.VerifyPeerCertificate = v.VerifyPeerCertificate
+}
+
+// VerifyPeerCertificate implements the [tls.Config] callback.
+// Chains verified by crypto/tls are ignored.
+func (v *Verifier) VerifyPeerCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, der := range rawCerts {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return core.Wrap(err, "bad client certificate")
+		}
+		certs = append(certs, cert)
+	}
+	return v.Verify(certs)
+}
+
+// Verify checks the certificates presented by a client,
+// leaf first.
+func (v *Verifier) Verify(certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		if v.rule.Optional {
+			return nil
+		}
+		return ErrCertificateRequired
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	chains, err := certs[0].Verify(opts)
+	if err != nil {
+		return core.Wrap(err, "failed to validate client certificate")
+	}
+
+	if err := v.match(certs[0]); err != nil {
+		return err
+	}
+
+	if v.revoke != nil {
+		return v.revoke(chains[0])
+	}
+	return nil
+}
+
+func (v *Verifier) match(cert *x509.Certificate) error {
+	r := &v.rule
+
+	switch {
+	case !matchAny(r.DNSNames, cert.DNSNames, matchDNSName):
+		return core.Wrap(ErrRejected, "DNS name not allowed")
+	case !matchAny(r.EmailAddresses, cert.EmailAddresses, matchEmail):
+		return core.Wrap(ErrRejected, "email address not allowed")
+	case !matchAny(r.URIs, uriStrings(cert), matchURI):
+		return core.Wrap(ErrRejected, "URI not allowed")
+	case !matchAny(r.OrganizationalUnits, cert.Subject.OrganizationalUnit, matchExact):
+		return core.Wrap(ErrRejected, "organizational unit not allowed")
+	case !matchAny(r.CommonNames, []string{cert.Subject.CommonName}, matchExact):
+		return core.Wrap(ErrRejected, "common name not allowed")
+	default:
+		return nil
+	}
+}
+
+// matchAny tells if any value matches any of the patterns,
+// or if there are no patterns at all.
+func matchAny(patterns, values []string, fn func(pattern, value string) bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if fn(pattern, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchExact(pattern, value string) bool {
+	return pattern == value
+}
+
+func matchDNSName(pattern, value string) bool {
+	pattern = strings.ToLower(pattern)
+	value = strings.ToLower(value)
+
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		s, ok := x509utils.NameAsSuffix(value)
+		return ok && s == suffix
+	}
+	return pattern == value
+}
+
+func matchEmail(pattern, value string) bool {
+	if strings.HasPrefix(pattern, "@") {
+		return strings.HasSuffix(strings.ToLower(value), strings.ToLower(pattern))
+	}
+	return strings.EqualFold(pattern, value)
+}
+
+func matchURI(pattern, value string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(value, pattern)
+	}
+	return pattern == value
+}
+
+func uriStrings(cert *x509.Certificate) []string {
+	out := make([]string, 0, len(cert.URIs))
+	for _, u := range cert.URIs {
+		out = append(out, u.String())
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"darvaza.org/core"
+)
+
+// maxResponseSize limits what we read from a responder.
+const maxResponseSize = 1 << 20
+
+// Run refreshes the responses in the background until the
+// context is cancelled, fetching new certificates as soon as
+// they are tracked.
+func (s *Stapler) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		case <-s.wake:
+		}
+
+		_ = s.Refresh(ctx)
+		resetTimer(timer, time.Until(s.nextDue()))
+	}
+}
+
+// resetTimer stops, drains and resets a timer.
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+// Refresh fetches all responses that are due.
+func (s *Stapler) Refresh(ctx context.Context) error {
+	var errs core.CompoundError
+
+	for _, e := range s.due(time.Now()) {
+		if err := s.update(ctx, e); err != nil {
+			errs.AppendError(err)
+		}
+	}
+	return errs.AsError()
+}
+
+func (s *Stapler) due(now time.Time) []*entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*entry
+	for _, e := range s.entries {
+		if e.err == nil && !now.Before(e.next) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// nextDue returns when the next response is due, or an hour
+// from now if nothing is tracked.
+func (s *Stapler) nextDue() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	next := time.Now().Add(DefaultRefreshInterval)
+	for _, e := range s.entries {
+		if e.err == nil && e.next.Before(next) {
+			next = e.next
+		}
+	}
+	return next
+}
+
+// update fetches the response of an entry and schedules
+// the next.
+func (s *Stapler) update(ctx context.Context, e *entry) error {
+	raw, resp, err := s.fetch(ctx, e)
+
+	s.mu.Lock()
+	now := time.Now()
+	if resp != nil {
+		e.raw, e.resp = raw, resp
+		e.next = s.nextRefresh(resp, now)
+	} else {
+		e.next = now.Add(s.cfg.RetryInterval)
+	}
+	s.mu.Unlock()
+
+	if resp != nil && s.cfg.OnUpdate != nil {
+		s.cfg.OnUpdate(e.leaf, resp)
+	}
+	if err != nil && s.cfg.OnError != nil {
+		s.cfg.OnError(e.leaf, err)
+	}
+	return err
+}
+
+// nextRefresh returns when to fetch again, halfway through
+// the validity of the response.
+func (s *Stapler) nextRefresh(resp *ocsp.Response, now time.Time) time.Time {
+	next := now.Add(DefaultRefreshInterval)
+	if !resp.NextUpdate.IsZero() {
+		next = resp.ThisUpdate.Add(resp.NextUpdate.Sub(resp.ThisUpdate) / 2)
+	}
+
+	if earliest := now.Add(s.cfg.MinInterval); next.Before(earliest) {
+		return earliest
+	}
+	return next
+}
+
+// fetch asks the responder of an entry. Revoked certificates
+// produce both the response and [ErrRevoked].
+func (s *Stapler) fetch(ctx context.Context, e *entry) ([]byte, *ocsp.Response, error) {
+	body, err := ocsp.CreateRequest(e.leaf, e.issuer, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := s.post(ctx, e.leaf.OCSPServer[0], body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := ocsp.ParseResponseForCert(raw, e.leaf, e.issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		return raw, resp, nil
+	case ocsp.Revoked:
+		return raw, resp, ErrRevoked
+	default:
+		return nil, nil, core.Wrap(core.ErrInvalid, "unknown certificate status")
+	}
+}
+
+func (s *Stapler) post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+
+	res, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+}
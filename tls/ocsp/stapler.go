@@ -0,0 +1,247 @@
+// Package ocsp fetches, caches and staples OCSP responses for
+// the certificates served, refreshing them before they expire.
+package ocsp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls"
+)
+
+const (
+	// DefaultRetryInterval is how long to wait after a failed
+	// fetch when the [Config] doesn't say.
+	DefaultRetryInterval = 5 * time.Minute
+
+	// DefaultMinInterval is the minimum time between fetches of
+	// the same response when the [Config] doesn't say.
+	DefaultMinInterval = time.Minute
+
+	// DefaultRefreshInterval is used for responses without
+	// NextUpdate.
+	DefaultRefreshInterval = time.Hour
+)
+
+var (
+	// ErrNoResponder indicates the certificate doesn't
+	// list an OCSP server.
+	ErrNoResponder = errors.New("no OCSP responder")
+
+	// ErrNoIssuer indicates the chain doesn't include the
+	// issuer of the leaf certificate.
+	ErrNoIssuer = errors.New("issuer certificate missing")
+
+	// ErrRevoked indicates the responder reported the
+	// certificate as revoked.
+	ErrRevoked = errors.New("certificate revoked")
+)
+
+// Config tunes a [Stapler].
+type Config struct {
+	// Client is used to reach the responders,
+	// http.DefaultClient if nil.
+	Client *http.Client
+
+	// OnUpdate is called, if set, after every successful fetch.
+	OnUpdate func(leaf *x509.Certificate, resp *ocsp.Response)
+
+	// OnError is called, if set, after every failed fetch.
+	OnError func(leaf *x509.Certificate, err error)
+
+	// RetryInterval is how long to wait after a failed fetch.
+	RetryInterval time.Duration
+
+	// MinInterval is the minimum time between fetches of the
+	// same response.
+	MinInterval time.Duration
+}
+
+// SetDefaults fills the gaps in the [Config].
+func (cfg *Config) SetDefaults() {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = DefaultRetryInterval
+	}
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = DefaultMinInterval
+	}
+}
+
+// GetCertificateFunc is the signature of the [tls.Config]
+// GetCertificate callback.
+type GetCertificateFunc func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+
+// Stapler keeps fresh OCSP responses for a set of certificates
+// and staples them when served.
+type Stapler struct {
+	mu      sync.RWMutex
+	entries map[[sha256.Size]byte]*entry
+	wake    chan struct{}
+	cfg     Config
+}
+
+// entry is a tracked certificate. Certificates that can't
+// be stapled are kept with their err to avoid checking them
+// again.
+type entry struct {
+	leaf   *x509.Certificate
+	issuer *x509.Certificate
+	resp   *ocsp.Response
+	err    error
+	raw    []byte
+	next   time.Time
+}
+
+// New creates a [Stapler]. A nil [Config] uses the defaults.
+func New(cfg *Config) *Stapler {
+	s := &Stapler{
+		entries: make(map[[sha256.Size]byte]*entry),
+		wake:    make(chan struct{}, 1),
+	}
+
+	if cfg != nil {
+		s.cfg = *cfg
+	}
+	s.cfg.SetDefaults()
+	return s
+}
+
+// Add starts tracking a certificate, fetching its
+// first response.
+func (s *Stapler) Add(ctx context.Context, cert *tls.Certificate) error {
+	e, err := s.track(cert)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, e)
+}
+
+// Remove stops tracking a certificate.
+func (s *Stapler) Remove(cert *tls.Certificate) {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, sha256.Sum256(cert.Certificate[0]))
+}
+
+// track adds an entry for the certificate, if new, due
+// immediately.
+func (s *Stapler) track(cert *tls.Certificate) (*entry, error) {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, core.Wrap(core.ErrInvalid, "certificate not provided")
+	}
+
+	key := sha256.Sum256(cert.Certificate[0])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = newEntry(cert)
+		s.entries[key] = e
+		s.notify()
+	}
+	return e, e.err
+}
+
+func newEntry(cert *tls.Certificate) *entry {
+	e := &entry{leaf: cert.Leaf}
+	if e.leaf == nil {
+		e.leaf, e.err = x509.ParseCertificate(cert.Certificate[0])
+	}
+
+	switch {
+	case e.err != nil:
+	case len(e.leaf.OCSPServer) == 0:
+		e.err = ErrNoResponder
+	case len(cert.Certificate) < 2:
+		e.err = ErrNoIssuer
+	default:
+		e.issuer, e.err = x509.ParseCertificate(cert.Certificate[1])
+	}
+	return e
+}
+
+func (s *Stapler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Staple returns a copy of the certificate with the current
+// OCSP response attached, or the certificate itself if there
+// is none.
+func (s *Stapler) Staple(cert *tls.Certificate) *tls.Certificate {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return cert
+	}
+
+	key := sha256.Sum256(cert.Certificate[0])
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.raw == nil || !e.valid(time.Now()) {
+		return cert
+	}
+
+	out := *cert
+	out.OCSPStaple = e.raw
+	return &out
+}
+
+// GetCertificate wraps a [tls.Config] callback stapling the
+// certificates it returns. New certificates are tracked and
+// fetched by [Stapler.Run].
+func (s *Stapler) GetCertificate(next GetCertificateFunc) GetCertificateFunc {
+	return func(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, err := next(chi)
+		if err != nil || cert == nil {
+			return cert, err
+		}
+
+		// certificates that can't be stapled are served as-is
+		if _, err := s.track(cert); err != nil {
+			return cert, nil
+		}
+		return s.Staple(cert), nil
+	}
+}
+
+// Response returns the current OCSP response of a tracked
+// certificate, if any.
+func (s *Stapler) Response(cert *tls.Certificate) (*ocsp.Response, bool) {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if e, ok := s.entries[sha256.Sum256(cert.Certificate[0])]; ok && e.resp != nil {
+		return e.resp, true
+	}
+	return nil, false
+}
+
+func (e *entry) valid(now time.Time) bool {
+	return e.resp.NextUpdate.IsZero() || now.Before(e.resp.NextUpdate)
+}
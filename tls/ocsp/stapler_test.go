@@ -0,0 +1,204 @@
+package ocsp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"darvaza.org/x/tls"
+)
+
+type testResponder struct {
+	key    *ecdsa.PrivateKey
+	cert   *x509.Certificate
+	status atomic.Int32
+	hits   atomic.Int32
+}
+
+func newTestResponder(t *testing.T) (*testResponder, *httptest.Server) {
+	t.Helper()
+
+	key := newKey(t)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	r := &testResponder{key: key, cert: mustCreate(t, tpl, tpl, key, key)}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+func (r *testResponder) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	r.hits.Add(1)
+
+	body, _ := io.ReadAll(req.Body)
+	q, err := ocsp.ParseRequest(body)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	der, err := ocsp.CreateResponse(r.cert, r.cert, ocsp.Response{
+		Status:       int(r.status.Load()),
+		SerialNumber: q.SerialNumber,
+		ThisUpdate:   now.Add(-time.Minute),
+		NextUpdate:   now.Add(time.Hour),
+		RevokedAt:    now.Add(-time.Minute),
+	}, r.key)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(der)
+}
+
+func (r *testResponder) issue(t *testing.T, url string) *tls.Certificate {
+	t.Helper()
+
+	key := newKey(t)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "example.org"},
+		DNSNames:     []string{"example.org"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		OCSPServer:   []string{url},
+	}
+	leaf := mustCreate(t, tpl, r.cert, key, r.key)
+
+	return &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw, r.cert.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func mustCreate(t *testing.T, tpl, parent *x509.Certificate, key, signer *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestStapler(t *testing.T) {
+	r, srv := newTestResponder(t)
+	cert := r.issue(t, srv.URL)
+
+	var updates atomic.Int32
+	s := New(&Config{
+		OnUpdate: func(*x509.Certificate, *ocsp.Response) { updates.Add(1) },
+	})
+
+	if err := s.Add(context.Background(), cert); err != nil {
+		t.Fatal(err)
+	}
+
+	stapled := s.Staple(cert)
+	if len(stapled.OCSPStaple) == 0 || cert.OCSPStaple != nil {
+		t.Fatal("ERROR: not stapled on a copy")
+	}
+
+	// not due, the responder isn't asked again
+	if err := s.Refresh(context.Background()); err != nil || r.hits.Load() != 1 {
+		t.Errorf("ERROR: refresh: %v, %v hits", err, r.hits.Load())
+	}
+	if n := updates.Load(); n != 1 {
+		t.Errorf("ERROR: %v updates (expected 1)", n)
+	}
+}
+
+func TestStaplerRevoked(t *testing.T) {
+	r, srv := newTestResponder(t)
+	r.status.Store(ocsp.Revoked)
+	cert := r.issue(t, srv.URL)
+
+	var failed error
+	s := New(&Config{
+		OnError: func(_ *x509.Certificate, err error) { failed = err },
+	})
+
+	if err := s.Add(context.Background(), cert); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("ERROR: unexpected %v", err)
+	}
+	if !errors.Is(failed, ErrRevoked) {
+		t.Errorf("ERROR: OnError got %v", failed)
+	}
+	if resp, ok := s.Response(cert); !ok || resp.Status != ocsp.Revoked {
+		t.Error("ERROR: revoked response not kept")
+	}
+}
+
+func TestStaplerGetCertificate(t *testing.T) {
+	r, srv := newTestResponder(t)
+	cert := r.issue(t, srv.URL)
+	plain := &tls.Certificate{Certificate: [][]byte{r.cert.Raw}, Leaf: r.cert}
+
+	s := New(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	get := s.GetCertificate(func(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if chi.ServerName == "plain" {
+			return plain, nil
+		}
+		return cert, nil
+	})
+
+	if got, _ := get(&tls.ClientHelloInfo{ServerName: "plain"}); got != plain {
+		t.Error("ERROR: certificate without responder altered")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := get(&tls.ClientHelloInfo{ServerName: "example.org"})
+		switch {
+		case err != nil:
+			t.Fatal(err)
+		case len(got.OCSPStaple) > 0:
+			return
+		case time.Now().After(deadline):
+			t.Fatal("ERROR: never stapled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,151 @@
+package tls
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// ErrPinMismatch indicates no certificate of the peer matched
+// the pinned public keys.
+var ErrPinMismatch = errors.New("public key pin mismatch")
+
+// PinConfig describes a set of SPKI pins. Pins are the base64
+// encoded SHA-256 of the SubjectPublicKeyInfo, as used by HPKP,
+// optionally prefixed by "sha256/".
+type PinConfig struct {
+	// OnFailure is called, if set, for every connection not
+	// matching the pins, even in report-only mode.
+	OnFailure func(cs tls.ConnectionState, err error)
+
+	// Pins of the keys in use.
+	Pins []string
+	// Backup pins of keys not deployed yet, accepted as well so
+	// upstreams can rotate before the pins are updated.
+	Backup []string
+
+	// ReportOnly accepts connections not matching the pins,
+	// only calling OnFailure.
+	ReportOnly bool
+}
+
+// PinVerifier enforces SPKI pins on connections.
+type PinVerifier struct {
+	onFailure  func(tls.ConnectionState, error)
+	pins       map[[sha256.Size]byte]bool
+	reportOnly bool
+}
+
+// NewPinVerifier compiles a [PinConfig].
+func NewPinVerifier(cfg *PinConfig) (*PinVerifier, error) {
+	switch {
+	case cfg == nil:
+		return nil, core.Wrap(core.ErrInvalid, "config not provided")
+	case len(cfg.Pins) == 0:
+		return nil, core.Wrap(core.ErrInvalid, "no pins provided")
+	}
+
+	v := &PinVerifier{
+		onFailure:  cfg.OnFailure,
+		pins:       make(map[[sha256.Size]byte]bool),
+		reportOnly: cfg.ReportOnly,
+	}
+
+	for _, s := range append(core.SliceCopy(cfg.Pins), cfg.Backup...) {
+		pin, err := parsePin(s)
+		if err != nil {
+			return nil, err
+		}
+		v.pins[pin] = true
+	}
+	return v, nil
+}
+
+func parsePin(s string) ([sha256.Size]byte, error) {
+	var pin [sha256.Size]byte
+
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, "sha256/"))
+	switch {
+	case err != nil:
+		return pin, core.Wrapf(err, "bad pin %q", s)
+	case len(b) != sha256.Size:
+		return pin, core.Wrapf(core.ErrInvalid, "bad pin %q", s)
+	default:
+		copy(pin[:], b)
+		return pin, nil
+	}
+}
+
+// SPKIPin returns the pin of a certificate's public key.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Apply makes the [tls.Config] verify the pins after any
+// VerifyConnection it already had.
+func (v *PinVerifier) Apply(cfg *tls.Config) {
+	next := cfg.VerifyConnection
+	if next == nil {
+		cfg.VerifyConnection = v.VerifyConnection
+		return
+	}
+
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if err := next(cs); err != nil {
+			return err
+		}
+		return v.VerifyConnection(cs)
+	}
+}
+
+// VerifyConnection implements the [tls.Config] callback, accepting
+// the connection if any certificate of the verified chains matches
+// a pin. Unverified chains, as with InsecureSkipVerify, only match
+// on the leaf, as the peer can send copies of any other certificate
+// without holding its key.
+func (v *PinVerifier) VerifyConnection(cs tls.ConnectionState) error {
+	if v.matchState(cs) {
+		return nil
+	}
+
+	err := core.Wrapf(ErrPinMismatch, "%q", cs.ServerName)
+	if v.onFailure != nil {
+		v.onFailure(cs, err)
+	}
+
+	if v.reportOnly {
+		return nil
+	}
+	return err
+}
+
+func (v *PinVerifier) matchState(cs tls.ConnectionState) bool {
+	if len(cs.VerifiedChains) == 0 {
+		if len(cs.PeerCertificates) == 0 {
+			return false
+		}
+		return v.matchAny(cs.PeerCertificates[:1])
+	}
+
+	for _, chain := range cs.VerifiedChains {
+		if v.matchAny(chain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *PinVerifier) matchAny(certs []*x509.Certificate) bool {
+	for _, cert := range certs {
+		if v.pins[sha256.Sum256(cert.RawSubjectPublicKeyInfo)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newTestCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.org"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func unverified(certs ...*x509.Certificate) tls.ConnectionState {
+	return tls.ConnectionState{PeerCertificates: certs}
+}
+
+func TestPinVerifier(t *testing.T) {
+	pinned, backup, other := newTestCert(t), newTestCert(t), newTestCert(t)
+
+	var failures int
+	cfg := &PinConfig{
+		Pins:      []string{SPKIPin(pinned)},
+		Backup:    []string{SPKIPin(backup)},
+		OnFailure: func(tls.ConnectionState, error) { failures++ },
+	}
+
+	v, err := NewPinVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tc := range []struct {
+		cs tls.ConnectionState
+		ok bool
+	}{
+		{unverified(pinned), true},
+		{unverified(backup), true},
+		{unverified(other), false},
+		// only the leaf of unverified chains counts
+		{unverified(other, pinned), false},
+		{tls.ConnectionState{}, false},
+		{tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{other, pinned},
+			VerifiedChains:   [][]*x509.Certificate{{other, pinned}},
+		}, true},
+	} {
+		err := v.VerifyConnection(tc.cs)
+		if (err == nil) != tc.ok || (err != nil && !errors.Is(err, ErrPinMismatch)) {
+			t.Errorf("[%v] ERROR: unexpected %v", i, err)
+		}
+	}
+
+	cfg.ReportOnly = true
+	v, _ = NewPinVerifier(cfg)
+	err = v.VerifyConnection(tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{other},
+	})
+	if err != nil || failures != 4 {
+		t.Errorf("ERROR: report-only: %v, %v failures", err, failures)
+	}
+
+	if _, err := NewPinVerifier(&PinConfig{Pins: []string{"sha256/short"}}); err == nil {
+		t.Error("ERROR: bad pin accepted")
+	}
+}
@@ -0,0 +1,356 @@
+package pkcs12
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"hash"
+	"math/big"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"darvaza.org/core"
+)
+
+const (
+	saltSize  = 16
+	macKeyID  = 3
+	blockSize = 64
+)
+
+func newHash(oid asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA256), oid.Equal(oidHMACSHA256):
+		return sha256.New, nil
+	case oid.Equal(oidSHA1), oid.Equal(oidHMACSHA1):
+		return sha1.New, nil
+	default:
+		return nil, core.Wrapf(errLegacy, "unsupported hash %s", oid)
+	}
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// bmpString encodes the password as a NUL terminated BMPString
+// as required by the PKCS#12 key derivation.
+func bmpString(s string) ([]byte, error) {
+	out := make([]byte, 0, 2*len(s)+2)
+	for _, r := range s {
+		if utf16.IsSurrogate(r) || r > 0xffff {
+			return nil, core.Wrap(core.ErrInvalid, "password can't be encoded as BMPString")
+		}
+		out = append(out, byte(r>>8), byte(r))
+	}
+	return append(out, 0, 0), nil
+}
+
+// deriveKey implements the PKCS#12 key derivation, RFC 7292
+// appendix B.2, as still used to compute the MAC.
+func deriveKey(h func() hash.Hash, id byte, password, salt []byte, iterations, size int) []byte {
+	fill := func(b []byte) []byte {
+		out := make([]byte, blockSize*((len(b)+blockSize-1)/blockSize))
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+
+	d := bytes.Repeat([]byte{id}, blockSize)
+	in := append(fill(salt), fill(password)...)
+
+	var out []byte
+	one := big.NewInt(1)
+	for len(out) < size {
+		a := hashN(h, iterations, d, in)
+		out = append(out, a...)
+
+		// I_j = (I_j + B + 1) mod 2^(v*8)
+		b := new(big.Int).SetBytes(fill(a))
+		b.Add(b, one)
+		for j := 0; j < len(in); j += blockSize {
+			ij := new(big.Int).SetBytes(in[j : j+blockSize])
+			ij.Add(ij, b)
+			copy(in[j:j+blockSize], padLeft(ij.Bytes(), blockSize))
+		}
+	}
+	return out[:size]
+}
+
+func hashN(h func() hash.Hash, n int, data ...[]byte) []byte {
+	w := h()
+	for _, b := range data {
+		_, _ = w.Write(b)
+	}
+	sum := w.Sum(nil)
+	for i := 1; i < n; i++ {
+		w.Reset()
+		_, _ = w.Write(sum)
+		sum = w.Sum(sum[:0])
+	}
+	return sum
+}
+
+// padLeft returns the last n bytes of b, zero padded on the left.
+func padLeft(b []byte, n int) []byte {
+	if len(b) >= n {
+		return b[len(b)-n:]
+	}
+	out := make([]byte, n)
+	copy(out[n-len(b):], b)
+	return out
+}
+
+func computeMac(md *macData, content []byte, password string) ([]byte, error) {
+	h, err := newHash(md.Mac.Algorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	pw, err := bmpString(password)
+	if err != nil {
+		return nil, err
+	}
+
+	key := deriveKey(h, macKeyID, pw, md.MacSalt, md.Iterations, h().Size())
+	mac := hmac.New(h, key)
+	_, _ = mac.Write(content)
+	return mac.Sum(nil), nil
+}
+
+func verifyMac(md *macData, content []byte, password string) error {
+	if md.Mac.Algorithm.Algorithm == nil {
+		return core.Wrap(core.ErrInvalid, "pkcs12: MAC missing")
+	}
+	if err := checkIterations(md.Iterations); err != nil {
+		return err
+	}
+
+	sum, err := computeMac(md, content, password)
+	switch {
+	case err != nil:
+		return err
+	case !hmac.Equal(sum, md.Mac.Digest):
+		return ErrIncorrectPassword
+	default:
+		return nil
+	}
+}
+
+func newMac(content []byte, password string, iterations int) (macData, error) {
+	salt, err := randomBytes(saltSize)
+	if err != nil {
+		return macData{}, err
+	}
+
+	md := macData{
+		Mac: digestInfo{
+			Algorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidSHA256,
+				Parameters: asn1.NullRawValue,
+			},
+		},
+		MacSalt:    salt,
+		Iterations: iterations,
+	}
+
+	md.Mac.Digest, err = computeMac(&md, content, password)
+	return md, err
+}
+
+// pbes2Key derives the AES key and returns the IV of a PBES2
+// algorithm identifier.
+func pbes2Key(alg pkix.AlgorithmIdentifier, password string) (key, iv []byte, err error) {
+	params, kdf, err := parsePBES2(alg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size, err := aesKeySize(params.EncryptionScheme.Algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// RFC 8018 default
+	prf := core.Coalesce(kdf.PRF.Algorithm, oidHMACSHA1)
+	h, err := newHash(prf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv)
+	switch {
+	case err != nil:
+		return nil, nil, err
+	case len(iv) != aes.BlockSize:
+		return nil, nil, core.Wrap(core.ErrInvalid, "pkcs12: bad IV")
+	}
+
+	key = pbkdf2.Key([]byte(password), kdf.Salt, kdf.Iterations, size, h)
+	return key, iv, nil
+}
+
+func parsePBES2(alg pkix.AlgorithmIdentifier) (*pbes2Params, *pbkdf2Params, error) {
+	var params pbes2Params
+	var kdf pbkdf2Params
+
+	if !alg.Algorithm.Equal(oidPBES2) {
+		// legacy schemes are decoded elsewhere, but still bounded
+		var legacy pbeParams
+		if unmarshal(alg.Parameters.FullBytes, &legacy) == nil {
+			if err := checkIterations(legacy.Iterations); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, core.Wrapf(errLegacy, "unsupported algorithm %s", alg.Algorithm)
+	}
+	if err := unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return nil, nil, err
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, nil, core.Wrapf(errLegacy, "unsupported KDF %s", params.KeyDerivationFunc.Algorithm)
+	}
+	if err := unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, nil, err
+	}
+	if err := checkIterations(kdf.Iterations); err != nil {
+		return nil, nil, err
+	}
+	return &params, &kdf, nil
+}
+
+// checkIterations refuses iteration counts outside the range
+// we are willing to compute for untrusted input.
+func checkIterations(n int) error {
+	if n < 1 || n > MaxIterations {
+		return core.Wrapf(core.ErrInvalid, "pkcs12: %v iterations out of range", n)
+	}
+	return nil
+}
+
+func aesKeySize(oid asn1.ObjectIdentifier) (int, error) {
+	switch {
+	case oid.Equal(oidAES128CBC):
+		return 16, nil
+	case oid.Equal(oidAES192CBC):
+		return 24, nil
+	case oid.Equal(oidAES256CBC):
+		return 32, nil
+	default:
+		return 0, core.Wrapf(errLegacy, "unsupported cipher %s", oid)
+	}
+}
+
+func decrypt(alg pkix.AlgorithmIdentifier, data []byte, password string) ([]byte, error) {
+	key, iv, err := pbes2Key(alg, password)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, core.Wrap(core.ErrInvalid, "pkcs12: bad ciphertext length")
+	}
+
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	return unpad(out)
+}
+
+func unpad(b []byte) ([]byte, error) {
+	n := int(b[len(b)-1])
+	if n == 0 || n > aes.BlockSize || n > len(b) {
+		return nil, ErrIncorrectPassword
+	}
+	for _, c := range b[len(b)-n:] {
+		if int(c) != n {
+			return nil, ErrIncorrectPassword
+		}
+	}
+	return b[:len(b)-n], nil
+}
+
+// encrypt uses PBES2 with PBKDF2-HMAC-SHA256 and AES-256-CBC.
+func encrypt(data []byte, password string, iterations int) (pkix.AlgorithmIdentifier, []byte, error) {
+	var alg pkix.AlgorithmIdentifier
+
+	salt, err := randomBytes(saltSize)
+	if err != nil {
+		return alg, nil, err
+	}
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return alg, nil, err
+	}
+
+	alg, err = pbes2Algorithm(salt, iv, iterations)
+	if err != nil {
+		return alg, nil, err
+	}
+
+	key := pbkdf2.Key([]byte(password), salt, iterations, 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return alg, nil, err
+	}
+
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	out := append(core.SliceCopy(data), bytes.Repeat([]byte{byte(n)}, n)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, out)
+	return alg, out, nil
+}
+
+func pbes2Algorithm(salt, iv []byte, iterations int) (pkix.AlgorithmIdentifier, error) {
+	kdf, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: iterations,
+		PRF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidHMACSHA256,
+			Parameters: asn1.NullRawValue,
+		},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+
+	ivDER, err := asn1.Marshal(iv)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBKDF2,
+			Parameters: asn1.RawValue{FullBytes: kdf},
+		},
+		EncryptionScheme: pkix.AlgorithmIdentifier{
+			Algorithm:  oidAES256CBC,
+			Parameters: asn1.RawValue{FullBytes: ivDER},
+		},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+
+	return pkix.AlgorithmIdentifier{
+		Algorithm:  oidPBES2,
+		Parameters: asn1.RawValue{FullBytes: params},
+	}, nil
+}
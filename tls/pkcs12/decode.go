@@ -0,0 +1,208 @@
+package pkcs12
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	"golang.org/x/crypto/pkcs12"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls/x509utils"
+)
+
+// MaxIterations is the highest iteration count accepted when
+// deriving the keys of a PKCS#12 file.
+const MaxIterations = 1 << 20
+
+// Decode extracts the certificates and private keys of a PKCS#12
+// file. Files using the legacy RC2 and 3DES encryption schemes
+// are supported as well.
+func Decode(data []byte, password string) (*x509utils.Bundle, error) {
+	out, err := decode(data, password)
+	if errors.Is(err, errLegacy) {
+		return decodeLegacy(data, password)
+	}
+	return out, err
+}
+
+func decode(data []byte, password string) (*x509utils.Bundle, error) {
+	safe, err := readAuthSafe(data, password)
+	if err != nil {
+		return nil, err
+	}
+
+	out := new(x509utils.Bundle)
+	for i := range safe {
+		if err := addContents(out, &safe[i], password); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func readAuthSafe(data []byte, password string) ([]contentInfo, error) {
+	var pfx pfxPdu
+	if err := unmarshal(data, &pfx); err != nil {
+		return nil, core.Wrap(err, "pkcs12")
+	}
+
+	if pfx.Version != 3 {
+		return nil, core.Wrapf(ErrNotImplemented, "version %v", pfx.Version)
+	}
+
+	content, err := dataContent(&pfx.AuthSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyMac(&pfx.MacData, content, password); err != nil {
+		return nil, err
+	}
+
+	var safe []contentInfo
+	if err := unmarshal(content, &safe); err != nil {
+		return nil, core.Wrap(err, "pkcs12")
+	}
+	return safe, nil
+}
+
+func dataContent(ci *contentInfo) ([]byte, error) {
+	var b []byte
+
+	if !ci.ContentType.Equal(oidData) {
+		return nil, core.Wrapf(ErrNotImplemented, "content type %s", ci.ContentType)
+	}
+	if err := unmarshal(ci.Content.Bytes, &b); err != nil {
+		return nil, core.Wrap(err, "pkcs12")
+	}
+	return b, nil
+}
+
+func addContents(out *x509utils.Bundle, ci *contentInfo, password string) error {
+	content, err := safeContents(ci, password)
+	if err != nil {
+		return err
+	}
+
+	var bags []safeBag
+	if err := unmarshal(content, &bags); err != nil {
+		return core.Wrap(err, "pkcs12")
+	}
+
+	for i := range bags {
+		if err := addBag(out, &bags[i], password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func safeContents(ci *contentInfo, password string) ([]byte, error) {
+	var ed encryptedData
+
+	if !ci.ContentType.Equal(oidEncryptedData) {
+		return dataContent(ci)
+	}
+	if err := unmarshal(ci.Content.Bytes, &ed); err != nil {
+		return nil, core.Wrap(err, "pkcs12")
+	}
+
+	eci := &ed.EncryptedContentInfo
+	return decrypt(eci.ContentEncryptionAlgorithm, eci.EncryptedContent, password)
+}
+
+func addBag(out *x509utils.Bundle, bag *safeBag, password string) error {
+	switch {
+	case bag.ID.Equal(oidCertBag):
+		cert, err := parseCertBag(bag.Value.Bytes)
+		if err != nil {
+			return err
+		}
+		out.Certs = append(out.Certs, cert)
+	case bag.ID.Equal(oidShroudedKeyBag), bag.ID.Equal(oidKeyBag):
+		key, err := parseKeyBag(bag, password)
+		if err != nil {
+			return err
+		}
+		out.Keys = append(out.Keys, key)
+	}
+	// other bags ignored
+	return nil
+}
+
+func parseCertBag(der []byte) (*x509.Certificate, error) {
+	var bag certBag
+
+	if err := unmarshal(der, &bag); err != nil {
+		return nil, core.Wrap(err, "pkcs12")
+	}
+	if !bag.ID.Equal(oidX509Cert) {
+		return nil, core.Wrapf(ErrNotImplemented, "certificate type %s", bag.ID)
+	}
+	return x509.ParseCertificate(bag.Data)
+}
+
+func parseKeyBag(bag *safeBag, password string) (x509utils.PrivateKey, error) {
+	der := bag.Value.Bytes
+	if bag.ID.Equal(oidShroudedKeyBag) {
+		var info encryptedPrivateKeyInfo
+		if err := unmarshal(der, &info); err != nil {
+			return nil, core.Wrap(err, "pkcs12")
+		}
+
+		b, err := decrypt(info.Algorithm, info.EncryptedData, password)
+		if err != nil {
+			return nil, err
+		}
+		der = b
+	}
+
+	return x509utils.BlockToPrivateKey(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: der,
+	})
+}
+
+// decodeLegacy uses [pkcs12.ToPEM], which converts ECDSA keys
+// to SEC 1.
+func decodeLegacy(data []byte, password string) (*x509utils.Bundle, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	switch {
+	case err == pkcs12.ErrIncorrectPassword:
+		return nil, ErrIncorrectPassword
+	case err != nil:
+		return nil, core.Wrap(err, "pkcs12")
+	}
+
+	out := new(x509utils.Bundle)
+	for _, block := range blocks {
+		if err := addLegacyBlock(out, block); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func addLegacyBlock(out *x509utils.Bundle, block *pem.Block) error {
+	cert, err := x509utils.BlockToCertificate(block)
+	switch {
+	case cert != nil:
+		out.Certs = append(out.Certs, cert)
+		return nil
+	case err != x509utils.ErrIgnored:
+		return err
+	}
+
+	key, err := x509utils.BlockToPrivateKey(block)
+	if key == nil {
+		ec, e2 := x509.ParseECPrivateKey(block.Bytes)
+		if e2 != nil {
+			return err
+		}
+		key = ec
+	}
+
+	out.Keys = append(out.Keys, key)
+	return nil
+}
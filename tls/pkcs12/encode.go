@@ -0,0 +1,170 @@
+package pkcs12
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls/x509utils"
+)
+
+// DefaultIterations is the number of iterations used to derive
+// the encryption and MAC keys.
+const DefaultIterations = 2048
+
+// Encode encodes a private key and the chain of its certificate,
+// leaf first, as PKCS#12 protected by the given password.
+// Both the private key and the certificates are encrypted using
+// PBES2 with AES-256, and the MAC uses SHA-256, as required by
+// modern versions of OpenSSL and Windows.
+func Encode(key x509utils.PrivateKey, chain []*x509.Certificate, password string) ([]byte, error) {
+	switch {
+	case key == nil:
+		return nil, core.Wrap(core.ErrInvalid, "private key not provided")
+	case len(chain) == 0:
+		return nil, core.Wrap(core.ErrInvalid, "certificate not provided")
+	case !x509utils.ValidCertKeyPair(chain[0], key):
+		return nil, &x509utils.ErrInvalidCert{
+			Cert:   chain[0],
+			Reason: "certificate doesn't match the private key",
+		}
+	}
+
+	attrs, err := localKeyID(chain[0])
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := encodeCerts(chain, attrs, password)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := encodeKey(key, attrs, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodePFX([]contentInfo{certs, keys}, password)
+}
+
+// localKeyID links the private key with its certificate.
+func localKeyID(leaf *x509.Certificate) ([]pkcs12Attribute, error) {
+	sum := sha1.Sum(leaf.Raw)
+	id, err := asn1.Marshal(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return []pkcs12Attribute{
+		{
+			ID: oidLocalKeyID,
+			Value: asn1.RawValue{
+				Tag:        asn1.TagSet,
+				IsCompound: true,
+				Bytes:      id,
+			},
+		},
+	}, nil
+}
+
+func encodeCerts(chain []*x509.Certificate, attrs []pkcs12Attribute, password string) (contentInfo, error) {
+	bags := make([]safeBag, 0, len(chain))
+	for i, cert := range chain {
+		der, err := asn1.Marshal(certBag{ID: oidX509Cert, Data: cert.Raw})
+		if err != nil {
+			return contentInfo{}, err
+		}
+
+		bag := safeBag{ID: oidCertBag, Value: explicit(der)}
+		if i == 0 {
+			bag.Attributes = attrs
+		}
+		bags = append(bags, bag)
+	}
+
+	content, err := asn1.Marshal(bags)
+	if err != nil {
+		return contentInfo{}, err
+	}
+
+	return encryptedContent(content, password)
+}
+
+func encryptedContent(content []byte, password string) (contentInfo, error) {
+	alg, data, err := encrypt(content, password, DefaultIterations)
+	if err != nil {
+		return contentInfo{}, err
+	}
+
+	der, err := asn1.Marshal(encryptedData{
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                oidData,
+			ContentEncryptionAlgorithm: alg,
+			EncryptedContent:           data,
+		},
+	})
+	if err != nil {
+		return contentInfo{}, err
+	}
+
+	return contentInfo{ContentType: oidEncryptedData, Content: explicit(der)}, nil
+}
+
+func encodeKey(key x509utils.PrivateKey, attrs []pkcs12Attribute, password string) (contentInfo, error) {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return contentInfo{}, err
+	}
+
+	alg, data, err := encrypt(pkcs8, password, DefaultIterations)
+	if err != nil {
+		return contentInfo{}, err
+	}
+
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: alg, EncryptedData: data})
+	if err != nil {
+		return contentInfo{}, err
+	}
+
+	content, err := asn1.Marshal([]safeBag{
+		{ID: oidShroudedKeyBag, Value: explicit(der), Attributes: attrs},
+	})
+	if err != nil {
+		return contentInfo{}, err
+	}
+
+	return newDataContent(content)
+}
+
+func newDataContent(content []byte) (contentInfo, error) {
+	der, err := asn1.Marshal(content)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{ContentType: oidData, Content: explicit(der)}, nil
+}
+
+func encodePFX(safe []contentInfo, password string) ([]byte, error) {
+	content, err := asn1.Marshal(safe)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := newMac(content, password, DefaultIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	authSafe, err := newDataContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(pfxPdu{
+		Version:  3,
+		AuthSafe: authSafe,
+		MacData:  md,
+	})
+}
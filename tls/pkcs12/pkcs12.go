@@ -0,0 +1,112 @@
+// Package pkcs12 reads and writes PKCS#12 (.p12/.pfx) files,
+// as used by Windows and MDM tooling to move certificates
+// together with their private key.
+package pkcs12
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls"
+	"darvaza.org/x/tls/x509utils"
+)
+
+var (
+	// ErrIncorrectPassword indicates the integrity check of the
+	// file failed, usually because of a wrong password.
+	ErrIncorrectPassword = errors.New("pkcs12: decryption password incorrect")
+
+	// ErrNotImplemented indicates the file uses a feature of
+	// PKCS#12 not supported by this package.
+	ErrNotImplemented = errors.New("pkcs12: not implemented")
+
+	// errLegacy indicates an encryption scheme only handled by
+	// golang.org/x/crypto/pkcs12.
+	errLegacy = core.Wrap(ErrNotImplemented, "legacy encryption scheme")
+)
+
+// DecodeCertificate extracts the first private key of a PKCS#12
+// file and the chain of its certificate.
+func DecodeCertificate(data []byte, password string) (*tls.Certificate, error) {
+	pairs, err := decodePairs(data, password)
+	if err != nil {
+		return nil, err
+	}
+	return newCertificate(&pairs[0]), nil
+}
+
+// Import adds every private key found in a PKCS#12 file, with
+// the chain of its certificate, to the [tls.StoreWriter].
+func Import(ctx context.Context, store tls.StoreWriter, data []byte, password string) error {
+	if store == nil {
+		return tls.ErrNoStore
+	}
+
+	pairs, err := decodePairs(data, password)
+	if err != nil {
+		return err
+	}
+
+	for i := range pairs {
+		if err := store.Put(ctx, newCertificate(&pairs[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodePairs(data []byte, password string) ([]x509utils.KeyPair, error) {
+	bundle, err := Decode(data, password)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, err := bundle.Pairs()
+	switch {
+	case err != nil:
+		return nil, err
+	case len(pairs) == 0:
+		return nil, core.Wrap(x509utils.ErrEmpty, "pkcs12: no private key")
+	default:
+		return pairs, nil
+	}
+}
+
+func newCertificate(pair *x509utils.KeyPair) *tls.Certificate {
+	chain := make([][]byte, 0, len(pair.Chain))
+	for _, cert := range pair.Chain {
+		chain = append(chain, cert.Raw)
+	}
+
+	return &tls.Certificate{
+		Certificate: chain,
+		PrivateKey:  pair.Key,
+		Leaf:        pair.Chain[0],
+	}
+}
+
+// EncodeCertificate encodes a [tls.Certificate] and its chain
+// as PKCS#12, protected by the given password.
+func EncodeCertificate(cert *tls.Certificate, password string) ([]byte, error) {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, core.Wrap(core.ErrInvalid, "certificate not provided")
+	}
+
+	key, ok := cert.PrivateKey.(x509utils.PrivateKey)
+	if !ok {
+		return nil, x509utils.ErrNotSupported
+	}
+
+	chain := make([]*x509.Certificate, 0, len(cert.Certificate))
+	for _, der := range cert.Certificate {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, c)
+	}
+
+	return Encode(key, chain, password)
+}
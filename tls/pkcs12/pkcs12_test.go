@@ -0,0 +1,126 @@
+package pkcs12
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/tls"
+)
+
+func newTestCert(t *testing.T, tpl, parent *x509.Certificate,
+	signer *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	//
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parent == nil {
+		parent, signer = tpl, key
+	}
+
+	tpl.NotBefore = time.Now().Add(-time.Hour)
+	tpl.NotAfter = time.Now().Add(time.Hour)
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestEncodeDecode(t *testing.T) {
+	ca, caKey := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+
+	leaf, key := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "example.org"},
+		DNSNames:     []string{"example.org"},
+	}, ca, caKey)
+
+	data, err := EncodeCertificate(&tls.Certificate{
+		Certificate: [][]byte{leaf.Raw, ca.Raw},
+		PrivateKey:  key,
+	}, "sécret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := DecodeCertificate(data, "sécret")
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case len(cert.Certificate) != 1, !cert.Leaf.Equal(leaf):
+		// self-signed roots aren't part of the chain
+		t.Errorf("ERROR: unexpected chain %v", len(cert.Certificate))
+	case !key.Equal(cert.PrivateKey):
+		t.Error("ERROR: private key mismatch")
+	}
+
+	if b, err := Decode(data, "sécret"); err != nil || len(b.Certs) != 2 {
+		t.Errorf("ERROR: unexpected bundle: %v", err)
+	}
+
+	if _, err := Decode(data, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("ERROR: wrong password: %v", err)
+	}
+
+	if _, err := Encode(key, []*x509.Certificate{ca}, ""); err == nil {
+		t.Error("ERROR: mismatching key accepted")
+	}
+}
+
+func TestIterationsLimit(t *testing.T) {
+	cert, key := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.org"},
+	}, nil, nil)
+
+	data, err := Encode(key, []*x509.Certificate{cert}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pfx pfxPdu
+	if err := unmarshal(data, &pfx); err != nil {
+		t.Fatal(err)
+	}
+	pfx.MacData.Iterations = MaxIterations + 1
+	data, err = asn1.Marshal(pfx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Decode(data, "secret"); !errors.Is(err, core.ErrInvalid) {
+		t.Errorf("ERROR: unexpected error: %v", err)
+	}
+
+	for i, n := range []int{0, 1, MaxIterations, MaxIterations + 1} {
+		err := checkIterations(n)
+		if ok := n >= 1 && n <= MaxIterations; ok != (err == nil) {
+			t.Errorf("[%v] ERROR: %v iterations: %v", i, n, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package tls
+
+import (
+	"crypto/tls"
+	"encoding"
+	"sort"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ encoding.TextMarshaler   = Profile{}
+	_ encoding.TextUnmarshaler = (*Profile)(nil)
+)
+
+// Profile is a curated set of [tls.Config] parameters,
+// selectable by name. It can be decoded from configuration
+// files using its name.
+type Profile struct {
+	Name             string
+	CipherSuites     []uint16
+	CurvePreferences []tls.CurveID
+	MinVersion       uint16
+	MaxVersion       uint16
+}
+
+// Names of the predefined profiles, following the Mozilla
+// server side TLS recommendations.
+const (
+	// ProfileModern only allows TLS 1.3.
+	ProfileModern = "modern"
+	// ProfileIntermediate allows TLS 1.2 with AEAD ciphers and
+	// forward secrecy, and TLS 1.3.
+	ProfileIntermediate = "intermediate"
+	// ProfileLegacy allows TLS 1.0 and CBC ciphers for
+	// ancient clients.
+	ProfileLegacy = "legacy"
+
+	// DefaultProfile is the profile used when none is named.
+	DefaultProfile = ProfileIntermediate
+)
+
+var defaultCurves = []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384}
+
+var intermediateSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+var legacySuites = append(core.SliceCopy(intermediateSuites),
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+)
+
+var profiles = map[string]Profile{
+	ProfileModern: {
+		Name:             ProfileModern,
+		CurvePreferences: defaultCurves,
+		MinVersion:       tls.VersionTLS13,
+	},
+	ProfileIntermediate: {
+		Name:             ProfileIntermediate,
+		CipherSuites:     intermediateSuites,
+		CurvePreferences: defaultCurves,
+		MinVersion:       tls.VersionTLS12,
+	},
+	ProfileLegacy: {
+		Name:             ProfileLegacy,
+		CipherSuites:     legacySuites,
+		CurvePreferences: defaultCurves,
+		MinVersion:       tls.VersionTLS10,
+	},
+}
+
+// GetProfile returns a predefined [Profile] by name, ignoring
+// case. An empty name returns the DefaultProfile.
+func GetProfile(name string) (Profile, bool) {
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	p, ok := profiles[strings.ToLower(name)]
+	if !ok {
+		return Profile{}, false
+	}
+	return p.Clone(), true
+}
+
+// ProfileNames returns the names of the predefined profiles.
+func ProfileNames() []string {
+	out := make([]string, 0, len(profiles))
+	for name := range profiles {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Clone returns a copy of the [Profile].
+func (p Profile) Clone() Profile {
+	p.CipherSuites = core.SliceCopy(p.CipherSuites)
+	p.CurvePreferences = core.SliceCopy(p.CurvePreferences)
+	return p
+}
+
+// Apply sets the parameters of the [Profile] on a [tls.Config].
+func (p Profile) Apply(cfg *tls.Config) {
+	cfg.CipherSuites = core.SliceCopy(p.CipherSuites)
+	cfg.CurvePreferences = core.SliceCopy(p.CurvePreferences)
+	cfg.MinVersion = p.MinVersion
+	cfg.MaxVersion = p.MaxVersion
+}
+
+// Config returns a new [tls.Config] using the [Profile].
+func (p Profile) Config() *tls.Config {
+	cfg := new(tls.Config)
+	p.Apply(cfg)
+	return cfg
+}
+
+// MarshalText encodes the [Profile] as its name.
+func (p Profile) MarshalText() ([]byte, error) {
+	return []byte(p.Name), nil
+}
+
+// UnmarshalText sets the [Profile] to the predefined
+// one of the given name.
+func (p *Profile) UnmarshalText(b []byte) error {
+	v, ok := GetProfile(string(b))
+	if !ok {
+		return core.Wrapf(core.ErrInvalid, "unknown TLS profile %q", b)
+	}
+
+	*p = v
+	return nil
+}
@@ -0,0 +1,38 @@
+package tls
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	var v struct {
+		Profile Profile `json:"profile"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"profile":"Modern"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := v.Profile.Config()
+	if cfg.MinVersion != tls.VersionTLS13 || v.Profile.Name != ProfileModern {
+		t.Errorf("ERROR: unexpected profile %+v", v.Profile)
+	}
+
+	b, _ := json.Marshal(&v)
+	if s := string(b); s != `{"profile":"modern"}` {
+		t.Errorf("ERROR: encoded as %s", s)
+	}
+
+	if err := json.Unmarshal([]byte(`{"profile":"bogus"}`), &v); err == nil {
+		t.Error("ERROR: unknown profile accepted")
+	}
+
+	// profiles are copies
+	p, _ := GetProfile("")
+	p.CipherSuites[0] = 0
+	if q, _ := GetProfile(ProfileIntermediate); q.CipherSuites[0] == 0 {
+		t.Error("ERROR: predefined profile modified")
+	}
+}
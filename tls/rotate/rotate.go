@@ -0,0 +1,317 @@
+// Package rotate replaces key pairs before they expire, keeping
+// the previous one around during an overlap period and notifying
+// dependent components of every change.
+package rotate
+
+import (
+	"context"
+	"crypto/x509"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/core"
+	xsync "darvaza.org/x/sync"
+	"darvaza.org/x/tls"
+)
+
+const (
+	// DefaultOverlap is how long the previous key pair is kept
+	// when the [Config] doesn't say.
+	DefaultOverlap = time.Hour
+
+	// DefaultRetryInterval is how long to wait after a failed
+	// rotation when the [Config] doesn't say.
+	DefaultRetryInterval = time.Minute
+
+	// DefaultEventBuffer is the number of [Event]s buffered for
+	// each subscriber when the [Config] doesn't say.
+	DefaultEventBuffer = 8
+)
+
+var _ tls.Store = (*Rotator)(nil)
+
+// Event describes a change of key pairs. Previous is the pair still
+// served during the overlap, and Retired the one no longer used.
+type Event struct {
+	Current  *tls.Certificate
+	Previous *tls.Certificate
+	Retired  *tls.Certificate
+}
+
+// Config describes how a [Rotator] obtains and publishes key pairs.
+type Config struct {
+	// Issue produces a new key pair. Required.
+	Issue func(ctx context.Context) (*tls.Certificate, error)
+
+	// Store, if set, receives every new key pair before it's
+	// served, and loses the retired ones.
+	Store tls.StoreWriter
+
+	// Roots, if set, are used to verify new key pairs.
+	Roots *x509.CertPool
+
+	// OnError is called, if set, for every failed rotation
+	// done by [Rotator.Run].
+	OnError func(error)
+
+	// RotateBefore is how long before expiration a key pair is
+	// replaced. If zero, a third of its lifetime.
+	RotateBefore time.Duration
+
+	// Overlap is how long the previous key pair is still served
+	// to clients not supporting the new one.
+	Overlap time.Duration
+
+	// RetryInterval is how long to wait after a failed rotation.
+	RetryInterval time.Duration
+
+	// EventBuffer is the number of [Event]s buffered for each
+	// subscriber. Slow subscribers miss events.
+	EventBuffer int
+}
+
+// SetDefaults fills the gaps in the [Config].
+func (cfg *Config) SetDefaults() {
+	if cfg.Overlap <= 0 {
+		cfg.Overlap = DefaultOverlap
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = DefaultRetryInterval
+	}
+	if cfg.EventBuffer <= 0 {
+		cfg.EventBuffer = DefaultEventBuffer
+	}
+}
+
+// Rotator serves a key pair replacing it before it expires.
+type Rotator struct {
+	mu     sync.Mutex // serialises changes
+	state  atomic.Pointer[state]
+	events *xsync.Broadcaster[Event]
+	cfg    Config
+}
+
+// state is an immutable snapshot of the key pairs in use.
+type state struct {
+	current  *tls.Certificate
+	previous *tls.Certificate
+	until    time.Time
+}
+
+// New creates a [Rotator] issuing its first key pair.
+func New(ctx context.Context, cfg *Config) (*Rotator, error) {
+	switch {
+	case cfg == nil:
+		return nil, core.Wrap(core.ErrInvalid, "config not provided")
+	case cfg.Issue == nil:
+		return nil, core.Wrap(core.ErrInvalid, "issuer not provided")
+	}
+
+	r := &Rotator{cfg: *cfg}
+	r.cfg.SetDefaults()
+	r.events = xsync.NewBroadcaster[Event](r.cfg.EventBuffer, xsync.BroadcastDrop)
+
+	if err := r.Rotate(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Subscribe returns a subscription to the [Event]s of
+// every rotation and retirement.
+func (r *Rotator) Subscribe() (*xsync.Subscription[Event], error) {
+	return r.events.Subscribe()
+}
+
+// Close ends all subscriptions.
+func (r *Rotator) Close() error {
+	return r.events.Close()
+}
+
+// Current returns the key pair being served.
+func (r *Rotator) Current() *tls.Certificate {
+	return r.state.Load().current
+}
+
+// Certificates returns the current key pair followed by the
+// previous, if still in the overlap period.
+func (r *Rotator) Certificates() []*tls.Certificate {
+	st := r.state.Load()
+	if st.previous == nil {
+		return []*tls.Certificate{st.current}
+	}
+	return []*tls.Certificate{st.current, st.previous}
+}
+
+// GetCertificate implements the [tls.Config] callback, serving the
+// previous key pair only to clients not supporting the current.
+func (r *Rotator) GetCertificate(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	st := r.state.Load()
+	if st.previous != nil && chi != nil && chi.SupportsCertificate(st.current) != nil {
+		if chi.SupportsCertificate(st.previous) == nil {
+			return st.previous, nil
+		}
+	}
+	return st.current, nil
+}
+
+// GetCAPool returns the configured Roots.
+func (r *Rotator) GetCAPool() *x509.CertPool {
+	return r.cfg.Roots
+}
+
+// Rotate replaces the current key pair with a new one, keeping
+// the current as previous during the overlap period.
+func (r *Rotator) Rotate(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cert, err := r.issue(ctx)
+	if err != nil {
+		return err
+	}
+
+	if s := r.cfg.Store; s != nil {
+		if err := s.Put(ctx, cert); err != nil {
+			return core.Wrap(err, "failed to store new key pair")
+		}
+	}
+
+	ev := Event{Current: cert}
+	st := &state{current: cert}
+	if old := r.state.Load(); old != nil {
+		st.previous = old.current
+		st.until = time.Now().Add(r.cfg.Overlap)
+		ev.Previous, ev.Retired = old.current, old.previous
+	}
+
+	r.state.Store(st)
+	return r.finish(ctx, ev)
+}
+
+func (r *Rotator) issue(ctx context.Context) (*tls.Certificate, error) {
+	cert, err := r.cfg.Issue(ctx)
+	switch {
+	case err != nil:
+		return nil, core.Wrap(err, "failed to issue key pair")
+	case cert == nil || len(cert.Certificate) == 0:
+		return nil, core.Wrap(core.ErrInvalid, "issuer returned no key pair")
+	case cert.Leaf == nil:
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, err
+		}
+
+		c := *cert
+		c.Leaf = leaf
+		cert = &c
+	}
+
+	if err := tls.Verify(cert, r.cfg.Roots); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// retire drops the previous key pair once the overlap is over.
+func (r *Rotator) retire(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := r.state.Load()
+	if st.previous == nil || time.Now().Before(st.until) {
+		return nil
+	}
+
+	r.state.Store(&state{current: st.current})
+	return r.finish(ctx, Event{Current: st.current, Retired: st.previous})
+}
+
+// finish removes the retired key pair from the Store and
+// publishes the [Event].
+func (r *Rotator) finish(ctx context.Context, ev Event) error {
+	var err error
+	if s := r.cfg.Store; s != nil && ev.Retired != nil {
+		err = s.Delete(ctx, ev.Retired)
+		if err != nil {
+			err = core.Wrap(err, "failed to remove retired key pair")
+		}
+	}
+
+	_ = r.events.Publish(ctx, ev)
+	return err
+}
+
+// Run rotates and retires key pairs when due until the context
+// is cancelled.
+func (r *Rotator) Run(ctx context.Context) error {
+	var retry time.Time
+
+	timer := time.NewTimer(time.Until(r.next(retry)))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		retry = r.tick(ctx)
+		timer.Reset(time.Until(r.next(retry)))
+	}
+}
+
+// tick does whatever is due, returning when to retry if
+// the rotation failed.
+func (r *Rotator) tick(ctx context.Context) time.Time {
+	if err := r.retire(ctx); err != nil {
+		r.onError(err)
+	}
+
+	if time.Now().Before(r.rotateAt(r.Current())) {
+		return time.Time{}
+	}
+
+	err := r.Rotate(ctx)
+	if err == nil && !time.Now().Before(r.rotateAt(r.Current())) {
+		// don't spin on key pairs too short for RotateBefore
+		err = core.Wrap(core.ErrInvalid, "new key pair already due for rotation")
+	}
+
+	if err != nil {
+		r.onError(err)
+		return time.Now().Add(r.cfg.RetryInterval)
+	}
+	return time.Time{}
+}
+
+func (r *Rotator) onError(err error) {
+	if fn := r.cfg.OnError; fn != nil {
+		fn(err)
+	}
+}
+
+// next returns when something is due next.
+func (r *Rotator) next(retry time.Time) time.Time {
+	st := r.state.Load()
+
+	next := r.rotateAt(st.current)
+	if !retry.IsZero() {
+		next = retry
+	}
+	if st.previous != nil && st.until.Before(next) {
+		next = st.until
+	}
+	return next
+}
+
+// rotateAt returns when a key pair needs to be replaced.
+func (r *Rotator) rotateAt(cert *tls.Certificate) time.Time {
+	leaf := cert.Leaf
+	if d := r.cfg.RotateBefore; d > 0 {
+		return leaf.NotAfter.Add(-d)
+	}
+	return leaf.NotAfter.Add(-leaf.NotAfter.Sub(leaf.NotBefore) / 3)
+}
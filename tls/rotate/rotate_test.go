@@ -0,0 +1,130 @@
+package rotate
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"darvaza.org/x/tls"
+)
+
+// newIssuer returns an Issue function producing self-signed key
+// pairs valid for ttl, numbered by serial.
+func newIssuer(t *testing.T, ttl time.Duration) (func(context.Context) (*tls.Certificate, error), *atomic.Int64) {
+	t.Helper()
+
+	var serial atomic.Int64
+	return func(context.Context) (*tls.Certificate, error) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		tpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial.Add(1)),
+			Subject:      pkix.Name{CommonName: "example.org"},
+			DNSNames:     []string{"example.org"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(ttl),
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+	}, &serial
+}
+
+func serialOf(cert *tls.Certificate) int64 {
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+func TestRotator(t *testing.T) {
+	issue, _ := newIssuer(t, time.Hour)
+	ctx := context.Background()
+
+	r, err := New(ctx, &Config{Issue: issue, Overlap: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	sub, err := r.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := <-sub.C()
+	if serialOf(ev.Current) != 2 || serialOf(ev.Previous) != 1 || ev.Retired != nil {
+		t.Fatalf("ERROR: unexpected event %+v", ev)
+	}
+	if certs := r.Certificates(); len(certs) != 2 {
+		t.Errorf("ERROR: %v certificates during overlap", len(certs))
+	}
+
+	// Run retires the previous once the overlap is over
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() { _ = r.Run(runCtx) }()
+
+	select {
+	case ev = <-sub.C():
+	case <-time.After(5 * time.Second):
+		t.Fatal("ERROR: previous never retired")
+	}
+	if serialOf(ev.Retired) != 1 || ev.Previous != nil {
+		t.Errorf("ERROR: unexpected event %+v", ev)
+	}
+	if certs := r.Certificates(); len(certs) != 1 || serialOf(certs[0]) != 2 {
+		t.Error("ERROR: previous still served")
+	}
+}
+
+func TestRotatorRun(t *testing.T) {
+	issue, serial := newIssuer(t, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 10)
+	r, err := New(ctx, &Config{
+		Issue:         issue,
+		RotateBefore:  2 * time.Hour,
+		RetryInterval: time.Hour,
+		OnError:       func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	go func() { _ = r.Run(ctx) }()
+
+	// each key pair is due right away, Run rotates
+	// once and then backs off
+	deadline := time.After(5 * time.Second)
+	for serial.Load() < 2 {
+		select {
+		case <-errs:
+		case <-deadline:
+			t.Fatal("ERROR: never rotated")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n := serial.Load(); n != 2 {
+		t.Errorf("ERROR: %v key pairs issued", n)
+	}
+}
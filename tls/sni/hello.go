@@ -0,0 +1,153 @@
+package sni
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	_ net.Listener = (*Listener)(nil)
+	_ net.Conn     = (*HelloConn)(nil)
+)
+
+// ErrAlreadyRead indicates the ClientHello can't be peeked
+// because the connection was read already.
+var ErrAlreadyRead = errors.New("connection already read")
+
+// HelloConn is a [net.Conn] whose ClientHello can be inspected
+// before the handshake without consuming it, so routing code can
+// decide on SNI and ALPN and still pass the untouched stream to
+// the chosen backend.
+type HelloConn struct {
+	net.Conn
+
+	mu      sync.Mutex
+	reader  io.Reader
+	chi     *tls.ClientHelloInfo
+	err     error
+	timeout time.Duration
+	peeked  bool
+}
+
+// NewHelloConn wraps a [net.Conn]. A positive timeout limits how
+// long [HelloConn.ClientHello] waits for the client.
+func NewHelloConn(conn net.Conn, timeout time.Duration) *HelloConn {
+	return &HelloConn{
+		Conn:    conn,
+		timeout: timeout,
+	}
+}
+
+// ClientHello reads the ClientHello once, returning the same
+// result on every call. It must be called before any Read.
+func (c *HelloConn) ClientHello(ctx context.Context) (*tls.ClientHelloInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.peeked {
+		c.peeked = true
+		if c.reader != nil {
+			c.err = ErrAlreadyRead
+		} else {
+			c.chi, c.err = c.peek(ctx)
+		}
+	}
+	return c.chi, c.err
+}
+
+func (c *HelloConn) peek(ctx context.Context) (*tls.ClientHelloInfo, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	deadline, ok := ctx.Deadline()
+	if c.timeout > 0 {
+		if t := time.Now().Add(c.timeout); !ok || t.Before(deadline) {
+			deadline, ok = t, true
+		}
+	}
+
+	if ok {
+		_ = c.Conn.SetReadDeadline(deadline)
+		defer func() { _ = c.Conn.SetReadDeadline(time.Time{}) }()
+	}
+
+	var buf bytes.Buffer
+	chi, err := ReadClientHelloInfo(ctx, io.TeeReader(c.Conn, &buf))
+	c.reader = io.MultiReader(&buf, c.Conn)
+	return chi, err
+}
+
+// ServerName returns the SNI of the ClientHello, if it was
+// read already.
+func (c *HelloConn) ServerName() string {
+	if chi := c.hello(); chi != nil {
+		return chi.ServerName
+	}
+	return ""
+}
+
+// SupportedProtos returns the ALPN protocols of the ClientHello,
+// if it was read already.
+func (c *HelloConn) SupportedProtos() []string {
+	if chi := c.hello(); chi != nil {
+		return chi.SupportedProtos
+	}
+	return nil
+}
+
+func (c *HelloConn) hello() *tls.ClientHelloInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.chi
+}
+
+// Read reads from the connection, starting with the replay of
+// the peeked bytes.
+func (c *HelloConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	if c.reader == nil {
+		c.reader = c.Conn
+	}
+	r := c.reader
+	c.mu.Unlock()
+
+	return r.Read(b)
+}
+
+// Listener is a [net.Listener] returning [HelloConn]s.
+type Listener struct {
+	net.Listener
+
+	// Timeout limits how long ClientHello waits for the client.
+	Timeout time.Duration
+}
+
+// NewListener wraps a [net.Listener] to sniff ClientHellos.
+func NewListener(ln net.Listener, timeout time.Duration) *Listener {
+	return &Listener{
+		Listener: ln,
+		Timeout:  timeout,
+	}
+}
+
+// Accept waits for the next connection, returned as a [HelloConn].
+func (l *Listener) Accept() (net.Conn, error) {
+	return l.AcceptHello()
+}
+
+// AcceptHello waits for the next connection.
+func (l *Listener) AcceptHello() (*HelloConn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewHelloConn(conn, l.Timeout), nil
+}
@@ -0,0 +1,58 @@
+package sni
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHelloConn(t *testing.T) {
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		conn := tls.Client(c, &tls.Config{
+			ServerName:         "example.org",
+			InsecureSkipVerify: true,
+			NextProtos:         []string{"h2"},
+		})
+		errCh <- conn.Handshake()
+	}()
+
+	conn := NewHelloConn(s, time.Second)
+	chi, err := conn.ClientHello(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chi.ServerName != "example.org" || conn.ServerName() != "example.org" {
+		t.Errorf("ERROR: server name %q", chi.ServerName)
+	}
+	if p := conn.SupportedProtos(); len(p) != 1 || p[0] != "h2" {
+		t.Errorf("ERROR: protocols %q", p)
+	}
+
+	// the backend still gets the whole handshake
+	cert := newTestCert(t, 1, "example.org")
+	srv := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{*cert}})
+	if err := srv.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHelloConnTimeout(t *testing.T) {
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	conn := NewHelloConn(s, 10*time.Millisecond)
+	if _, err := conn.ClientHello(context.Background()); err == nil {
+		t.Fatal("ERROR: no timeout")
+	}
+}
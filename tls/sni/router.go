@@ -0,0 +1,235 @@
+package sni
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"sync"
+
+	"darvaza.org/core"
+	xtls "darvaza.org/x/tls"
+	"darvaza.org/x/tls/x509utils"
+)
+
+// ErrNoCertificate indicates no certificate matched the
+// requested name and there was no default.
+var ErrNoCertificate = errors.New("no certificate for server name")
+
+// A Router chooses the [tls.Config] and certificate of each
+// connection based on the SNI, by exact name first, then by
+// wildcard and finally falling back to the defaults.
+//
+// Configs without certificates of their own use the
+// certificates added to the Router.
+//
+// router := &sni.Router{}
+// router.AddCertificate(cert)
+// router.AddConfig("*.example.org", &tls.Config{ClientAuth: ...})
+//
+// lsn := tls.NewListener(ln, router.Config())
+type Router struct {
+	mu sync.RWMutex
+
+	configs     map[string]*tls.Config
+	cfgPatterns map[string]*tls.Config
+	certs       map[string][]*tls.Certificate
+	patterns    map[string][]*tls.Certificate
+	defConfig   *tls.Config
+	defCert     *tls.Certificate
+}
+
+func (r *Router) init() {
+	if r.configs == nil {
+		r.configs = make(map[string]*tls.Config)
+		r.cfgPatterns = make(map[string]*tls.Config)
+		r.certs = make(map[string][]*tls.Certificate)
+		r.patterns = make(map[string][]*tls.Certificate)
+	}
+}
+
+// Config returns a [tls.Config] using the Router for
+// every connection.
+func (r *Router) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetCertificate:     r.GetCertificate,
+		GetConfigForClient: r.GetConfigForClient,
+	}
+}
+
+// AddConfig sets the [tls.Config] of a name, or of all direct
+// subdomains if the name starts with "*.". The config is cloned.
+func (r *Router) AddConfig(name string, cfg *tls.Config) error {
+	if cfg == nil {
+		return core.Wrap(core.ErrInvalid, "config not provided")
+	}
+
+	name, pattern, err := parseRouteName(name)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.init()
+	if pattern {
+		r.cfgPatterns[name] = r.prepare(cfg)
+	} else {
+		r.configs[name] = r.prepare(cfg)
+	}
+	return nil
+}
+
+// RemoveConfig removes the [tls.Config] of a name or pattern.
+func (r *Router) RemoveConfig(name string) {
+	name, pattern, err := parseRouteName(name)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pattern {
+		delete(r.cfgPatterns, name)
+	} else {
+		delete(r.configs, name)
+	}
+}
+
+// SetDefaultConfig sets the [tls.Config] used when no name
+// matches. With none, the listener's config is used.
+func (r *Router) SetDefaultConfig(cfg *tls.Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cfg != nil {
+		cfg = r.prepare(cfg)
+	}
+	r.defConfig = cfg
+}
+
+// prepare clones a config making it use the Router for
+// certificates if it has none.
+func (r *Router) prepare(cfg *tls.Config) *tls.Config {
+	cfg = cfg.Clone()
+	cfg.GetConfigForClient = nil
+	if len(cfg.Certificates) == 0 && cfg.GetCertificate == nil {
+		cfg.GetCertificate = r.GetCertificate
+	}
+	return cfg
+}
+
+// AddCertificate adds a key pair for all the names and
+// patterns of its leaf certificate.
+func (r *Router) AddCertificate(cert *tls.Certificate) error {
+	leaf, err := leafOf(cert)
+	if err != nil {
+		return err
+	}
+
+	names, patterns := x509utils.Names(leaf)
+	if len(names)+len(patterns) == 0 {
+		return core.Wrap(core.ErrInvalid, "certificate without names")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.init()
+	for _, name := range names {
+		r.certs[name] = append(r.certs[name], cert)
+	}
+	for _, suffix := range patterns {
+		r.patterns[suffix] = append(r.patterns[suffix], cert)
+	}
+	return nil
+}
+
+// SetDefaultCertificate sets the key pair used when no
+// certificate matches the name.
+func (r *Router) SetDefaultCertificate(cert *tls.Certificate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defCert = cert
+}
+
+// GetConfigForClient implements the [tls.Config] callback
+// returning the config for the requested name. If nothing matches
+// and there is no default, nil is returned so the listener's own
+// config is used.
+func (r *Router) GetConfigForClient(chi *tls.ClientHelloInfo) (*tls.Config, error) {
+	name := routeName(chi)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if cfg, ok := r.configs[name]; ok {
+		return cfg, nil
+	}
+	if suffix, ok := x509utils.NameAsSuffix(name); ok {
+		if cfg, ok := r.cfgPatterns[suffix]; ok {
+			return cfg, nil
+		}
+	}
+	return r.defConfig, nil
+}
+
+// GetCertificate implements the [tls.Config] callback returning
+// the first key pair for the requested name supported by the
+// client, or the default.
+func (r *Router) GetCertificate(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := routeName(chi)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if cert := xtls.FirstSupported(r.certs[name], chi); cert != nil {
+		return cert, nil
+	}
+	if suffix, ok := x509utils.NameAsSuffix(name); ok {
+		if cert := xtls.FirstSupported(r.patterns[suffix], chi); cert != nil {
+			return cert, nil
+		}
+	}
+	if r.defCert != nil {
+		return r.defCert, nil
+	}
+	return nil, core.Wrapf(ErrNoCertificate, "%q", name)
+}
+
+func routeName(chi *tls.ClientHelloInfo) string {
+	if chi == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(chi.ServerName, "."))
+}
+
+// parseRouteName normalises a name, telling if it's a
+// wildcard, in which case the suffix is returned.
+func parseRouteName(name string) (string, bool, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	switch {
+	case strings.HasPrefix(name, "*.") && len(name) > 2:
+		return name[1:], true, nil
+	case name == "", strings.ContainsAny(name, "*/ "):
+		return "", false, core.Wrapf(core.ErrInvalid, "bad name %q", name)
+	default:
+		return name, false, nil
+	}
+}
+
+func leafOf(cert *tls.Certificate) (*x509.Certificate, error) {
+	switch {
+	case cert == nil, len(cert.Certificate) == 0:
+		return nil, core.Wrap(core.ErrInvalid, "certificate not provided")
+	case cert.Leaf != nil:
+		return cert.Leaf, nil
+	default:
+		return x509.ParseCertificate(cert.Certificate[0])
+	}
+}
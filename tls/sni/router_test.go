@@ -0,0 +1,112 @@
+package sni
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestCert(t *testing.T, serial int64, names ...string) *tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshake connects to the Router using the given server name
+// and returns the serial of the certificate and the protocol.
+func handshake(t *testing.T, r *Router, name string) (int64, string) {
+	t.Helper()
+
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	go func() {
+		_ = tls.Server(s, r.Config()).Handshake()
+	}()
+
+	conn := tls.Client(c, &tls.Config{
+		ServerName:         name,
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"h2", "http/1.1"},
+	})
+	if err := conn.Handshake(); err != nil {
+		t.Fatalf("ERROR: %s: %v", name, err)
+	}
+
+	st := conn.ConnectionState()
+	return st.PeerCertificates[0].SerialNumber.Int64(), st.NegotiatedProtocol
+}
+
+func TestRouter(t *testing.T) {
+	r := &Router{}
+	for _, cert := range []*tls.Certificate{
+		newTestCert(t, 1, "a.example.org"),
+		newTestCert(t, 2, "*.example.org"),
+	} {
+		if err := r.AddCertificate(cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.SetDefaultCertificate(newTestCert(t, 3, "default"))
+
+	if err := r.AddConfig("*.example.org", &tls.Config{NextProtos: []string{"h2"}}); err != nil {
+		t.Fatal(err)
+	}
+	r.SetDefaultConfig(&tls.Config{NextProtos: []string{"http/1.1"}})
+
+	for _, tc := range []struct {
+		name   string
+		serial int64
+		proto  string
+	}{
+		{"a.example.org", 1, "h2"},
+		{"B.example.org", 2, "h2"},
+		{"c.b.example.org", 3, "http/1.1"},
+		{"example.net", 3, "http/1.1"},
+	} {
+		serial, proto := handshake(t, r, tc.name)
+		if serial != tc.serial || proto != tc.proto {
+			t.Errorf("ERROR: %s: %v/%q (expected %v/%q)", tc.name, serial, proto, tc.serial, tc.proto)
+		}
+	}
+}
+
+func TestRouterNoDefault(t *testing.T) {
+	r := &Router{}
+	chi := &tls.ClientHelloInfo{ServerName: "example.org"}
+
+	if cfg, err := r.GetConfigForClient(chi); cfg != nil || err != nil {
+		t.Errorf("ERROR: unexpected %v, %v", cfg, err)
+	}
+	if _, err := r.GetCertificate(chi); err == nil {
+		t.Error("ERROR: unexpected certificate")
+	}
+	if err := r.AddConfig("*", &tls.Config{}); err == nil {
+		t.Error("ERROR: bad name accepted")
+	}
+}
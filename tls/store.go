@@ -108,3 +108,19 @@ func SplitClientHelloInfo(chi *tls.ClientHelloInfo) (ctx context.Context, server
 
 	return ctx, serverName, nil
 }
+
+// FirstSupported returns the first key pair supported by the client,
+// or the first of the list if none is so the handshake fails with
+// a proper alert. Without [tls.ClientHelloInfo] the first is returned.
+func FirstSupported(certs []*tls.Certificate, chi *tls.ClientHelloInfo) *tls.Certificate {
+	for _, cert := range certs {
+		if chi == nil || chi.SupportsCertificate(cert) == nil {
+			return cert
+		}
+	}
+
+	if len(certs) > 0 {
+		return certs[0]
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package reload
+
+import (
+	"crypto/x509"
+	"strings"
+
+	"darvaza.org/x/tls"
+	"darvaza.org/x/tls/store/buffer"
+	"darvaza.org/x/tls/x509utils"
+)
+
+// certIndex finds key pairs by name
+type certIndex struct {
+	all      []*tls.Certificate
+	names    map[string][]*tls.Certificate
+	patterns map[string][]*tls.Certificate
+}
+
+func newCertIndex(pairs []buffer.CertKeyPairs, pool []*x509.Certificate) *certIndex {
+	idx := &certIndex{
+		names:    make(map[string][]*tls.Certificate),
+		patterns: make(map[string][]*tls.Certificate),
+	}
+
+	for _, p := range pairs {
+		for _, leaf := range p.Certs {
+			if leaf.IsCA {
+				continue
+			}
+
+			idx.add(&tls.Certificate{
+				Certificate: derChain(x509utils.BuildChain(leaf, pool)),
+				PrivateKey:  p.Key,
+				Leaf:        leaf,
+			})
+		}
+	}
+	return idx
+}
+
+func (idx *certIndex) add(cert *tls.Certificate) {
+	names, patterns := x509utils.Names(cert.Leaf)
+
+	idx.all = append(idx.all, cert)
+	for _, name := range names {
+		idx.names[name] = append(idx.names[name], cert)
+	}
+	for _, suffix := range patterns {
+		idx.patterns[suffix] = append(idx.patterns[suffix], cert)
+	}
+}
+
+// Len returns the number of key pairs indexed.
+func (idx *certIndex) Len() int {
+	return len(idx.all)
+}
+
+// All returns all key pairs.
+func (idx *certIndex) All() []*tls.Certificate {
+	out := make([]*tls.Certificate, len(idx.all))
+	copy(out, idx.all)
+	return out
+}
+
+// Match returns the first key pair for the name supported by
+// the client, preferring exact names over patterns.
+func (idx *certIndex) Match(name string, chi *tls.ClientHelloInfo) *tls.Certificate {
+	name = strings.ToLower(name)
+	if cert := tls.FirstSupported(idx.names[name], chi); cert != nil {
+		return cert
+	}
+
+	if suffix, ok := x509utils.NameAsSuffix(name); ok {
+		return tls.FirstSupported(idx.patterns[suffix], chi)
+	}
+	return nil
+}
+
+func derChain(chain []*x509.Certificate) [][]byte {
+	out := make([][]byte, 0, len(chain))
+	for _, cert := range chain {
+		out = append(out, cert.Raw)
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+// Package reload implements a TLS store loaded from files and
+// reloaded when they change.
+package reload
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+
+	"darvaza.org/x/tls"
+	"darvaza.org/x/tls/store/buffer"
+	"darvaza.org/x/tls/x509utils"
+	"darvaza.org/x/tls/x509utils/certpool"
+)
+
+var (
+	// ErrNoCertificates indicates no key pair was found.
+	ErrNoCertificates = errors.New("no certificates found")
+	// ErrNoMatch indicates no certificate matched the requested name.
+	ErrNoMatch = errors.New("no matching certificate")
+)
+
+var _ tls.Store = (*Store)(nil)
+
+// Config describes what a [Store] loads.
+type Config struct {
+	Logger slog.Logger
+
+	// Certs is the list of PEM files or directories containing
+	// certificates and their private keys, in any order.
+	Certs []string
+	// Roots is the list of PEM files or directories containing
+	// trusted CA certificates.
+	Roots []string
+
+	// OnReload is called, if set, after every attempt to reload,
+	// with the error if it failed.
+	OnReload func(error)
+}
+
+// Store is a [tls.Store] serving the key pairs found in the
+// configured files. Reloading replaces the whole set atomically and
+// failed attempts keep the previous.
+type Store struct {
+	cfg   Config
+	state atomic.Pointer[state]
+	mu    sync.Mutex // serialises reloads
+}
+
+// state is an immutable snapshot of the loaded files
+type state struct {
+	index *certIndex
+	roots *x509.CertPool
+}
+
+// New creates a [Store] and loads the files for the first time.
+func New(ctx context.Context, cfg *Config) (*Store, error) {
+	if cfg == nil {
+		return nil, core.Wrap(core.ErrInvalid, "config not provided")
+	}
+
+	s := &Store{cfg: *cfg}
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the files again, replacing the served key pairs if
+// successful.
+func (s *Store) Reload(ctx context.Context) error {
+	err := s.load(ctx)
+	if fn := s.cfg.OnReload; fn != nil {
+		fn(err)
+	}
+	return err
+}
+
+func (s *Store) load(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.loadCerts(ctx)
+	if err != nil {
+		return err
+	}
+
+	roots, err := s.loadRoots(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.state.Store(&state{index: index, roots: roots})
+	return nil
+}
+
+func (s *Store) loadCerts(ctx context.Context) (*certIndex, error) {
+	buf := buffer.New(ctx, s.cfg.Logger)
+	fn := buf.NewAddCallback()
+
+	for _, v := range s.cfg.Certs {
+		if err := x509utils.ReadStringPEM(v, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	pairs, err := buf.Pairs()
+	if err != nil {
+		return nil, err
+	}
+
+	index := newCertIndex(pairs, buf.Certs().Values())
+	if index.Len() == 0 {
+		return nil, ErrNoCertificates
+	}
+	return index, nil
+}
+
+func (s *Store) loadRoots(ctx context.Context) (*x509.CertPool, error) {
+	if len(s.cfg.Roots) == 0 {
+		return nil, nil
+	}
+
+	buf := buffer.New(ctx, s.cfg.Logger)
+	fn := buf.NewAddCertsCallback()
+	for _, v := range s.cfg.Roots {
+		if err := x509utils.ReadStringPEM(v, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	pool := certpool.New()
+	for _, cert := range buf.Certs().Values() {
+		pool.AddCert(cert)
+	}
+	return pool.Export(), nil
+}
+
+// GetCertificate returns the best key pair for the requested name,
+// implementing the [tls.Config] callback.
+func (s *Store) GetCertificate(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	_, name, err := tls.SplitClientHelloInfo(chi)
+	if err != nil {
+		return nil, err
+	}
+
+	st := s.state.Load()
+	if cert := st.index.Match(name, chi); cert != nil {
+		return cert, nil
+	}
+	return nil, core.Wrapf(ErrNoMatch, "%q", name)
+}
+
+// GetCAPool returns the pool of trusted roots, or nil if none
+// were configured.
+func (s *Store) GetCAPool() *x509.CertPool {
+	return s.state.Load().roots
+}
+
+// Certificates returns all the key pairs loaded.
+func (s *Store) Certificates() []*tls.Certificate {
+	return s.state.Load().index.All()
+}
@@ -0,0 +1,174 @@
+package reload
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"darvaza.org/x/fs/watch"
+	"darvaza.org/x/tls"
+	"darvaza.org/x/tls/x509utils"
+)
+
+type testCA struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	return &testCA{key: key, cert: mustCreate(t, tpl, tpl, key, key)}
+}
+
+// writePair writes a new key pair for the given names into a PEM file.
+func (ca *testCA) writePair(t *testing.T, fileName string, serial int64, names ...string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	cert := mustCreate(t, tpl, ca.cert, key, ca.key)
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// key last, intermediates aren't needed in order
+	_, _ = x509utils.WriteCert(f, cert)
+	_, _ = x509utils.WriteKey(f, key)
+}
+
+func mustCreate(t *testing.T, tpl, parent *x509.Certificate, key, signer *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func getSerial(t *testing.T, s *Store, name string) int64 {
+	t.Helper()
+
+	cert, err := s.GetCertificate(&tls.ClientHelloInfo{ServerName: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+func TestStore(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	ca.writePair(t, filepath.Join(dir, "a.pem"), 10, "a.example.org", "*.b.example.org")
+
+	s, err := New(context.Background(), &Config{Certs: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := getSerial(t, s, "A.example.org"); n != 10 {
+		t.Errorf("ERROR: serial %v (expected 10)", n)
+	}
+	if n := getSerial(t, s, "x.b.example.org"); n != 10 {
+		t.Errorf("ERROR: serial %v (expected 10)", n)
+	}
+	if _, err := s.GetCertificate(&tls.ClientHelloInfo{ServerName: "c.example.org"}); err == nil {
+		t.Error("ERROR: unexpected match")
+	}
+
+	// broken files keep the previous state
+	if err := os.WriteFile(filepath.Join(dir, "a.pem"), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(context.Background()); err == nil {
+		t.Error("ERROR: reload of broken files succeeded")
+	}
+	if n := getSerial(t, s, "a.example.org"); n != 10 {
+		t.Errorf("ERROR: serial %v (expected 10)", n)
+	}
+}
+
+func TestStoreWatch(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "a.pem")
+	ca.writePair(t, fileName, 10, "a.example.org")
+
+	reloaded := make(chan error, 1)
+	s, err := New(context.Background(), &Config{
+		Certs:    []string{fileName},
+		OnReload: func(err error) { reloaded <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = s.Watch(ctx, &watch.Config{
+			Debounce: 10 * time.Millisecond,
+			Interval: 10 * time.Millisecond,
+		})
+	}()
+
+	// replace by rename, like most tools do
+	time.Sleep(50 * time.Millisecond)
+	ca.writePair(t, fileName+".new", 20, "a.example.org")
+	if err := os.Rename(fileName+".new", fileName); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for getSerial(t, s, "a.example.org") != 20 {
+		select {
+		case <-reloaded:
+		case <-deadline:
+			t.Fatal("ERROR: not reloaded")
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package reload
+
+import (
+	"context"
+
+	"darvaza.org/core"
+	"darvaza.org/x/fs/watch"
+)
+
+// Watch reloads the [Store] whenever the configured files change,
+// until the context is cancelled.
+func (s *Store) Watch(ctx context.Context, cfg *watch.Config) error {
+	names := append(core.SliceCopy(s.cfg.Certs), s.cfg.Roots...)
+
+	w, err := watch.NewFiles(cfg, watch.Existing(names...)...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	return w.Run(ctx, func(ctx context.Context, _ []watch.Event) {
+		_ = s.Reload(ctx)
+	}, nil)
+}
@@ -0,0 +1,42 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"testing"
+)
+
+func TestFirstSupported(t *testing.T) {
+	leaf := newTestCert(t)
+	ecdsaCert := &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  &ecdsa.PrivateKey{PublicKey: *leaf.PublicKey.(*ecdsa.PublicKey)},
+		Leaf:        leaf,
+	}
+	other := &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  ecdsaCert.PrivateKey,
+		Leaf:        leaf,
+	}
+
+	// a client only supporting RSA
+	rsaOnly := &tls.ClientHelloInfo{
+		SignatureSchemes:  []tls.SignatureScheme{tls.PSSWithSHA256},
+		SupportedVersions: []uint16{tls.VersionTLS13},
+	}
+
+	for i, tc := range []struct {
+		certs    []*tls.Certificate
+		chi      *tls.ClientHelloInfo
+		expected *tls.Certificate
+	}{
+		{nil, nil, nil},
+		{[]*tls.Certificate{ecdsaCert, other}, nil, ecdsaCert},
+		// none supported, the first makes the handshake fail
+		{[]*tls.Certificate{other, ecdsaCert}, rsaOnly, other},
+	} {
+		if cert := FirstSupported(tc.certs, tc.chi); cert != tc.expected {
+			t.Errorf("[%v] ERROR: FirstSupported() → %p (expected %p)", i, cert, tc.expected)
+		}
+	}
+}
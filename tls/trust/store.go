@@ -0,0 +1,77 @@
+package trust
+
+import (
+	"context"
+	"crypto/x509"
+	"sync"
+	"sync/atomic"
+
+	"darvaza.org/core"
+	"darvaza.org/x/fs/watch"
+)
+
+// Store holds a trust pool rebuilt on demand. Failed attempts
+// keep the previous pool.
+type Store struct {
+	cfg  Config
+	pool atomic.Pointer[x509.CertPool]
+	mu   sync.Mutex // serialises reloads
+}
+
+// New creates a [Store] building its pool for the first time.
+func New(ctx context.Context, cfg *Config) (*Store, error) {
+	if cfg == nil {
+		return nil, core.Wrap(core.ErrInvalid, "config not provided")
+	}
+
+	s := &Store{cfg: *cfg}
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetCAPool returns the current pool.
+func (s *Store) GetCAPool() *x509.CertPool {
+	return s.pool.Load()
+}
+
+// Reload rebuilds the pool, replacing the current one if successful.
+func (s *Store) Reload(ctx context.Context) error {
+	err := s.load(ctx)
+	if fn := s.cfg.OnReload; fn != nil {
+		fn(err)
+	}
+	return err
+}
+
+func (s *Store) load(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pool, err := Build(ctx, &s.cfg)
+	if err != nil {
+		return err
+	}
+
+	s.pool.Store(pool.Export())
+	return nil
+}
+
+// Watch reloads the [Store] whenever the source files change,
+// until the context is cancelled.
+func (s *Store) Watch(ctx context.Context, cfg *watch.Config) error {
+	w, err := watch.NewFiles(cfg, watch.Existing(s.cfg.Sources...)...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	return w.Run(ctx, func(ctx context.Context, _ []watch.Event) {
+		_ = s.Reload(ctx)
+	}, nil)
+}
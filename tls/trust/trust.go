@@ -0,0 +1,144 @@
+// Package trust builds pools of trusted certificates from the
+// system roots, directories and PEM bundles.
+package trust
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"strings"
+	"time"
+
+	"darvaza.org/x/tls/x509utils"
+	"darvaza.org/x/tls/x509utils/certpool"
+)
+
+// ErrNoCertificates indicates the filters left no certificates.
+var ErrNoCertificates = errors.New("no trusted certificates")
+
+// Filter tells if a certificate matches.
+type Filter func(*x509.Certificate) bool
+
+// Config describes the contents of a trust pool.
+type Config struct {
+	// Sources is the list of PEM files, directories or raw PEM
+	// containing the certificates to trust.
+	Sources []string
+
+	// Include, if not empty, only keeps the certificates
+	// matching any of the filters.
+	Include []Filter
+
+	// Exclude drops the certificates matching any of the filters,
+	// after Include.
+	Exclude []Filter
+
+	// OnReload is called, if set, after every attempt to reload
+	// a [Store], with the error if it failed.
+	OnReload func(error)
+
+	// System adds the system roots.
+	System bool
+}
+
+// Build creates a [certpool.CertPool] with the certificates
+// described by the [Config].
+func Build(ctx context.Context, cfg *Config) (*certpool.CertPool, error) {
+	pool := certpool.New()
+	add := func(cert *x509.Certificate) {
+		if cfg.keep(cert) {
+			pool.AddCert(cert)
+		}
+	}
+
+	if cfg.System {
+		roots, err := certpool.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+
+		roots.ForEach(ctx, func(_ context.Context, cert *x509.Certificate) bool {
+			add(cert)
+			return true
+		})
+	}
+
+	fn := func(_ fs.FS, _ string, block *pem.Block) bool {
+		if cert, _ := x509utils.BlockToCertificate(block); cert != nil {
+			add(cert)
+		}
+		return ctx.Err() == nil
+	}
+
+	for _, s := range cfg.Sources {
+		if err := x509utils.ReadStringPEM(s, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case pool.Count() == 0:
+		return nil, ErrNoCertificates
+	default:
+		return pool, nil
+	}
+}
+
+func (cfg *Config) keep(cert *x509.Certificate) bool {
+	if len(cfg.Include) > 0 && !matchAny(cfg.Include, cert) {
+		return false
+	}
+	return !matchAny(cfg.Exclude, cert)
+}
+
+func matchAny(filters []Filter, cert *x509.Certificate) bool {
+	for _, fn := range filters {
+		if fn(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fingerprints matches certificates by the SHA-256 of their DER,
+// in hex, ignoring case and colons.
+func Fingerprints(sums ...string) Filter {
+	set := make(map[string]bool, len(sums))
+	for _, s := range sums {
+		s = strings.ReplaceAll(strings.ToLower(s), ":", "")
+		set[s] = true
+	}
+
+	return func(cert *x509.Certificate) bool {
+		sum := sha256.Sum256(cert.Raw)
+		return set[hex.EncodeToString(sum[:])]
+	}
+}
+
+// SubjectContains matches certificates whose subject, in its
+// RFC 2253 representation, contains the given string.
+func SubjectContains(s string) Filter {
+	return func(cert *x509.Certificate) bool {
+		return strings.Contains(cert.Subject.String(), s)
+	}
+}
+
+// Expired matches certificates no longer valid.
+func Expired() Filter {
+	return func(cert *x509.Certificate) bool {
+		return time.Now().After(cert.NotAfter)
+	}
+}
+
+// IsCA matches CA certificates.
+func IsCA() Filter {
+	return func(cert *x509.Certificate) bool {
+		return cert.IsCA
+	}
+}
@@ -0,0 +1,144 @@
+package trust
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"darvaza.org/x/tls/x509utils"
+)
+
+func newTestCA(t *testing.T, name string, ttl time.Duration) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"Test"}},
+		NotBefore:             time.Now().Add(-2 * time.Hour),
+		NotAfter:              time.Now().Add(ttl),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func writeCerts(t *testing.T, fileName string, certs ...*x509.Certificate) {
+	t.Helper()
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	for _, cert := range certs {
+		if _, err := x509utils.WriteCert(f, cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	a := newTestCA(t, "a", time.Hour)
+	b := newTestCA(t, "b", time.Hour)
+	old := newTestCA(t, "old", -time.Hour)
+
+	writeCerts(t, filepath.Join(dir, "bundle.pem"), a, old)
+	writeCerts(t, filepath.Join(dir, "b.pem"), b)
+
+	for _, tc := range []struct {
+		name  string
+		cfg   Config
+		count int
+	}{
+		{"all", Config{}, 3},
+		{"not expired", Config{Exclude: []Filter{Expired()}}, 2},
+		{"fingerprint", Config{Include: []Filter{Fingerprints(fingerprint(b))}}, 1},
+		{"subject", Config{
+			Include: []Filter{SubjectContains("CN=a,"), SubjectContains("CN=old,")},
+			Exclude: []Filter{Expired()},
+		}, 1},
+	} {
+		tc.cfg.Sources = []string{dir}
+		pool, err := Build(context.Background(), &tc.cfg)
+		if err != nil {
+			t.Fatalf("ERROR: %s: %v", tc.name, err)
+		}
+		if n := pool.Count(); n != tc.count {
+			t.Errorf("ERROR: %s: %v certificates (expected %v)", tc.name, n, tc.count)
+		}
+	}
+
+	_, err := Build(context.Background(), &Config{
+		Sources: []string{dir},
+		Include: []Filter{Fingerprints("00")},
+	})
+	if !errors.Is(err, ErrNoCertificates) {
+		t.Errorf("ERROR: unexpected %v", err)
+	}
+}
+
+func TestStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "ca.pem")
+	a := newTestCA(t, "a", time.Hour)
+	writeCerts(t, fileName, a)
+
+	s, err := New(context.Background(), &Config{Sources: []string{fileName}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := s.GetCAPool()
+
+	// broken files keep the previous pool
+	if err := os.WriteFile(fileName, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(context.Background()); err == nil {
+		t.Error("ERROR: reload of empty file succeeded")
+	}
+	if s.GetCAPool() != first {
+		t.Error("ERROR: pool replaced")
+	}
+
+	writeCerts(t, fileName, a, newTestCA(t, "b", time.Hour))
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetCAPool().Equal(first) {
+		t.Error("ERROR: pool not replaced")
+	}
+}
@@ -0,0 +1,215 @@
+package x509utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"darvaza.org/core"
+)
+
+var _ core.Unwrappable = (*ErrInvalidBlock)(nil)
+
+// ErrInvalidBlock indicates a block of a PEM bundle couldn't
+// be decoded.
+type ErrInvalidBlock struct {
+	Err   error
+	Type  string
+	Index int
+}
+
+func (err ErrInvalidBlock) Error() string {
+	if err.Type == "" {
+		return fmt.Sprintf("block %v: %s", err.Index, err.Err)
+	}
+	return fmt.Sprintf("block %v (%s): %s", err.Index, err.Type, err.Err)
+}
+
+func (err ErrInvalidBlock) Unwrap() error {
+	return err.Err
+}
+
+// Bundle holds the certificates and private keys found
+// in a PEM bundle, in the order they appeared.
+type Bundle struct {
+	Certs []*x509.Certificate
+	Keys  []PrivateKey
+}
+
+// KeyPair is a private key and the chain of its certificate,
+// leaf first.
+type KeyPair struct {
+	Key   PrivateKey
+	Chain []*x509.Certificate
+}
+
+// ParseBundle decodes a PEM bundle containing certificates and
+// private keys in any order. Blocks of other types are ignored.
+// Every invalid block is reported as an [ErrInvalidBlock] and the
+// valid ones are returned anyway.
+func ParseBundle(b []byte) (*Bundle, error) {
+	var errs core.CompoundError
+
+	out := new(Bundle)
+	for i := 0; ; i++ {
+		var block *pem.Block
+
+		block, b = pem.Decode(b)
+		if block == nil {
+			if len(bytes.TrimSpace(b)) > 0 {
+				errs.AppendError(&ErrInvalidBlock{Index: i, Err: core.ErrInvalid})
+			}
+			break
+		}
+
+		if err := out.add(block); err != nil {
+			errs.AppendError(&ErrInvalidBlock{Index: i, Type: block.Type, Err: err})
+		}
+	}
+
+	return out, errs.AsError()
+}
+
+func (b *Bundle) add(block *pem.Block) error {
+	cert, err := BlockToCertificate(block)
+	switch {
+	case cert != nil:
+		b.Certs = append(b.Certs, cert)
+		return nil
+	case err != ErrIgnored:
+		return err
+	}
+
+	key, err := BlockToPrivateKey(block)
+	switch {
+	case key != nil:
+		b.Keys = append(b.Keys, key)
+		return nil
+	case err != ErrIgnored:
+		return err
+	default:
+		return nil
+	}
+}
+
+// Pairs returns every private key with the chain of its
+// certificate. Keys without certificate are an error.
+func (b *Bundle) Pairs() ([]KeyPair, error) {
+	out := make([]KeyPair, 0, len(b.Keys))
+	for i, key := range b.Keys {
+		chain, err := b.Chain(key)
+		if err != nil {
+			return nil, core.Wrapf(err, "key %v", i)
+		}
+		out = append(out, KeyPair{Key: key, Chain: chain})
+	}
+	return out, nil
+}
+
+// Chain returns the chain of the certificate matching the private
+// key, leaf first, including the intermediates found in the bundle.
+// Self-signed roots are excluded.
+func (b *Bundle) Chain(key PrivateKey) ([]*x509.Certificate, error) {
+	var leaf *x509.Certificate
+
+	for _, cert := range b.Certs {
+		if ValidCertKeyPair(cert, key) {
+			leaf = cert
+			if !cert.IsCA {
+				break
+			}
+		}
+	}
+
+	if leaf == nil {
+		return nil, &ErrInvalidCert{Reason: "no certificate matches the private key"}
+	}
+
+	return BuildChain(leaf, b.Certs), nil
+}
+
+// BuildChain returns the chain of a certificate, leaf first,
+// including the intermediates found in the pool. Self-signed
+// roots are excluded.
+func BuildChain(leaf *x509.Certificate, pool []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+	for cert := leaf; len(chain) <= len(pool); {
+		issuer := findIssuer(cert, pool)
+		if issuer == nil || IsSelfSigned(issuer) {
+			break
+		}
+
+		chain = append(chain, issuer)
+		cert = issuer
+	}
+	return chain
+}
+
+// SortChain orders certificates leaf first, each followed by
+// its issuer. It fails if they don't form a single chain.
+func SortChain(certs []*x509.Certificate) ([]*x509.Certificate, error) {
+	if len(certs) == 0 {
+		return nil, ErrEmpty
+	}
+
+	leaf, err := findLeaf(certs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []*x509.Certificate{leaf}
+	for cert := leaf; len(out) < len(certs); {
+		issuer := findIssuer(cert, certs)
+		if issuer == nil {
+			return nil, &ErrInvalidCert{Cert: cert, Reason: "issuer not in the chain"}
+		}
+
+		out = append(out, issuer)
+		cert = issuer
+	}
+	return out, nil
+}
+
+// findLeaf returns the only certificate not issuing any other.
+func findLeaf(certs []*x509.Certificate) (*x509.Certificate, error) {
+	var leaf *x509.Certificate
+
+	for _, cert := range certs {
+		if issuesAny(cert, certs) {
+			continue
+		}
+		if leaf != nil {
+			return nil, &ErrInvalidCert{Cert: cert, Reason: "more than one leaf"}
+		}
+		leaf = cert
+	}
+
+	if leaf == nil {
+		return nil, &ErrInvalidCert{Reason: "no leaf certificate"}
+	}
+	return leaf, nil
+}
+
+func issuesAny(issuer *x509.Certificate, certs []*x509.Certificate) bool {
+	for _, cert := range certs {
+		if cert != issuer && isIssuer(issuer, cert) {
+			return true
+		}
+	}
+	return false
+}
+
+func findIssuer(cert *x509.Certificate, certs []*x509.Certificate) *x509.Certificate {
+	for _, c := range certs {
+		if c != cert && !c.Equal(cert) && isIssuer(c, cert) {
+			return c
+		}
+	}
+	return nil
+}
+
+func isIssuer(issuer, cert *x509.Certificate) bool {
+	return bytes.Equal(issuer.RawSubject, cert.RawIssuer) &&
+		cert.CheckSignatureFrom(issuer) == nil
+}
@@ -0,0 +1,152 @@
+package x509utils
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type testBundle struct {
+	root, inter, leaf *x509.Certificate
+	key               *ecdsa.PrivateKey
+}
+
+func newTestBundle(t *testing.T) *testBundle {
+	t.Helper()
+
+	rootKey, interKey, leafKey := newTestKey(t), newTestKey(t), newTestKey(t)
+
+	root := newTestCert(t, "root", true, nil, rootKey, rootKey)
+	inter := newTestCert(t, "inter", true, root, interKey, rootKey)
+	leaf := newTestCert(t, "leaf", false, inter, leafKey, interKey)
+	return &testBundle{root: root, inter: inter, leaf: leaf, key: leafKey}
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newTestCert(t *testing.T, name string, ca bool, parent *x509.Certificate,
+	key, signer *ecdsa.PrivateKey) *x509.Certificate {
+	//
+	t.Helper()
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  ca,
+	}
+	if ca {
+		tpl.KeyUsage = x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent = tpl
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestParseBundle(t *testing.T) {
+	tb := newTestBundle(t)
+
+	// key in the middle, chain in reverse order
+	var buf bytes.Buffer
+	_, _ = WriteCert(&buf, tb.root)
+	_, _ = WriteKey(&buf, tb.key)
+	_, _ = WriteCert(&buf, tb.inter)
+	buf.Write(EncodeBytes("CERTIFICATE", []byte("garbage"), nil))
+	_, _ = WriteCert(&buf, tb.leaf)
+
+	b, err := ParseBundle(buf.Bytes())
+
+	var be *ErrInvalidBlock
+	if !errors.As(err, &be) || be.Index != 3 {
+		t.Fatalf("ERROR: unexpected %v", err)
+	}
+	if len(b.Certs) != 3 || len(b.Keys) != 1 {
+		t.Fatalf("ERROR: %v certs, %v keys", len(b.Certs), len(b.Keys))
+	}
+
+	pairs, err := b.Pairs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := pairs[0].Chain
+	if len(chain) != 2 || chain[0] != b.Certs[2] || chain[1] != b.Certs[1] {
+		t.Errorf("ERROR: bad chain of %v certificates", len(chain))
+	}
+
+	// mismatched key
+	b.Keys = append(b.Keys, newTestKey(t))
+	if _, err := b.Pairs(); err == nil {
+		t.Error("ERROR: mismatched key accepted")
+	}
+}
+
+func TestSortChain(t *testing.T) {
+	tb := newTestBundle(t)
+
+	chain, err := SortChain([]*x509.Certificate{tb.root, tb.leaf, tb.inter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain[0] != tb.leaf || chain[1] != tb.inter || chain[2] != tb.root {
+		t.Error("ERROR: wrong order")
+	}
+
+	if _, err := SortChain([]*x509.Certificate{tb.root, tb.leaf}); err == nil {
+		t.Error("ERROR: broken chain accepted")
+	}
+}
+
+func TestBuildChain(t *testing.T) {
+	tb := newTestBundle(t)
+	leafCopy, _ := x509.ParseCertificate(tb.leaf.Raw)
+
+	for i, tc := range []struct {
+		pool     []*x509.Certificate
+		expected []*x509.Certificate
+	}{
+		{nil, []*x509.Certificate{tb.leaf}},
+		{[]*x509.Certificate{tb.root}, []*x509.Certificate{tb.leaf}},
+		{[]*x509.Certificate{tb.root, tb.inter}, []*x509.Certificate{tb.leaf, tb.inter}},
+		// duplicates in the pool are harmless
+		{[]*x509.Certificate{leafCopy, tb.inter, tb.leaf}, []*x509.Certificate{tb.leaf, tb.inter}},
+	} {
+		chain := BuildChain(tb.leaf, tc.pool)
+		if len(chain) != len(tc.expected) {
+			t.Errorf("[%v] ERROR: %v certificates (expected %v)", i, len(chain), len(tc.expected))
+			continue
+		}
+		for j, cert := range chain {
+			if cert != tc.expected[j] {
+				t.Errorf("[%v] ERROR: wrong certificate at %v", i, j)
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"darvaza.org/core"
+	"darvaza.org/x/web"
 	"darvaza.org/x/web/consts"
 	"darvaza.org/x/web/qlist"
 )
@@ -114,7 +115,7 @@ func (h *AssetHandler) serveContent(rw http.ResponseWriter, req *http.Request) {
 		defer unsafeClose(f)
 	}
 
-	http.ServeContent(rw, req, "", modTime, content)
+	web.ServeContent(rw, req, "", modTime, content)
 }
 
 func (h *AssetHandler) copyHeaders(dest http.Header) {
@@ -132,7 +133,7 @@ func (h *AssetHandler) setETags(dest http.Header) {
 	if !headerExist(dest, consts.ETag) {
 		tags, _ := getETags(h.Asset)
 		if len(tags) > 0 {
-			dest[consts.ETag] = tags
+			dest[consts.ETag] = []string{web.QuoteETag(tags[0])}
 		}
 	}
 }
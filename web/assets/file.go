@@ -39,7 +39,7 @@ func ServeFile(rw http.ResponseWriter, req *http.Request, file File) {
 
 	// TODO: Content-Encoding
 
-	http.ServeContent(rw, req, name, fi.ModTime(), file)
+	web.ServeContent(rw, req, name, fi.ModTime(), file)
 }
 
 func setContentType(hdr http.Header, file any, name string) error {
@@ -114,7 +114,7 @@ func setETag(hdr http.Header, file any) error {
 		case err != nil:
 			return err
 		case len(tags) > 0:
-			hdr[consts.ETag] = tags
+			hdr[consts.ETag] = []string{web.QuoteETag(tags[0])}
 		}
 	}
 	return nil
@@ -0,0 +1,325 @@
+package assets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+	xfs "darvaza.org/x/fs"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+	"darvaza.org/x/web/qlist"
+)
+
+var _ http.Handler = (*FileServer)(nil)
+
+// DefaultIndex is the file served for directories when
+// the [FileServer] doesn't specify any.
+const DefaultIndex = "index.html"
+
+// precompressed lists the encodings looked up when serving
+// [FileServer.Precompressed] files, by order of preference.
+var precompressed = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// FileServer serves the content of a [fs.FS], as a hardened
+// replacement of [http.FileServer].
+//
+// Only GET and HEAD are allowed, paths are cleaned and validated
+// before reaching the [fs.FS], and hidden files are refused unless
+// explicitly enabled. Errors are passed to [web.HandleError].
+type FileServer struct {
+	// FS is the file system to serve. Symbolic links are followed
+	// as the [fs.FS] does, so directories of the operating system
+	// should be served using [NewDirFileServer] instead of [os.DirFS].
+	FS fs.FS
+
+	// Resolver optionally extracts the path from the request,
+	// otherwise [web.Resolve] is used.
+	Resolver func(*http.Request) (string, error)
+
+	// Index lists the files served for a directory, [DefaultIndex]
+	// if none is given.
+	Index []string
+
+	// MaxAge, if not zero, sets the Cache-Control header of files
+	// using [web.SetCache].
+	MaxAge time.Duration
+
+	// Listing enables listing directories without index.
+	Listing bool
+	// Hidden allows serving files and directories whose names
+	// start with a dot.
+	Hidden bool
+	// Precompressed enables serving ".br" and ".gz" variants of
+	// the requested files when accepted by the client.
+	Precompressed bool
+}
+
+// NewFileServer creates a [FileServer] for the given [fs.FS]
+// with the default options.
+func NewFileServer(fSys fs.FS) *FileServer {
+	return &FileServer{FS: fSys}
+}
+
+// NewDirFileServer creates a [FileServer] for a directory of the
+// operating system with the default options. The directory is
+// wrapped in a [xfs.Sandbox] so symbolic links can't reach files
+// outside of it.
+func NewDirFileServer(dir string) (*FileServer, error) {
+	fSys, err := xfs.NewSandbox(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewFileServer(fSys), nil
+}
+
+func (h *FileServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var err error
+
+	switch req.Method {
+	case consts.GET, consts.HEAD:
+		err = h.serve(rw, req)
+	default:
+		err = web.NewStatusMethodNotAllowed(consts.GET, consts.HEAD)
+	}
+
+	if err != nil {
+		web.HandleError(rw, req, err)
+	}
+}
+
+func (h *FileServer) serve(rw http.ResponseWriter, req *http.Request) error {
+	name, err := h.resolve(req)
+	if err != nil {
+		return err
+	}
+
+	fi, err := fs.Stat(h.FS, name)
+	switch {
+	case err != nil:
+		return asFileError(err)
+	case !fi.IsDir():
+		return h.serveFile(rw, req, name, fi)
+	case name != "." && !strings.HasSuffix(req.URL.Path, "/"):
+		// directories end in /
+		return web.NewStatusMovedPermanently(relativeDir(req))
+	default:
+		return h.serveDir(rw, req, name)
+	}
+}
+
+func (h *FileServer) resolve(req *http.Request) (string, error) {
+	var p string
+	var err error
+
+	if h.Resolver == nil {
+		p, err = web.Resolve(req)
+	} else if p, err = h.Resolver(req); err == nil {
+		p, err = cleanPath(p)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimPrefix(p, "/")
+	switch {
+	case name == "":
+		return ".", nil
+	case !fs.ValidPath(name):
+		return "", web.NewStatusBadRequest(fmt.Errorf("%q: invalid path", p))
+	case !h.Hidden && isHidden(name):
+		return "", web.NewStatusNotFound()
+	default:
+		return name, nil
+	}
+}
+
+func cleanPath(p string) (string, error) {
+	s, ok := web.CleanPath(p)
+	if !ok {
+		return "", web.NewStatusBadRequest(fmt.Errorf("%q: invalid path", p))
+	}
+	return s, nil
+}
+
+func isHidden(name string) bool {
+	for _, s := range strings.Split(name, "/") {
+		if strings.HasPrefix(s, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func relativeDir(req *http.Request) string {
+	dest := path.Base(req.URL.Path) + "/"
+	if req.URL.RawQuery != "" {
+		dest += "?" + req.URL.RawQuery
+	}
+	return dest
+}
+
+func asFileError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return web.NewStatusNotFound()
+	case errors.Is(err, fs.ErrPermission):
+		return web.NewStatusForbidden()
+	default:
+		return web.AsError(err)
+	}
+}
+
+func (h *FileServer) serveDir(rw http.ResponseWriter, req *http.Request, dir string) error {
+	index := h.Index
+	if len(index) == 0 {
+		index = []string{DefaultIndex}
+	}
+
+	for _, s := range index {
+		name := unsafeJoin(dir, s)
+		if fi, err := fs.Stat(h.FS, name); err == nil && !fi.IsDir() {
+			return h.serveFile(rw, req, name, fi)
+		}
+	}
+
+	if !h.Listing {
+		return web.NewStatusNotFound()
+	}
+	return h.serveListing(rw, req, dir)
+}
+
+func (h *FileServer) serveFile(rw http.ResponseWriter, req *http.Request, name string, fi fs.FileInfo) error {
+	hdr := rw.Header()
+	if h.MaxAge != 0 {
+		web.SetCache(hdr, h.MaxAge)
+	}
+
+	fileName := name
+	if h.Precompressed {
+		hdr.Add("Vary", consts.AcceptEncoding)
+
+		if s, encoding := h.precompressed(req, name); s != "" {
+			// Content-Type of the original
+			hdr[consts.ContentType] = []string{core.Coalesce(TypeByFilename(name), consts.BIN)}
+			hdr[consts.ContentEncoding] = []string{encoding}
+			fileName = s
+		}
+	}
+
+	f, err := h.open(fileName)
+	if err != nil {
+		return asFileError(err)
+	}
+	defer unsafeClose(f)
+
+	if err := setContentType(hdr, f, name); err != nil {
+		return err
+	}
+	if err := setETag(hdr, f); err != nil {
+		return err
+	}
+
+	web.ServeContent(rw, req, name, fi.ModTime(), f)
+	return nil
+}
+
+// precompressed returns the name and encoding of the best
+// precompressed variant of a file acceptable by the client.
+func (h *FileServer) precompressed(req *http.Request, name string) (string, string) {
+	var supported []string
+	for _, p := range precompressed {
+		if fi, err := fs.Stat(h.FS, name+p.ext); err == nil && !fi.IsDir() {
+			supported = append(supported, p.encoding)
+		}
+	}
+
+	if len(supported) == 0 {
+		return "", ""
+	}
+
+	best, _ := qlist.BestEncoding(supported, req.Header)
+	for _, p := range precompressed {
+		if p.encoding == best {
+			return name + p.ext, p.encoding
+		}
+	}
+	return "", ""
+}
+
+// open returns a seekable file, reading it into memory if
+// the [fs.FS] doesn't provide one.
+func (h *FileServer) open(name string) (io.ReadSeekCloser, error) {
+	f, err := h.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if rs, ok := f.(io.ReadSeekCloser); ok {
+		return rs, nil
+	}
+
+	defer unsafeClose(f)
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{bytes.NewReader(b)}, nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
+
+func (h *FileServer) serveListing(rw http.ResponseWriter, req *http.Request, dir string) error {
+	entries, err := fs.ReadDir(h.FS, dir)
+	if err != nil {
+		return asFileError(err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, e := range entries {
+		name := e.Name()
+		if !h.Hidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if e.IsDir() {
+			name += "/"
+		}
+
+		u := url.URL{Path: name}
+		fmt.Fprintf(&buf, "<a href=\"%s\">%s</a>\n", u.String(), html.EscapeString(name))
+	}
+	buf.WriteString("</pre>\n")
+
+	hdr := rw.Header()
+	hdr[consts.ContentType] = []string{consts.HTML}
+	web.SetNoCache(hdr)
+
+	web.ServeContent(rw, req, "", time.Time{}, bytes.NewReader(buf.Bytes()))
+	return nil
+}
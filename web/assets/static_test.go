@@ -0,0 +1,127 @@
+package assets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"darvaza.org/x/web/consts"
+)
+
+func TestFileServer(t *testing.T) {
+	h := &FileServer{
+		FS: fstest.MapFS{
+			"index.html":      {Data: []byte("<h1>root</h1>")},
+			"app.js":          {Data: []byte("console.log(1)")},
+			"app.js.gz":       {Data: []byte("gzipped")},
+			".env":            {Data: []byte("SECRET=1")},
+			"docs/readme.txt": {Data: []byte("hello")},
+		},
+		Precompressed: true,
+	}
+
+	for _, tc := range []struct {
+		method, path, encoding string
+		code                   int
+		body                   string
+	}{
+		{"GET", "/", "", http.StatusOK, "<h1>root</h1>"},
+		{"GET", "/app.js", "", http.StatusOK, "console.log(1)"},
+		{"GET", "/app.js", "gzip, br", http.StatusOK, "gzipped"},
+		{"GET", "/.env", "", http.StatusNotFound, ""},
+		{"GET", "/docs", "", http.StatusMovedPermanently, ""},
+		{"GET", "/docs/", "", http.StatusNotFound, ""},
+		{"GET", "/missing", "", http.StatusNotFound, ""},
+		{"POST", "/app.js", "", http.StatusMethodNotAllowed, ""},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.encoding != "" {
+			req.Header.Set(consts.AcceptEncoding, tc.encoding)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		switch {
+		case rec.Code != tc.code:
+			t.Errorf("ERROR: %s %s: unexpected status %v", tc.method, tc.path, rec.Code)
+		case tc.body != "" && rec.Body.String() != tc.body:
+			t.Errorf("ERROR: %s %s: unexpected body %q", tc.method, tc.path, rec.Body.String())
+		}
+	}
+
+	h.Listing = true
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/docs/", nil))
+	if !strings.Contains(rec.Body.String(), `<a href="readme.txt">`) {
+		t.Errorf("ERROR: unexpected listing %q", rec.Body.String())
+	}
+}
+
+func TestDirFileServerSymlinks(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+
+	for _, dir := range []string{root, outside} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secret := filepath.Join(outside, "secret.txt")
+	files := map[string]string{
+		filepath.Join(root, "public.txt"): "public",
+		secret:                            "secret",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"relative.txt": filepath.Join("..", "outside", "secret.txt"),
+		"absolute.txt": secret,
+		"escape":       outside,
+		"inside.txt":   "public.txt",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	h, err := NewDirFileServer(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/public.txt", http.StatusOK, "public"},
+		{"/inside.txt", http.StatusOK, "public"},
+		{"/relative.txt", http.StatusNotFound, ""},
+		{"/absolute.txt", http.StatusNotFound, ""},
+		{"/escape/secret.txt", http.StatusNotFound, ""},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+
+		switch {
+		case rec.Code != tc.code:
+			t.Errorf("ERROR: %s: unexpected status %v", tc.path, rec.Code)
+		case tc.body != "" && rec.Body.String() != tc.body:
+			t.Errorf("ERROR: %s: unexpected body %q", tc.path, rec.Body.String())
+		case strings.Contains(rec.Body.String(), "secret"):
+			t.Errorf("ERROR: %s: leaked %q", tc.path, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package capture
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+func TestWriterPassThrough(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := New(rec, Config{MaxBody: 4})
+
+	w.Header().Set(consts.ContentType, consts.TXT)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.WriteString(w, "hello world")
+
+	switch {
+	case rec.Code != http.StatusCreated:
+		t.Errorf("ERROR: unexpected status %v", rec.Code)
+	case rec.Body.String() != "hello world":
+		t.Errorf("ERROR: unexpected body %q", rec.Body.String())
+	case rec.Header().Get(consts.ContentType) != consts.TXT:
+		t.Errorf("ERROR: header not committed")
+	case w.Status() != http.StatusCreated, w.Size() != 11:
+		t.Errorf("ERROR: unexpected status %v or size %v", w.Status(), w.Size())
+	case string(w.Body()) != "hell", !w.Truncated():
+		t.Errorf("ERROR: unexpected capture %q", w.Body())
+	}
+}
+
+func TestWriterBuffer(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Outer", "1")
+
+	h := NewMiddleware(Config{Buffer: true}, func(w *ResponseWriter, _ *http.Request) error {
+		if w.Committed() {
+			t.Errorf("ERROR: committed before hook")
+		}
+		w.Header().Set("X-Size", strconv.FormatInt(w.Size(), 10))
+		return nil
+	})(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(rw, "abc")
+	}))
+
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	switch {
+	case rec.Code != http.StatusOK:
+		t.Errorf("ERROR: unexpected status %v", rec.Code)
+	case rec.Body.String() != "abc":
+		t.Errorf("ERROR: unexpected body %q", rec.Body.String())
+	case rec.Header().Get("X-Size") != "3", rec.Header().Get("X-Outer") != "1":
+		t.Errorf("ERROR: unexpected headers %v", rec.Header())
+	}
+}
+
+func TestWriterSpill(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := New(rec, Config{Buffer: true, MaxBody: 4})
+
+	_, _ = io.WriteString(w, "ab")
+	if w.Committed() || rec.Body.Len() != 0 {
+		t.Fatalf("ERROR: written before exceeding MaxBody")
+	}
+
+	_, _ = io.WriteString(w, "cdef")
+	switch {
+	case !w.Committed():
+		t.Errorf("ERROR: not committed after exceeding MaxBody")
+	case rec.Body.String() != "abcdef":
+		t.Errorf("ERROR: unexpected body %q", rec.Body.String())
+	case string(w.Body()) != "abcd":
+		t.Errorf("ERROR: unexpected capture %q", w.Body())
+	case w.Reset():
+		t.Errorf("ERROR: reset after commit")
+	}
+
+	if err := w.Commit(); err != nil || rec.Body.Len() != 6 {
+		t.Errorf("ERROR: second commit wrote again")
+	}
+}
+
+func TestWriterFlushHijack(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := New(rec, Config{Buffer: true})
+
+	_, _ = io.WriteString(w, "event")
+	http.NewResponseController(w).Flush()
+
+	if !rec.Flushed || rec.Body.String() != "event" {
+		t.Errorf("ERROR: buffered response not flushed")
+	}
+
+	if _, _, err := w.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("ERROR: expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestErrorPages(t *testing.T) {
+	h := NewErrorPagesMiddleware(nil)(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/json":
+			rw.Header().Set(consts.ContentType, consts.JSON)
+			rw.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(rw, "{}")
+		default:
+			rw.Header().Set("X-Inner", "1")
+			http.Error(rw, "internal detail", http.StatusNotFound)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	switch {
+	case rec.Code != http.StatusNotFound:
+		t.Errorf("ERROR: unexpected status %v", rec.Code)
+	case strings.Contains(rec.Body.String(), "internal detail"):
+		t.Errorf("ERROR: response not replaced")
+	case rec.Header().Get("X-Inner") != "":
+		t.Errorf("ERROR: headers of replaced response kept")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/json", nil))
+	if rec.Body.String() != "{}" {
+		t.Errorf("ERROR: unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestETagMiddleware(t *testing.T) {
+	h := NewETagMiddleware(0, false)(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set(consts.ContentType, consts.TXT)
+		_, _ = io.WriteString(rw, "content")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	etag := rec.Header().Get(consts.ETag)
+	if etag != web.ETagOf([]byte("content")) || rec.Body.String() != "content" {
+		t.Fatalf("ERROR: unexpected response %q %q", etag, rec.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(consts.IfNoneMatch, etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	switch {
+	case rec.Code != http.StatusNotModified:
+		t.Errorf("ERROR: unexpected status %v", rec.Code)
+	case rec.Body.Len() != 0, rec.Header().Get(consts.ContentType) != "":
+		t.Errorf("ERROR: unexpected content")
+	case rec.Header().Get(consts.ETag) != etag:
+		t.Errorf("ERROR: ETag missing in 304")
+	}
+}
@@ -0,0 +1,118 @@
+package capture
+
+import (
+	"net/http"
+	"strings"
+
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+// HookFunc is called after the handler returns and before the
+// captured response is committed. Returned errors replace the
+// response via [web.HandleError] if it's still possible.
+type HookFunc func(w *ResponseWriter, req *http.Request) error
+
+// NewMiddleware returns a middleware capturing responses using the
+// given [Config], and passing them to the [HookFunc] before
+// committing them.
+func NewMiddleware(cfg Config, hook HookFunc) func(http.Handler) http.Handler {
+	return web.NewMiddleware(func(rw http.ResponseWriter, req *http.Request, next http.Handler) {
+		w := New(rw, cfg)
+		next.ServeHTTP(w, req)
+
+		if hook != nil {
+			if err := hook(w, req); err != nil {
+				if w.Reset() {
+					web.HandleError(rw, req, err)
+				}
+				return
+			}
+		}
+
+		_ = w.Commit()
+	})
+}
+
+// NewErrorPagesMiddleware returns a middleware replacing error
+// responses with [web.HandleError], so they are rendered by the
+// [web.ErrorHandler] of the context. If match is nil, error
+// responses without Content-Type or of type text/plain, as produced
+// by [http.Error], are replaced.
+func NewErrorPagesMiddleware(match func(code int, hdr http.Header) bool) func(http.Handler) http.Handler {
+	if match == nil {
+		match = isPlainError
+	}
+
+	cfg := Config{Intercept: match}
+	return NewMiddleware(cfg, func(w *ResponseWriter, _ *http.Request) error {
+		if w.Intercepted() {
+			return web.NewHTTPError(w.Status(), nil, "")
+		}
+		return nil
+	})
+}
+
+// NewETagMiddleware returns a middleware computing the entity-tag of
+// successful GET responses not providing one, and answering their
+// conditional requests. Responses larger than maxBody, or
+// [DefaultMaxBody] if zero, are passed through.
+func NewETagMiddleware(maxBody int, weak bool) func(http.Handler) http.Handler {
+	cfg := Config{Buffer: true, MaxBody: maxBody}
+	mw := NewMiddleware(cfg, func(w *ResponseWriter, req *http.Request) error {
+		if w.Status() != http.StatusOK || w.Committed() {
+			return nil
+		}
+
+		hdr := w.Header()
+		etag := hdr.Get(consts.ETag)
+		if etag == "" {
+			etag = web.ETagOf(w.Body())
+			if weak {
+				etag = "W/" + etag
+			}
+			hdr[consts.ETag] = []string{etag}
+		}
+
+		return serveConditional(w, req, etag)
+	})
+
+	return func(next http.Handler) http.Handler {
+		h := mw(next)
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if req.Method == consts.GET {
+				h.ServeHTTP(rw, req)
+			} else {
+				next.ServeHTTP(rw, req)
+			}
+		})
+	}
+}
+
+func serveConditional(w *ResponseWriter, req *http.Request, etag string) error {
+	hdr := w.Header()
+	modTime, _ := http.ParseTime(hdr.Get(consts.LastModified))
+
+	switch web.CheckPreconditions(req, etag, modTime) {
+	case http.StatusNotModified:
+		if w.Reset() {
+			rw := w.Unwrap()
+			copyHeader(rw.Header(), hdr)
+			web.WriteNotModified(rw)
+		}
+		return nil
+	case http.StatusPreconditionFailed:
+		return web.NewStatusPreconditionFailed()
+	default:
+		return nil
+	}
+}
+
+func isPlainError(code int, hdr http.Header) bool {
+	if code < http.StatusBadRequest {
+		return false
+	}
+
+	ct := consts.ContentTypeValue(hdr.Get(consts.ContentType))
+	return ct == "" || strings.EqualFold(ct, consts.ContentTypeValue(consts.TXT))
+}
@@ -0,0 +1,282 @@
+// Package capture implements an [http.ResponseWriter] wrapper recording
+// the status, size and optionally the body of responses, allowing
+// middleware to inspect, amend or replace them.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+)
+
+// DefaultMaxBody is the number of bytes buffered when [Config]
+// enables Buffer without specifying MaxBody.
+const DefaultMaxBody = 1 << 20
+
+var (
+	_ http.ResponseWriter = (*ResponseWriter)(nil)
+	_ http.Flusher        = (*ResponseWriter)(nil)
+	_ http.Hijacker       = (*ResponseWriter)(nil)
+)
+
+// Config describes how a [ResponseWriter] captures responses.
+type Config struct {
+	// Buffer holds the response until [ResponseWriter.Commit] so
+	// headers can still be modified after the handler returns.
+	// Responses exceeding MaxBody, or flushed by the handler, are
+	// written through.
+	Buffer bool
+
+	// MaxBody is the number of bytes of the body kept. If zero
+	// the body isn't captured, unless Buffer is set and then
+	// [DefaultMaxBody] is used.
+	MaxBody int
+
+	// Intercept is called with the status and headers before
+	// they are written. Returning true discards the response of
+	// the handler so a replacement can be rendered afterwards.
+	Intercept func(code int, hdr http.Header) bool
+}
+
+// ResponseWriter wraps an [http.ResponseWriter] capturing the response.
+// Headers are kept apart until committed, so intercepted or reset
+// responses leave the underlying writer untouched.
+type ResponseWriter struct {
+	rw        http.ResponseWriter
+	hdr       http.Header
+	buf       bytes.Buffer
+	limit     int
+	buffer    bool
+	intercept func(int, http.Header) bool
+
+	code        int
+	size        int64
+	wroteHeader bool
+	committed   bool
+	discarded   bool
+	intercepted bool
+	hijacked    bool
+}
+
+// New wraps an [http.ResponseWriter] using the given [Config].
+func New(rw http.ResponseWriter, cfg Config) *ResponseWriter {
+	hdr := rw.Header().Clone()
+	if hdr == nil {
+		hdr = make(http.Header)
+	}
+
+	limit := cfg.MaxBody
+	if limit <= 0 {
+		limit = 0
+		if cfg.Buffer {
+			limit = DefaultMaxBody
+		}
+	}
+
+	return &ResponseWriter{
+		rw:        rw,
+		hdr:       hdr,
+		limit:     limit,
+		buffer:    cfg.Buffer,
+		intercept: cfg.Intercept,
+	}
+}
+
+// Header returns the header map of the response.
+func (w *ResponseWriter) Header() http.Header {
+	if w.committed {
+		return w.rw.Header()
+	}
+	return w.hdr
+}
+
+// WriteHeader records the status code, and writes it through unless
+// buffering or intercepted. Informational codes are always written
+// through.
+func (w *ResponseWriter) WriteHeader(code int) {
+	switch {
+	case w.wroteHeader || w.hijacked:
+		return
+	case code >= 100 && code < 200 && code != http.StatusSwitchingProtocols:
+		copyHeader(w.rw.Header(), w.hdr)
+		w.rw.WriteHeader(code)
+		return
+	}
+
+	w.wroteHeader = true
+	w.code = code
+
+	if w.intercept != nil && w.intercept(code, w.hdr) {
+		w.intercepted = true
+		w.discarded = true
+		return
+	}
+
+	if !w.buffer {
+		w.commitHeader()
+	}
+}
+
+// Write records the body, and writes it through unless buffering
+// or intercepted.
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	w.size += int64(len(b))
+
+	switch {
+	case w.discarded:
+		return len(b), nil
+	case w.committed:
+		w.capture(b)
+		return w.rw.Write(b)
+	case w.buf.Len()+len(b) <= w.limit:
+		w.buf.Write(b)
+		return len(b), nil
+	}
+
+	// too large to buffer
+	if err := w.Commit(); err != nil {
+		return 0, err
+	}
+	w.capture(b)
+	return w.rw.Write(b)
+}
+
+func (w *ResponseWriter) capture(b []byte) {
+	if n := w.limit - w.buf.Len(); n > 0 {
+		if len(b) > n {
+			b = b[:n]
+		}
+		w.buf.Write(b)
+	}
+}
+
+// Commit writes the status, headers and buffered body to the
+// underlying writer, unless already done, discarded or hijacked.
+func (w *ResponseWriter) Commit() error {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.committed || w.discarded || w.hijacked {
+		return nil
+	}
+
+	w.commitHeader()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.rw.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *ResponseWriter) commitHeader() {
+	copyHeader(w.rw.Header(), w.hdr)
+	w.rw.WriteHeader(w.code)
+	w.committed = true
+}
+
+// Reset discards the response, leaving the underlying writer
+// untouched so a replacement can be written to it directly.
+// It fails if the response was already committed or hijacked.
+func (w *ResponseWriter) Reset() bool {
+	if w.committed || w.hijacked {
+		return false
+	}
+
+	w.discarded = true
+	w.buf.Reset()
+	return true
+}
+
+// Flush commits the response and flushes the underlying writer
+// if supported.
+func (w *ResponseWriter) Flush() {
+	if w.discarded || w.hijacked {
+		return
+	}
+
+	if err := w.Commit(); err != nil {
+		return
+	}
+
+	if f, ok := w.rw.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection if the underlying writer
+// supports it, otherwise [http.ErrNotSupported] is returned.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.rw.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, brw, err := hj.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, brw, err
+}
+
+// Unwrap returns the underlying writer, as used by
+// [http.ResponseController].
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.rw
+}
+
+// Status returns the status code of the response, or zero if
+// not written yet.
+func (w *ResponseWriter) Status() int {
+	return w.code
+}
+
+// Size returns the number of bytes of body written by the handler.
+func (w *ResponseWriter) Size() int64 {
+	return w.size
+}
+
+// Body returns the captured part of the body.
+func (w *ResponseWriter) Body() []byte {
+	return w.buf.Bytes()
+}
+
+// Truncated tells if the body was larger than what was captured.
+func (w *ResponseWriter) Truncated() bool {
+	return w.size > int64(w.buf.Len())
+}
+
+// Committed tells if the status and headers were written to the
+// underlying writer.
+func (w *ResponseWriter) Committed() bool {
+	return w.committed
+}
+
+// Intercepted tells if the response was discarded by the
+// Intercept function of the [Config].
+func (w *ResponseWriter) Intercepted() bool {
+	return w.intercepted
+}
+
+// Hijacked tells if the connection was hijacked.
+func (w *ResponseWriter) Hijacked() bool {
+	return w.hijacked
+}
+
+// copyHeader makes dst match src.
+func copyHeader(dst, src http.Header) {
+	for k := range dst {
+		if _, ok := src[k]; !ok {
+			delete(dst, k)
+		}
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+}
@@ -0,0 +1,155 @@
+package web
+
+import "net/http"
+
+// Chain is an ordered list of middleware. Middleware are applied
+// in the order they were added, the first being the outermost,
+// so it sees the request first and the response last.
+//
+// Entries can be named to allow routes to override or remove
+// them on their own copy of the Chain via [Chain.Override].
+// The zero value is an empty Chain ready to use.
+type Chain struct {
+	entries []chainEntry
+}
+
+type chainEntry struct {
+	mw   func(http.Handler) http.Handler
+	name string
+}
+
+// NewChain creates a [Chain] using the given middleware.
+func NewChain(mw ...func(http.Handler) http.Handler) *Chain {
+	return new(Chain).Use(mw...)
+}
+
+// Use appends middleware to the [Chain]. nil entries are ignored.
+func (c *Chain) Use(mw ...func(http.Handler) http.Handler) *Chain {
+	for _, fn := range mw {
+		if fn != nil {
+			c.entries = append(c.entries, chainEntry{mw: fn})
+		}
+	}
+	return c
+}
+
+// UseFunc appends a [MiddlewareFunc] to the [Chain].
+func (c *Chain) UseFunc(fn MiddlewareFunc) *Chain {
+	if fn == nil {
+		return c
+	}
+	return c.Use(NewMiddleware(fn))
+}
+
+// UseWithError appends a [MiddlewareWithErrorFunc] to the [Chain].
+func (c *Chain) UseWithError(fn MiddlewareWithErrorFunc) *Chain {
+	if fn == nil {
+		return c
+	}
+	return c.Use(NewMiddlewareWithError(fn))
+}
+
+// UseNamed appends a named middleware to the [Chain]. If the name
+// is already in use, the entry is replaced keeping its position.
+func (c *Chain) UseNamed(name string, mw func(http.Handler) http.Handler) *Chain {
+	if mw == nil {
+		return c
+	}
+
+	if i := c.index(name); i >= 0 {
+		c.entries[i].mw = mw
+		return c
+	}
+
+	c.entries = append(c.entries, chainEntry{mw: mw, name: name})
+	return c
+}
+
+func (c *Chain) index(name string) int {
+	if c == nil || name == "" {
+		return -1
+	}
+
+	for i, e := range c.entries {
+		if e.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Len returns the number of middleware in the [Chain].
+func (c *Chain) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.entries)
+}
+
+// Clone returns a copy of the [Chain] that can be modified
+// without affecting the original.
+func (c *Chain) Clone() *Chain {
+	out := new(Chain)
+	if c != nil && len(c.entries) > 0 {
+		out.entries = make([]chainEntry, len(c.entries))
+		copy(out.entries, c.entries)
+	}
+	return out
+}
+
+// With returns a copy of the [Chain] with additional middleware
+// appended, for routes that need more than the shared ones.
+func (c *Chain) With(mw ...func(http.Handler) http.Handler) *Chain {
+	return c.Clone().Use(mw...)
+}
+
+// Override returns a copy of the [Chain] where the named middleware
+// is replaced, or removed if mw is nil. Unknown names are appended.
+func (c *Chain) Override(name string, mw func(http.Handler) http.Handler) *Chain {
+	out := c.Clone()
+	if mw != nil {
+		return out.UseNamed(name, mw)
+	}
+
+	if i := out.index(name); i >= 0 {
+		out.entries = append(out.entries[:i], out.entries[i+1:]...)
+	}
+	return out
+}
+
+// Then returns the handler wrapped by all the middleware of the
+// [Chain]. A nil handler is replaced by a 404 one.
+func (c *Chain) Then(h http.Handler) http.Handler {
+	if h == nil {
+		h = NewStatusNotFound()
+	}
+
+	if c != nil {
+		for i := len(c.entries) - 1; i >= 0; i-- {
+			h = c.entries[i].mw(h)
+		}
+	}
+	return h
+}
+
+// ThenFunc is like [Chain.Then] but taking a function.
+func (c *Chain) ThenFunc(fn func(http.ResponseWriter, *http.Request)) http.Handler {
+	if fn == nil {
+		return c.Then(nil)
+	}
+	return c.Then(http.HandlerFunc(fn))
+}
+
+// ThenHandler is like [Chain.Then] but taking a [Handler], whose
+// errors are passed to [HandleError].
+func (c *Chain) ThenHandler(h Handler) http.Handler {
+	if h == nil {
+		return c.Then(nil)
+	}
+	return c.Then(HandlerFunc(h.TryServeHTTP))
+}
+
+// Middleware returns the [Chain] as a single middleware.
+func (c *Chain) Middleware() func(http.Handler) http.Handler {
+	return c.Clone().Then
+}
@@ -0,0 +1,50 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestMiddleware(name string) func(http.Handler) http.Handler {
+	return NewMiddleware(func(rw http.ResponseWriter, req *http.Request, next http.Handler) {
+		rw.Header().Add("X-Order", name)
+		next.ServeHTTP(rw, req)
+	})
+}
+
+func testChainOrder(t *testing.T, h http.Handler, expected string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if s := strings.Join(rec.Header().Values("X-Order"), ","); s != expected {
+		t.Errorf("ERROR: expected %q, got %q", expected, s)
+	}
+}
+
+func TestChain(t *testing.T) {
+	ok := func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	c := NewChain(newTestMiddleware("a"), nil).
+		UseNamed("auth", newTestMiddleware("b")).
+		Use(newTestMiddleware("c"))
+
+	testChainOrder(t, c.ThenFunc(ok), "a,b,c")
+	testChainOrder(t, c.With(newTestMiddleware("d")).ThenFunc(ok), "a,b,c,d")
+	testChainOrder(t, c.Override("auth", newTestMiddleware("x")).ThenFunc(ok), "a,x,c")
+	testChainOrder(t, c.Override("auth", nil).ThenFunc(ok), "a,c")
+
+	// overrides don't affect the original
+	testChainOrder(t, c.ThenFunc(ok), "a,b,c")
+
+	rec := httptest.NewRecorder()
+	new(Chain).Then(nil).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("ERROR: expected 404, got %v", rec.Code)
+	}
+}
@@ -0,0 +1,128 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"darvaza.org/x/web/consts"
+)
+
+// CheckPreconditions evaluates the conditional headers of a request
+// following RFC 9110 section 13.2.2, against the current entity-tag
+// and modification time of the resource, both optional.
+// It returns zero if the request should proceed, otherwise
+// 304 Not Modified or 412 Precondition Failed.
+func CheckPreconditions(req *http.Request, etag string, modTime time.Time) int {
+	if code := checkIfMatch(req, etag, modTime); code != 0 {
+		return code
+	}
+	return checkIfNoneMatch(req, etag, modTime)
+}
+
+func checkIfMatch(req *http.Request, etag string, modTime time.Time) int {
+	if s := req.Header.Get(consts.IfMatch); s != "" {
+		if matchAnyETag(s, etag, false) {
+			return 0
+		}
+		return http.StatusPreconditionFailed
+	}
+
+	if modified, ok := modifiedSince(req.Header.Get(consts.IfUnmodifiedSince), modTime); ok && modified {
+		return http.StatusPreconditionFailed
+	}
+	return 0
+}
+
+func checkIfNoneMatch(req *http.Request, etag string, modTime time.Time) int {
+	safe := isSafeMethod(req.Method)
+
+	if s := req.Header.Get(consts.IfNoneMatch); s != "" {
+		switch {
+		case !matchAnyETag(s, etag, true):
+			return 0
+		case safe:
+			return http.StatusNotModified
+		default:
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if !safe {
+		return 0
+	}
+
+	if modified, ok := modifiedSince(req.Header.Get(consts.IfModifiedSince), modTime); ok && !modified {
+		return http.StatusNotModified
+	}
+	return 0
+}
+
+// matchAnyETag tests an If-Match or If-None-Match value, where "*"
+// matches any existing resource.
+func matchAnyETag(list, etag string, weak bool) bool {
+	return strings.TrimSpace(list) == "*" || MatchETag(list, etag, weak)
+}
+
+func isSafeMethod(method string) bool {
+	return method == consts.GET || method == consts.HEAD
+}
+
+// modifiedSince tells if modTime is after the given HTTP date. It fails
+// if the date can't be parsed or modTime is unknown.
+func modifiedSince(date string, modTime time.Time) (modified, ok bool) {
+	if date == "" || isZeroTime(modTime) {
+		return false, false
+	}
+
+	t, err := http.ParseTime(date)
+	if err != nil {
+		return false, false
+	}
+
+	// HTTP dates have a resolution of seconds
+	return modTime.Truncate(time.Second).After(t), true
+}
+
+func isZeroTime(t time.Time) bool {
+	return t.IsZero() || t.Equal(time.Unix(0, 0))
+}
+
+// WriteNotModified writes a 304 Not Modified response, removing
+// the headers describing a body.
+func WriteNotModified(rw http.ResponseWriter) {
+	hdr := rw.Header()
+	delete(hdr, consts.ContentType)
+	delete(hdr, consts.ContentLength)
+	delete(hdr, consts.ContentEncoding)
+	if hdr.Get(consts.ETag) != "" {
+		delete(hdr, consts.LastModified)
+	}
+
+	rw.WriteHeader(http.StatusNotModified)
+}
+
+// ServeConditional sets the ETag and Last-Modified headers, when
+// known, and evaluates the preconditions of the request. It tells
+// if the request has been answered, either by a 304 Not Modified or
+// passing a 412 Precondition Failed to [HandleError].
+func ServeConditional(rw http.ResponseWriter, req *http.Request, etag string, modTime time.Time) bool {
+	hdr := rw.Header()
+	if etag != "" {
+		hdr[consts.ETag] = []string{etag}
+	}
+	if !isZeroTime(modTime) {
+		hdr[consts.LastModified] = []string{modTime.UTC().Format(http.TimeFormat)}
+	}
+
+	switch CheckPreconditions(req, etag, modTime) {
+	case http.StatusNotModified:
+		WriteNotModified(rw)
+		return true
+	case http.StatusPreconditionFailed:
+		HandleError(rw, req, NewStatusPreconditionFailed())
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,112 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"darvaza.org/x/web/consts"
+)
+
+func TestParseETag(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		tag  string
+		weak bool
+		ok   bool
+	}{
+		{`"abc"`, "abc", false, true},
+		{`W/"abc"`, "abc", true, true},
+		{`""`, "", false, true},
+		{`abc`, "", false, false},
+		{`"a"b"`, "", false, false},
+		{`W/abc`, "", false, false},
+	} {
+		tag, weak, ok := ParseETag(tc.in)
+		if tag != tc.tag || weak != tc.weak || ok != tc.ok {
+			t.Errorf("ERROR: %q: unexpected %q %v %v", tc.in, tag, weak, ok)
+		}
+	}
+
+	if s := QuoteETag("a+b/c="); s != `"a+b/c="` {
+		t.Errorf("ERROR: unexpected %s", s)
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	for _, tc := range []struct {
+		list, etag string
+		weak, ok   bool
+	}{
+		{`"a", "b"`, `"b"`, false, true},
+		{`"a",W/"b"`, `"b"`, false, false},
+		{`"a",W/"b"`, `"b"`, true, true},
+		{`"a"`, `W/"a"`, false, false},
+		{`"a"`, `W/"a"`, true, true},
+		{`*`, `"a"`, false, true},
+		{`"a`, `"a"`, true, false},
+	} {
+		if ok := MatchETag(tc.list, tc.etag, tc.weak); ok != tc.ok {
+			t.Errorf("ERROR: %q %q weak:%v: unexpected %v", tc.list, tc.etag, tc.weak, ok)
+		}
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	before := modTime.Add(-time.Hour).Format(http.TimeFormat)
+	after := modTime.Add(time.Hour).Format(http.TimeFormat)
+	etag := `"v1"`
+
+	for _, tc := range []struct {
+		method string
+		hdr    http.Header
+		code   int
+	}{
+		{"GET", nil, 0},
+		{"GET", http.Header{consts.IfNoneMatch: {`"v0", "v1"`}}, http.StatusNotModified},
+		{"GET", http.Header{consts.IfNoneMatch: {`W/"v1"`}}, http.StatusNotModified},
+		{"GET", http.Header{consts.IfNoneMatch: {`"v0"`}}, 0},
+		{"PUT", http.Header{consts.IfNoneMatch: {`*`}}, http.StatusPreconditionFailed},
+		{"PUT", http.Header{consts.IfMatch: {`"v0"`}}, http.StatusPreconditionFailed},
+		{"PUT", http.Header{consts.IfMatch: {`"v1"`}}, 0},
+		{"GET", http.Header{consts.IfModifiedSince: {after}}, http.StatusNotModified},
+		{"GET", http.Header{consts.IfModifiedSince: {before}}, 0},
+		{"GET", http.Header{
+			consts.IfNoneMatch:     {`"v0"`},
+			consts.IfModifiedSince: {after},
+		}, 0},
+		{"PUT", http.Header{consts.IfUnmodifiedSince: {before}}, http.StatusPreconditionFailed},
+		{"PUT", http.Header{consts.IfUnmodifiedSince: {after}}, 0},
+	} {
+		req := httptest.NewRequest(tc.method, "/", nil)
+		req.Header = tc.hdr
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+
+		if code := CheckPreconditions(req, etag, modTime); code != tc.code {
+			t.Errorf("ERROR: %s %v: expected %v, got %v", tc.method, tc.hdr, tc.code, code)
+		}
+	}
+}
+
+func TestServeConditional(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(consts.IfNoneMatch, `"v1"`)
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set(consts.ContentType, consts.TXT)
+
+	switch {
+	case !ServeConditional(rec, req, `"v1"`, time.Now()):
+		t.Errorf("ERROR: request not answered")
+	case rec.Code != http.StatusNotModified:
+		t.Errorf("ERROR: unexpected status %v", rec.Code)
+	case rec.Header().Get(consts.ContentType) != "", rec.Header().Get(consts.LastModified) != "":
+		t.Errorf("ERROR: unexpected headers %v", rec.Header())
+	case rec.Header().Get(consts.ETag) != `"v1"`:
+		t.Errorf("ERROR: missing ETag")
+	}
+}
@@ -11,10 +11,22 @@ const (
 	// for the content of the Request response
 	Accept = "Accept"
 
+	// AcceptCharset is the canonical header name used for negotiating
+	// the character set of the Request response
+	AcceptCharset = "Accept-Charset"
+
 	// AcceptEncoding is the canonical name given to the header used
 	// to indicate compression options
 	AcceptEncoding = "Accept-Encoding"
 
+	// AcceptLanguage is the canonical header name used for negotiating
+	// the natural language of the Request response
+	AcceptLanguage = "Accept-Language"
+
+	// AcceptRanges is the canonical header used to indicate the
+	// support of range requests.
+	AcceptRanges = "Accept-Ranges"
+
 	// Allow is the canonical header used to indicate the supported
 	// Methods.
 	Allow = "Allow"
@@ -25,16 +37,56 @@ const (
 
 	// ContentEncoding is the canonical Content-Encoding header
 	ContentEncoding = "Content-Encoding"
+	// ContentLanguage is the canonical Content-Language header
+	ContentLanguage = "Content-Language"
 	// ContentLength is the canonical Content-Length header
 	ContentLength = "Content-Length"
+	// ContentRange is the canonical Content-Range header
+	ContentRange = "Content-Range"
 	// ContentType is the canonical Content-Type header
 	ContentType = "Content-Type"
 	// ETag is the canonical ETag header
 	ETag = "Etag"
 
+	// IfMatch is the canonical header used to make a request
+	// conditional to the current ETag of the resource.
+	IfMatch = "If-Match"
+	// IfModifiedSince is the canonical header used to make a request
+	// conditional to the resource being modified after a date.
+	IfModifiedSince = "If-Modified-Since"
+	// IfNoneMatch is the canonical header used to make a request
+	// conditional to the resource not having any of the given ETags.
+	IfNoneMatch = "If-None-Match"
+	// IfRange is the canonical header used to make a range request
+	// conditional to the resource not having changed.
+	IfRange = "If-Range"
+	// IfUnmodifiedSince is the canonical header used to make a request
+	// conditional to the resource not being modified after a date.
+	IfUnmodifiedSince = "If-Unmodified-Since"
+
+	// LastModified is the canonical header used to indicate when
+	// the resource was last modified.
+	LastModified = "Last-Modified"
+
 	// Location is the canonical name given to the header used
 	// to indicate a redirection.
 	Location = "Location"
+
+	// Range is the canonical header used to request parts of
+	// the content.
+	Range = "Range"
+
+	// RetryAfter is the canonical header used to indicate how long
+	// to wait before making a new request.
+	RetryAfter = "Retry-After"
+
+	// TraceParent is the canonical W3C Trace Context header
+	// identifying the incoming request in a tracing system.
+	TraceParent = "Traceparent"
+
+	// XRequestID is the canonical header used to correlate
+	// requests across services.
+	XRequestID = "X-Request-Id"
 )
 
 const (
@@ -67,6 +119,11 @@ const (
 	HTML = "text/html; charset=utf-8"
 	// TXT is the standard Media Type for plain text content.
 	TXT = "text/plain; charset=utf-8"
+	// XML is the standard Media Type for XML content.
+	XML = "application/xml; charset=utf-8"
+	// ProblemJSON is the standard Media Type for RFC 7807
+	// problem details.
+	ProblemJSON = "application/problem+json"
 
 	// URLEncodedForm is the standard Media Type for HTML
 	// forms by default.
@@ -0,0 +1,55 @@
+package web
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"darvaza.org/core"
+	xerrors "darvaza.org/x/sync/errors"
+)
+
+var sentinelStatus = []struct {
+	err  error
+	code int
+}{
+	{core.ErrNotImplemented, http.StatusNotImplemented},
+	{core.ErrNotExists, http.StatusNotFound},
+	{fs.ErrNotExist, http.StatusNotFound},
+	{core.ErrExists, http.StatusConflict},
+	{fs.ErrExist, http.StatusConflict},
+	{fs.ErrPermission, http.StatusForbidden},
+	{core.ErrInvalid, http.StatusBadRequest},
+	{fs.ErrInvalid, http.StatusBadRequest},
+}
+
+// ErrorStatus infers the HTTP status code of an error.
+// The status of an [Error] in the chain is used if present,
+// otherwise well known sentinel errors are checked, timeouts
+// become 504 and cancellations and temporary failures 503.
+// Anything else is a 500.
+func ErrorStatus(err error) int {
+	var e Error
+
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.As(err, &e) && e.HTTPStatus() > 0:
+		return e.HTTPStatus()
+	}
+
+	for _, s := range sentinelStatus {
+		if errors.Is(err, s.err) {
+			return s.code
+		}
+	}
+
+	switch {
+	case xerrors.IsTimeout(err):
+		return http.StatusGatewayTimeout
+	case xerrors.IsCancel(err), xerrors.IsTemporary(err):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -173,3 +173,10 @@ func NewStatusNotAcceptable() *HTTPError {
 		Code: http.StatusNotAcceptable,
 	}
 }
+
+// NewStatusPreconditionFailed returns a 412 HTTP error.
+func NewStatusPreconditionFailed() *HTTPError {
+	return &HTTPError{
+		Code: http.StatusPreconditionFailed,
+	}
+}
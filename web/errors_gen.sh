@@ -97,6 +97,7 @@ for x in \
 	Forbidden=403 \
 	NotFound=404 \
 	NotAcceptable=406 \
+	PreconditionFailed=412 \
 	; do
 
 	name=${x%=*}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// FormatETag returns the entity-tag of an opaque value, weak
+// or strong.
+func FormatETag(tag string, weak bool) string {
+	if weak {
+		return `W/"` + tag + `"`
+	}
+	return `"` + tag + `"`
+}
+
+// QuoteETag returns the given value as entity-tag, quoting it
+// unless it's one already.
+func QuoteETag(s string) string {
+	if _, _, ok := ParseETag(s); ok {
+		return s
+	}
+	return FormatETag(s, false)
+}
+
+// ETagOf returns a strong entity-tag for the given content.
+func ETagOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return FormatETag(base64.RawURLEncoding.EncodeToString(sum[:16]), false)
+}
+
+// WeakETagOf returns a weak entity-tag for the given content.
+func WeakETagOf(data []byte) string {
+	return "W/" + ETagOf(data)
+}
+
+// ParseETag splits an entity-tag into its opaque value and if it
+// is weak.
+func ParseETag(s string) (tag string, weak, ok bool) {
+	tag, weak, rest, ok := scanETag(s)
+	if !ok || rest != "" {
+		return "", false, false
+	}
+	return tag, weak, true
+}
+
+// scanETag reads an entity-tag at the beginning of a string,
+// returning the rest.
+func scanETag(s string) (tag string, weak bool, rest string, ok bool) {
+	if strings.HasPrefix(s, "W/") {
+		weak = true
+		s = s[2:]
+	}
+
+	tag, rest, ok = scanOpaqueTag(s)
+	return tag, weak, rest, ok
+}
+
+func scanOpaqueTag(s string) (tag, rest string, ok bool) {
+	if len(s) < 2 || s[0] != '"' {
+		return "", "", false
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return s[1:i], s[i+1:], true
+		case !isETagChar(c):
+			return "", "", false
+		}
+	}
+	return "", "", false
+}
+
+func isETagChar(c byte) bool {
+	return c == 0x21 || (c >= 0x23 && c <= 0x7e) || c >= 0x80
+}
+
+// MatchETag tests if an entity-tag matches any of a list as used
+// by If-Match and If-None-Match. Strong comparison requires both
+// to be strong, while weak comparison only compares the values.
+func MatchETag(list, etag string, weak bool) bool {
+	tag, isWeak, ok := ParseETag(etag)
+	switch {
+	case !ok:
+		return false
+	case isWeak && !weak:
+		return false
+	default:
+		return matchETagList(list, tag, weak)
+	}
+}
+
+func matchETagList(list, tag string, weak bool) bool {
+	s := trimETagList(list)
+	for s != "" {
+		if s[0] == '*' {
+			return true
+		}
+
+		t, w, rest, ok := scanETag(s)
+		switch {
+		case !ok:
+			return false
+		case t != tag:
+			// next
+		case weak || !w:
+			return true
+		}
+		s = trimETagList(rest)
+	}
+	return false
+}
+
+func trimETagList(s string) string {
+	return strings.TrimLeft(s, " \t,")
+}
@@ -0,0 +1,299 @@
+package forms
+
+import (
+	"encoding"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	fileType            = reflect.TypeOf((*File)(nil))
+	fileSliceType       = reflect.TypeOf([]*File(nil))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Validator is implemented by types checking themselves after
+// being decoded. Returning [FieldErrors] describes the problems
+// by field.
+type Validator interface {
+	Validate() error
+}
+
+// Decode reads the form of a request into the struct pointed by v,
+// applying the limits of the [Options].
+//
+// Fields are named by their `form` tag, or the field name if
+// not tagged, `form:"-"` skips a field and `form:"name,required"`
+// makes it mandatory. Nested structs use dots to join names, like
+// JSON objects read by [ParseForm], and embedded ones are flattened.
+// Strings, booleans, numbers, [time.Duration], [encoding.TextUnmarshaler]
+// types, pointers and slices of them are supported.
+//
+// Multipart requests are read with [ReadMultipart], binding uploads to
+// *[File] and []*[File] fields. Files not bound are removed.
+//
+// Invalid fields are reported as a 400 [web.Problem] listing them,
+// and finally [Validator] is called if implemented.
+func Decode(req *http.Request, v any, opts *Options) error {
+	var files map[string][]*File
+
+	if req.Form == nil && getContentType(req) == multiPartForm {
+		m, err := ReadMultipart(req, opts)
+		if err != nil {
+			return err
+		}
+		files = m.Files
+	} else {
+		LimitBody(nil, req, opts.maxBodySize())
+		if err := ParseForm(req, opts.maxMemory()); err != nil {
+			return AsSizeError(err)
+		}
+	}
+
+	d := newDecoder(req.Form, files)
+	err := d.decode(v)
+	if err == nil {
+		err = validate(v)
+	}
+
+	d.removeFiles(err != nil)
+	return err
+}
+
+// Unmarshal decodes form values into the struct pointed by v,
+// as described in [Decode].
+func Unmarshal(values url.Values, v any) error {
+	d := newDecoder(values, nil)
+	return d.decode(v)
+}
+
+func validate(v any) error {
+	var fe FieldErrors
+
+	val, ok := v.(Validator)
+	if !ok {
+		return nil
+	}
+
+	err := val.Validate()
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &fe):
+		return fe.AsError()
+	default:
+		return web.AsErrorWithCode(err, http.StatusBadRequest)
+	}
+}
+
+type decoder struct {
+	err    error
+	values url.Values
+	files  map[string][]*File
+	bound  map[*File]bool
+	errs   FieldErrors
+}
+
+func newDecoder(values url.Values, files map[string][]*File) *decoder {
+	return &decoder{
+		values: values,
+		files:  files,
+		bound:  make(map[*File]bool),
+		errs:   make(FieldErrors),
+	}
+}
+
+func (d *decoder) decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return core.Wrap(core.ErrInvalid, "forms: pointer to struct expected")
+	}
+
+	d.decodeStruct(rv.Elem(), "")
+
+	if d.err != nil {
+		return d.err
+	}
+	return d.errs.AsError()
+}
+
+func (d *decoder) removeFiles(all bool) {
+	for _, files := range d.files {
+		for _, f := range files {
+			if all || !d.bound[f] {
+				_ = f.Remove()
+			}
+		}
+	}
+}
+
+func (d *decoder) decodeStruct(rv reflect.Value, prefix string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		d.decodeField(rv.Field(i), rt.Field(i), prefix)
+	}
+}
+
+func (d *decoder) decodeField(fv reflect.Value, sf reflect.StructField, prefix string) {
+	name, required, ok := fieldName(sf)
+	t := fv.Type()
+
+	switch {
+	case !ok:
+		// skip
+	case name == "":
+		// embedded
+		d.decodeStruct(fv, prefix)
+	case t == fileType || t == fileSliceType:
+		d.bindFiles(fv, prefix+name, required)
+	case isNested(t):
+		d.decodeStruct(fv, prefix+name+".")
+	default:
+		d.decodeValue(fv, prefix+name, required)
+	}
+}
+
+// fieldName returns the form name of a struct field, empty for
+// embedded structs to be flattened, and if it's required.
+func fieldName(sf reflect.StructField) (name string, required, ok bool) {
+	tag := sf.Tag.Get("form")
+	if tag == "-" {
+		return "", false, false
+	}
+
+	name, flags, _ := strings.Cut(tag, ",")
+	required = flags == "required"
+
+	switch {
+	case sf.Anonymous && name == "" && isNested(sf.Type):
+		return "", required, true
+	case !sf.IsExported():
+		return "", false, false
+	default:
+		return core.Coalesce(name, sf.Name), required, true
+	}
+}
+
+func isNested(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func (d *decoder) bindFiles(fv reflect.Value, name string, required bool) {
+	files := d.files[name]
+	switch {
+	case len(files) == 0:
+		if required {
+			d.errs.Add(name, ErrMissing)
+		}
+		return
+	case fv.Type() == fileType:
+		files = files[:1]
+		fv.Set(reflect.ValueOf(files[0]))
+	default:
+		fv.Set(reflect.ValueOf(files))
+	}
+
+	for _, f := range files {
+		d.bound[f] = true
+	}
+}
+
+func (d *decoder) decodeValue(fv reflect.Value, name string, required bool) {
+	ss, _ := doFormValues[string](d.values, name)
+
+	var err error
+	switch {
+	case len(ss) == 0:
+		err = core.IIf(required, ErrMissing, nil)
+	case fv.Kind() == reflect.Slice && !isText(fv.Type()):
+		err = setSlice(fv, ss)
+	default:
+		err = setValue(fv, ss[0])
+	}
+
+	if errors.Is(err, core.ErrNotImplemented) {
+		// unsupported field type, not the client's fault
+		d.err = core.Coalesce(d.err, err)
+		return
+	}
+	d.errs.Add(name, err)
+}
+
+func isText(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func setSlice(fv reflect.Value, ss []string) error {
+	out := reflect.MakeSlice(fv.Type(), 0, len(ss))
+	for _, s := range ss {
+		v := reflect.New(fv.Type().Elem()).Elem()
+		if err := setValue(v, s); err != nil {
+			return err
+		}
+		out = reflect.Append(out, v)
+	}
+
+	fv.Set(out)
+	return nil
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), s)
+	}
+
+	if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		v.SetInt(int64(d))
+		return err
+	}
+
+	return setKind(v, s)
+}
+
+func setKind(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Slice:
+		// []byte
+		v.SetBytes([]byte(s))
+		return nil
+	case reflect.Bool:
+		b, err := ParseBool[bool](s)
+		v.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(n)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(n)
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+		return err
+	default:
+		return core.Wrapf(core.ErrNotImplemented, "forms: unsupported type %s", v.Type())
+	}
+}
@@ -0,0 +1,206 @@
+package forms
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+type testAddress struct {
+	City string `form:"city,required"`
+}
+
+type testBase struct {
+	ID int `form:"id"`
+}
+
+type testForm struct {
+	testBase
+	Name    string        `form:"name,required"`
+	Tags    []string      `form:"tag"`
+	Age     *uint8        `form:"age"`
+	Timeout time.Duration `form:"timeout"`
+	Address testAddress   `form:"address"`
+	Avatar  *File         `form:"avatar"`
+	Ignored string        `form:"-"`
+	Admin   bool
+}
+
+func TestUnmarshal(t *testing.T) {
+	values := url.Values{
+		"id":           {"42"},
+		"name":         {" alice "},
+		"tag":          {"a", "", "b"},
+		"age":          {"30"},
+		"timeout":      {"1m"},
+		"address.city": {"Berlin"},
+		"Ignored":      {"x"},
+		"Admin":        {"yes"},
+	}
+
+	var v testForm
+	if err := Unmarshal(values, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	switch {
+	case v.ID != 42, v.Name != "alice", v.Admin != true, v.Ignored != "":
+		t.Errorf("ERROR: unexpected values %#v", v)
+	case len(v.Tags) != 2 || v.Tags[1] != "b":
+		t.Errorf("ERROR: unexpected tags %q", v.Tags)
+	case v.Age == nil || *v.Age != 30:
+		t.Errorf("ERROR: unexpected age %v", v.Age)
+	case v.Timeout != time.Minute, v.Address.City != "Berlin":
+		t.Errorf("ERROR: unexpected values %#v", v)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	values := url.Values{
+		"age": {"300"},
+		"id":  {"x"},
+	}
+
+	var v testForm
+	err := Unmarshal(values, &v)
+
+	var p *web.Problem
+	switch {
+	case !errors.As(err, &p):
+		t.Fatalf("ERROR: unexpected error %v", err)
+	case p.Status != http.StatusBadRequest:
+		t.Errorf("ERROR: unexpected status %v", p.Status)
+	case len(p.Fields) != 4:
+		t.Errorf("ERROR: unexpected fields %v", p.Fields)
+	case p.Fields["name"] != ErrMissing.Error(), p.Fields["age"] != ErrRange.Error():
+		t.Errorf("ERROR: unexpected fields %v", p.Fields)
+	case !errors.Is(err, ErrMissing):
+		t.Errorf("ERROR: ErrMissing not in the chain")
+	}
+}
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+	var buf bytes.Buffer
+
+	w := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		_ = w.WriteField(k, v)
+	}
+	for k, v := range files {
+		fw, err := w.CreateFormFile(k, k+".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.WriteString(fw, v)
+	}
+	_ = w.Close()
+
+	req := httptest.NewRequest("POST", "/", &buf)
+	req.Header.Set(consts.ContentType, w.FormDataContentType())
+	return req
+}
+
+func TestDecodeMultipart(t *testing.T) {
+	opts := &Options{Storage: DirStorage(t.TempDir())}
+	req := newMultipartRequest(t,
+		map[string]string{"name": "bob", "address.city": "Paris"},
+		map[string]string{"avatar": "picture", "other": "unbound"})
+
+	var v testForm
+	if err := Decode(req, &v, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Avatar == nil {
+		t.Fatal("ERROR: avatar not bound")
+	}
+	defer v.Avatar.Remove()
+
+	f, err := v.Avatar.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(f)
+	_ = f.Close()
+
+	switch {
+	case string(b) != "picture", v.Avatar.Filename != "avatar.txt":
+		t.Errorf("ERROR: unexpected file %q %q", v.Avatar.Filename, b)
+	case v.Name != "bob", req.FormValue("name") != "bob":
+		t.Errorf("ERROR: unexpected name %q", v.Name)
+	}
+}
+
+func TestReadMultipartLimits(t *testing.T) {
+	for _, tc := range []struct {
+		opts Options
+		code int
+	}{
+		{Options{MaxFileSize: 4}, http.StatusRequestEntityTooLarge},
+		{Options{MaxFiles: -1}, http.StatusRequestEntityTooLarge},
+		{Options{MaxBodySize: 64}, http.StatusRequestEntityTooLarge},
+		{Options{}, http.StatusOK},
+	} {
+		tc.opts.Storage = DirStorage(t.TempDir())
+		req := newMultipartRequest(t,
+			map[string]string{"name": strings.Repeat("x", 32)},
+			map[string]string{"file": "too large"})
+
+		m, err := ReadMultipart(req, &tc.opts)
+		code := http.StatusOK
+		if err != nil {
+			code = web.ErrorStatus(err)
+		} else {
+			_ = m.RemoveAll()
+		}
+
+		if code != tc.code {
+			t.Errorf("ERROR: %#v: expected %v, got %v (%v)", tc.opts, tc.code, code, err)
+		}
+	}
+}
+
+func TestDirStorageRename(t *testing.T) {
+	dir := t.TempDir()
+	storage := DirStorage(dir)
+
+	tmp, err := storage.CreateTemp("upload-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.WriteString(tmp, "content")
+
+	// the name is reserved, but empty until closed
+	if fi, err := os.Stat(tmp.Name()); err != nil || fi.Size() != 0 {
+		t.Errorf("ERROR: %q not reserved before Close() (%v)", tmp.Name(), err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	switch {
+	case len(entries) != 1:
+		t.Errorf("ERROR: %v entries after Close() (expected 1)", len(entries))
+	case filepath.Join(dir, entries[0].Name()) != tmp.Name():
+		t.Errorf("ERROR: unexpected entry %q (expected %q)", entries[0].Name(), tmp.Name())
+	}
+
+	b, err := os.ReadFile(tmp.Name())
+	if err != nil || string(b) != "content" {
+		t.Errorf("ERROR: unexpected content %q (%v)", b, err)
+	}
+}
@@ -0,0 +1,59 @@
+package forms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+)
+
+// FieldErrors collects validation errors by field name.
+// It can be returned directly as error by a [Validator].
+type FieldErrors map[string]error
+
+func (fe FieldErrors) Error() string {
+	return "invalid fields: " + strings.Join(core.SortedKeys(fe), ", ")
+}
+
+// Add records an error for a field, the first one wins.
+func (fe FieldErrors) Add(field string, err error) {
+	if err != nil {
+		if _, ok := fe[field]; !ok {
+			fe[field] = err
+		}
+	}
+}
+
+// AsError returns nil if there are no errors, otherwise a 400
+// [web.Problem] describing each field.
+func (fe FieldErrors) AsError() error {
+	if len(fe) == 0 {
+		return nil
+	}
+
+	names := core.SortedKeys(fe)
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, core.Wrap(fe[name], name))
+	}
+
+	p := web.NewProblem(http.StatusBadRequest, errors.Join(errs...))
+	p.Detail = fe.Error()
+	for _, name := range names {
+		p.SetField(name, fieldMessage(fe[name]))
+	}
+	return p
+}
+
+// fieldMessage describes a field error without repeating the field
+// name or the value.
+func fieldMessage(err error) string {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		return ne.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,110 @@
+package forms
+
+import (
+	"errors"
+	"net/http"
+
+	"darvaza.org/x/web"
+)
+
+const (
+	// DefaultMaxBodySize is the limit of the request body used
+	// when the [Options] don't specify one.
+	DefaultMaxBodySize = 32 << 20 // 32MiB
+
+	// DefaultMaxFiles is the maximum number of files accepted in
+	// a multipart request when the [Options] don't specify it.
+	DefaultMaxFiles = 16
+)
+
+// Options describes the limits and storage used when reading forms.
+type Options struct {
+	// Storage holds uploaded files, [DirStorage] on the default
+	// temporary directory if not set.
+	Storage FileStorage
+
+	// MaxBodySize is the limit of the whole request body,
+	// [DefaultMaxBodySize] if not positive.
+	MaxBodySize int64
+
+	// MaxMemory is the limit of the non-file fields,
+	// [DefaultFormMaxMemory] if not positive.
+	MaxMemory int64
+
+	// MaxFileSize is the limit of each uploaded file,
+	// the MaxBodySize if not positive.
+	MaxFileSize int64
+
+	// MaxFiles is the maximum number of uploaded files,
+	// [DefaultMaxFiles] if zero. Negative refuses files.
+	MaxFiles int
+}
+
+func (opts *Options) storage() FileStorage {
+	if opts == nil || opts.Storage == nil {
+		return DirStorage("")
+	}
+	return opts.Storage
+}
+
+func (opts *Options) maxBodySize() int64 {
+	if opts == nil || opts.MaxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+	return opts.MaxBodySize
+}
+
+func (opts *Options) maxMemory() int64 {
+	if opts == nil || opts.MaxMemory <= 0 {
+		return DefaultFormMaxMemory
+	}
+	return opts.MaxMemory
+}
+
+func (opts *Options) maxFileSize() int64 {
+	if opts == nil || opts.MaxFileSize <= 0 {
+		return opts.maxBodySize()
+	}
+	return opts.MaxFileSize
+}
+
+func (opts *Options) maxFiles() int {
+	switch {
+	case opts == nil || opts.MaxFiles == 0:
+		return DefaultMaxFiles
+	case opts.MaxFiles < 0:
+		return 0
+	default:
+		return opts.MaxFiles
+	}
+}
+
+// LimitBody restricts the size of the request body. Reading beyond
+// the limit fails with an error [AsSizeError] converts into a 413.
+func LimitBody(rw http.ResponseWriter, req *http.Request, maxBytes int64) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodySize
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = http.MaxBytesReader(rw, req.Body, maxBytes)
+	}
+}
+
+// errTooLarge indicates a limit imposed by the [Options] was exceeded.
+var errTooLarge = errors.New("size limit exceeded")
+
+// AsSizeError converts errors caused by exceeding size limits into
+// 413 Request Entity Too Large, and anything else into a 400.
+func AsSizeError(err error) error {
+	var mbe *http.MaxBytesError
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &mbe), errors.Is(err, errTooLarge):
+		return web.NewHTTPError(http.StatusRequestEntityTooLarge, err, "")
+	default:
+		return web.AsErrorWithCode(err, http.StatusBadRequest)
+	}
+}
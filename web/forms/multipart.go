@@ -0,0 +1,320 @@
+package forms
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// FileStorage holds uploaded files while the request is handled.
+type FileStorage interface {
+	// CreateTemp creates a new file to write an upload to.
+	CreateTemp(pattern string) (TempFile, error)
+	// Open opens a previously created file for reading.
+	Open(name string) (fs.File, error)
+	// Remove deletes a previously created file.
+	Remove(name string) error
+}
+
+// TempFile is a file created by a [FileStorage].
+type TempFile interface {
+	io.WriteCloser
+	Name() string
+}
+
+var _ FileStorage = DirStorage("")
+
+// DirStorage is a [FileStorage] creating temporary files in
+// a directory, or the default temporary directory if empty.
+type DirStorage string
+
+// CreateTemp creates a file using [os.CreateTemp], reserving its
+// name. Content is written to a hidden file in the same directory,
+// renamed over [TempFile.Name] when closed, so a crash mid-write
+// never leaves a truncated entry. A crash can still leave the hidden
+// partial file and an empty entry behind, to be removed by the
+// application when no uploads are in progress.
+func (d DirStorage) CreateTemp(pattern string) (TempFile, error) {
+	final, err := os.CreateTemp(string(d), pattern)
+	if err != nil {
+		return nil, err
+	}
+	name := final.Name()
+	_ = final.Close()
+
+	dir, base := filepath.Split(name)
+	partial := filepath.Join(dir, dirStoragePartial+base)
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		_ = os.Remove(name)
+		return nil, err
+	}
+
+	return &dirStorageFile{File: f, name: name}, nil
+}
+
+// dirStoragePartial prefixes the files [DirStorage] is still writing.
+const dirStoragePartial = ".partial-"
+
+// dirStorageFile is a [TempFile] renamed into place when closed.
+type dirStorageFile struct {
+	*os.File
+
+	name string
+	err  error
+}
+
+// Name returns the name the file will have once closed.
+func (f *dirStorageFile) Name() string {
+	return f.name
+}
+
+// Write writes to the partial file, remembering the first error.
+func (f *dirStorageFile) Write(b []byte) (int, error) {
+	n, err := f.File.Write(b)
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// Close closes the partial file and renames it over the reserved
+// name, or removes both if anything failed.
+func (f *dirStorageFile) Close() error {
+	err := f.File.Close()
+	if err == nil {
+		err = f.err
+	}
+	if err == nil {
+		err = os.Rename(f.File.Name(), f.name)
+	}
+	if err != nil {
+		_ = os.Remove(f.File.Name())
+		_ = os.Remove(f.name)
+	}
+	return err
+}
+
+// Open opens a file created by [DirStorage.CreateTemp].
+func (d DirStorage) Open(name string) (fs.File, error) {
+	if err := d.check(name); err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+// Remove deletes a file created by [DirStorage.CreateTemp].
+func (d DirStorage) Remove(name string) error {
+	if err := d.check(name); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// check refuses names outside the directory.
+func (d DirStorage) check(name string) error {
+	dir := filepath.Clean(string(d))
+	if string(d) == "" {
+		dir = filepath.Clean(os.TempDir())
+	}
+
+	if filepath.Dir(filepath.Clean(name)) != dir {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+// File is an uploaded file streamed into a [FileStorage].
+type File struct {
+	storage FileStorage
+
+	// Header contains the MIME headers of the part.
+	Header textproto.MIMEHeader
+	// Field is the name of the form field.
+	Field string
+	// Filename is the name given by the client.
+	Filename string
+	// Name is the name of the file in the [FileStorage].
+	Name string
+	// Size is the length of the content.
+	Size int64
+}
+
+// ContentType returns the Content-Type declared by the client.
+func (f *File) ContentType() string {
+	return f.Header.Get("Content-Type")
+}
+
+// Open opens the stored content for reading.
+func (f *File) Open() (fs.File, error) {
+	return f.storage.Open(f.Name)
+}
+
+// Remove deletes the stored content.
+func (f *File) Remove() error {
+	return f.storage.Remove(f.Name)
+}
+
+// Multipart is the content of a multipart/form-data request read
+// by [ReadMultipart].
+type Multipart struct {
+	// Values contains the non-file fields.
+	Values url.Values
+	// Files contains the uploaded files by field name.
+	Files map[string][]*File
+}
+
+// RemoveAll deletes all the stored files.
+func (m *Multipart) RemoveAll() error {
+	var errs []error
+	for _, files := range m.Files {
+		for _, f := range files {
+			if err := f.Remove(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReadMultipart reads a multipart/form-data request applying the
+// limits of the [Options], streaming file parts into its [FileStorage].
+// If the form hasn't been parsed yet, the values are also stored
+// in the request's Form and PostForm.
+// Errors are [web.Error], with stored files already removed.
+// Otherwise the caller is responsible for removing them.
+func ReadMultipart(req *http.Request, opts *Options) (*Multipart, error) {
+	LimitBody(nil, req, opts.maxBodySize())
+
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return nil, AsSizeError(err)
+	}
+
+	r := &multipartReader{
+		opts:   opts,
+		memory: opts.maxMemory(),
+		files:  opts.maxFiles(),
+		m: &Multipart{
+			Values: make(url.Values),
+			Files:  make(map[string][]*File),
+		},
+	}
+
+	if err := r.readAll(mr); err != nil {
+		_ = r.m.RemoveAll()
+		return nil, AsSizeError(err)
+	}
+
+	setRequestForm(req, r.m.Values)
+	return r.m, nil
+}
+
+func setRequestForm(req *http.Request, values url.Values) {
+	if req.Form != nil {
+		return
+	}
+
+	req.PostForm = values
+	req.Form = cloneValues(values)
+	for k, s := range req.URL.Query() {
+		req.Form[k] = append(req.Form[k], s...)
+	}
+}
+
+type multipartReader struct {
+	opts   *Options
+	m      *Multipart
+	memory int64
+	files  int
+}
+
+func (r *multipartReader) readAll(mr *multipart.Reader) error {
+	for {
+		part, err := mr.NextPart()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		err = r.readPart(part)
+		_ = part.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *multipartReader) readPart(part *multipart.Part) error {
+	name := part.FormName()
+	switch {
+	case name == "":
+		// not a form field
+		return nil
+	case part.FileName() == "":
+		return r.readValue(name, part)
+	default:
+		return r.readFile(name, part)
+	}
+}
+
+func (r *multipartReader) readValue(name string, part *multipart.Part) error {
+	b, err := ReadAll(part, r.memory)
+	if err != nil {
+		return errTooLarge
+	}
+
+	r.memory -= int64(len(b))
+	r.m.Values.Add(name, string(b))
+	return nil
+}
+
+func (r *multipartReader) readFile(name string, part *multipart.Part) error {
+	if r.files <= 0 {
+		return errTooLarge
+	}
+	r.files--
+
+	storage := r.opts.storage()
+	tmp, err := storage.CreateTemp("upload-*")
+	if err != nil {
+		return err
+	}
+
+	f := &File{
+		storage:  storage,
+		Header:   part.Header,
+		Field:    name,
+		Filename: filepath.Base(part.FileName()),
+		Name:     tmp.Name(),
+	}
+	// register first, so it's removed on failure
+	r.m.Files[name] = append(r.m.Files[name], f)
+
+	f.Size, err = copyLimited(tmp, part, r.opts.maxFileSize())
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func copyLimited(w io.Writer, r io.Reader, maxBytes int64) (int64, error) {
+	n, err := io.Copy(w, io.LimitReader(r, maxBytes+1))
+	switch {
+	case err != nil:
+		return n, err
+	case n > maxBytes:
+		return n, errTooLarge
+	default:
+		return n, nil
+	}
+}
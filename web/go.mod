@@ -4,7 +4,9 @@ go 1.22
 
 require (
 	darvaza.org/core v0.16.0
-	darvaza.org/x/fs v0.4.0
+	darvaza.org/slog v0.6.0
+	darvaza.org/x/fs v0.5.0
+	darvaza.org/x/sync v0.1.0
 )
 
 require lukechampine.com/blake3 v1.3.0
@@ -16,3 +18,8 @@ require (
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
+
+replace (
+	darvaza.org/x/fs => ../fs
+	darvaza.org/x/sync => ../sync
+)
@@ -0,0 +1,178 @@
+// Package health implements liveness and readiness checks
+// and handlers reporting them as JSON.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// DefaultTimeout is the time a check is given when neither it
+// nor the [Health] specify one.
+const DefaultTimeout = 5 * time.Second
+
+// Kind tells if a check affects liveness or readiness.
+type Kind int
+
+const (
+	// Liveness checks tell if the service is working at all, and
+	// a failure suggests restarting it.
+	Liveness Kind = iota + 1
+	// Readiness checks tell if the service can take traffic.
+	Readiness
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Liveness:
+		return "liveness"
+	case Readiness:
+		return "readiness"
+	default:
+		return "unknown"
+	}
+}
+
+// CheckFunc verifies the state of a component, returning an error
+// if it's unhealthy. Checks must honour the cancellation of the context.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name    string
+	kind    Kind
+	timeout time.Duration
+	fn      CheckFunc
+}
+
+// Health holds the checks registered by the components of
+// a service.
+type Health struct {
+	// Timeout is the time given to checks registered without one,
+	// [DefaultTimeout] if zero.
+	Timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+}
+
+// Register adds a check of the given [Kind]. A zero timeout uses
+// the one of the [Health].
+func (h *Health) Register(kind Kind, name string, timeout time.Duration, fn CheckFunc) error {
+	switch {
+	case kind != Liveness && kind != Readiness:
+		return core.Wrapf(core.ErrInvalid, "kind %v", int(kind))
+	case name == "":
+		return core.Wrap(core.ErrInvalid, "name not specified")
+	case fn == nil:
+		return core.Wrap(core.ErrInvalid, "check not specified")
+	case timeout < 0:
+		return core.Wrap(core.ErrInvalid, "negative timeout")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, c := range h.checks {
+		if c.name == name {
+			return core.Wrapf(core.ErrExists, "check %q", name)
+		}
+	}
+
+	h.checks = append(h.checks, check{
+		name:    name,
+		kind:    kind,
+		timeout: timeout,
+		fn:      fn,
+	})
+	return nil
+}
+
+// AddLiveness registers a [Liveness] check using the default timeout.
+func (h *Health) AddLiveness(name string, fn CheckFunc) error {
+	return h.Register(Liveness, name, 0, fn)
+}
+
+// AddReadiness registers a [Readiness] check using the default timeout.
+func (h *Health) AddReadiness(name string, fn CheckFunc) error {
+	return h.Register(Readiness, name, 0, fn)
+}
+
+// Check runs concurrently the checks of the given [Kind], each
+// within its timeout. Readiness includes the liveness checks too,
+// as a service that isn't alive can't be ready either.
+func (h *Health) Check(ctx context.Context, kind Kind) *Report {
+	checks := h.selectChecks(kind)
+	results := make([]Result, len(checks))
+
+	eg := &core.ErrGroup{Parent: ctx}
+	for i, c := range checks {
+		i, c := i, c
+		eg.Go(func(ctx context.Context) error {
+			results[i] = h.run(ctx, c)
+			return nil
+		}, nil)
+	}
+	_ = eg.Wait()
+	eg.Cancel(nil)
+
+	return newReport(results)
+}
+
+func (h *Health) selectChecks(kind Kind) []check {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	out := make([]check, 0, len(h.checks))
+	for _, c := range h.checks {
+		if c.kind == kind || c.kind == Liveness {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (h *Health) run(ctx context.Context, c check) Result {
+	timeout := core.Coalesce(c.timeout, h.Timeout, DefaultTimeout)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runCheck(ctx, c.fn)
+	if err == nil {
+		// if the check ignored the context, it's still too late
+		err = ctx.Err()
+	}
+
+	return Result{
+		Name:     c.name,
+		Status:   StatusOf(err),
+		Error:    errorString(err),
+		Duration: time.Since(start),
+	}
+}
+
+func runCheck(ctx context.Context, fn CheckFunc) (err error) {
+	defer func() {
+		if err2 := core.AsRecovered(recover()); err2 != nil {
+			err = err2
+		}
+	}()
+
+	return fn(ctx)
+}
+
+func errorString(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return err.Error()
+	}
+}
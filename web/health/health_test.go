@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestHealth(t *testing.T) *Health {
+	h := &Health{Timeout: 20 * time.Millisecond}
+
+	for _, err := range []error{
+		h.AddLiveness("loop", func(context.Context) error { return nil }),
+		h.AddReadiness("db", func(context.Context) error { return errors.New("down") }),
+		h.AddReadiness("cache", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return h
+}
+
+func TestHealthRegister(t *testing.T) {
+	h := newTestHealth(t)
+
+	if err := h.AddLiveness("loop", func(context.Context) error { return nil }); err == nil {
+		t.Errorf("ERROR: duplicate check accepted")
+	}
+	if err := h.Register(Kind(0), "x", 0, func(context.Context) error { return nil }); err == nil {
+		t.Errorf("ERROR: invalid kind accepted")
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	h := newTestHealth(t)
+
+	live := h.Check(context.Background(), Liveness)
+	if !live.OK() || len(live.Checks) != 1 {
+		t.Errorf("ERROR: unexpected liveness %+v", live)
+	}
+
+	ready := h.Check(context.Background(), Readiness)
+	if ready.OK() || len(ready.Checks) != 3 {
+		t.Fatalf("ERROR: unexpected readiness %+v", ready)
+	}
+
+	expected := map[string]string{"cache": "timeout", "db": "down", "loop": ""}
+	for _, r := range ready.Checks {
+		if r.Error != expected[r.Name] {
+			t.Errorf("ERROR: %s: unexpected error %q", r.Name, r.Error)
+		}
+	}
+}
+
+func TestHealthServeHTTP(t *testing.T) {
+	h := newTestHealth(t)
+
+	for path, code := range map[string]int{
+		LivenessPath:  http.StatusOK,
+		ReadinessPath: http.StatusServiceUnavailable,
+		"/other":      http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+
+		if rec.Code != code {
+			t.Errorf("ERROR: %s: unexpected status %v", path, rec.Code)
+			continue
+		}
+		if code == http.StatusNotFound {
+			continue
+		}
+
+		var r Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+			t.Errorf("ERROR: %s: %v", path, err)
+		} else if r.HTTPStatus() != code {
+			t.Errorf("ERROR: %s: unexpected report %+v", path, r)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+const (
+	// LivenessPath is the path where [Health.ServeHTTP] reports
+	// liveness.
+	LivenessPath = "/healthz"
+	// ReadinessPath is the path where [Health.ServeHTTP] reports
+	// readiness.
+	ReadinessPath = "/readyz"
+)
+
+var _ http.Handler = (*Health)(nil)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	// StatusOK indicates the check passed.
+	StatusOK Status = "ok"
+	// StatusFail indicates the check failed.
+	StatusFail Status = "fail"
+)
+
+// StatusOf returns the [Status] corresponding to the error
+// of a check.
+func StatusOf(err error) Status {
+	if err != nil {
+		return StatusFail
+	}
+	return StatusOK
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"-"`
+}
+
+// MarshalJSON encodes the [Result] including its duration
+// in milliseconds.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type alias Result
+	return json.Marshal(struct {
+		alias
+		Millis float64 `json:"duration_ms"`
+	}{
+		alias:  alias(r),
+		Millis: float64(r.Duration) / float64(time.Millisecond),
+	})
+}
+
+// Report is the aggregate outcome of the checks of a [Kind].
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks,omitempty"`
+}
+
+func newReport(results []Result) *Report {
+	r := &Report{
+		Status: StatusOK,
+		Checks: results,
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	for _, c := range results {
+		if c.Status != StatusOK {
+			r.Status = StatusFail
+		}
+	}
+	return r
+}
+
+// OK tells if all checks passed.
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// HTTPStatus returns 200 if all checks passed, or 503 otherwise.
+func (r *Report) HTTPStatus() int {
+	if r.OK() {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
+// Handler returns an [http.Handler] running the checks of the
+// given [Kind] and rendering the [Report] as JSON.
+func (h *Health) Handler(kind Kind) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		h.serve(rw, req, kind)
+	})
+}
+
+// ServeHTTP reports liveness on [LivenessPath] and readiness on
+// [ReadinessPath].
+func (h *Health) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case LivenessPath:
+		h.serve(rw, req, Liveness)
+	case ReadinessPath:
+		h.serve(rw, req, Readiness)
+	default:
+		web.HandleError(rw, req, web.NewStatusNotFound())
+	}
+}
+
+func (h *Health) serve(rw http.ResponseWriter, req *http.Request, kind Kind) {
+	switch req.Method {
+	case consts.GET, consts.HEAD:
+	default:
+		web.HandleError(rw, req, web.NewStatusMethodNotAllowed(consts.GET, consts.HEAD))
+		return
+	}
+
+	r := h.Check(req.Context(), kind)
+
+	web.SetNoCache(rw.Header())
+	rw.Header().Set(consts.ContentType, consts.JSON)
+	rw.WriteHeader(r.HTTPStatus())
+
+	if req.Method != consts.HEAD {
+		_ = json.NewEncoder(rw).Encode(r)
+	}
+}
@@ -0,0 +1,100 @@
+// Package locale implements the negotiation of the natural
+// language of responses.
+package locale
+
+import (
+	"context"
+	"net/http"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+	"darvaza.org/x/web/qlist"
+)
+
+var languageCtxKey = core.NewContextKey[string]("Language")
+
+// WithLanguage attaches the chosen language tag to a context.
+func WithLanguage(ctx context.Context, tag string) context.Context {
+	return languageCtxKey.WithValue(ctx, tag)
+}
+
+// Language returns the language tag attached to a context.
+func Language(ctx context.Context) (string, bool) {
+	return languageCtxKey.Get(ctx)
+}
+
+// Config describes the languages a service offers.
+type Config struct {
+	// Supported are the offered language tags, by preference.
+	Supported []string
+
+	// Default is the language used when none of the supported
+	// is acceptable, the first supported if empty.
+	Default string
+
+	// Param optionally names a query parameter allowing clients
+	// to choose the language explicitly.
+	Param string
+}
+
+// Negotiate chooses the language of a request, considering the
+// Param of the [Config] first, then the Accept-Language header,
+// and falling back to the default.
+func (cfg *Config) Negotiate(req *http.Request) string {
+	if tag, ok := cfg.fromParam(req); ok {
+		return tag
+	}
+
+	if tag, ok := qlist.BestLanguage(cfg.Supported, req.Header); ok {
+		return tag
+	}
+
+	return cfg.fallback()
+}
+
+func (cfg *Config) fromParam(req *http.Request) (string, bool) {
+	if cfg.Param == "" {
+		return "", false
+	}
+
+	s := req.URL.Query().Get(cfg.Param)
+	if s == "" {
+		return "", false
+	}
+
+	ql, err := qlist.ParseQualityString(s)
+	if err != nil {
+		return "", false
+	}
+
+	tag, _, ok := qlist.BestLanguageParsed(cfg.Supported, ql)
+	return tag, ok
+}
+
+func (cfg *Config) fallback() string {
+	if cfg.Default != "" {
+		return cfg.Default
+	}
+	if len(cfg.Supported) > 0 {
+		return cfg.Supported[0]
+	}
+	return ""
+}
+
+// NewMiddleware returns a middleware attaching the negotiated language
+// to the request context. Vary and Content-Language headers are set,
+// but handlers can override the latter.
+func NewMiddleware(cfg Config) func(http.Handler) http.Handler {
+	return web.NewMiddleware(func(rw http.ResponseWriter, req *http.Request, next http.Handler) {
+		tag := cfg.Negotiate(req)
+
+		hdr := rw.Header()
+		hdr.Add("Vary", consts.AcceptLanguage)
+		if tag != "" {
+			hdr.Set(consts.ContentLanguage, tag)
+		}
+
+		next.ServeHTTP(rw, req.WithContext(WithLanguage(req.Context(), tag)))
+	})
+}
@@ -0,0 +1,63 @@
+package locale
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"darvaza.org/x/web/consts"
+)
+
+func TestNegotiate(t *testing.T) {
+	cfg := Config{
+		Supported: []string{"en-US", "es", "pt-BR", "pt-PT"},
+		Param:     "lang",
+	}
+
+	for _, tc := range []struct {
+		url    string
+		accept string
+		tag    string
+	}{
+		{"/", "", "en-US"},
+		{"/", "es-AR, en;q=0.5", "es"},
+		{"/", "en-GB;q=0.8, pt;q=0.9", "pt-BR"},
+		{"/", "pt-PT, pt;q=0.9", "pt-PT"},
+		{"/", "*;q=0.1, es;q=0", "en-US"},
+		{"/", "de", "en-US"},
+		{"/?lang=pt-pt", "es", "pt-PT"},
+		{"/?lang=de", "es", "es"},
+	} {
+		req := httptest.NewRequest("GET", tc.url, nil)
+		if tc.accept != "" {
+			req.Header.Set(consts.AcceptLanguage, tc.accept)
+		}
+
+		if tag := cfg.Negotiate(req); tag != tc.tag {
+			t.Errorf("ERROR: %s %q: expected %q, got %q", tc.url, tc.accept, tc.tag, tag)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	cfg := Config{Supported: []string{"en", "es"}, Default: "en"}
+
+	var got string
+	h := NewMiddleware(cfg)(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		got, _ = Language(req.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(consts.AcceptLanguage, "es-ES")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	switch {
+	case got != "es":
+		t.Errorf("ERROR: unexpected language %q", got)
+	case rec.Header().Get(consts.ContentLanguage) != "es":
+		t.Errorf("ERROR: unexpected Content-Language %q", rec.Header().Get(consts.ContentLanguage))
+	case rec.Header().Get("Vary") != consts.AcceptLanguage:
+		t.Errorf("ERROR: unexpected Vary %q", rec.Header().Get("Vary"))
+	}
+}
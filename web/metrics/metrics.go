@@ -0,0 +1,97 @@
+// Package metrics implements HTTP request metrics middleware
+// reporting to a pluggable [Sink].
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/capture"
+)
+
+// Observation describes a completed request.
+type Observation struct {
+	Method       string
+	Route        string
+	Code         int
+	Duration     time.Duration
+	RequestSize  int64
+	ResponseSize int64
+}
+
+// Sink receives the metrics of requests. Implementations must be
+// safe for concurrent use.
+type Sink interface {
+	// Started is called when a request begins.
+	Started(method, route string)
+	// Done is called when a request completes.
+	Done(Observation)
+}
+
+// Config describes how requests are measured.
+type Config struct {
+	// Sink receives the metrics.
+	Sink Sink
+
+	// Route returns the label identifying the route of a request.
+	// Requests paths shouldn't be used directly to keep the number
+	// of series bounded. If nil, the route is left empty.
+	Route func(*http.Request) string
+}
+
+// NewMiddleware returns a middleware measuring requests and
+// reporting them to the [Sink] of the [Config].
+func NewMiddleware(cfg Config) func(http.Handler) http.Handler {
+	if cfg.Sink == nil {
+		return web.NoMiddleware
+	}
+
+	return web.NewMiddleware(func(rw http.ResponseWriter, req *http.Request, next http.Handler) {
+		var route string
+		if cfg.Route != nil {
+			route = cfg.Route(req)
+		}
+
+		cfg.Sink.Started(req.Method, route)
+
+		start := time.Now()
+		w := capture.New(rw, capture.Config{})
+		body := &countingReader{rc: req.Body}
+		if req.Body != nil {
+			req.Body = body
+		}
+
+		defer func() {
+			cfg.Sink.Done(Observation{
+				Method:       req.Method,
+				Route:        route,
+				Code:         core.IIf(w.Status() == 0, http.StatusOK, w.Status()),
+				Duration:     time.Since(start),
+				RequestSize:  body.n.Load(),
+				ResponseSize: w.Size(),
+			})
+		}()
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	rc io.ReadCloser
+	n  atomic.Int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.rc.Read(b)
+	r.n.Add(int64(n))
+	return n, err
+}
+
+func (r *countingReader) Close() error {
+	return r.rc.Close()
+}
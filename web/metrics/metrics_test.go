@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	p := &Prometheus{Namespace: "test", Buckets: []float64{1}}
+
+	h := NewMiddleware(Config{
+		Sink:  p,
+		Route: func(*http.Request) string { return "/echo" },
+	})(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		if len(b) == 0 {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = rw.Write(b)
+	}))
+
+	for _, body := range []string{"hello", "world!", ""} {
+		req := httptest.NewRequest("POST", "/echo", strings.NewReader(body))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != PrometheusContentType {
+		t.Errorf("ERROR: unexpected Content-Type %q", ct)
+	}
+
+	out := rec.Body.String()
+	for _, s := range []string{
+		"# TYPE test_http_requests_total counter\n",
+		`test_http_requests_in_flight{method="POST",route="/echo"} 0` + "\n",
+		`test_http_requests_total{method="POST",route="/echo",code="200"} 2` + "\n",
+		`test_http_requests_total{method="POST",route="/echo",code="400"} 1` + "\n",
+		`test_http_request_duration_seconds_bucket{method="POST",route="/echo",code="200",le="1"} 2` + "\n",
+		`test_http_request_duration_seconds_bucket{method="POST",route="/echo",code="200",le="+Inf"} 2` + "\n",
+		`test_http_request_size_bytes_sum{method="POST",route="/echo",code="200"} 11` + "\n",
+		`test_http_response_size_bytes_sum{method="POST",route="/echo",code="400"} 0` + "\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("ERROR: missing %q", s)
+		}
+	}
+
+	if t.Failed() {
+		t.Log(out)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if s := quote("a\"b\\c\nd"); s != `"a\"b\\c\nd"` {
+		t.Errorf("ERROR: unexpected %s", s)
+	}
+}
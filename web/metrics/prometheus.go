@@ -0,0 +1,268 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+// PrometheusContentType is the Media Type of the Prometheus
+// text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of the request
+// duration histogram when [Prometheus] doesn't specify them.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	_ Sink         = (*Prometheus)(nil)
+	_ http.Handler = (*Prometheus)(nil)
+)
+
+type seriesKey struct {
+	method string
+	route  string
+	code   string
+}
+
+type series struct {
+	count        uint64
+	buckets      []uint64
+	durationSum  float64
+	requestSize  int64
+	responseSize int64
+}
+
+// Prometheus is a [Sink] aggregating metrics in memory and
+// serving them in the Prometheus text exposition format.
+type Prometheus struct {
+	// Namespace is prepended to the metric names if set.
+	Namespace string
+
+	// Buckets are the upper bounds of the duration histogram,
+	// [DefaultBuckets] if empty. They can't be changed once
+	// requests are observed.
+	Buckets []float64
+
+	mu       sync.Mutex
+	inFlight map[[2]string]int64
+	series   map[seriesKey]*series
+}
+
+// Started counts a request in flight.
+func (p *Prometheus) Started(method, route string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+	p.inFlight[[2]string{method, route}]++
+}
+
+// Done records a completed request.
+func (p *Prometheus) Done(o Observation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+	p.inFlight[[2]string{o.Method, o.Route}]--
+
+	s := p.getSeries(seriesKey{
+		method: o.Method,
+		route:  o.Route,
+		code:   strconv.Itoa(o.Code),
+	})
+
+	seconds := o.Duration.Seconds()
+	for i, le := range p.buckets() {
+		if seconds <= le {
+			s.buckets[i]++
+		}
+	}
+
+	s.count++
+	s.durationSum += seconds
+	s.requestSize += o.RequestSize
+	s.responseSize += o.ResponseSize
+}
+
+func (p *Prometheus) init() {
+	if p.series == nil {
+		p.inFlight = make(map[[2]string]int64)
+		p.series = make(map[seriesKey]*series)
+	}
+}
+
+func (p *Prometheus) getSeries(key seriesKey) *series {
+	s, ok := p.series[key]
+	if !ok {
+		s = &series{buckets: make([]uint64, len(p.buckets()))}
+		p.series[key] = s
+	}
+	return s
+}
+
+func (p *Prometheus) buckets() []float64 {
+	if len(p.Buckets) > 0 {
+		return p.Buckets
+	}
+	return DefaultBuckets
+}
+
+// ServeHTTP renders the metrics in the Prometheus text
+// exposition format.
+func (p *Prometheus) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case consts.GET, consts.HEAD:
+	default:
+		web.HandleError(rw, req, web.NewStatusMethodNotAllowed(consts.GET, consts.HEAD))
+		return
+	}
+
+	web.SetNoCache(rw.Header())
+	rw.Header().Set(consts.ContentType, PrometheusContentType)
+	rw.WriteHeader(http.StatusOK)
+
+	if req.Method != consts.HEAD {
+		_, _ = p.WriteTo(rw)
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition
+// format.
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	p.writeInFlight(cw)
+	keys := p.sortedKeys()
+	p.writeCounters(cw, keys)
+	p.writeDurations(cw, keys)
+	p.writeSizes(cw, keys, "request_size_bytes", "Size of HTTP requests.",
+		func(s *series) int64 { return s.requestSize })
+	p.writeSizes(cw, keys, "response_size_bytes", "Size of HTTP responses.",
+		func(s *series) int64 { return s.responseSize })
+
+	err := core.Coalesce(cw.err, cw.w.Flush())
+	return cw.n, err
+}
+
+func (p *Prometheus) name(s string) string {
+	if p.Namespace != "" {
+		return p.Namespace + "_http_" + s
+	}
+	return "http_" + s
+}
+
+func (p *Prometheus) sortedKeys() []seriesKey {
+	keys := core.Keys(p.series)
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch {
+		case a.method != b.method:
+			return a.method < b.method
+		case a.route != b.route:
+			return a.route < b.route
+		default:
+			return a.code < b.code
+		}
+	})
+	return keys
+}
+
+func (p *Prometheus) writeInFlight(w *countingWriter) {
+	name := p.name("requests_in_flight")
+	w.header(name, "gauge", "Number of HTTP requests being served.")
+
+	keys := core.Keys(p.inFlight)
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] ||
+			(keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+
+	for _, k := range keys {
+		w.printf("%s{method=%s,route=%s} %d\n", name,
+			quote(k[0]), quote(k[1]), p.inFlight[k])
+	}
+}
+
+func (p *Prometheus) writeCounters(w *countingWriter, keys []seriesKey) {
+	name := p.name("requests_total")
+	w.header(name, "counter", "Total number of HTTP requests.")
+
+	for _, k := range keys {
+		w.printf("%s{%s} %d\n", name, k.labels(), p.series[k].count)
+	}
+}
+
+func (p *Prometheus) writeDurations(w *countingWriter, keys []seriesKey) {
+	name := p.name("request_duration_seconds")
+	w.header(name, "histogram", "Duration of HTTP requests.")
+
+	buckets := p.buckets()
+	for _, k := range keys {
+		s := p.series[k]
+		labels := k.labels()
+		for i, le := range buckets {
+			w.printf("%s_bucket{%s,le=%q} %d\n", name, labels,
+				strconv.FormatFloat(le, 'g', -1, 64), s.buckets[i])
+		}
+		w.printf("%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, s.count)
+		w.printf("%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(s.durationSum, 'g', -1, 64))
+		w.printf("%s_count{%s} %d\n", name, labels, s.count)
+	}
+}
+
+func (p *Prometheus) writeSizes(w *countingWriter, keys []seriesKey,
+	suffix, help string, value func(*series) int64) {
+	//
+	name := p.name(suffix)
+	w.header(name, "summary", help)
+
+	for _, k := range keys {
+		s := p.series[k]
+		labels := k.labels()
+		w.printf("%s_sum{%s} %d\n", name, labels, value(s))
+		w.printf("%s_count{%s} %d\n", name, labels, s.count)
+	}
+}
+
+func (k seriesKey) labels() string {
+	return fmt.Sprintf("method=%s,route=%s,code=%s",
+		quote(k.method), quote(k.route), quote(k.code))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote renders a label value.
+func quote(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) header(name, kind, help string) {
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (w *countingWriter) printf(format string, args ...any) {
+	if w.err == nil {
+		n, err := fmt.Fprintf(w.w, format, args...)
+		w.n += int64(n)
+		w.err = err
+	}
+}
@@ -0,0 +1,212 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web/consts"
+)
+
+var (
+	_ Error            = (*Problem)(nil)
+	_ core.Unwrappable = (*Problem)(nil)
+	_ http.Handler     = (*Problem)(nil)
+)
+
+// Problem is an error rendered as RFC 7807 problem details.
+type Problem struct {
+	// Err is the cause, never rendered.
+	Err error `json:"-"`
+	// Hdr contains headers to include in the response.
+	Hdr http.Header `json:"-"`
+	// Fields describes problems with particular fields of
+	// the request, like form validation errors.
+	Fields map[string]string `json:"fields,omitempty"`
+
+	// Type is a URI identifying the problem type,
+	// "about:blank" if not specified.
+	Type string `json:"type,omitempty"`
+	// Title is a short summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI identifying this occurrence.
+	Instance string `json:"instance,omitempty"`
+	// Code is an application specific error code.
+	Code string `json:"code,omitempty"`
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+}
+
+// NewProblem creates a [Problem] with the given status code,
+// using the error's text as detail.
+func NewProblem(status int, err error) *Problem {
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+
+	p := &Problem{
+		Err:    err,
+		Status: status,
+		Title:  http.StatusText(status),
+	}
+
+	if err != nil {
+		p.Detail = err.Error()
+	}
+	return p
+}
+
+// HTTPStatus returns the HTTP status code of the [Problem].
+func (p *Problem) HTTPStatus() int {
+	if p.Status <= 0 {
+		return http.StatusInternalServerError
+	}
+	return p.Status
+}
+
+func (p *Problem) Error() string {
+	title := core.Coalesce(p.Title, ErrorText(p.HTTPStatus()))
+	if p.Detail == "" {
+		return title
+	}
+	return title + ": " + p.Detail
+}
+
+func (p *Problem) Unwrap() error {
+	return p.Err
+}
+
+// Header returns a [http.Header] attached to this error for custom fields
+func (p *Problem) Header() http.Header {
+	if p.Hdr == nil {
+		p.Hdr = make(http.Header)
+	}
+	return p.Hdr
+}
+
+// SetField sets the problem description of a field.
+func (p *Problem) SetField(name, problem string) {
+	if p.Fields == nil {
+		p.Fields = make(map[string]string)
+	}
+	p.Fields[name] = problem
+}
+
+// ServeHTTP passes the [Problem] to the [ErrorHandler] of the
+// request's context if there is one, otherwise it's rendered.
+func (p *Problem) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if h, ok := ErrorHandler(req.Context()); ok {
+		// pass over to the error handler
+		h(rw, req, p)
+		return
+	}
+
+	p.Render(rw, req)
+}
+
+// Render serves the [Problem] as application/problem+json,
+// ignoring the [ErrorHandler].
+func (p *Problem) Render(rw http.ResponseWriter, req *http.Request) {
+	v := *p
+	v.Status = p.HTTPStatus()
+
+	hdr := rw.Header()
+	for k, s := range p.Hdr {
+		hdr[k] = append(hdr[k], s...)
+	}
+
+	delete(hdr, consts.ContentLength)
+	delete(hdr, consts.ContentEncoding)
+	hdr[consts.ContentType] = []string{consts.ProblemJSON}
+
+	rw.WriteHeader(v.Status)
+	if req.Method != consts.HEAD {
+		_ = json.NewEncoder(rw).Encode(&v)
+	}
+}
+
+// ProblemHandler renders errors as RFC 7807 problem details.
+// Its HandleError method is meant to be used as [ErrorHandlerFunc]
+// via [ProblemHandler.Middleware].
+type ProblemHandler struct {
+	// Map optionally converts errors into a [Problem] before
+	// [ProblemFromError] is tried.
+	Map func(error) (*Problem, bool)
+
+	// OnError is called, if set, for every error handled,
+	// allowing them to be logged.
+	OnError func(req *http.Request, err error, p *Problem)
+
+	// Verbose includes the text of server errors as detail.
+	// Otherwise it's removed for 5xx errors not describing
+	// themselves as a [Problem].
+	Verbose bool
+}
+
+// Middleware returns a middleware attaching the [ProblemHandler]
+// to every request as [ErrorHandler].
+func (h *ProblemHandler) Middleware() func(http.Handler) http.Handler {
+	return NewErrorHandlerMiddleware(h.HandleError)
+}
+
+// HandleError renders an error as problem details. Errors below
+// 400, like redirects, are served as [HTTPError] instead.
+func (h *ProblemHandler) HandleError(rw http.ResponseWriter, req *http.Request, err error) {
+	p := h.problem(err)
+	if h.OnError != nil {
+		h.OnError(req, err, p)
+	}
+
+	if p.Status < http.StatusBadRequest {
+		he := &HTTPError{Err: err, Code: p.Status, Hdr: p.Hdr}
+		he.Render(rw, req)
+		return
+	}
+
+	p.Render(rw, req)
+}
+
+func (h *ProblemHandler) problem(err error) *Problem {
+	if h.Map != nil {
+		if p, ok := h.Map(err); ok && p != nil {
+			return p
+		}
+	}
+
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	p = ProblemFromError(err)
+	if !h.Verbose && p.Status >= http.StatusInternalServerError {
+		p.Detail = ""
+	}
+	return p
+}
+
+// ProblemFromError converts an error into a [Problem], inferring
+// the status code from [Error] or from well known sentinel errors
+// like [core.ErrNotExists], using [ErrorStatus].
+func ProblemFromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	p = NewProblem(ErrorStatus(err), err)
+
+	var he *HTTPError
+	if errors.As(err, &he) {
+		// the title already describes the status
+		p.Detail = ""
+		if he.Err != nil {
+			p.Detail = he.Err.Error()
+		}
+		p.Hdr = he.Hdr.Clone()
+	}
+	return p
+}
@@ -0,0 +1,57 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web/consts"
+)
+
+func TestProblemHandler(t *testing.T) {
+	var logged int
+	h := &ProblemHandler{
+		OnError: func(*http.Request, error, *Problem) { logged++ },
+	}
+
+	for _, tc := range []struct {
+		err    error
+		status int
+		detail string
+	}{
+		{core.Wrap(core.ErrNotExists, "user"), http.StatusNotFound, "user: does not exist"},
+		{core.Wrap(core.ErrInvalid, "bad id"), http.StatusBadRequest, "bad id: invalid argument"},
+		{NewStatusMethodNotAllowed("GET"), http.StatusMethodNotAllowed, ""},
+		{core.ErrUnknown, http.StatusInternalServerError, ""},
+		{&Problem{Status: http.StatusConflict, Code: "dup", Detail: "taken"}, http.StatusConflict, "taken"},
+	} {
+		rec := httptest.NewRecorder()
+		h.HandleError(rec, httptest.NewRequest("GET", "/", nil), tc.err)
+
+		var p Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+
+		switch {
+		case rec.Code != tc.status, p.Status != tc.status:
+			t.Errorf("ERROR: %v: unexpected status %v", tc.err, rec.Code)
+		case p.Detail != tc.detail:
+			t.Errorf("ERROR: %v: unexpected detail %q", tc.err, p.Detail)
+		case rec.Header().Get(consts.ContentType) != consts.ProblemJSON:
+			t.Errorf("ERROR: %v: unexpected Content-Type", tc.err)
+		}
+	}
+
+	if logged != 5 {
+		t.Errorf("ERROR: OnError called %v times", logged)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleError(rec, httptest.NewRequest("GET", "/", nil), NewStatusFound("/there"))
+	if rec.Code != http.StatusFound || rec.Header().Get(consts.Location) != "/there" {
+		t.Errorf("ERROR: redirect served as %v", rec.Code)
+	}
+}
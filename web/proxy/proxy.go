@@ -0,0 +1,162 @@
+// Package proxy implements a reverse proxy http.Handler.
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+)
+
+var _ http.Handler = (*Proxy)(nil)
+
+var targetCtxKey = core.NewContextKey[*url.URL]("ProxyTarget")
+
+// Proxy is a reverse proxy [http.Handler] built on top of
+// [httputil.ReverseProxy], choosing the upstream of every request
+// via a [Selector]. Errors are passed to [web.HandleError], so they
+// are rendered by the [web.ErrorHandler] of the context, like
+// [web.ProblemHandler].
+type Proxy struct {
+	// Selector chooses the upstream of each request.
+	Selector Selector
+
+	// Transport is used to perform the upstream requests,
+	// [http.DefaultTransport] if not set.
+	Transport http.RoundTripper
+
+	// Rewrite, if set, is called after the outbound request has
+	// been directed to the upstream, allowing headers to be altered.
+	Rewrite func(*httputil.ProxyRequest)
+
+	// ModifyResponse, if set, can alter the upstream response.
+	// Returned errors are handled as upstream failures.
+	ModifyResponse func(*http.Response) error
+
+	// OnError is called, if set, with proxying errors before
+	// they are handled.
+	OnError func(*http.Request, error)
+
+	// Retries is the number of times idempotent requests are
+	// retried when the upstream can't be reached.
+	Retries int
+
+	// FlushInterval is the interval between flushes of the response
+	// body. Negative flushes after each write. Streamed responses
+	// are always flushed immediately.
+	FlushInterval time.Duration
+
+	// PreserveHost passes the Host header of the incoming
+	// request upstream instead of the one of the target.
+	PreserveHost bool
+
+	// Forwarded sets the X-Forwarded-For, X-Forwarded-Host
+	// and X-Forwarded-Proto headers.
+	Forwarded bool
+
+	once sync.Once
+	rp   *httputil.ReverseProxy
+}
+
+// New creates a [Proxy] using the given [Selector].
+func New(sel Selector) *Proxy {
+	return &Proxy{Selector: sel, Forwarded: true}
+}
+
+func (p *Proxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	target, err := p.selectTarget(req)
+	if err != nil {
+		p.handleError(rw, req, err)
+		return
+	}
+
+	p.once.Do(p.init)
+
+	ctx := targetCtxKey.WithValue(req.Context(), target)
+	p.rp.ServeHTTP(rw, req.WithContext(ctx))
+}
+
+func (p *Proxy) selectTarget(req *http.Request) (*url.URL, error) {
+	if p.Selector == nil {
+		return nil, ErrNoUpstream
+	}
+
+	target, err := p.Selector.Select(req)
+	switch {
+	case err != nil:
+		return nil, err
+	case target == nil:
+		return nil, ErrNoUpstream
+	default:
+		return target, nil
+	}
+}
+
+func (p *Proxy) init() {
+	p.rp = &httputil.ReverseProxy{
+		Rewrite:        p.rewrite,
+		Transport:      p.transport(),
+		FlushInterval:  p.FlushInterval,
+		ModifyResponse: p.ModifyResponse,
+		ErrorHandler:   p.handleError,
+	}
+}
+
+func (p *Proxy) transport() http.RoundTripper {
+	rt := core.IIf[http.RoundTripper](p.Transport != nil, p.Transport, http.DefaultTransport)
+	if p.Retries > 0 {
+		rt = &retryTransport{next: rt, retries: p.Retries}
+	}
+	return rt
+}
+
+func (p *Proxy) rewrite(pr *httputil.ProxyRequest) {
+	target, _ := targetCtxKey.Get(pr.In.Context())
+
+	pr.SetURL(target)
+	if p.Forwarded {
+		pr.SetXForwarded()
+	}
+	if p.PreserveHost {
+		pr.Out.Host = pr.In.Host
+	}
+
+	if p.Rewrite != nil {
+		p.Rewrite(pr)
+	}
+}
+
+func (p *Proxy) handleError(rw http.ResponseWriter, req *http.Request, err error) {
+	if p.OnError != nil {
+		p.OnError(req, err)
+	}
+
+	web.HandleError(rw, req, AsError(err))
+}
+
+// AsError converts a proxying error into a [web.Error]. Errors
+// already describing their status are kept, timeouts become 504,
+// and other failures to reach the upstream 502.
+func AsError(err error) error {
+	var e web.Error
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &e):
+		return err
+	case errors.Is(err, ErrNoUpstream):
+		return web.NewHTTPError(http.StatusServiceUnavailable, err, "")
+	}
+
+	code := web.ErrorStatus(err)
+	if code == http.StatusInternalServerError || code == http.StatusServiceUnavailable {
+		code = http.StatusBadGateway
+	}
+	return web.NewHTTPError(code, err, "")
+}
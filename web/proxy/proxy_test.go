@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Path", req.URL.Path)
+		rw.Header().Set("X-Forwarded", req.Header.Get("X-Forwarded-For"))
+		rw.Header().Set("X-Added", req.Header.Get("X-Added"))
+		_, _ = io.Copy(rw, req.Body)
+	}))
+	defer upstream.Close()
+
+	rr, err := NewRoundRobin(upstream.URL + "/base")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(rr)
+	p.Rewrite = func(pr *httputil.ProxyRequest) {
+		pr.Out.Header.Set("X-Added", "yes")
+	}
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/echo", consts.TXT, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	switch {
+	case res.StatusCode != http.StatusOK:
+		t.Errorf("ERROR: unexpected status %v", res.StatusCode)
+	case string(body) != "hello":
+		t.Errorf("ERROR: unexpected body %q", body)
+	case res.Header.Get("X-Path") != "/base/echo":
+		t.Errorf("ERROR: unexpected path %q", res.Header.Get("X-Path"))
+	case res.Header.Get("X-Forwarded") == "":
+		t.Errorf("ERROR: X-Forwarded-For missing")
+	case res.Header.Get("X-Added") != "yes":
+		t.Errorf("ERROR: Rewrite not applied")
+	}
+}
+
+type failingTransport struct {
+	next  http.RoundTripper
+	fails int32
+	calls atomic.Int32
+}
+
+func (t *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.calls.Add(1) <= t.fails {
+		return nil, errors.New("connection refused")
+	}
+	return t.next.RoundTrip(req)
+}
+
+func TestProxyRetry(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(rw, "ok")
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+
+	for _, tc := range []struct {
+		method string
+		code   int
+		calls  int32
+	}{
+		{"GET", http.StatusOK, 3},
+		{"POST", http.StatusBadGateway, 1},
+	} {
+		tr := &failingTransport{next: http.DefaultTransport, fails: 2}
+		p := New(SingleHost(u))
+		p.Transport = tr
+		p.Retries = 2
+
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(tc.method, "/", nil))
+
+		switch {
+		case rec.Code != tc.code:
+			t.Errorf("ERROR: %s: expected %v, got %v", tc.method, tc.code, rec.Code)
+		case tr.calls.Load() != tc.calls:
+			t.Errorf("ERROR: %s: expected %v attempts, got %v", tc.method, tc.calls, tr.calls.Load())
+		}
+	}
+}
+
+func TestProxyProblem(t *testing.T) {
+	p := New(SelectorFunc(func(*http.Request) (*url.URL, error) {
+		return nil, ErrNoUpstream
+	}))
+
+	h := (&web.ProblemHandler{}).Middleware()(p)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	switch {
+	case rec.Code != http.StatusServiceUnavailable:
+		t.Errorf("ERROR: unexpected status %v", rec.Code)
+	case rec.Header().Get(consts.ContentType) != consts.ProblemJSON:
+		t.Errorf("ERROR: unexpected Content-Type %q", rec.Header().Get(consts.ContentType))
+	}
+}
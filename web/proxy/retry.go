@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"darvaza.org/x/web/consts"
+)
+
+// retryTransport retries idempotent requests when the upstream
+// fails before giving a response.
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	for i := 0; i < t.retries && canRetry(req, err); i++ {
+		r2, err2 := rewind(req)
+		if err2 != nil {
+			break
+		}
+
+		res, err = t.next.RoundTrip(r2)
+	}
+	return res, err
+}
+
+func canRetry(req *http.Request, err error) bool {
+	switch {
+	case err == nil, req.Context().Err() != nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	default:
+		return IsIdempotent(req) && isReplayable(req)
+	}
+}
+
+// IsIdempotent tells if a request can be safely repeated, because
+// of its method or an Idempotency-Key header.
+func IsIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case consts.GET, consts.HEAD, consts.OPTIONS, consts.TRACE, consts.PUT, consts.DELETE:
+		return true
+	default:
+		return req.Header.Get("Idempotency-Key") != ""
+	}
+}
+
+func isReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	r2 := req.Clone(req.Context())
+	r2.Body = body
+	return r2, nil
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"darvaza.org/core"
+)
+
+// ErrNoUpstream indicates there is no upstream available for the request.
+var ErrNoUpstream = errors.New("no upstream available")
+
+// Selector chooses the upstream of a request.
+type Selector interface {
+	Select(*http.Request) (*url.URL, error)
+}
+
+var (
+	_ Selector = SelectorFunc(nil)
+	_ Selector = (*RoundRobin)(nil)
+)
+
+// SelectorFunc is a function implementing [Selector].
+type SelectorFunc func(*http.Request) (*url.URL, error)
+
+// Select calls the function.
+func (fn SelectorFunc) Select(req *http.Request) (*url.URL, error) {
+	return fn(req)
+}
+
+// SingleHost returns a [Selector] always choosing the given upstream.
+func SingleHost(target *url.URL) Selector {
+	return SelectorFunc(func(*http.Request) (*url.URL, error) {
+		return target, nil
+	})
+}
+
+// RoundRobin is a [Selector] rotating over a list of upstreams.
+type RoundRobin struct {
+	targets []*url.URL
+	next    atomic.Uint64
+}
+
+// NewRoundRobin creates a [RoundRobin] [Selector] for the given
+// upstreams, given as URLs.
+func NewRoundRobin(targets ...string) (*RoundRobin, error) {
+	rr := &RoundRobin{}
+	for _, s := range targets {
+		u, err := url.Parse(s)
+		switch {
+		case err != nil:
+			return nil, err
+		case u.Scheme == "" || u.Host == "":
+			return nil, core.Wrapf(core.ErrInvalid, "%q: invalid upstream", s)
+		}
+		rr.targets = append(rr.targets, u)
+	}
+
+	return rr, nil
+}
+
+// Select returns the next upstream.
+func (rr *RoundRobin) Select(*http.Request) (*url.URL, error) {
+	n := uint64(len(rr.targets))
+	if n == 0 {
+		return nil, ErrNoUpstream
+	}
+
+	i := rr.next.Add(1) - 1
+	return rr.targets[i%n], nil
+}
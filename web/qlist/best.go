@@ -7,9 +7,17 @@ import (
 )
 
 const (
+	// AcceptCharset is the canonical name given to the header used
+	// to indicate the accepted character sets
+	AcceptCharset = consts.AcceptCharset
+
 	// AcceptEncoding is the canonical name given to the header used
 	// to indicate compression options
 	AcceptEncoding = consts.AcceptEncoding
+
+	// AcceptLanguage is the canonical name given to the header used
+	// to indicate the preferred natural languages
+	AcceptLanguage = consts.AcceptLanguage
 )
 
 // FitnessAndQualityParsed finds the best accepted match
@@ -48,18 +56,30 @@ func FitnessAndQuality(target string,
 // BestQualityParsed takes a list of supported and accepted quality entries
 // and finds the best match among all combinations.
 func BestQualityParsed(supported, accepted []QualityValue) (string, float32, bool) {
+	i, quality := BestQualityIndex(supported, accepted)
+	if i < 0 {
+		return "", 0, false
+	}
+	return supported[i].String(), quality, true
+}
+
+// BestQualityIndex takes a list of supported and accepted quality entries
+// and returns the index of the best supported option and its quality.
+// On ties the first supported option wins. -1 is returned if nothing
+// supported is acceptable.
+func BestQualityIndex(supported, accepted []QualityValue) (int, float32) {
 	var bestQuality float32
-	var bestOption string
+	bestIndex := -1
 
-	for _, v := range supported {
+	for i, v := range supported {
 		_, quality := FitnessAndQualityParsed(v, accepted)
 		if quality > bestQuality {
 			bestQuality = quality
-			bestOption = v.String()
+			bestIndex = i
 		}
 	}
 
-	return bestOption, bestQuality, bestOption != ""
+	return bestIndex, bestQuality
 }
 
 // BestQuality searches for the best option among supported values
@@ -69,7 +89,7 @@ func BestQuality(supported []string, ql QualityList) (string, float32, bool) {
 
 	for _, s := range supported {
 		q, err := ParseQualityValue(s)
-		if err != nil {
+		if err == nil {
 			sql = append(sql, q)
 		}
 	}
@@ -114,3 +134,19 @@ func BestEncoding(supported []string, hdr http.Header) (string, bool) {
 	best, _, ok := BestQualityWithIdentity(supported, ql, "identity")
 	return best, ok
 }
+
+// BestCharset chooses the best supported character set considering
+// the Accept-Charset header. If the client doesn't specify a
+// preference the first supported is chosen.
+func BestCharset(supported []string, hdr http.Header) (string, bool) {
+	ql, _ := ParseQualityHeader(hdr, AcceptCharset)
+	switch {
+	case len(supported) == 0:
+		return "", false
+	case len(ql) == 0:
+		return supported[0], true
+	default:
+		best, _, ok := BestQuality(supported, ql)
+		return best, ok
+	}
+}
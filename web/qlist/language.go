@@ -0,0 +1,78 @@
+package qlist
+
+import (
+	"net/http"
+	"strings"
+)
+
+// LanguageFitness tells how well a language range matches a language
+// tag. 3 if they are equal, 2 if the range is a prefix of the tag, 1
+// if the tag is a prefix of the range, 0 for the "*" wildcard and -1
+// if they don't match. Prefixes follow RFC 4647, at subtag boundaries.
+func LanguageFitness(lang, tag string) int {
+	lang, tag = strings.ToLower(lang), strings.ToLower(tag)
+
+	switch {
+	case lang == tag:
+		return 3
+	case strings.HasPrefix(tag, lang+"-"):
+		return 2
+	case strings.HasPrefix(lang, tag+"-"):
+		return 1
+	case lang == "*":
+		return 0
+	default:
+		return -1
+	}
+}
+
+// BestLanguageParsed takes a list of supported language tags and the
+// accepted language ranges, and finds the best match. The quality
+// of the most specific range matching a tag applies, and on ties
+// the better fit, or otherwise the first supported, wins.
+func BestLanguageParsed(supported []string, accepted QualityList) (string, float32, bool) {
+	var bestQuality float32
+	bestFitness, bestIndex := -1, -1
+
+	for i, tag := range supported {
+		fitness, quality := languageFitnessAndQuality(tag, accepted)
+		if quality > bestQuality || (quality == bestQuality && quality > 0 && fitness > bestFitness) {
+			bestQuality, bestFitness, bestIndex = quality, fitness, i
+		}
+	}
+
+	if bestIndex < 0 {
+		return "", 0, false
+	}
+	return supported[bestIndex], bestQuality, true
+}
+
+func languageFitnessAndQuality(tag string, accepted QualityList) (int, float32) {
+	bestFitness := -1
+	bestQuality := float32(0.0)
+
+	for _, r := range accepted {
+		if fitness := LanguageFitness(r.Value(), tag); fitness > bestFitness {
+			bestFitness = fitness
+			bestQuality = r.Quality()
+		}
+	}
+
+	return bestFitness, bestQuality
+}
+
+// BestLanguage chooses the best supported language tag considering
+// the Accept-Language header. If the client doesn't specify a
+// preference the first supported is chosen.
+func BestLanguage(supported []string, hdr http.Header) (string, bool) {
+	ql, _ := ParseQualityHeader(hdr, AcceptLanguage)
+	switch {
+	case len(supported) == 0:
+		return "", false
+	case len(ql) == 0:
+		return supported[0], true
+	default:
+		best, _, ok := BestLanguageParsed(supported, ql)
+		return best, ok
+	}
+}
@@ -0,0 +1,74 @@
+package qlist
+
+import (
+	"net/http"
+
+	"darvaza.org/core"
+)
+
+// Negotiator chooses the best of a set of offered media types
+// for the Accept header of a request. Offers are returned as
+// given, and in case of a tie the first offered wins.
+type Negotiator struct {
+	offers []string
+	parsed QualityList
+}
+
+// NewNegotiator creates a [Negotiator] for the given media types,
+// in order of preference.
+func NewNegotiator(offers ...string) (*Negotiator, error) {
+	n := &Negotiator{
+		offers: make([]string, 0, len(offers)),
+		parsed: make(QualityList, 0, len(offers)),
+	}
+
+	for _, s := range offers {
+		qv, err := ParseMediaRange(s)
+		switch {
+		case err != nil:
+			return nil, err
+		case !qv.IsMediaType():
+			return nil, core.Wrapf(core.ErrInvalid, "%q: invalid media type", s)
+		}
+
+		n.offers = append(n.offers, s)
+		n.parsed = append(n.parsed, qv)
+	}
+
+	return n, nil
+}
+
+// Offers returns the media types offered by the [Negotiator].
+func (n *Negotiator) Offers() []string {
+	return core.SliceCopy(n.offers)
+}
+
+// Negotiate chooses the best offer for the given accepted
+// media ranges. If nothing was specified the first offer
+// is chosen.
+func (n *Negotiator) Negotiate(accepted QualityList) (string, bool) {
+	switch {
+	case len(n.offers) == 0:
+		return "", false
+	case len(accepted) == 0:
+		return n.offers[0], true
+	}
+
+	i, _ := BestQualityIndex(n.parsed, accepted)
+	if i < 0 {
+		return "", false
+	}
+	return n.offers[i], true
+}
+
+// NegotiateHeader chooses the best offer for the Accept header.
+// Invalid headers are an error.
+func (n *Negotiator) NegotiateHeader(hdr http.Header) (string, bool, error) {
+	accepted, err := ParseMediaRangeHeader(hdr)
+	if err != nil {
+		return "", false, err
+	}
+
+	s, ok := n.Negotiate(accepted)
+	return s, ok, nil
+}
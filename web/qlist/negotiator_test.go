@@ -0,0 +1,62 @@
+package qlist
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNegotiator(t *testing.T) {
+	n, err := NewNegotiator("application/json; charset=utf-8", "text/html", "application/xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		accept   string
+		expected string
+	}{
+		{"", "application/json; charset=utf-8"},
+		{"*/*", "application/json; charset=utf-8"},
+		{"text/*", "text/html"},
+		{"application/xml, application/json;q=0.5", "application/xml"},
+		{"text/html;q=0.1, */*;q=0.2", "application/json; charset=utf-8"},
+		{"image/png", ""},
+	} {
+		hdr := http.Header{}
+		if tc.accept != "" {
+			hdr.Set(Accept, tc.accept)
+		}
+
+		s, ok, err := n.NegotiateHeader(hdr)
+		if err != nil || s != tc.expected || ok != (tc.expected != "") {
+			t.Errorf("ERROR: %q: expected %q, got %q (%v)", tc.accept, tc.expected, s, err)
+		}
+	}
+
+	if _, err := NewNegotiator("text/*"); err == nil {
+		t.Error("ERROR: media range accepted as offer")
+	}
+}
+
+func TestBestCharset(t *testing.T) {
+	supported := []string{"utf-8", "iso-8859-1"}
+
+	for _, tc := range []struct {
+		accept   string
+		expected string
+	}{
+		{"", "utf-8"},
+		{"ISO-8859-1", "iso-8859-1"},
+		{"utf-8;q=0.5, *", "iso-8859-1"},
+		{"koi8-r", ""},
+	} {
+		hdr := http.Header{}
+		if tc.accept != "" {
+			hdr.Set(AcceptCharset, tc.accept)
+		}
+
+		if s, _ := BestCharset(supported, hdr); s != tc.expected {
+			t.Errorf("ERROR: %q: expected %q, got %q", tc.accept, tc.expected, s)
+		}
+	}
+}
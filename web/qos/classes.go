@@ -0,0 +1,43 @@
+package qos
+
+import (
+	"net/http"
+
+	"darvaza.org/x/web"
+)
+
+// Classes routes requests to a dedicated [Limiter] by class, so
+// routes or kinds of clients don't exhaust the capacity of others.
+type Classes struct {
+	// Classify returns the class of a request.
+	Classify func(*http.Request) string
+
+	// Limiters contains the [Limiter] of each class.
+	Limiters map[string]*Limiter
+
+	// Default is the [Limiter] of unknown classes. If not set
+	// they aren't limited.
+	Default *Limiter
+}
+
+// Limiter returns the [Limiter] of a request, if any.
+func (c *Classes) Limiter(req *http.Request) *Limiter {
+	if c.Classify != nil {
+		if l, ok := c.Limiters[c.Classify(req)]; ok {
+			return l
+		}
+	}
+	return c.Default
+}
+
+// Middleware returns a middleware applying the [Limiter] of each
+// request's class.
+func (c *Classes) Middleware() func(http.Handler) http.Handler {
+	return web.NewMiddleware(func(rw http.ResponseWriter, req *http.Request, next http.Handler) {
+		if l := c.Limiter(req); l != nil {
+			l.serve(rw, req, next)
+		} else {
+			next.ServeHTTP(rw, req)
+		}
+	})
+}
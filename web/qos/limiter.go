@@ -0,0 +1,141 @@
+// Package qos implements load shedding middleware bounding the
+// number of requests handled concurrently.
+package qos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/sync/semaphore"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+// DefaultRetryAfter is the delay suggested to clients when the
+// [Limit] doesn't specify one.
+const DefaultRetryAfter = time.Second
+
+var (
+	// ErrOverloaded indicates a request was rejected because
+	// the [Limiter] is at capacity.
+	ErrOverloaded = errors.New("server overloaded")
+
+	// ErrQueueTimeout indicates a request waited too long for
+	// its turn.
+	ErrQueueTimeout = errors.New("queue timeout")
+)
+
+// Limit describes the capacity of a [Limiter].
+type Limit struct {
+	// MaxInFlight is the number of requests handled concurrently.
+	MaxInFlight int
+
+	// MaxQueue is the number of requests allowed to wait for a
+	// slot. Beyond it requests are rejected immediately.
+	MaxQueue int
+
+	// QueueTimeout is how long a request can wait for a slot,
+	// unlimited if zero.
+	QueueTimeout time.Duration
+
+	// RetryAfter is the delay suggested to rejected clients,
+	// [DefaultRetryAfter] if zero.
+	RetryAfter time.Duration
+}
+
+// Limiter bounds the number of requests in flight, queueing a limited
+// number of them and rejecting the rest with 503 Service Unavailable.
+type Limiter struct {
+	slots *semaphore.Semaphore
+	limit Limit
+}
+
+// NewLimiter creates a [Limiter] for the given [Limit].
+func NewLimiter(limit Limit) (*Limiter, error) {
+	switch {
+	case limit.MaxInFlight <= 0:
+		return nil, core.Wrap(core.ErrInvalid, "MaxInFlight must be positive")
+	case limit.MaxQueue < 0, limit.QueueTimeout < 0, limit.RetryAfter < 0:
+		return nil, core.Wrap(core.ErrInvalid, "negative limit")
+	}
+
+	slots, err := semaphore.New(limit.MaxInFlight)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Limiter{
+		slots: slots,
+		limit: limit,
+	}, nil
+}
+
+// InFlight returns the number of requests holding a slot.
+func (l *Limiter) InFlight() int {
+	return l.slots.Len()
+}
+
+// Waiting returns the number of requests waiting for a slot.
+func (l *Limiter) Waiting() int {
+	return l.slots.Waiting()
+}
+
+// Acquire waits for a slot, returning the function to release it.
+// Fails with [ErrOverloaded] if the queue is full, [ErrQueueTimeout]
+// if it waited too long, or the context's error. As the queue is
+// checked before joining it, concurrent requests may briefly
+// exceed MaxQueue.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	switch {
+	case l.slots.TryAcquire():
+		return l.slots.Release, nil
+	case l.slots.Waiting() >= l.limit.MaxQueue:
+		return nil, ErrOverloaded
+	}
+
+	if d := l.limit.QueueTimeout; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, d, ErrQueueTimeout)
+		defer cancel()
+	}
+
+	if err := l.slots.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	return l.slots.Release, nil
+}
+
+// Middleware returns a middleware handling requests within the
+// capacity of the [Limiter]. Rejected requests are passed to
+// [web.HandleError] as 503 with a Retry-After header.
+func (l *Limiter) Middleware() func(http.Handler) http.Handler {
+	return web.NewMiddleware(l.serve)
+}
+
+func (l *Limiter) serve(rw http.ResponseWriter, req *http.Request, next http.Handler) {
+	release, err := l.Acquire(req.Context())
+	if err != nil {
+		web.HandleError(rw, req, l.newError(err))
+		return
+	}
+	defer release()
+
+	next.ServeHTTP(rw, req)
+}
+
+func (l *Limiter) newError(err error) *web.HTTPError {
+	d := l.limit.RetryAfter
+	if d <= 0 {
+		d = DefaultRetryAfter
+	}
+
+	seconds := int64((d + time.Second - 1) / time.Second)
+
+	e := web.NewHTTPError(http.StatusServiceUnavailable, err, "")
+	e.Header().Set(consts.RetryAfter, strconv.FormatInt(seconds, 10))
+	return e
+}
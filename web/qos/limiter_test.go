@@ -0,0 +1,92 @@
+package qos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web/consts"
+)
+
+func TestNewLimiterInvalid(t *testing.T) {
+	tests := []Limit{
+		{},
+		{MaxInFlight: 1, MaxQueue: -1},
+		{MaxInFlight: 1, QueueTimeout: -1},
+		{MaxInFlight: 1, RetryAfter: -1},
+	}
+
+	for i, limit := range tests {
+		if _, err := NewLimiter(limit); !errors.Is(err, core.ErrInvalid) {
+			t.Errorf("[%v] ERROR: %+v: unexpected error: %v", i, limit, err)
+		}
+	}
+}
+
+func TestLimiterAcquire(t *testing.T) {
+	l, err := NewLimiter(Limit{MaxInFlight: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	release, err := l.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// queue slot taken by a waiter
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := l.Acquire(ctx); !errors.Is(err, ErrQueueTimeout) {
+			t.Errorf("ERROR: expected ErrQueueTimeout, got %v", err)
+		}
+	}()
+
+	for l.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := l.Acquire(ctx); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("ERROR: expected ErrOverloaded, got %v", err)
+	}
+
+	wg.Wait()
+	release()
+
+	if l.InFlight() != 0 {
+		t.Errorf("ERROR: slot not released")
+	}
+}
+
+func TestLimiterMiddleware(t *testing.T) {
+	l, _ := NewLimiter(Limit{MaxInFlight: 1, RetryAfter: 1500 * time.Millisecond})
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	h := l.Middleware()(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		close(started)
+		<-block
+	}))
+
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	close(block)
+
+	switch {
+	case rec.Code != http.StatusServiceUnavailable:
+		t.Errorf("ERROR: unexpected status %v", rec.Code)
+	case rec.Header().Get(consts.RetryAfter) != "2":
+		t.Errorf("ERROR: unexpected Retry-After %q", rec.Header().Get(consts.RetryAfter))
+	}
+}
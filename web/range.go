@@ -0,0 +1,146 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"darvaza.org/x/web/consts"
+)
+
+// MaxRanges is the number of ranges a request can ask for before
+// the complete content is served instead.
+const MaxRanges = 16
+
+var (
+	// ErrInvalidRange indicates the Range header can't be parsed.
+	ErrInvalidRange = errors.New("invalid range")
+
+	// ErrRangeNotSatisfiable indicates none of the requested ranges
+	// overlap the content.
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+)
+
+// Range is a part of the content.
+type Range struct {
+	Start  int64
+	Length int64
+}
+
+// ContentRange returns the Content-Range value of the [Range] for
+// content of the given size.
+func (r Range) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+func (r Range) mimeHeader(contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		consts.ContentRange: {r.ContentRange(size)},
+		consts.ContentType:  {contentType},
+	}
+}
+
+// ParseRange parses a bytes Range header for content of the given
+// size, trimming ranges beyond its end. It fails with [ErrInvalidRange]
+// if the header can't be parsed, or [ErrRangeNotSatisfiable] if no
+// range overlaps the content.
+func ParseRange(s string, size int64) ([]Range, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(s), "bytes=")
+	if !ok {
+		return nil, ErrInvalidRange
+	}
+
+	var out []Range
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		r, ok, err := parseRangeSpec(part, size)
+		switch {
+		case err != nil:
+			return nil, err
+		case ok:
+			out = append(out, r)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, ErrRangeNotSatisfiable
+	}
+	return out, nil
+}
+
+// parseRangeSpec parses one range, reporting if it's satisfiable.
+func parseRangeSpec(s string, size int64) (Range, bool, error) {
+	first, last, ok := strings.Cut(s, "-")
+	if !ok {
+		return Range{}, false, ErrInvalidRange
+	}
+
+	if first == "" {
+		return parseSuffixRange(last, size)
+	}
+
+	start, err := parseRangeInt(first)
+	if err != nil {
+		return Range{}, false, err
+	}
+
+	end := size - 1
+	if last != "" {
+		n, err := parseRangeInt(last)
+		switch {
+		case err != nil:
+			return Range{}, false, err
+		case n < start:
+			return Range{}, false, ErrInvalidRange
+		case n < end:
+			end = n
+		}
+	}
+
+	if start >= size {
+		return Range{}, false, nil
+	}
+	return Range{Start: start, Length: end - start + 1}, true, nil
+}
+
+// parseSuffixRange parses a range of the last bytes of the content.
+func parseSuffixRange(s string, size int64) (Range, bool, error) {
+	n, err := parseRangeInt(s)
+	switch {
+	case err != nil:
+		return Range{}, false, err
+	case n == 0 || size == 0:
+		return Range{}, false, nil
+	case n > size:
+		n = size
+	}
+
+	return Range{Start: size - n, Length: n}, true, nil
+}
+
+func parseRangeInt(s string) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 || s == "" || s[0] == '+' {
+		return 0, ErrInvalidRange
+	}
+	return n, nil
+}
+
+// NewStatusRangeNotSatisfiable returns a 416 HTTP error indicating
+// the size of the content.
+func NewStatusRangeNotSatisfiable(size int64) *HTTPError {
+	return &HTTPError{
+		Code: http.StatusRequestedRangeNotSatisfiable,
+		Err:  ErrRangeNotSatisfiable,
+		Hdr: http.Header{
+			consts.ContentRange: {fmt.Sprintf("bytes */%d", size)},
+		},
+	}
+}
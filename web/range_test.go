@@ -0,0 +1,131 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/x/web/consts"
+)
+
+func TestParseRange(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out []Range
+		err error
+	}{
+		{"bytes=0-4", []Range{{0, 5}}, nil},
+		{"bytes=5-", []Range{{5, 5}}, nil},
+		{"bytes=-3", []Range{{7, 3}}, nil},
+		{"bytes=-20", []Range{{0, 10}}, nil},
+		{"bytes=8-20", []Range{{8, 2}}, nil},
+		{"bytes=0-0, 2-3", []Range{{0, 1}, {2, 2}}, nil},
+		{"bytes=10-", nil, ErrRangeNotSatisfiable},
+		{"bytes=-0", nil, ErrRangeNotSatisfiable},
+		{"bytes=3-1", nil, ErrInvalidRange},
+		{"bytes=a-", nil, ErrInvalidRange},
+		{"bytes=+1-2", nil, ErrInvalidRange},
+		{"items=0-1", nil, ErrInvalidRange},
+	} {
+		out, err := ParseRange(tc.in, 10)
+		switch {
+		case !errors.Is(err, tc.err):
+			t.Errorf("ERROR: %q: unexpected error %v", tc.in, err)
+		case len(out) != len(tc.out):
+			t.Errorf("ERROR: %q: unexpected %v", tc.in, out)
+		default:
+			for i := range out {
+				if out[i] != tc.out[i] {
+					t.Errorf("ERROR: %q: unexpected %v", tc.in, out)
+				}
+			}
+		}
+	}
+}
+
+func serveTestContent(hdr http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/file.txt", nil)
+	for k, v := range hdr {
+		req.Header[k] = v
+	}
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set(consts.ETag, `"v1"`)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ServeContent(rec, req, "file.txt", modTime, strings.NewReader("0123456789"))
+	return rec
+}
+
+func TestServeContentRange(t *testing.T) {
+	for _, tc := range []struct {
+		hdr   http.Header
+		code  int
+		body  string
+		crang string
+	}{
+		{nil, http.StatusOK, "0123456789", ""},
+		{http.Header{consts.Range: {"bytes=2-4"}}, http.StatusPartialContent, "234", "bytes 2-4/10"},
+		{http.Header{consts.Range: {"bytes=-2"}}, http.StatusPartialContent, "89", "bytes 8-9/10"},
+		{http.Header{consts.Range: {"bytes=20-"}}, http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+		{http.Header{consts.Range: {"invalid"}}, http.StatusOK, "0123456789", ""},
+		{http.Header{
+			consts.Range:   {"bytes=2-4"},
+			consts.IfRange: {`"v1"`},
+		}, http.StatusPartialContent, "234", "bytes 2-4/10"},
+		{http.Header{
+			consts.Range:   {"bytes=2-4"},
+			consts.IfRange: {`"v0"`},
+		}, http.StatusOK, "0123456789", ""},
+		{http.Header{
+			consts.Range:   {"bytes=2-4"},
+			consts.IfRange: {"Tue, 02 Jan 2024 03:04:05 GMT"},
+		}, http.StatusPartialContent, "234", "bytes 2-4/10"},
+		{http.Header{consts.IfNoneMatch: {`"v1"`}}, http.StatusNotModified, "", ""},
+	} {
+		rec := serveTestContent(tc.hdr)
+		switch {
+		case rec.Code != tc.code:
+			t.Errorf("ERROR: %v: unexpected status %v", tc.hdr, rec.Code)
+		case rec.Header().Get(consts.ContentRange) != tc.crang:
+			t.Errorf("ERROR: %v: unexpected Content-Range %q", tc.hdr, rec.Header().Get(consts.ContentRange))
+		case tc.code < 300 && rec.Body.String() != tc.body:
+			t.Errorf("ERROR: %v: unexpected body %q", tc.hdr, rec.Body.String())
+		}
+	}
+}
+
+func TestServeContentMultipart(t *testing.T) {
+	rec := serveTestContent(http.Header{consts.Range: {"bytes=0-1,-2"}})
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("ERROR: unexpected status %v", rec.Code)
+	}
+
+	if n := rec.Header().Get(consts.ContentLength); n != strconv.Itoa(rec.Body.Len()) {
+		t.Errorf("ERROR: Content-Length %s doesn't match body %v", n, rec.Body.Len())
+	}
+
+	mt, params, err := mime.ParseMediaType(rec.Header().Get(consts.ContentType))
+	if err != nil || mt != "multipart/byteranges" {
+		t.Fatalf("ERROR: unexpected Content-Type %q", rec.Header().Get(consts.ContentType))
+	}
+
+	mr := multipart.NewReader(rec.Body, params["boundary"])
+	for _, expected := range []string{"01", "89"} {
+		p, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, _ := io.ReadAll(p)
+		if string(b) != expected || !strings.HasPrefix(p.Header.Get(consts.ContentType), "text/plain") {
+			t.Errorf("ERROR: unexpected part %q %v", b, p.Header)
+		}
+	}
+}
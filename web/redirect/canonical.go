@@ -0,0 +1,132 @@
+package redirect
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+)
+
+// TrailingSlash is the policy on the trailing slash of paths.
+type TrailingSlash int
+
+const (
+	// KeepSlash leaves paths as they are.
+	KeepSlash TrailingSlash = iota
+	// AddSlash redirects paths without trailing slash.
+	AddSlash
+	// RemoveSlash redirects paths with trailing slash, except
+	// the root.
+	RemoveSlash
+)
+
+// Config describes the canonical URLs enforced by [NewMiddleware].
+type Config struct {
+	// Host is the canonical host, including the port if any.
+	// Requests for any other host are redirected to it.
+	Host string
+
+	// HTTPS redirects plain HTTP requests to HTTPS.
+	HTTPS bool
+
+	// TrustForwarded uses the X-Forwarded-Proto header to tell
+	// if the request was made over HTTPS, for servers behind
+	// a reverse proxy.
+	TrustForwarded bool
+
+	// TrailingSlash is the policy on trailing slashes.
+	TrailingSlash TrailingSlash
+
+	// Temporary uses 307 Temporary Redirect instead of
+	// 308 Permanent Redirect.
+	Temporary bool
+}
+
+// NewMiddleware returns a middleware redirecting requests to their
+// canonical URL. Redirects preserve the method and body.
+func NewMiddleware(cfg Config) func(http.Handler) http.Handler {
+	return web.NewMiddleware(func(rw http.ResponseWriter, req *http.Request, next http.Handler) {
+		if u, ok := cfg.Canonical(req); ok {
+			code := http.StatusPermanentRedirect
+			if cfg.Temporary {
+				code = http.StatusTemporaryRedirect
+			}
+
+			web.HandleError(rw, req, NewRedirect(code, u.String()))
+			return
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// Canonical returns the canonical URL of a request, and if it
+// differs from the requested one.
+func (cfg *Config) Canonical(req *http.Request) (*url.URL, bool) {
+	u := &url.URL{
+		Scheme:   cfg.scheme(req),
+		Host:     req.Host,
+		Path:     req.URL.Path,
+		RawPath:  req.URL.RawPath,
+		RawQuery: req.URL.RawQuery,
+	}
+
+	changed := cfg.fixScheme(u)
+	changed = cfg.fixHost(u) || changed
+	changed = cfg.fixPath(u) || changed
+	return u, changed
+}
+
+func (cfg *Config) scheme(req *http.Request) string {
+	switch {
+	case req.TLS != nil:
+		return "https"
+	case cfg.TrustForwarded && strings.EqualFold(req.Header.Get("X-Forwarded-Proto"), "https"):
+		return "https"
+	default:
+		return "http"
+	}
+}
+
+func (cfg *Config) fixScheme(u *url.URL) bool {
+	if !cfg.HTTPS || u.Scheme == "https" || u.Host == "" {
+		return false
+	}
+
+	u.Scheme = "https"
+	if host, port, err := net.SplitHostPort(u.Host); err == nil && port == "80" {
+		u.Host = host
+	}
+	return true
+}
+
+func (cfg *Config) fixHost(u *url.URL) bool {
+	if cfg.Host == "" || strings.EqualFold(u.Host, cfg.Host) {
+		return false
+	}
+
+	u.Host = cfg.Host
+	return true
+}
+
+func (cfg *Config) fixPath(u *url.URL) bool {
+	p := u.Path
+	switch {
+	case p == "" || p == "/":
+		return false
+	case cfg.TrailingSlash == AddSlash && !strings.HasSuffix(p, "/"):
+		u.Path = p + "/"
+		if u.RawPath != "" {
+			u.RawPath += "/"
+		}
+	case cfg.TrailingSlash == RemoveSlash && strings.HasSuffix(p, "/"):
+		u.Path = core.Coalesce(strings.TrimRight(p, "/"), "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+// Package redirect implements safe redirects and middleware
+// enforcing canonical URLs.
+package redirect
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+// ErrUnsafeLocation indicates a redirect target was refused.
+var ErrUnsafeLocation = errors.New("unsafe redirect location")
+
+// Location returns a safe Location value to redirect a request to.
+// Control characters are removed and backslashes treated as slashes,
+// as browsers do. Relative targets are resolved against the request,
+// and absolute ones, including scheme-relative, are only accepted over
+// HTTP or HTTPS for the host of the request or one of the given hosts.
+func Location(req *http.Request, target string, hosts ...string) (string, error) {
+	u, err := url.Parse(strings.Map(sanitizeRune, target))
+	switch {
+	case err != nil:
+		return "", core.Wrap(ErrUnsafeLocation, err.Error())
+	case u.Scheme == "" && u.Host == "" && u.Opaque == "":
+		return resolve(req, u), nil
+	case u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https":
+		return "", core.Wrapf(ErrUnsafeLocation, "scheme %q", u.Scheme)
+	case u.User != nil, !allowedHost(req, u.Host, hosts):
+		return "", core.Wrapf(ErrUnsafeLocation, "host %q", u.Host)
+	default:
+		return u.String(), nil
+	}
+}
+
+func sanitizeRune(r rune) rune {
+	switch {
+	case r < 0x20, r == 0x7f:
+		// control characters
+		return -1
+	case r == '\\':
+		return '/'
+	default:
+		return r
+	}
+}
+
+// resolve makes a relative target absolute within the host of
+// the request.
+func resolve(req *http.Request, u *url.URL) string {
+	base := &url.URL{Path: "/"}
+	if req.URL != nil {
+		base = &url.URL{Path: req.URL.Path}
+	}
+
+	ref := base.ResolveReference(u)
+	out := url.URL{
+		// never scheme-relative
+		Path:     "/" + strings.TrimLeft(ref.Path, "/"),
+		RawQuery: ref.RawQuery,
+		Fragment: ref.Fragment,
+	}
+	return out.String()
+}
+
+func allowedHost(req *http.Request, host string, hosts []string) bool {
+	if host == "" {
+		return false
+	}
+
+	if strings.EqualFold(host, req.Host) {
+		return true
+	}
+
+	for _, s := range hosts {
+		if strings.EqualFold(host, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRedirect returns an [web.HTTPError] redirecting to an already
+// validated location.
+func NewRedirect(code int, location string) *web.HTTPError {
+	err := web.NewHTTPError(code, nil, "")
+	err.Header().Set(consts.Location, location)
+	return err
+}
+
+// Send redirects a request to a safe [Location] via [web.HandleError].
+// Unsafe targets are refused with 400 Bad Request.
+func Send(rw http.ResponseWriter, req *http.Request, target string, code int, hosts ...string) {
+	loc, err := Location(req, target, hosts...)
+	if err != nil {
+		web.HandleError(rw, req, web.NewStatusBadRequest(err))
+		return
+	}
+
+	web.HandleError(rw, req, NewRedirect(code, loc))
+}
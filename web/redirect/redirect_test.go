@@ -0,0 +1,108 @@
+package redirect
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"darvaza.org/x/web/consts"
+)
+
+func TestLocation(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/a/b?x=1", nil)
+
+	for _, tc := range []struct {
+		target string
+		out    string
+		ok     bool
+	}{
+		{"c", "/a/c", true},
+		{"../c?y=2#top", "/c?y=2#top", true},
+		{"/x\r\nSet-Cookie: a=b", "/xSet-Cookie:%20a=b", true},
+		{"https://example.com/z", "https://example.com/z", true},
+		{"https://other.example/z", "https://other.example/z", true},
+		{"//evil.example/z", "", false},
+		{`/\evil.example/z`, "", false},
+		{"https://evil.example/z", "", false},
+		{"https://example.com@evil.example/", "", false},
+		{"javascript:alert(1)", "", false},
+		{"http:evil.example", "", false},
+	} {
+		out, err := Location(req, tc.target, "other.example")
+		switch {
+		case tc.ok && err != nil:
+			t.Errorf("ERROR: %q: %v", tc.target, err)
+		case !tc.ok && !errors.Is(err, ErrUnsafeLocation):
+			t.Errorf("ERROR: %q: accepted as %q", tc.target, out)
+		case out != tc.out:
+			t.Errorf("ERROR: %q: expected %q, got %q", tc.target, tc.out, out)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Send(rec, httptest.NewRequest("GET", "/a", nil), "//evil.example", http.StatusSeeOther)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ERROR: unexpected status %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	Send(rec, httptest.NewRequest("GET", "/a/", nil), "b", http.StatusSeeOther)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get(consts.Location) != "/a/b" {
+		t.Errorf("ERROR: unexpected response %v %q", rec.Code, rec.Header().Get(consts.Location))
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	for _, tc := range []struct {
+		cfg    Config
+		url    string
+		tls    bool
+		header http.Header
+		out    string
+	}{
+		{Config{HTTPS: true}, "http://example.com:80/a?b=c", false, nil, "https://example.com/a?b=c"},
+		{Config{HTTPS: true}, "https://example.com/a", true, nil, ""},
+		{Config{HTTPS: true, TrustForwarded: true}, "http://example.com/a", false,
+			http.Header{"X-Forwarded-Proto": {"https"}}, ""},
+		{Config{HTTPS: true}, "http://example.com/a", false,
+			http.Header{"X-Forwarded-Proto": {"https"}}, "https://example.com/a"},
+		{Config{Host: "www.example.com"}, "http://example.com/a", false, nil, "http://www.example.com/a"},
+		{Config{Host: "www.example.com"}, "http://WWW.example.com/a", false, nil, ""},
+		{Config{TrailingSlash: AddSlash}, "http://example.com/a", false, nil, "http://example.com/a/"},
+		{Config{TrailingSlash: AddSlash}, "http://example.com/", false, nil, ""},
+		{Config{TrailingSlash: RemoveSlash}, "http://example.com/a/", false, nil, "http://example.com/a"},
+		{Config{TrailingSlash: RemoveSlash}, "http://example.com/", false, nil, ""},
+	} {
+		req := httptest.NewRequest("GET", tc.url, nil)
+		for k, v := range tc.header {
+			req.Header[k] = v
+		}
+		if tc.tls {
+			req.TLS = &tls.ConnectionState{}
+		}
+
+		u, changed := tc.cfg.Canonical(req)
+		switch {
+		case tc.out == "" && changed:
+			t.Errorf("ERROR: %s: unexpected redirect to %s", tc.url, u)
+		case tc.out != "" && (!changed || u.String() != tc.out):
+			t.Errorf("ERROR: %s: expected %s, got %s", tc.url, tc.out, u)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	h := NewMiddleware(Config{HTTPS: true})(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "http://example.com/a", nil))
+	if rec.Code != http.StatusPermanentRedirect || rec.Header().Get(consts.Location) != "https://example.com/a" {
+		t.Errorf("ERROR: unexpected response %v %q", rec.Code, rec.Header().Get(consts.Location))
+	}
+}
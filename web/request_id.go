@@ -0,0 +1,160 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"darvaza.org/x/web/consts"
+)
+
+const (
+	// LogFieldRequestID is the field name used to store the
+	// request ID when logging.
+	LogFieldRequestID = "request_id"
+
+	// LogFieldTraceID is the field name used to store the
+	// trace ID when logging.
+	LogFieldTraceID = "trace_id"
+
+	// LogFieldSpanID is the field name used to store our
+	// parent ID of the trace when logging.
+	LogFieldSpanID = "span_id"
+
+	// maxRequestIDLength is the longest request ID accepted
+	// from clients.
+	maxRequestIDLength = 128
+)
+
+var (
+	requestIDCtxKey = core.NewContextKey[string]("RequestID")
+)
+
+// WithRequestID attaches a request ID to a context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return requestIDCtxKey.WithValue(ctx, id)
+}
+
+// RequestID attempts to get the request ID from the given context.
+func RequestID(ctx context.Context) (string, bool) {
+	return requestIDCtxKey.Get(ctx)
+}
+
+// RequestIDConfig describes how [NewRequestIDMiddleware] identifies
+// requests.
+type RequestIDConfig struct {
+	// Logger, if set, is annotated with the request ID and trace,
+	// and attached to the request's context. Otherwise a logger
+	// already in the context is annotated.
+	Logger slog.Logger
+
+	// Generate creates new request IDs, 16 random bytes in
+	// hexadecimal if not set.
+	Generate func() string
+
+	// Trust, if set, decides if the IDs and traceparent given by
+	// the client are accepted. Otherwise they always are when valid.
+	Trust func(*http.Request) bool
+
+	// Header is the name of the request ID header,
+	// [consts.XRequestID] if empty.
+	Header string
+
+	// TraceContext enables W3C traceparent handling, continuing
+	// the trace of the client or starting a new one.
+	TraceContext bool
+}
+
+// NewRequestIDMiddleware creates a middleware assigning an ID to
+// every request, or propagating the one given by the client. The ID
+// is stored in the request's context, set on the request headers to
+// be passed on by proxies, and on the response headers.
+func NewRequestIDMiddleware(cfg *RequestIDConfig) func(http.Handler) http.Handler {
+	if cfg == nil {
+		cfg = &RequestIDConfig{}
+	}
+
+	return NewMiddleware(func(rw http.ResponseWriter, req *http.Request, next http.Handler) {
+		next.ServeHTTP(rw, cfg.prepare(rw, req))
+	})
+}
+
+func (cfg *RequestIDConfig) prepare(rw http.ResponseWriter, req *http.Request) *http.Request {
+	trusted := cfg.Trust == nil || cfg.Trust(req)
+	hdr := core.Coalesce(cfg.Header, consts.XRequestID)
+
+	id := req.Header.Get(hdr)
+	if !trusted || !validRequestID(id) {
+		id = cfg.generate()
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(hdr, id)
+	rw.Header().Set(hdr, id)
+
+	ctx := WithRequestID(req.Context(), id)
+	fields := slog.Fields{LogFieldRequestID: id}
+
+	if cfg.TraceContext {
+		tp := cfg.trace(req, trusted)
+		req.Header.Set(consts.TraceParent, tp.String())
+
+		ctx = WithTrace(ctx, tp)
+		fields[LogFieldTraceID] = tp.TraceIDString()
+		fields[LogFieldSpanID] = tp.ParentIDString()
+	}
+
+	if l, ok := cfg.logger(ctx); ok {
+		ctx = slog.WithLogger(ctx, l.WithFields(fields))
+	}
+
+	return req.WithContext(ctx)
+}
+
+func (cfg *RequestIDConfig) generate() string {
+	if cfg.Generate != nil {
+		if id := cfg.Generate(); id != "" {
+			return id
+		}
+	}
+
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// trace continues the client's trace if valid and trusted,
+// or starts a new one.
+func (*RequestIDConfig) trace(req *http.Request, trusted bool) TraceParent {
+	if trusted {
+		if tp, err := ParseTraceParent(req.Header.Get(consts.TraceParent)); err == nil {
+			return tp.Child()
+		}
+	}
+	return NewTraceParent()
+}
+
+func (cfg *RequestIDConfig) logger(ctx context.Context) (slog.Logger, bool) {
+	if cfg.Logger != nil {
+		return cfg.Logger, true
+	}
+	return slog.GetLogger(ctx)
+}
+
+// validRequestID checks a request ID is reasonably sized and made
+// of visible ASCII characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range []byte(id) {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
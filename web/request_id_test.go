@@ -0,0 +1,71 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"darvaza.org/x/web/consts"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	for _, tc := range []struct {
+		s  string
+		ok bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+	} {
+		tp, err := ParseTraceParent(tc.s)
+		switch {
+		case tc.ok && err != nil:
+			t.Errorf("ERROR: %q: %v", tc.s, err)
+		case !tc.ok && err == nil:
+			t.Errorf("ERROR: %q: accepted", tc.s)
+		case tc.ok && !tp.IsSampled() && tc.s[len(tc.s)-2:] == "01":
+			t.Errorf("ERROR: %q: sampled flag lost", tc.s)
+		}
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var id string
+	var tp TraceParent
+	h := NewRequestIDMiddleware(&RequestIDConfig{
+		TraceContext: true,
+	})(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		id, _ = RequestID(req.Context())
+		tp, _ = Trace(req.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(consts.XRequestID, "abc-123")
+	req.Header.Set(consts.TraceParent, "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	switch {
+	case id != "abc-123", rec.Header().Get(consts.XRequestID) != id:
+		t.Errorf("ERROR: request ID not propagated: %q", id)
+	case tp.TraceIDString() != traceID, tp.ParentIDString() == "00f067aa0ba902b7":
+		t.Errorf("ERROR: unexpected trace %v", tp)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(consts.XRequestID, "bad id")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if id == "bad id" || len(id) != 32 || !tp.IsValid() {
+		t.Errorf("ERROR: unexpected request ID %q", id)
+	}
+}
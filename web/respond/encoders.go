@@ -0,0 +1,48 @@
+package respond
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web/consts"
+)
+
+// NewJSONRenderer creates a [Renderer] encoding objects as JSON.
+func NewJSONRenderer() Renderer {
+	return NewRenderer(consts.JSON, func(w io.Writer, v any) error {
+		return json.NewEncoder(w).Encode(v)
+	})
+}
+
+// NewXMLRenderer creates a [Renderer] encoding objects as XML.
+func NewXMLRenderer() Renderer {
+	return NewRenderer(consts.XML, func(w io.Writer, v any) error {
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		return xml.NewEncoder(w).Encode(v)
+	})
+}
+
+// NewHTMLRenderer creates a [Renderer] executing the given
+// template with the object as data.
+func NewHTMLRenderer(tmpl *template.Template) Renderer {
+	if tmpl == nil {
+		core.Panic("template not provided")
+	}
+
+	return NewRenderer(consts.HTML, tmpl.Execute)
+}
+
+// NewTextRenderer creates a [Renderer] writing objects as
+// plain text using their default format.
+func NewTextRenderer() Renderer {
+	return NewRenderer(consts.TXT, func(w io.Writer, v any) error {
+		_, err := fmt.Fprintln(w, v)
+		return err
+	})
+}
@@ -214,8 +214,9 @@ func (reg *Registry) Supports(types ...string) *Responder {
 	return res.Supports(types...)
 }
 
-// global is the global Renderer registry
-var global = NewRegistry()
+// global is the global Renderer registry, supporting JSON,
+// XML and plain text by default
+var global = NewRegistry(NewJSONRenderer(), NewXMLRenderer(), NewTextRenderer())
 
 // Register adds a [Renderer] to the global [Registry]
 func Register(ct string, h Renderer) error {
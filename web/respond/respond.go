@@ -162,9 +162,9 @@ func (res *Responder) WithRequest(req *http.Request) (*Response, error) {
 		err = web.NewHTTPError(http.StatusBadRequest, err, "Invalid Accept Header")
 	}
 
-	preferred, _, _ := qlist.BestQualityParsed(res.ql, accepted)
-	if preferred == "" {
-		preferred = res.identity
+	preferred := res.identity
+	if i, _ := qlist.BestQualityIndex(res.ql, accepted); i >= 0 {
+		preferred = res.supported[i]
 	}
 
 	h, ok := res.registry.Get(preferred)
@@ -173,10 +173,22 @@ func (res *Responder) WithRequest(req *http.Request) (*Response, error) {
 	}
 
 	r := &Response{
-		res: res,
-		req: req,
-		h:   h,
+		res:  res,
+		req:  req,
+		hdrs: make(http.Header),
+		h:    h,
 	}
 
 	return r, err
 }
+
+// Render negotiates the best accepted type for the request and
+// sends v rendered with the given status code.
+func (res *Responder) Render(rw http.ResponseWriter, req *http.Request, code int, v any) error {
+	r, err := res.WithRequest(req)
+	if err != nil {
+		return err
+	}
+
+	return r.WithWriter(rw).WithStatus(code).Render(v)
+}
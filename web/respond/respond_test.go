@@ -0,0 +1,45 @@
+package respond
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"darvaza.org/x/web/consts"
+)
+
+func TestResponderRender(t *testing.T) {
+	type item struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	res := Supports(consts.JSON, consts.XML)
+
+	for _, tc := range []struct {
+		accept   string
+		ct       string
+		contains string
+	}{
+		{"", consts.JSON, `{"name":"foo"}`},
+		{"application/xml", consts.XML, `<item><name>foo</name></item>`},
+		{"image/png", consts.JSON, `{"name":"foo"}`},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tc.accept != "" {
+			req.Header.Set(consts.Accept, tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		if err := res.Render(rec, req, http.StatusOK, item{Name: "foo"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if ct := rec.Header().Get(consts.ContentType); ct != tc.ct {
+			t.Errorf("ERROR: %q: unexpected Content-Type %q", tc.accept, ct)
+		}
+		if !strings.Contains(rec.Body.String(), tc.contains) {
+			t.Errorf("ERROR: %q: unexpected body %q", tc.accept, rec.Body.String())
+		}
+	}
+}
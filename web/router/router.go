@@ -0,0 +1,177 @@
+// Package router implements a lightweight trie based HTTP router
+// with path parameters, wildcards and route groups.
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+	"darvaza.org/x/web/resource"
+)
+
+var _ http.Handler = (*Router)(nil)
+
+var (
+	paramsCtxKey = core.NewContextKey[resource.RouteParamsTable]("RouterParams")
+)
+
+// Router dispatches requests by method and path.
+//
+// Patterns are made of static segments, `:name` parameters matching
+// one segment and an optional final `*name` wildcard matching the rest.
+// Static segments take precedence over parameters, and parameters over
+// wildcards. Trailing slashes are ignored.
+//
+// Parameters are exposed as [resource.RouteParamsTable] via [Params]
+// and [resource.RouteParams], so [resource.Resolve] can use them.
+type Router struct {
+	// NotFound handles requests without matching route, otherwise
+	// a 404 is passed to [web.HandleError]. Only the one of the root
+	// [Router] is used.
+	NotFound http.Handler
+
+	mu     *sync.RWMutex
+	root   *Router
+	tree   *node
+	chain  *web.Chain
+	prefix string
+}
+
+// New creates a new root [Router].
+func New() *Router {
+	r := &Router{
+		mu:    new(sync.RWMutex),
+		tree:  &node{},
+		chain: web.NewChain(),
+	}
+	r.root = r
+	return r
+}
+
+// Use adds middleware applied to routes registered afterwards
+// on this [Router] and groups created from it.
+func (r *Router) Use(mw ...func(http.Handler) http.Handler) *Router {
+	r.chain.Use(mw...)
+	return r
+}
+
+// Group returns a [Router] registering routes under the given
+// prefix, with additional middleware.
+func (r *Router) Group(prefix string, mw ...func(http.Handler) http.Handler) *Router {
+	return &Router{
+		mu:     r.mu,
+		root:   r.root,
+		tree:   r.tree,
+		chain:  r.chain.With(mw...),
+		prefix: joinPattern(r.prefix, prefix),
+	}
+}
+
+// Method registers a handler for a method and pattern.
+// It panics if the pattern is invalid or already registered.
+func (r *Router) Method(method, pattern string, h http.Handler) {
+	if h == nil {
+		h = web.NoMiddleware(nil)
+	}
+
+	pattern = joinPattern(r.prefix, pattern)
+	h = r.chain.Then(h)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tree.insert(pattern, strings.ToUpper(method), h)
+}
+
+// Handle registers a handler for any method.
+func (r *Router) Handle(pattern string, h http.Handler) {
+	r.Method(anyMethod, pattern, h)
+}
+
+// HandleFunc registers a handler function for any method.
+func (r *Router) HandleFunc(pattern string, fn func(http.ResponseWriter, *http.Request)) {
+	r.Method(anyMethod, pattern, http.HandlerFunc(fn))
+}
+
+// Get registers a handler for GET, also used for HEAD.
+func (r *Router) Get(pattern string, h http.Handler) { r.Method(consts.GET, pattern, h) }
+
+// Post registers a handler for POST.
+func (r *Router) Post(pattern string, h http.Handler) { r.Method(consts.POST, pattern, h) }
+
+// Put registers a handler for PUT.
+func (r *Router) Put(pattern string, h http.Handler) { r.Method(consts.PUT, pattern, h) }
+
+// Patch registers a handler for PATCH.
+func (r *Router) Patch(pattern string, h http.Handler) { r.Method(consts.PATCH, pattern, h) }
+
+// Delete registers a handler for DELETE.
+func (r *Router) Delete(pattern string, h http.Handler) { r.Method(consts.DELETE, pattern, h) }
+
+func (r *Router) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	path, ok := web.CleanPath(req.URL.Path)
+	if !ok {
+		web.HandleError(rw, req, web.NewStatusBadRequest(nil))
+		return
+	}
+
+	h, params, allowed := r.lookup(req.Method, path)
+	switch {
+	case h != nil:
+		h.ServeHTTP(rw, withParams(req, path, params))
+	case len(allowed) > 0:
+		web.HandleError(rw, req, web.NewStatusMethodNotAllowed(allowed...))
+	case r.root.NotFound != nil:
+		r.root.NotFound.ServeHTTP(rw, req)
+	default:
+		web.HandleError(rw, req, web.NewStatusNotFound())
+	}
+}
+
+func (r *Router) lookup(method, path string) (http.Handler, []param, []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n, params := r.tree.match(splitPath(path), nil)
+	if n == nil {
+		return nil, nil, nil
+	}
+
+	h, allowed := n.handler(method)
+	return h, params, allowed
+}
+
+func withParams(req *http.Request, path string, params []param) *http.Request {
+	table := make(resource.RouteParamsTable)
+	for _, p := range params {
+		table.Add(p.name, p.value)
+	}
+
+	ctx := paramsCtxKey.WithValue(req.Context(), table)
+	ctx = resource.WithRouteParams(ctx, func(context.Context) (string, resource.RouteParamsTable, error) {
+		return path, table, nil
+	})
+	return req.WithContext(ctx)
+}
+
+// Params returns the parameters of the matched route.
+func Params(ctx context.Context) (resource.RouteParamsTable, bool) {
+	return paramsCtxKey.Get(ctx)
+}
+
+// Param returns the value of a parameter of the matched route,
+// or an empty string.
+func Param(req *http.Request, name string) string {
+	params, _ := Params(req.Context())
+	s, _ := resource.RouteParamFirst[string](params, name)
+	return s
+}
+
+func joinPattern(prefix, pattern string) string {
+	return "/" + strings.Trim(strings.Trim(prefix, "/")+"/"+strings.Trim(pattern, "/"), "/")
+}
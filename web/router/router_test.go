@@ -0,0 +1,113 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"darvaza.org/x/web/resource"
+)
+
+func echo(name string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		params, _ := Params(req.Context())
+		_, _ = io.WriteString(rw, name)
+		for _, k := range params.Params() {
+			_, _ = io.WriteString(rw, " "+k+"="+Param(req, k))
+		}
+	})
+}
+
+func TestRouter(t *testing.T) {
+	r := New()
+	r.Get("/", echo("root"))
+	r.Get("/users/:id", echo("user"))
+	r.Get("/users/me", echo("me"))
+	r.Delete("/users/:id", echo("delete"))
+	r.Get("/files/*path", echo("files"))
+
+	api := r.Group("/api/v1", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("X-Group", "api")
+			next.ServeHTTP(rw, req)
+		})
+	})
+	api.Handle("/items/:id/", echo("item"))
+
+	for _, tc := range []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/", http.StatusOK, "root"},
+		{"GET", "/users/42", http.StatusOK, "user id=42"},
+		{"HEAD", "/users/42", http.StatusOK, ""},
+		{"GET", "/users/me", http.StatusOK, "me"},
+		{"DELETE", "/users/42", http.StatusOK, "delete id=42"},
+		{"POST", "/users/42", http.StatusMethodNotAllowed, ""},
+		{"GET", "/files/a/b.txt", http.StatusOK, "files path=a/b.txt"},
+		{"GET", "/files", http.StatusOK, "files path="},
+		{"PUT", "/api/v1/items/7", http.StatusOK, "item id=7"},
+		{"GET", "/missing", http.StatusNotFound, ""},
+		{"GET", "/users", http.StatusNotFound, ""},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+
+		switch {
+		case rec.Code != tc.code:
+			t.Errorf("ERROR: %s %s: expected %v, got %v", tc.method, tc.path, tc.code, rec.Code)
+		case tc.body != "" && rec.Body.String() != tc.body:
+			t.Errorf("ERROR: %s %s: unexpected body %q", tc.method, tc.path, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("POST", "/users/1", nil))
+	if allow := strings.Join(rec.Header().Values("Allow"), ", "); allow != "DELETE, GET, HEAD" {
+		t.Errorf("ERROR: unexpected Allow %q", allow)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/items/1", nil))
+	if rec.Header().Get("X-Group") != "api" {
+		t.Errorf("ERROR: group middleware not applied")
+	}
+}
+
+func TestRouterResolve(t *testing.T) {
+	var id string
+	r := New()
+	r.Get("/things/:id", http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		_, params, _ := resource.Resolve(req)
+		id, _ = resource.RouteParamFirst[string](params, "id")
+	}))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/things/abc", nil))
+	if id != "abc" {
+		t.Errorf("ERROR: unexpected id %q", id)
+	}
+}
+
+func TestRouterConflicts(t *testing.T) {
+	for _, patterns := range [][]string{
+		{"/a/:id", "/a/:name"},
+		{"/a/*rest/b"},
+		{"/a", "/a/"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ERROR: %q: expected panic", patterns)
+				}
+			}()
+
+			r := New()
+			for _, p := range patterns {
+				r.Get(p, echo(p))
+			}
+		}()
+	}
+}
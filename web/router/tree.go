@@ -0,0 +1,160 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// anyMethod is the key of handlers accepting any method.
+const anyMethod = "*"
+
+// node is a segment of the routing trie. Static children take
+// precedence over parameters, and those over wildcards.
+type node struct {
+	static   map[string]*node
+	param    *node
+	wildcard *node
+	handlers map[string]http.Handler
+
+	// name is the parameter or wildcard name
+	name string
+}
+
+type param struct {
+	name  string
+	value string
+}
+
+// splitPath splits a path in segments, ignoring the trailing slash.
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// insert adds the handler of a method for a pattern.
+func (n *node) insert(pattern, method string, h http.Handler) {
+	segs := splitPath(pattern)
+	for i, s := range segs {
+		switch {
+		case strings.HasPrefix(s, "*"):
+			if i != len(segs)-1 {
+				core.Panicf("router: %q: wildcard must be the last segment", pattern)
+			}
+			n = n.child(&n.wildcard, s[1:], pattern)
+		case strings.HasPrefix(s, ":"):
+			n = n.child(&n.param, s[1:], pattern)
+		default:
+			n = n.staticChild(s)
+		}
+	}
+
+	if n.handlers == nil {
+		n.handlers = make(map[string]http.Handler)
+	}
+	if _, ok := n.handlers[method]; ok {
+		core.Panicf("router: %s %q: already registered", method, pattern)
+	}
+	n.handlers[method] = h
+}
+
+func (n *node) staticChild(s string) *node {
+	if n.static == nil {
+		n.static = make(map[string]*node)
+	}
+
+	c, ok := n.static[s]
+	if !ok {
+		c = &node{}
+		n.static[s] = c
+	}
+	return c
+}
+
+// child returns the parameter or wildcard child, refusing different
+// names at the same position.
+func (*node) child(p **node, name, pattern string) *node {
+	switch {
+	case name == "":
+		core.Panicf("router: %q: unnamed parameter", pattern)
+	case *p == nil:
+		*p = &node{name: name}
+	case (*p).name != name:
+		core.Panicf("router: %q: conflicting parameter %q and %q", pattern, (*p).name, name)
+	}
+	return *p
+}
+
+// match finds the node handling the given segments, collecting
+// the parameters.
+func (n *node) match(segs []string, params []param) (*node, []param) {
+	if len(segs) == 0 {
+		return n.matchEnd(params)
+	}
+
+	if m, p := n.matchStatic(segs, params); m != nil {
+		return m, p
+	}
+	if m, p := n.matchParam(segs, params); m != nil {
+		return m, p
+	}
+	return n.matchWildcard(segs, params)
+}
+
+func (n *node) matchStatic(segs []string, params []param) (*node, []param) {
+	if c, ok := n.static[segs[0]]; ok {
+		return c.match(segs[1:], params)
+	}
+	return nil, params
+}
+
+func (n *node) matchParam(segs []string, params []param) (*node, []param) {
+	if n.param != nil {
+		return n.param.match(segs[1:], append(params, param{n.param.name, segs[0]}))
+	}
+	return nil, params
+}
+
+func (n *node) matchWildcard(segs []string, params []param) (*node, []param) {
+	if n.wildcard != nil {
+		return n.wildcard, append(params, param{n.wildcard.name, strings.Join(segs, "/")})
+	}
+	return nil, params
+}
+
+func (n *node) matchEnd(params []param) (*node, []param) {
+	switch {
+	case n.handlers != nil:
+		return n, params
+	case n.wildcard != nil:
+		return n.wildcard, append(params, param{n.wildcard.name, ""})
+	default:
+		return nil, params
+	}
+}
+
+// handler returns the handler for a method, and if not found
+// the allowed methods.
+func (n *node) handler(method string) (http.Handler, []string) {
+	if h, ok := n.handlers[method]; ok {
+		return h, nil
+	}
+	if h, ok := n.handlers[http.MethodGet]; ok && method == http.MethodHead {
+		return h, nil
+	}
+	if h, ok := n.handlers[anyMethod]; ok {
+		return h, nil
+	}
+
+	allowed := core.Keys(n.handlers)
+	if _, ok := n.handlers[http.MethodGet]; ok {
+		allowed = append(allowed, http.MethodHead)
+	}
+	sort.Strings(allowed)
+	return nil, allowed
+}
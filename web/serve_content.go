@@ -0,0 +1,238 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web/consts"
+)
+
+// sniffLen is the number of bytes used to detect the Content-Type.
+const sniffLen = 512
+
+// ServeContent serves content like [http.ServeContent], honouring the
+// ETag set on the response headers, conditional requests via
+// [ServeConditional] and single or multipart Range requests, also
+// conditional to If-Range. Errors are passed to [HandleError].
+func ServeContent(rw http.ResponseWriter, req *http.Request, name string,
+	modTime time.Time, content io.ReadSeeker) {
+	//
+	hdr := rw.Header()
+	etag := hdr.Get(consts.ETag)
+	if ServeConditional(rw, req, etag, modTime) {
+		return
+	}
+
+	size, err := contentSize(content)
+	if err == nil {
+		err = setContentType(hdr, name, content)
+	}
+	if err != nil {
+		HandleError(rw, req, err)
+		return
+	}
+
+	ranges, err := requestRanges(req, etag, modTime, size)
+	if err != nil {
+		HandleError(rw, req, NewStatusRangeNotSatisfiable(size))
+		return
+	}
+
+	hdr[consts.AcceptRanges] = []string{"bytes"}
+	switch len(ranges) {
+	case 0:
+		serveRange(rw, req, content, Range{Length: size}, http.StatusOK)
+	case 1:
+		hdr[consts.ContentRange] = []string{ranges[0].ContentRange(size)}
+		serveRange(rw, req, content, ranges[0], http.StatusPartialContent)
+	default:
+		serveMultipart(rw, req, content, ranges, size)
+	}
+}
+
+// ServeFile serves an [fs.File] using [ServeContent]. The file must
+// implement [io.Seeker].
+func ServeFile(rw http.ResponseWriter, req *http.Request, f fs.File) {
+	fi, err := f.Stat()
+	switch {
+	case err != nil:
+		HandleError(rw, req, err)
+	case fi.IsDir():
+		HandleError(rw, req, NewStatusNotFound())
+	default:
+		rs, ok := f.(io.ReadSeeker)
+		if !ok {
+			err = core.Wrap(core.ErrNotImplemented, "file not seekable")
+			HandleError(rw, req, err)
+			return
+		}
+
+		ServeContent(rw, req, fi.Name(), fi.ModTime(), rs)
+	}
+}
+
+func contentSize(content io.Seeker) (int64, error) {
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = content.Seek(0, io.SeekStart)
+	return size, err
+}
+
+// setContentType sets the Content-Type if missing, using the
+// extension of the name or sniffing the content.
+func setContentType(hdr http.Header, name string, content io.ReadSeeker) error {
+	if _, ok := hdr[consts.ContentType]; ok {
+		return nil
+	}
+
+	ct := mime.TypeByExtension(path.Ext(name))
+	if ct == "" {
+		var buf [sniffLen]byte
+		n, _ := io.ReadFull(content, buf[:])
+		ct = http.DetectContentType(buf[:n])
+
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	hdr[consts.ContentType] = []string{ct}
+	return nil
+}
+
+// requestRanges returns the ranges to serve, if any, failing only
+// if none is satisfiable.
+func requestRanges(req *http.Request, etag string, modTime time.Time, size int64) ([]Range, error) {
+	s := req.Header.Get(consts.Range)
+	if s == "" || req.Method != consts.GET || !checkIfRange(req, etag, modTime) {
+		return nil, nil
+	}
+
+	ranges, err := ParseRange(s, size)
+	switch {
+	case errors.Is(err, ErrInvalidRange):
+		// ignored
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case !reasonableRanges(ranges, size):
+		// serve it all instead
+		return nil, nil
+	default:
+		return ranges, nil
+	}
+}
+
+// checkIfRange tells if the Range should be honoured according
+// to If-Range, which requires a strong match.
+func checkIfRange(req *http.Request, etag string, modTime time.Time) bool {
+	s := req.Header.Get(consts.IfRange)
+	switch {
+	case s == "":
+		return true
+	case strings.HasPrefix(s, `"`), strings.HasPrefix(s, "W/"):
+		return MatchETag(s, etag, false)
+	case isZeroTime(modTime):
+		return false
+	default:
+		t, err := http.ParseTime(s)
+		return err == nil && modTime.Truncate(time.Second).Equal(t)
+	}
+}
+
+// reasonableRanges refuses too many ranges, or adding up to more than
+// the content itself.
+func reasonableRanges(ranges []Range, size int64) bool {
+	if len(ranges) > MaxRanges {
+		return false
+	}
+
+	var total int64
+	for _, r := range ranges {
+		total += r.Length
+	}
+	return total <= size
+}
+
+func serveRange(rw http.ResponseWriter, req *http.Request, content io.ReadSeeker, r Range, code int) {
+	if _, err := content.Seek(r.Start, io.SeekStart); err != nil {
+		HandleError(rw, req, err)
+		return
+	}
+
+	rw.Header()[consts.ContentLength] = []string{strconv.FormatInt(r.Length, 10)}
+	rw.WriteHeader(code)
+
+	if req.Method != consts.HEAD {
+		_, _ = io.CopyN(rw, content, r.Length)
+	}
+}
+
+func serveMultipart(rw http.ResponseWriter, req *http.Request, content io.ReadSeeker,
+	ranges []Range, size int64) {
+	//
+	hdr := rw.Header()
+	ct := hdr.Get(consts.ContentType)
+	mw := multipart.NewWriter(rw)
+
+	hdr[consts.ContentType] = []string{"multipart/byteranges; boundary=" + mw.Boundary()}
+	hdr[consts.ContentLength] = []string{strconv.FormatInt(multipartSize(ranges, ct, size), 10)}
+	rw.WriteHeader(http.StatusPartialContent)
+
+	if req.Method == consts.HEAD {
+		return
+	}
+
+	for _, r := range ranges {
+		if err := copyPart(mw, content, r, ct, size); err != nil {
+			return
+		}
+	}
+	_ = mw.Close()
+}
+
+func copyPart(mw *multipart.Writer, content io.ReadSeeker, r Range, ct string, size int64) error {
+	part, err := mw.CreatePart(r.mimeHeader(ct, size))
+	if err != nil {
+		return err
+	}
+
+	if _, err := content.Seek(r.Start, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(part, content, r.Length)
+	return err
+}
+
+// multipartSize computes the length of a multipart/byteranges body
+// without producing it.
+func multipartSize(ranges []Range, ct string, size int64) int64 {
+	var w countWriter
+	mw := multipart.NewWriter(&w)
+	for _, r := range ranges {
+		_, _ = mw.CreatePart(r.mimeHeader(ct, size))
+		w += countWriter(r.Length)
+	}
+	_ = mw.Close()
+	return int64(w)
+}
+
+type countWriter int64
+
+func (w *countWriter) Write(b []byte) (int, error) {
+	*w += countWriter(len(b))
+	return len(b), nil
+}
@@ -0,0 +1,212 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/capture"
+)
+
+const (
+	// DefaultCookieName is the name of the cookie when the
+	// [Config] doesn't specify one.
+	DefaultCookieName = "session"
+
+	// DefaultMaxAge is the lifetime of sessions when the [Config]
+	// doesn't specify one.
+	DefaultMaxAge = 24 * time.Hour
+)
+
+// Config describes the sessions of a [Manager]. The cookie is
+// always HttpOnly, and Secure unless Insecure is set.
+type Config struct {
+	// Store persists the sessions.
+	Store Store
+
+	// CookieName is the name of the cookie, [DefaultCookieName]
+	// if empty.
+	CookieName string
+	// Path is the path of the cookie, "/" if empty.
+	Path string
+	// Domain is the domain of the cookie, the host if empty.
+	Domain string
+	// MaxAge is the lifetime of a session since its last change,
+	// [DefaultMaxAge] if zero.
+	MaxAge time.Duration
+	// SameSite is the SameSite policy of the cookie, Lax
+	// if not specified.
+	SameSite http.SameSite
+	// Insecure allows sending the cookie over plain HTTP.
+	Insecure bool
+}
+
+// Manager loads and saves the [Session] of each request.
+type Manager struct {
+	cfg Config
+}
+
+// New creates a [Manager] for the given [Config].
+func New(cfg Config) (*Manager, error) {
+	switch {
+	case cfg.Store == nil:
+		return nil, core.Wrap(core.ErrInvalid, "store not specified")
+	case cfg.MaxAge < 0:
+		return nil, core.Wrap(core.ErrInvalid, "negative MaxAge")
+	}
+
+	cfg.CookieName = core.Coalesce(cfg.CookieName, DefaultCookieName)
+	cfg.Path = core.Coalesce(cfg.Path, "/")
+	cfg.MaxAge = core.Coalesce(cfg.MaxAge, DefaultMaxAge)
+	cfg.SameSite = core.Coalesce(cfg.SameSite, http.SameSiteLaxMode)
+
+	return &Manager{cfg: cfg}, nil
+}
+
+// Middleware returns a middleware attaching the [Session] of each
+// request to its context, and saving it before the response
+// headers are written. Failures are passed to [web.HandleError].
+func (m *Manager) Middleware() func(http.Handler) http.Handler {
+	return web.NewMiddlewareWithError(m.serve)
+}
+
+func (m *Manager) serve(rw http.ResponseWriter, req *http.Request, next http.Handler) error {
+	s, err := m.Load(req)
+	if err != nil {
+		return err
+	}
+
+	ctx := req.Context()
+
+	var saveErr error
+	w := capture.New(rw, capture.Config{
+		Intercept: func(_ int, hdr http.Header) bool {
+			saveErr = m.Save(ctx, s, hdr)
+			return saveErr != nil
+		},
+	})
+
+	next.ServeHTTP(w, req.WithContext(WithSession(ctx, s)))
+	_ = w.Commit()
+
+	if w.Intercepted() {
+		return saveErr
+	}
+	return nil
+}
+
+// Load returns the [Session] of a request, or a new one if it
+// doesn't have a valid one.
+func (m *Manager) Load(req *http.Request) (*Session, error) {
+	s := newSession()
+
+	c, err := req.Cookie(m.cfg.CookieName)
+	if err != nil || !validID(c.Value) {
+		return s, nil
+	}
+
+	data, err := m.cfg.Store.Load(req.Context(), c.Value)
+	switch {
+	case errors.Is(err, core.ErrNotExists):
+		return s, nil
+	case err != nil:
+		return nil, err
+	case s.decode(data) != nil:
+		// corrupted, start over
+		return newSession(), nil
+	}
+
+	s.id = c.Value
+	return s, nil
+}
+
+// Save stores a changed [Session] and adds the cookie to
+// the given headers.
+func (m *Manager) Save(ctx context.Context, s *Session, hdr http.Header) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.destroyed:
+		return m.destroy(ctx, s, hdr)
+	case !s.changed:
+		return nil
+	}
+
+	if err := m.deleteOld(ctx, s); err != nil {
+		return err
+	}
+
+	if s.id == "" && len(s.values) == 0 {
+		// nothing to keep
+		return nil
+	}
+
+	return m.store(ctx, s, hdr)
+}
+
+func (m *Manager) store(ctx context.Context, s *Session, hdr http.Header) error {
+	data, err := s.encode()
+	if err != nil {
+		return err
+	}
+
+	if s.id == "" {
+		s.id, err = newID()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := m.cfg.Store.Save(ctx, s.id, data, m.cfg.MaxAge); err != nil {
+		return err
+	}
+
+	s.changed = false
+	hdr.Add("Set-Cookie", m.cookie(s.id, int(m.cfg.MaxAge/time.Second)).String())
+	return nil
+}
+
+func (m *Manager) deleteOld(ctx context.Context, s *Session) error {
+	if s.oldID != "" {
+		if err := m.cfg.Store.Delete(ctx, s.oldID); err != nil {
+			return err
+		}
+		s.oldID = ""
+	}
+	return nil
+}
+
+func (m *Manager) destroy(ctx context.Context, s *Session, hdr http.Header) error {
+	if err := m.deleteOld(ctx, s); err != nil {
+		return err
+	}
+
+	if s.id != "" {
+		if err := m.cfg.Store.Delete(ctx, s.id); err != nil {
+			return err
+		}
+		s.id = ""
+	}
+
+	s.changed = false
+	s.destroyed = false
+	hdr.Add("Set-Cookie", m.cookie("", -1).String())
+	return nil
+}
+
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.cfg.CookieName,
+		Value:    value,
+		Path:     m.cfg.Path,
+		Domain:   m.cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   !m.cfg.Insecure,
+		HttpOnly: true,
+		SameSite: m.cfg.SameSite,
+	}
+}
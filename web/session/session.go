@@ -0,0 +1,200 @@
+// Package session implements cookie based sessions backed by
+// pluggable stores.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// idSize is the number of random bytes of a session ID.
+const idSize = 32
+
+var sessionCtxKey = core.NewContextKey[*Session]("Session")
+
+// Session holds the values of a client across requests. Values are
+// stored encoded as JSON so they survive any [Store].
+type Session struct {
+	mu        sync.Mutex
+	id        string
+	oldID     string
+	values    map[string]json.RawMessage
+	changed   bool
+	destroyed bool
+}
+
+func newSession() *Session {
+	return &Session{
+		values: make(map[string]json.RawMessage),
+	}
+}
+
+// ID returns the identifier of the session, empty if it hasn't
+// been saved yet.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.id
+}
+
+// IsNew tells if the session wasn't loaded from the [Store].
+func (s *Session) IsNew() bool {
+	return s.ID() == ""
+}
+
+// Set stores a value encoded as JSON.
+func (s *Session) Set(key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = b
+	s.changed = true
+	return nil
+}
+
+// Decode decodes a value into v, reporting if it was present.
+func (s *Session) Decode(key string, v any) (bool, error) {
+	s.mu.Lock()
+	b, ok := s.values[key]
+	s.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, v)
+}
+
+// Delete removes a value.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.changed = true
+	}
+}
+
+// Keys returns the sorted keys of the values.
+func (s *Session) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return core.SortedKeys(s.values)
+}
+
+// Clear removes all values.
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.values) > 0 {
+		s.values = make(map[string]json.RawMessage)
+		s.changed = true
+	}
+}
+
+// Rotate assigns a new ID to the session when saved, removing the
+// old one. It should be called on privilege changes, like logging
+// in, to prevent session fixation.
+func (s *Session) Rotate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.id != "" {
+		s.oldID, s.id = s.id, ""
+	}
+	s.changed = true
+}
+
+// Destroy removes the session from the [Store] and the client.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values = make(map[string]json.RawMessage)
+	s.destroyed = true
+}
+
+func (s *Session) encode() ([]byte, error) {
+	return json.Marshal(s.values)
+}
+
+func (s *Session) decode(b []byte) error {
+	return json.Unmarshal(b, &s.values)
+}
+
+// WithSession attaches a [Session] to a context.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return sessionCtxKey.WithValue(ctx, s)
+}
+
+// FromContext returns the [Session] attached to a context.
+func FromContext(ctx context.Context) (*Session, bool) {
+	return sessionCtxKey.Get(ctx)
+}
+
+// Get returns a typed value of the [Session] attached to the context.
+// It fails if there is no session, no value or it can't be decoded
+// as T.
+func Get[T any](ctx context.Context, key string) (T, bool) {
+	var v T
+
+	s, ok := FromContext(ctx)
+	if !ok {
+		return v, false
+	}
+
+	found, err := s.Decode(key, &v)
+	if !found || err != nil {
+		var zero T
+		return zero, false
+	}
+	return v, true
+}
+
+// Set stores a value in the [Session] attached to the context.
+func Set(ctx context.Context, key string, v any) error {
+	s, ok := FromContext(ctx)
+	if !ok {
+		return core.Wrap(core.ErrNotExists, "session")
+	}
+	return s.Set(key, v)
+}
+
+func newID() (string, error) {
+	var b [idSize]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// validID tells if a string can be a session ID, so it's safe to
+// pass it to a [Store].
+func validID(id string) bool {
+	if len(id) != base64.RawURLEncoding.EncodedLen(idSize) {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+type testUser struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+func newTestHandler(t *testing.T, store Store) http.Handler {
+	m, err := New(Config{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m.Middleware()(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		s, _ := FromContext(ctx)
+
+		switch req.URL.Path {
+		case "/login":
+			s.Rotate()
+			_ = Set(ctx, "user", testUser{Name: "alice", Admin: true})
+		case "/logout":
+			s.Destroy()
+		}
+
+		if u, ok := Get[testUser](ctx, "user"); ok {
+			_, _ = rw.Write([]byte(u.Name))
+		}
+	}))
+}
+
+func doRequest(h http.Handler, path string, c *http.Cookie) (*http.Cookie, string) {
+	req := httptest.NewRequest("GET", path, nil)
+	if c != nil {
+		req.AddCookie(c)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	if cookies := res.Cookies(); len(cookies) > 0 {
+		return cookies[0], rec.Body.String()
+	}
+	return nil, rec.Body.String()
+}
+
+func TestManager(t *testing.T) {
+	store := NewMemoryStore()
+	h := newTestHandler(t, store)
+
+	if c, _ := doRequest(h, "/", nil); c != nil {
+		t.Errorf("ERROR: cookie set for an empty session")
+	}
+
+	c1, _ := doRequest(h, "/login", nil)
+	switch {
+	case c1 == nil:
+		t.Fatal("ERROR: no cookie after login")
+	case !c1.HttpOnly, !c1.Secure, c1.SameSite != http.SameSiteLaxMode:
+		t.Errorf("ERROR: insecure cookie %v", c1)
+	}
+
+	if c, body := doRequest(h, "/", c1); c != nil || body != "alice" {
+		t.Errorf("ERROR: unexpected response %v %q", c, body)
+	}
+
+	c2, _ := doRequest(h, "/login", c1)
+	if c2 == nil || c2.Value == c1.Value {
+		t.Fatalf("ERROR: session not rotated")
+	}
+	if _, err := store.Load(context.Background(), c1.Value); !errors.Is(err, core.ErrNotExists) {
+		t.Errorf("ERROR: old session kept: %v", err)
+	}
+
+	c3, _ := doRequest(h, "/logout", c2)
+	if c3 == nil || c3.MaxAge >= 0 {
+		t.Errorf("ERROR: cookie not expired: %v", c3)
+	}
+	if _, body := doRequest(h, "/", c2); body != "" {
+		t.Errorf("ERROR: destroyed session still valid")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	id, _ := newID()
+
+	if err := store.Save(ctx, "../escape", nil, time.Minute); !errors.Is(err, core.ErrInvalid) {
+		t.Errorf("ERROR: invalid id accepted: %v", err)
+	}
+
+	if err := store.Save(ctx, id, []byte(`{"a":1}`), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := store.Load(ctx, id)
+	if err != nil || string(b) != `{"a":1}` {
+		t.Errorf("ERROR: unexpected %q %v", b, err)
+	}
+
+	_ = store.Save(ctx, id, b, -time.Second)
+	if _, err := store.Load(ctx, id); !errors.Is(err, core.ErrNotExists) {
+		t.Errorf("ERROR: expired session loaded: %v", err)
+	}
+
+	if err := store.Delete(ctx, id); err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+}
+
+func TestFileStoreManager(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	h := newTestHandler(t, store)
+
+	c, _ := doRequest(h, "/login", nil)
+	if c == nil {
+		t.Fatal("ERROR: no cookie after login")
+	}
+	if _, body := doRequest(h, "/", c); !strings.HasPrefix(body, "alice") {
+		t.Errorf("ERROR: unexpected body %q", body)
+	}
+}
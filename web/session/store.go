@@ -0,0 +1,93 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// Store persists the encoded data of sessions. Implementations must
+// be safe for concurrent use.
+type Store interface {
+	// Load returns the data of a session, or an error wrapping
+	// [core.ErrNotExists] if unknown or expired.
+	Load(ctx context.Context, id string) ([]byte, error)
+	// Save stores the data of a session, to expire after the
+	// given time.
+	Save(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// Delete removes a session. Unknown sessions aren't an error.
+	Delete(ctx context.Context, id string) error
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// MemoryStore is a [Store] keeping sessions in memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryStore creates a new [MemoryStore].
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+// Load returns the data of a session.
+func (s *MemoryStore) Load(_ context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	switch {
+	case !ok:
+		return nil, core.Wrap(core.ErrNotExists, "session")
+	case time.Now().After(e.expires):
+		delete(s.entries, id)
+		return nil, core.Wrap(core.ErrNotExists, "session")
+	default:
+		return e.data, nil
+	}
+}
+
+// Save stores the data of a session.
+func (s *MemoryStore) Save(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[id] = memoryEntry{
+		data:    append([]byte(nil), data...),
+		expires: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// Delete removes a session.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, id)
+	return nil
+}
+
+// Prune removes expired sessions.
+func (s *MemoryStore) Prune() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, id)
+		}
+	}
+}
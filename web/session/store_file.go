@@ -0,0 +1,151 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"darvaza.org/core"
+)
+
+var _ Store = (*FileStore)(nil)
+
+// fileEntry is the content of a session file.
+type fileEntry struct {
+	Expires time.Time `json:"expires"`
+	Data    []byte    `json:"data"`
+}
+
+// FileStore is a [Store] keeping each session in a file
+// within a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a [FileStore] using the given directory,
+// creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, core.Wrap(core.ErrInvalid, "directory not specified")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(id string) (string, error) {
+	if !validID(id) {
+		return "", core.Wrap(core.ErrInvalid, "session id")
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+// Load returns the data of a session.
+func (s *FileStore) Load(_ context.Context, id string) ([]byte, error) {
+	filename, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := readEntry(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, core.Wrap(core.ErrNotExists, "session")
+	case err != nil:
+		return nil, err
+	case time.Now().After(e.Expires):
+		_ = os.Remove(filename)
+		return nil, core.Wrap(core.ErrNotExists, "session")
+	default:
+		return e.Data, nil
+	}
+}
+
+// Save stores the data of a session, replacing the file atomically.
+func (s *FileStore) Save(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	filename, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(fileEntry{
+		Expires: time.Now().Add(ttl),
+		Data:    data,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFile(s.dir, filename, b)
+}
+
+// Delete removes a session.
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	filename, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Prune removes expired sessions.
+func (s *FileStore) Prune() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, de := range entries {
+		if !de.Type().IsRegular() || !validID(de.Name()) {
+			continue
+		}
+
+		filename := filepath.Join(s.dir, de.Name())
+		if e, err := readEntry(filename); err == nil && now.After(e.Expires) {
+			_ = os.Remove(filename)
+		}
+	}
+	return nil
+}
+
+func readEntry(filename string) (*fileEntry, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	e := new(fileEntry)
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func writeFile(dir, filename string, b []byte) error {
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	err = core.Coalesce(err, f.Close())
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
@@ -0,0 +1,87 @@
+// Package sse implements server-sent events streams.
+package sse
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// Event is a message sent to the client.
+type Event struct {
+	// ID sets the client's last event ID, sent back as
+	// Last-Event-ID when reconnecting.
+	ID string
+	// Event is the event type, "message" if not specified.
+	Event string
+	// Data is the payload, split in multiple data fields
+	// when it contains new lines.
+	Data string
+	// Retry, if positive, tells the client how long to wait
+	// before reconnecting.
+	Retry time.Duration
+}
+
+// Validate checks the ID and Event fields don't contain new lines,
+// as they would break the framing of the stream.
+func (ev *Event) Validate() error {
+	switch {
+	case strings.ContainsAny(ev.ID, "\r\n\x00"):
+		return core.Wrap(core.ErrInvalid, "invalid event id")
+	case strings.ContainsAny(ev.Event, "\r\n"):
+		return core.Wrap(core.ErrInvalid, "invalid event type")
+	default:
+		return nil
+	}
+}
+
+// WriteTo writes the [Event] in the text/event-stream format.
+func (ev *Event) WriteTo(w io.Writer) (int64, error) {
+	if err := ev.Validate(); err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	writeField(&buf, "id", ev.ID)
+	writeField(&buf, "event", ev.Event)
+	if ev.Retry > 0 {
+		writeField(&buf, "retry", formatMillis(ev.Retry))
+	}
+	writeLines(&buf, "data", ev.Data)
+	buf.WriteByte('\n')
+
+	return buf.WriteTo(w)
+}
+
+func writeField(buf *bytes.Buffer, field, value string) {
+	if value != "" {
+		buf.WriteString(field)
+		buf.WriteString(": ")
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+	}
+}
+
+// writeLines writes one field per line of the value, normalising
+// CRLF and CR line breaks.
+func writeLines(buf *bytes.Buffer, field, value string) {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.ReplaceAll(value, "\r", "\n")
+
+	for _, s := range strings.Split(value, "\n") {
+		buf.WriteString(field)
+		if s != "" {
+			buf.WriteString(": ")
+			buf.WriteString(s)
+		}
+		buf.WriteByte('\n')
+	}
+}
+
+func formatMillis(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10)
+}
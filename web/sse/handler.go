@@ -0,0 +1,43 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+
+	"darvaza.org/x/web"
+)
+
+// HandlerFunc produces the events of a [Stream] until it returns
+// or the context is cancelled.
+type HandlerFunc func(ctx context.Context, s *Stream) error
+
+// Handler returns an [http.Handler] starting a [Stream] per request
+// and passing it to the given function. The stream is closed once
+// the function returns. Errors before the stream starts are passed
+// to [web.HandleError], and errors after that to [Config.OnError].
+func Handler(cfg *Config, fn HandlerFunc) http.Handler {
+	if fn == nil {
+		return web.NoMiddleware(nil)
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		s, err := New(rw, req, cfg)
+		if err != nil {
+			web.HandleError(rw, req, err)
+			return
+		}
+
+		s.Go(func(ctx context.Context) error {
+			err := fn(ctx, s)
+			if err == nil {
+				err = ErrClosed
+			}
+			return err
+		})
+
+		err = s.Wait()
+		if err != nil && cfg != nil && cfg.OnError != nil {
+			cfg.OnError(req, err)
+		}
+	})
+}
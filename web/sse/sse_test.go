@@ -0,0 +1,94 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventWriteTo(t *testing.T) {
+	for _, tc := range []struct {
+		ev     Event
+		expect string
+	}{
+		{Event{Data: "hello"}, "data: hello\n\n"},
+		{Event{ID: "1", Event: "update", Data: "a\nb"}, "id: 1\nevent: update\ndata: a\ndata: b\n\n"},
+		{Event{Data: "a\r\n\r\nb", Retry: time.Second}, "retry: 1000\ndata: a\ndata\ndata: b\n\n"},
+	} {
+		var sb strings.Builder
+		if _, err := tc.ev.WriteTo(&sb); err != nil {
+			t.Errorf("ERROR: %#v: %v", tc.ev, err)
+		} else if s := sb.String(); s != tc.expect {
+			t.Errorf("ERROR: %#v: expected %q, got %q", tc.ev, tc.expect, s)
+		}
+	}
+
+	ev := Event{ID: "1\n2"}
+	if _, err := ev.WriteTo(&strings.Builder{}); err == nil {
+		t.Errorf("ERROR: invalid ID accepted")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	done := make(chan struct{})
+	h := Handler(&Config{Heartbeat: 10 * time.Millisecond}, func(ctx context.Context, s *Stream) error {
+		defer close(done)
+
+		if err := s.Send(Event{ID: "2", Data: "resumed from " + s.LastEventID()}); err != nil {
+			return err
+		}
+
+		// wait for the client to go away
+		<-ctx.Done()
+		return nil
+	})
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set(LastEventIDHeader, "1")
+
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ct := res.Header.Get("Content-Type"); ct != ContentType {
+		t.Errorf("ERROR: unexpected Content-Type %q", ct)
+	}
+
+	r := bufio.NewReader(res.Body)
+	expected := []string{"id: 2\n", "data: resumed from 1\n", "\n", ": ping\n"}
+	for _, s := range expected {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != s {
+			t.Errorf("ERROR: expected %q, got %q", s, line)
+		}
+	}
+
+	// disconnect
+	_ = res.Body.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("ERROR: disconnect not detected")
+	}
+}
+
+func TestNewNoFlusher(t *testing.T) {
+	rw := struct{ http.ResponseWriter }{httptest.NewRecorder()}
+	req := httptest.NewRequest("GET", "/", nil)
+
+	if _, err := New(rw, req, nil); err == nil {
+		t.Errorf("ERROR: stream without flusher accepted")
+	}
+}
@@ -0,0 +1,231 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+const (
+	// LastEventIDHeader is the header used by clients to resume
+	// a stream after reconnecting.
+	LastEventIDHeader = "Last-Event-ID"
+
+	// ContentType is the media type of server-sent events.
+	ContentType = "text/event-stream"
+
+	// DefaultHeartbeat is the interval between keep-alive comments
+	// when the [Config] doesn't specify one.
+	DefaultHeartbeat = 15 * time.Second
+)
+
+// ErrClosed indicates the [Stream] has been closed, by the server
+// or the client.
+var ErrClosed = errors.New("stream closed")
+
+// Config describes how a [Stream] behaves.
+type Config struct {
+	// OnError is called, if set, with the error that terminated
+	// a stream served by [Handler].
+	OnError func(*http.Request, error)
+
+	// Heartbeat is the interval between keep-alive comments,
+	// [DefaultHeartbeat] if zero. Negative disables them.
+	Heartbeat time.Duration
+
+	// Retry, if positive, is sent to the client when the stream
+	// starts as reconnection delay.
+	Retry time.Duration
+}
+
+func (cfg *Config) heartbeat() time.Duration {
+	switch {
+	case cfg == nil || cfg.Heartbeat == 0:
+		return DefaultHeartbeat
+	case cfg.Heartbeat < 0:
+		return 0
+	default:
+		return cfg.Heartbeat
+	}
+}
+
+// Stream writes server-sent events to a client. Its goroutines
+// are tied to the connection, and cancelled when the client
+// disconnects or the [Stream] is closed.
+type Stream struct {
+	mu  sync.Mutex
+	eg  core.ErrGroup
+	rw  http.ResponseWriter
+	fl  http.Flusher
+	req *http.Request
+}
+
+// New starts a [Stream] on the given response, failing if it
+// doesn't support flushing. Nothing should be written to the
+// response other than via the [Stream].
+func New(rw http.ResponseWriter, req *http.Request, cfg *Config) (*Stream, error) {
+	fl, ok := rw.(http.Flusher)
+	if !ok {
+		return nil, web.NewHTTPError(http.StatusInternalServerError, nil,
+			"streaming not supported")
+	}
+
+	hdr := rw.Header()
+	hdr[consts.ContentType] = []string{ContentType}
+	hdr["X-Accel-Buffering"] = []string{"no"}
+	delete(hdr, consts.ContentLength)
+	web.SetNoCache(hdr)
+
+	s := &Stream{rw: rw, fl: fl, req: req}
+	s.eg.Parent = req.Context()
+	s.eg.SetDefaults()
+
+	rw.WriteHeader(http.StatusOK)
+
+	var err error
+	if cfg != nil && cfg.Retry > 0 {
+		err = s.write(func(buf *bytes.Buffer) {
+			writeField(buf, "retry", formatMillis(cfg.Retry))
+			buf.WriteByte('\n')
+		})
+	} else {
+		err = s.flush()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if d := cfg.heartbeat(); d > 0 {
+		s.Go(func(ctx context.Context) error {
+			return s.runHeartbeat(ctx, d)
+		})
+	}
+
+	return s, nil
+}
+
+// LastEventID returns the ID of the last event received by the
+// client before reconnecting, if any.
+func (s *Stream) LastEventID() string {
+	return LastEventID(s.req)
+}
+
+// LastEventID returns the Last-Event-ID of a request, if any.
+func LastEventID(req *http.Request) string {
+	return req.Header.Get(LastEventIDHeader)
+}
+
+// Context returns a context cancelled when the client disconnects
+// or the [Stream] is closed.
+func (s *Stream) Context() context.Context {
+	return s.eg.Context()
+}
+
+// Done returns a channel closed when the client disconnects or
+// the [Stream] is closed.
+func (s *Stream) Done() <-chan struct{} {
+	return s.eg.Cancelled()
+}
+
+// Go spawns a goroutine tied to the [Stream]. If it fails the
+// [Stream] is closed.
+func (s *Stream) Go(fn func(context.Context) error) {
+	s.eg.Go(func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			// cancel directly, the first cause wins
+			s.eg.Cancel(err)
+		}
+		return nil
+	}, nil)
+}
+
+// Send writes an [Event] and flushes it to the client.
+func (s *Stream) Send(ev Event) error {
+	if err := ev.Validate(); err != nil {
+		return err
+	}
+
+	return s.write(func(buf *bytes.Buffer) {
+		_, _ = ev.WriteTo(buf)
+	})
+}
+
+// Comment writes a comment, ignored by clients but useful to keep
+// the connection alive.
+func (s *Stream) Comment(text string) error {
+	return s.write(func(buf *bytes.Buffer) {
+		writeLines(buf, "", text)
+		buf.WriteByte('\n')
+	})
+}
+
+// Close terminates the [Stream] and waits for its goroutines
+// to finish, returning the error that caused the shutdown, if any.
+func (s *Stream) Close() error {
+	s.eg.Cancel(ErrClosed)
+	return s.Wait()
+}
+
+// Wait blocks until the [Stream] is closed and its goroutines have
+// finished, returning the error that caused the shutdown, if any.
+func (s *Stream) Wait() error {
+	<-s.eg.Cancelled()
+	if err := s.eg.Wait(); err != nil {
+		// panic
+		return err
+	}
+
+	err := context.Cause(s.eg.Context())
+	if err == nil || errors.Is(err, ErrClosed) || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func (s *Stream) write(fn func(*bytes.Buffer)) error {
+	var buf bytes.Buffer
+	fn(&buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.eg.IsCancelled() || s.eg.Context().Err() != nil {
+		return ErrClosed
+	}
+
+	if _, err := buf.WriteTo(s.rw); err != nil {
+		s.eg.Cancel(err)
+		return err
+	}
+
+	s.fl.Flush()
+	return nil
+}
+
+func (s *Stream) flush() error {
+	return s.write(func(*bytes.Buffer) {})
+}
+
+func (s *Stream) runHeartbeat(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Comment("ping"); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+var (
+	traceCtxKey = core.NewContextKey[TraceParent]("TraceParent")
+)
+
+// TraceParent is a W3C Trace Context traceparent, identifying a
+// request within a distributed trace.
+type TraceParent struct {
+	TraceID  [16]byte
+	ParentID [8]byte
+	Flags    byte
+}
+
+// TraceSampled is the flag indicating the caller may have
+// recorded the trace.
+const TraceSampled = 0x01
+
+// NewTraceParent creates a [TraceParent] for a new trace.
+func NewTraceParent() TraceParent {
+	var tp TraceParent
+	_, _ = rand.Read(tp.TraceID[:])
+	_, _ = rand.Read(tp.ParentID[:])
+	return tp
+}
+
+// ParseTraceParent parses a traceparent header value.
+func ParseTraceParent(s string) (TraceParent, error) {
+	var tp TraceParent
+
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	switch {
+	case len(parts) < 4, len(parts[0]) != 2, parts[0] == "ff":
+		return tp, errInvalidTraceParent
+	case parts[0] == "00" && len(parts) != 4:
+		return tp, errInvalidTraceParent
+	}
+
+	fields := []struct {
+		s   string
+		out []byte
+	}{
+		{parts[1], tp.TraceID[:]},
+		{parts[2], tp.ParentID[:]},
+		{parts[3], []byte{0}},
+	}
+
+	for _, f := range fields {
+		if !decodeLowerHex(f.out, f.s) {
+			return tp, errInvalidTraceParent
+		}
+	}
+	tp.Flags = fields[2].out[0]
+
+	if !tp.IsValid() {
+		return tp, errInvalidTraceParent
+	}
+	return tp, nil
+}
+
+var errInvalidTraceParent = core.Wrap(core.ErrInvalid, "invalid traceparent")
+
+func decodeLowerHex(out []byte, s string) bool {
+	if len(s) != 2*len(out) || strings.ToLower(s) != s {
+		return false
+	}
+
+	_, err := hex.Decode(out, []byte(s))
+	return err == nil
+}
+
+// IsValid tells the trace and parent IDs aren't all zeros.
+func (tp TraceParent) IsValid() bool {
+	return tp.TraceID != [16]byte{} && tp.ParentID != [8]byte{}
+}
+
+// IsSampled tells if the [TraceSampled] flag is set.
+func (tp TraceParent) IsSampled() bool {
+	return tp.Flags&TraceSampled != 0
+}
+
+// Child returns a [TraceParent] for the same trace with a new
+// parent ID, identifying our part of the request.
+func (tp TraceParent) Child() TraceParent {
+	out := tp
+	_, _ = rand.Read(out.ParentID[:])
+	return out
+}
+
+// TraceIDString returns the trace ID in hexadecimal.
+func (tp TraceParent) TraceIDString() string {
+	return hex.EncodeToString(tp.TraceID[:])
+}
+
+// ParentIDString returns the parent ID in hexadecimal.
+func (tp TraceParent) ParentIDString() string {
+	return hex.EncodeToString(tp.ParentID[:])
+}
+
+func (tp TraceParent) String() string {
+	return "00-" + tp.TraceIDString() + "-" + tp.ParentIDString() + "-" +
+		hex.EncodeToString([]byte{tp.Flags})
+}
+
+// WithTrace attaches a [TraceParent] to a context.
+func WithTrace(ctx context.Context, tp TraceParent) context.Context {
+	return traceCtxKey.WithValue(ctx, tp)
+}
+
+// Trace attempts to get a [TraceParent] from the given context.
+func Trace(ctx context.Context) (TraceParent, bool) {
+	return traceCtxKey.Get(ctx)
+}
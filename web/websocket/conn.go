@@ -0,0 +1,422 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"darvaza.org/core"
+)
+
+type message struct {
+	data []byte
+	typ  MessageType
+}
+
+// Conn is an upgraded WebSocket connection. A read loop handles
+// control frames and delivers data messages via [Conn.ReadMessage],
+// and it's tied together with the keep-alive loop and any goroutine
+// started with [Conn.Go], so they are all cancelled when the connection
+// is closed by either side.
+type Conn struct {
+	eg      core.ErrGroup
+	netConn net.Conn
+	r       *bufio.Reader
+	in      chan message
+	wmu     sync.Mutex
+
+	proto        string
+	readLimit    int64
+	pingInterval time.Duration
+	closeTimeout time.Duration
+
+	closeSent atomic.Bool
+}
+
+func newConn(ctx context.Context, netConn net.Conn, r *bufio.Reader,
+	proto string, opts *Options) *Conn {
+	//
+	c := &Conn{
+		netConn:      netConn,
+		r:            r,
+		in:           make(chan message),
+		proto:        proto,
+		readLimit:    opts.readLimit(),
+		pingInterval: opts.pingInterval(),
+		closeTimeout: opts.closeTimeout(),
+	}
+
+	c.eg.Parent = ctx
+	c.eg.SetDefaults()
+
+	c.eg.Go(c.watch(c.readLoop), c.shutdown)
+	if c.pingInterval > 0 {
+		c.Go(c.pingLoop)
+	}
+
+	return c
+}
+
+// Subprotocol returns the negotiated subprotocol, if any.
+func (c *Conn) Subprotocol() string {
+	return c.proto
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.netConn.RemoteAddr()
+}
+
+// Context returns a context cancelled when the connection is closed.
+func (c *Conn) Context() context.Context {
+	return c.eg.Context()
+}
+
+// Done returns a channel closed when the connection starts closing.
+func (c *Conn) Done() <-chan struct{} {
+	return c.eg.Cancelled()
+}
+
+// Go spawns a goroutine tied to the connection. If it fails the
+// connection is closed.
+func (c *Conn) Go(fn func(context.Context) error) {
+	c.eg.Go(c.watch(fn), nil)
+}
+
+// watch wraps a worker to cancel the connection with the error
+// that terminated it, instead of passing it to the [core.ErrGroup].
+func (c *Conn) watch(fn func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			c.eg.Cancel(err)
+		}
+		return nil
+	}
+}
+
+// ReadMessage waits for the next data message.
+func (c *Conn) ReadMessage(ctx context.Context) (MessageType, []byte, error) {
+	select {
+	case m := <-c.in:
+		return m.typ, m.data, nil
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case <-c.eg.Cancelled():
+		return 0, nil, c.cause()
+	}
+}
+
+// WriteMessage sends a data message. The context's deadline, if any,
+// is applied to the write.
+func (c *Conn) WriteMessage(ctx context.Context, typ MessageType, data []byte) error {
+	switch {
+	case typ != TextMessage && typ != BinaryMessage:
+		return core.Wrap(core.ErrInvalid, "invalid message type")
+	case typ == TextMessage && !utf8.Valid(data):
+		return core.Wrap(core.ErrInvalid, "invalid UTF-8 text")
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case c.closeSent.Load():
+		return ErrClosed
+	}
+
+	deadline, _ := ctx.Deadline()
+	return c.writeFrame(byte(typ), data, deadline)
+}
+
+// Close starts the closing handshake with the given code and reason,
+// and waits until the connection is terminated.
+func (c *Conn) Close(code int, reason string) error {
+	c.sendClose(code, reason)
+	c.eg.Cancel(ErrClosed)
+	return c.Wait()
+}
+
+// Wait blocks until the connection is terminated, returning the error
+// that caused it unless it was a normal closure.
+func (c *Conn) Wait() error {
+	<-c.eg.Cancelled()
+	if err := c.eg.Wait(); err != nil {
+		// panic
+		return err
+	}
+	return filterError(context.Cause(c.eg.Context()))
+}
+
+func filterError(err error) error {
+	var ce *CloseError
+
+	switch {
+	case err == nil, errors.Is(err, ErrClosed), errors.Is(err, context.Canceled):
+		return nil
+	case errors.As(err, &ce) && ce.IsNormal():
+		return nil
+	default:
+		return err
+	}
+}
+
+func (c *Conn) cause() error {
+	err := context.Cause(c.eg.Context())
+	if errors.Is(err, context.Canceled) {
+		return ErrClosed
+	}
+	return err
+}
+
+// readLoop reads frames until the connection fails or is closed,
+// and then terminates the connection.
+func (c *Conn) readLoop(ctx context.Context) error {
+	defer c.netConn.Close()
+
+	for {
+		c.extendDeadline(ctx)
+
+		m, err := c.readMessage()
+		if err != nil {
+			return c.readError(err)
+		}
+
+		select {
+		case c.in <- m:
+		case <-ctx.Done():
+			// closing, discard
+		}
+	}
+}
+
+func (c *Conn) readError(err error) error {
+	var pe *protocolError
+	var ce *CloseError
+
+	switch {
+	case errors.As(err, &pe):
+		c.sendClose(pe.code, "")
+		return err
+	case errors.As(err, &ce):
+		return err
+	case c.closeSent.Load():
+		// we initiated the shutdown, the peer
+		// didn't complete the handshake.
+		return ErrClosed
+	default:
+		return err
+	}
+}
+
+func (c *Conn) extendDeadline(ctx context.Context) {
+	if c.pingInterval > 0 && ctx.Err() == nil {
+		_ = c.netConn.SetReadDeadline(time.Now().Add(2 * c.pingInterval))
+	}
+}
+
+// shutdown is called when the connection is cancelled, to start the
+// closing handshake and give the peer time to complete it.
+func (c *Conn) shutdown() error {
+	c.sendClose(closeCode(c.cause()), "")
+	_ = c.netConn.SetReadDeadline(time.Now().Add(c.closeTimeout))
+	return nil
+}
+
+func closeCode(cause error) int {
+	var pe *protocolError
+
+	switch {
+	case errors.Is(cause, ErrClosed):
+		return CloseNormal
+	case errors.As(cause, &pe):
+		return pe.code
+	case errors.Is(cause, context.DeadlineExceeded):
+		return CloseGoingAway
+	default:
+		return CloseInternalError
+	}
+}
+
+func (c *Conn) pingLoop(ctx context.Context) error {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.ping(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *Conn) ping() error {
+	if c.closeSent.Load() {
+		return nil
+	}
+	return c.writeFrame(opPing, nil, time.Now().Add(c.pingInterval))
+}
+
+// readMessage reads frames until a data message is complete,
+// handling control frames in between.
+func (c *Conn) readMessage() (message, error) {
+	var m message
+
+	for {
+		done, err := c.readFrame(&m)
+		if done || err != nil {
+			return m, err
+		}
+	}
+}
+
+// readFrame reads a frame, returning true when a data message
+// is complete.
+func (c *Conn) readFrame(m *message) (bool, error) {
+	h, err := c.nextFrame()
+	switch {
+	case err != nil:
+		return false, err
+	case h.isControl():
+		return false, c.handleControl(&h)
+	default:
+		return c.appendData(m, &h)
+	}
+}
+
+func (c *Conn) nextFrame() (frameHeader, error) {
+	h, err := readFrameHeader(c.r)
+	if err == nil {
+		err = h.validate()
+	}
+	return h, err
+}
+
+// appendData adds a data frame to the message, returning true
+// when it's complete.
+func (c *Conn) appendData(m *message, h *frameHeader) (bool, error) {
+	if err := c.checkData(m, h); err != nil {
+		return false, err
+	}
+
+	b, err := readPayload(c.r, h)
+	if err != nil {
+		return false, err
+	}
+
+	if m.typ == 0 {
+		m.typ = MessageType(h.opcode)
+	}
+	m.data = append(m.data, b...)
+
+	switch {
+	case !h.fin:
+		return false, nil
+	case m.typ == TextMessage && !utf8.Valid(m.data):
+		return true, newProtocolError(CloseInvalidPayload, "invalid UTF-8 text")
+	default:
+		return true, nil
+	}
+}
+
+func (c *Conn) checkData(m *message, h *frameHeader) error {
+	switch {
+	case m.typ == 0 && h.opcode == opContinuation:
+		return newProtocolError(CloseProtocolError, "unexpected continuation frame")
+	case m.typ != 0 && h.opcode != opContinuation:
+		return newProtocolError(CloseProtocolError, "expected continuation frame")
+	case h.length > c.readLimit-int64(len(m.data)):
+		// compared without adding, as hostile lengths overflow
+		return newProtocolError(CloseMessageTooBig, "message too big")
+	default:
+		return nil
+	}
+}
+
+func (c *Conn) handleControl(h *frameHeader) error {
+	b, err := readPayload(c.r, h)
+	if err != nil {
+		return err
+	}
+
+	switch h.opcode {
+	case opPing:
+		return c.writeFrame(opPong, b, time.Now().Add(c.closeTimeout))
+	case opClose:
+		return c.handleClose(b)
+	default:
+		// pong, the deadline was already extended
+		return nil
+	}
+}
+
+func (c *Conn) handleClose(b []byte) error {
+	ce := &CloseError{Code: CloseNoStatus}
+
+	switch {
+	case len(b) == 1:
+		return newProtocolError(CloseProtocolError, "invalid close frame")
+	case len(b) >= 2:
+		ce.Code = int(binary.BigEndian.Uint16(b))
+		ce.Reason = string(b[2:])
+		if !validCloseCode(ce.Code) {
+			return newProtocolError(CloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(ce.Reason) {
+			return newProtocolError(CloseInvalidPayload, "invalid close reason")
+		}
+	}
+
+	// echo
+	c.sendClose(ce.Code, "")
+	return ce
+}
+
+// validCloseCode tells if a received close code is acceptable,
+// RFC 6455 section 7.4. Codes reserved for local use, like
+// [CloseNoStatus] and [CloseAbnormal], can't be sent on the wire.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		// defined by the RFC and registered by IANA
+		return true
+	default:
+		// registered and private use
+		return code >= 3000 && code <= 4999
+	}
+}
+
+// sendClose sends a close frame once, the first code wins.
+func (c *Conn) sendClose(code int, reason string) {
+	if !c.closeSent.CompareAndSwap(false, true) {
+		return
+	}
+
+	var b []byte
+	if code != CloseNoStatus && code != CloseAbnormal {
+		if len(reason) > maxControlPayload-2 {
+			reason = reason[:maxControlPayload-2]
+		}
+
+		b = binary.BigEndian.AppendUint16(b, uint16(code))
+		b = append(b, reason...)
+	}
+
+	_ = c.writeFrame(opClose, b, time.Now().Add(c.closeTimeout))
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte, deadline time.Time) error {
+	b := appendFrame(nil, opcode, payload)
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_ = c.netConn.SetWriteDeadline(deadline)
+	_, err := c.netConn.Write(b)
+	return err
+}
@@ -0,0 +1,134 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+
+	finBit  = 0x80
+	rsvBits = 0x70
+	maskBit = 0x80
+
+	maxControlPayload = 125
+)
+
+type frameHeader struct {
+	length int64
+	mask   [4]byte
+	opcode byte
+	fin    bool
+	masked bool
+}
+
+func (h *frameHeader) isControl() bool {
+	return h.opcode&0x8 != 0
+}
+
+func readFrameHeader(r *bufio.Reader) (frameHeader, error) {
+	var h frameHeader
+	var b [2]byte
+
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return h, err
+	}
+
+	if b[0]&rsvBits != 0 {
+		return h, newProtocolError(CloseProtocolError, "reserved bits set")
+	}
+
+	h.fin = b[0]&finBit != 0
+	h.opcode = b[0] & 0x0f
+	h.masked = b[1]&maskBit != 0
+
+	n, err := readLength(r, b[1]&0x7f)
+	if err != nil {
+		return h, err
+	}
+	h.length = n
+
+	if h.masked {
+		_, err = io.ReadFull(r, h.mask[:])
+	}
+	return h, err
+}
+
+func readLength(r *bufio.Reader, n byte) (int64, error) {
+	var b [8]byte
+
+	switch n {
+	case 126:
+		if _, err := io.ReadFull(r, b[:2]); err != nil {
+			return 0, err
+		}
+		return int64(binary.BigEndian.Uint16(b[:2])), nil
+	case 127:
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+
+		l := binary.BigEndian.Uint64(b[:])
+		if l>>63 != 0 {
+			return 0, newProtocolError(CloseProtocolError, "invalid frame length")
+		}
+		return int64(l), nil
+	default:
+		return int64(n), nil
+	}
+}
+
+// validate checks the header against the rules for frames sent
+// by clients.
+func (h *frameHeader) validate() error {
+	switch {
+	case !h.masked:
+		return newProtocolError(CloseProtocolError, "unmasked client frame")
+	case h.opcode > opBinary && !h.isControl(), h.opcode > opPong:
+		return newProtocolError(CloseProtocolError, "unknown opcode")
+	case h.isControl() && (!h.fin || h.length > maxControlPayload):
+		return newProtocolError(CloseProtocolError, "invalid control frame")
+	default:
+		return nil
+	}
+}
+
+func readPayload(r *bufio.Reader, h *frameHeader) ([]byte, error) {
+	b := make([]byte, h.length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	if h.masked {
+		for i := range b {
+			b[i] ^= h.mask[i%4]
+		}
+	}
+	return b, nil
+}
+
+// appendFrame appends an unmasked final frame, as sent by servers.
+func appendFrame(b []byte, opcode byte, payload []byte) []byte {
+	n := len(payload)
+
+	b = append(b, finBit|opcode)
+	switch {
+	case n <= maxControlPayload:
+		b = append(b, byte(n))
+	case n <= 0xffff:
+		b = append(b, 126)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 127)
+		b = binary.BigEndian.AppendUint64(b, uint64(n))
+	}
+
+	return append(b, payload...)
+}
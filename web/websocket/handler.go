@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+
+	"darvaza.org/x/web"
+)
+
+// HandlerFunc serves an upgraded connection until it returns or the
+// context is cancelled.
+type HandlerFunc func(ctx context.Context, c *Conn) error
+
+// Handler returns an [http.Handler] upgrading requests and passing
+// the connection to the given function. The connection is closed once
+// the function returns, normally if it returns nil. Handshake failures
+// are passed to [web.HandleError], and errors after that to
+// [Options.OnError].
+func Handler(opts *Options, fn HandlerFunc) http.Handler {
+	if fn == nil {
+		return web.NoMiddleware(nil)
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		c, err := Upgrade(rw, req, opts)
+		if err != nil {
+			web.HandleError(rw, req, err)
+			return
+		}
+
+		c.Go(func(ctx context.Context) error {
+			err := fn(ctx, c)
+			if err == nil {
+				c.sendClose(CloseNormal, "")
+				err = ErrClosed
+			}
+			return err
+		})
+
+		err = c.Wait()
+		if err != nil && opts != nil && opts.OnError != nil {
+			opts.OnError(req, err)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package websocket
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"darvaza.org/x/web"
+	"darvaza.org/x/web/consts"
+)
+
+// acceptGUID is the magic value used to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Options describes how connections are upgraded and maintained.
+type Options struct {
+	// CheckOrigin validates the Origin of the request. If not set
+	// requests with an Origin must match the Host.
+	CheckOrigin func(*http.Request) bool
+
+	// OnError is called, if set, with the error that terminated
+	// a connection served by [Handler].
+	OnError func(*http.Request, error)
+
+	// Subprotocols lists the supported subprotocols by order of
+	// preference.
+	Subprotocols []string
+
+	// ReadLimit is the maximum size of a message,
+	// [DefaultReadLimit] if zero.
+	ReadLimit int64
+
+	// PingInterval is the interval between keep-alive pings,
+	// [DefaultPingInterval] if zero. Negative disables them.
+	// Connections not receiving anything for twice the interval
+	// are considered dead.
+	PingInterval time.Duration
+
+	// CloseTimeout is how long to wait for the peer to complete
+	// the closing handshake, [DefaultCloseTimeout] if zero.
+	CloseTimeout time.Duration
+}
+
+func (opts *Options) readLimit() int64 {
+	if opts == nil || opts.ReadLimit <= 0 {
+		return DefaultReadLimit
+	}
+	return opts.ReadLimit
+}
+
+func (opts *Options) pingInterval() time.Duration {
+	switch {
+	case opts == nil || opts.PingInterval == 0:
+		return DefaultPingInterval
+	case opts.PingInterval < 0:
+		return 0
+	default:
+		return opts.PingInterval
+	}
+}
+
+func (opts *Options) closeTimeout() time.Duration {
+	if opts == nil || opts.CloseTimeout <= 0 {
+		return DefaultCloseTimeout
+	}
+	return opts.CloseTimeout
+}
+
+// IsUpgrade tells if the request asks for a WebSocket upgrade.
+func IsUpgrade(req *http.Request) bool {
+	return hasToken(req.Header, "Connection", "upgrade") &&
+		hasToken(req.Header, "Upgrade", "websocket")
+}
+
+// Upgrade validates the handshake, hijacks the connection and starts
+// its read and keep-alive loops. On failure the returned error is
+// meant to be passed to [web.HandleError].
+func Upgrade(rw http.ResponseWriter, req *http.Request, opts *Options) (*Conn, error) {
+	key, err := checkHandshake(req, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	hj, ok := rw.(http.Hijacker)
+	if !ok {
+		return nil, web.NewHTTPError(http.StatusInternalServerError, nil,
+			"hijacking not supported")
+	}
+
+	proto := selectSubprotocol(req, opts)
+
+	netConn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, web.NewHTTPError(http.StatusInternalServerError, err, "")
+	}
+
+	// clear deadlines set by the http.Server
+	_ = netConn.SetDeadline(time.Time{})
+
+	var sb strings.Builder
+	sb.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	sb.WriteString(acceptKey(key))
+	if proto != "" {
+		sb.WriteString("\r\nSec-WebSocket-Protocol: ")
+		sb.WriteString(proto)
+	}
+	sb.WriteString("\r\n\r\n")
+
+	if _, err := netConn.Write([]byte(sb.String())); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	// the request's context is cancelled when the handler returns
+	ctx := context.WithoutCancel(req.Context())
+	return newConn(ctx, netConn, brw.Reader, proto, opts), nil
+}
+
+func checkHandshake(req *http.Request, opts *Options) (string, error) {
+	switch {
+	case req.Method != consts.GET:
+		return "", web.NewStatusMethodNotAllowed(consts.GET)
+	case !IsUpgrade(req):
+		err := web.NewHTTPError(http.StatusUpgradeRequired, nil, "websocket upgrade required")
+		setUpgradeHeaders(err.Header())
+		return "", err
+	case req.Header.Get("Sec-WebSocket-Version") != "13":
+		err := web.NewHTTPError(http.StatusUpgradeRequired, nil, "unsupported websocket version")
+		setUpgradeHeaders(err.Header())
+		return "", err
+	case !checkOrigin(req, opts):
+		return "", web.NewStatusForbidden()
+	}
+
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return "", web.NewStatusBadRequest(errors.New("invalid Sec-WebSocket-Key"))
+	}
+	return key, nil
+}
+
+func setUpgradeHeaders(hdr http.Header) {
+	hdr.Set("Upgrade", "websocket")
+	hdr.Set("Connection", "Upgrade")
+	hdr.Set("Sec-WebSocket-Version", "13")
+}
+
+func checkOrigin(req *http.Request, opts *Options) bool {
+	if opts != nil && opts.CheckOrigin != nil {
+		return opts.CheckOrigin(req)
+	}
+
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, req.Host)
+}
+
+func selectSubprotocol(req *http.Request, opts *Options) string {
+	if opts == nil {
+		return ""
+	}
+
+	offered := tokens(req.Header, "Sec-WebSocket-Protocol")
+	for _, s := range opts.Subprotocols {
+		for _, o := range offered {
+			if s == o {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func tokens(hdr http.Header, name string) []string {
+	var out []string
+	for _, v := range hdr.Values(name) {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+func hasToken(hdr http.Header, name, token string) bool {
+	for _, s := range tokens(hdr, name) {
+		if strings.EqualFold(s, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Package websocket implements a minimal RFC 6455 server.
+package websocket
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultReadLimit is the maximum size of a message when the
+	// [Options] don't specify one.
+	DefaultReadLimit = 1 << 20
+
+	// DefaultPingInterval is the interval between keep-alive pings
+	// when the [Options] don't specify one.
+	DefaultPingInterval = 30 * time.Second
+
+	// DefaultCloseTimeout is how long to wait for the peer to
+	// complete the closing handshake when the [Options] don't
+	// specify it.
+	DefaultCloseTimeout = 5 * time.Second
+)
+
+// MessageType is the type of a data message.
+type MessageType int
+
+const (
+	// TextMessage is an UTF-8 encoded text message.
+	TextMessage MessageType = opText
+	// BinaryMessage is a binary data message.
+	BinaryMessage MessageType = opBinary
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case TextMessage:
+		return "text"
+	case BinaryMessage:
+		return "binary"
+	default:
+		return "MessageType(" + strconv.Itoa(int(t)) + ")"
+	}
+}
+
+// Close codes defined by RFC 6455.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseAbnormal        = 1006
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// ErrClosed indicates the connection was closed by our side.
+var ErrClosed = errors.New("websocket closed")
+
+// CloseError is the cause of the termination of a connection closed
+// by the peer.
+type CloseError struct {
+	Reason string
+	Code   int
+}
+
+func (e *CloseError) Error() string {
+	s := "websocket closed by peer (" + strconv.Itoa(e.Code) + ")"
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+// IsNormal tells if the peer closed the connection without
+// reporting a failure.
+func (e *CloseError) IsNormal() bool {
+	switch e.Code {
+	case CloseNormal, CloseGoingAway, CloseNoStatus:
+		return true
+	default:
+		return false
+	}
+}
+
+// protocolError is a violation of the protocol by the peer, closing
+// the connection with the given code.
+type protocolError struct {
+	msg  string
+	code int
+}
+
+func (e *protocolError) Error() string {
+	return "websocket: " + e.msg
+}
+
+func newProtocolError(code int, msg string) error {
+	return &protocolError{code: code, msg: msg}
+}
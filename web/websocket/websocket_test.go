@@ -0,0 +1,280 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// RFC 6455, section 1.3
+	const expected = "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="
+	if s := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); s != expected {
+		t.Errorf("ERROR: expected %q, got %q", expected, s)
+	}
+}
+
+func TestUpgradeRejected(t *testing.T) {
+	h := Handler(nil, func(context.Context, *Conn) error { return nil })
+
+	for _, tc := range []struct {
+		name    string
+		method  string
+		headers map[string]string
+		code    int
+	}{
+		{"post", "POST", nil, http.StatusMethodNotAllowed},
+		{"no upgrade", "GET", nil, http.StatusUpgradeRequired},
+		{"version", "GET", map[string]string{
+			"Connection": "Upgrade", "Upgrade": "websocket",
+			"Sec-WebSocket-Version": "8",
+		}, http.StatusUpgradeRequired},
+		{"key", "GET", map[string]string{
+			"Connection": "keep-alive, Upgrade", "Upgrade": "websocket",
+			"Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "short",
+		}, http.StatusBadRequest},
+		{"origin", "GET", map[string]string{
+			"Connection": "Upgrade", "Upgrade": "websocket",
+			"Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ==",
+			"Origin": "https://evil.example",
+		}, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(tc.method, "/", nil)
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("ERROR: %s: expected %v, got %v", tc.name, tc.code, rec.Code)
+		}
+	}
+}
+
+func TestEcho(t *testing.T) {
+	done := make(chan struct{})
+	opts := &Options{
+		Subprotocols: []string{"v2", "v1"},
+		OnError: func(_ *http.Request, err error) {
+			t.Errorf("ERROR: unexpected error: %v", err)
+		},
+	}
+
+	h := Handler(opts, func(ctx context.Context, c *Conn) error {
+		defer close(done)
+		for {
+			typ, b, err := c.ReadMessage(ctx)
+			if err != nil {
+				return err
+			}
+			if err := c.WriteMessage(ctx, typ, b); err != nil {
+				return err
+			}
+		}
+	})
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	conn, r := dial(t, srv.URL, "v1, v2")
+	defer conn.Close()
+
+	// fragmented text, with a ping in between
+	writeFrame(t, conn, opText, false, []byte("hel"))
+	writeFrame(t, conn, opPing, true, []byte("x"))
+	writeFrame(t, conn, opContinuation, true, []byte("lo"))
+
+	expectFrame(t, r, opPong, "x")
+	expectFrame(t, r, opText, "hello")
+
+	writeFrame(t, conn, opClose, true, []byte{0x03, 0xe8})
+	expectFrame(t, r, opClose, "\x03\xe8")
+
+	// server closes the TCP connection
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Errorf("ERROR: expected EOF, got %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("ERROR: handler didn't finish")
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	h := Handler(nil, func(ctx context.Context, c *Conn) error {
+		_, _, err := c.ReadMessage(ctx)
+		return err
+	})
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	conn, r := dial(t, srv.URL, "")
+	defer conn.Close()
+
+	// unmasked frame
+	_, _ = conn.Write([]byte{finBit | opText, 1, 'a'})
+	expectFrame(t, r, opClose, "\x03\xea")
+}
+
+func TestInvalidCloseCode(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		payload []byte
+		code    string
+	}{
+		{"1000", []byte{0x03, 0xe8}, "\x03\xe8"},
+		{"4000", []byte{0x0f, 0xa0}, "\x0f\xa0"},
+		{"999", []byte{0x03, 0xe7}, "\x03\xea"},
+		{"1005", []byte{0x03, 0xed}, "\x03\xea"},
+		{"1006", []byte{0x03, 0xee}, "\x03\xea"},
+		{"1015", []byte{0x03, 0xf7}, "\x03\xea"},
+		{"2000", []byte{0x07, 0xd0}, "\x03\xea"},
+		{"5000", []byte{0x13, 0x88}, "\x03\xea"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := Handler(nil, func(ctx context.Context, c *Conn) error {
+				_, _, err := c.ReadMessage(ctx)
+				return err
+			})
+
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+
+			conn, r := dial(t, srv.URL, "")
+			defer conn.Close()
+
+			writeFrame(t, conn, opClose, true, tc.payload)
+			expectFrame(t, r, opClose, tc.code)
+		})
+	}
+}
+
+func TestHostileLength(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		length uint64
+		code   string
+	}{
+		// would overflow the size of the message so far
+		{"huge", 1<<63 - 1, "\x03\xf1"},
+		// most significant bit set, RFC 6455 section 5.2
+		{"msb", 1 << 63, "\x03\xea"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := Handler(nil, func(ctx context.Context, c *Conn) error {
+				_, _, err := c.ReadMessage(ctx)
+				return err
+			})
+
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+
+			conn, r := dial(t, srv.URL, "")
+			defer conn.Close()
+
+			writeFrame(t, conn, opText, false, []byte("hel"))
+
+			b := []byte{finBit | opContinuation, maskBit | 127}
+			b = binary.BigEndian.AppendUint64(b, tc.length)
+			b = append(b, 1, 2, 3, 4)
+			if _, err := conn.Write(b); err != nil {
+				t.Fatal(err)
+			}
+
+			expectFrame(t, r, opClose, tc.code)
+		})
+	}
+}
+
+func dial(t *testing.T, url, protocols string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if protocols != "" {
+		req.Header.Set("Sec-WebSocket-Protocol", protocols)
+	}
+
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case res.StatusCode != http.StatusSwitchingProtocols:
+		t.Fatalf("unexpected status %v", res.StatusCode)
+	case res.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=":
+		t.Errorf("ERROR: unexpected Sec-WebSocket-Accept")
+	case protocols != "" && res.Header.Get("Sec-WebSocket-Protocol") != "v2":
+		t.Errorf("ERROR: unexpected subprotocol %q", res.Header.Get("Sec-WebSocket-Protocol"))
+	}
+
+	return conn, r
+}
+
+func writeFrame(t *testing.T, conn net.Conn, opcode byte, fin bool, payload []byte) {
+	t.Helper()
+
+	mask := [4]byte{1, 2, 3, 4}
+	b := []byte{opcode, maskBit | byte(len(payload))}
+	if fin {
+		b[0] |= finBit
+	}
+	b = append(b, mask[:]...)
+	for i, c := range payload {
+		b = append(b, c^mask[i%4])
+	}
+
+	if _, err := conn.Write(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectFrame(t *testing.T, r *bufio.Reader, opcode byte, payload string) {
+	t.Helper()
+
+	h, err := readFrameHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := readPayload(r, &h)
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case h.opcode != opcode || !h.fin || h.masked:
+		t.Errorf("ERROR: unexpected frame %#v", h)
+	case string(b) != payload:
+		t.Errorf("ERROR: expected %q, got %q (code %v)", payload, b, codeOf(b))
+	}
+}
+
+func codeOf(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(b))
+}